@@ -0,0 +1,259 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// maxA1Row bounds row numbers well above the 10 million cell limit of a spreadsheet, so that a typo
+// cannot overflow
+const maxA1Row = 10_000_000
+
+// a1Endpoint is one side of an A1 range, with 0-based indexes; a side without a column (3 in 3:10)
+// or without a row (A in A:C) has -1 there
+type a1Endpoint struct {
+	col, row int64
+}
+
+// parseA1Endpoint parses a cell (B7), a column (B) or a row (7). Letters may be lowercase and either
+// part may carry a $ anchor, which makes no difference here.
+func parseA1Endpoint(ref string) (a1Endpoint, error) {
+	text := strings.ToUpper(strings.TrimSpace(ref))
+	point := a1Endpoint{col: -1, row: -1}
+
+	i := 0
+	if i < len(text) && text[i] == '$' {
+		i++
+	}
+	start := i
+	col := int64(0)
+	for i < len(text) && text[i] >= 'A' && text[i] <= 'Z' {
+		col = col*26 + int64(text[i]-'A'+1)
+		i++
+	}
+	if i-start > 3 {
+		return point, fmt.Errorf("invalid cell reference '%s': columns end at ZZZ", ref)
+	}
+	if i > start {
+		point.col = col - 1
+	}
+
+	if i < len(text) && text[i] == '$' {
+		i++
+	}
+	start = i
+	row := int64(0)
+	for i < len(text) && text[i] >= '0' && text[i] <= '9' && row <= maxA1Row {
+		row = row*10 + int64(text[i]-'0')
+		i++
+	}
+	if i > start {
+		if row == 0 || row > maxA1Row {
+			return point, fmt.Errorf("invalid cell reference '%s': rows are numbered from 1 to %d", ref, maxA1Row)
+		}
+		point.row = row - 1
+	}
+
+	if i != len(text) || point.col < 0 && point.row < 0 {
+		return point, fmt.Errorf("invalid cell reference '%s'", ref)
+	}
+	return point, nil
+}
+
+// parseA1Notation converts a single cell reference such as B7 or $b$7 into 0-based column and row indexes
+func parseA1Notation(cell string) (col int64, row int64, err error) {
+	point, err := parseA1Endpoint(cell)
+	if err != nil {
+		return 0, 0, err
+	}
+	if point.col < 0 || point.row < 0 {
+		return 0, 0, fmt.Errorf("invalid cell notation: %s", cell)
+	}
+	return point.col, point.row, nil
+}
+
+// splitSheetPrefix separates the sheet name from a range such as 'Q1 Sales'!A1:C9 or Data!A:A. Quoted
+// names may contain ! and escape a quote by doubling it; sheet is empty when the range names no sheet.
+func splitSheetPrefix(rangeStr string) (sheet, cells string, err error) {
+	rangeStr = strings.TrimSpace(rangeStr)
+	if !strings.HasPrefix(rangeStr, "'") {
+		if i := strings.LastIndex(rangeStr, "!"); i >= 0 {
+			return rangeStr[:i], rangeStr[i+1:], nil
+		}
+		return "", rangeStr, nil
+	}
+
+	var name strings.Builder
+	for i := 1; i < len(rangeStr); i++ {
+		if rangeStr[i] != '\'' {
+			name.WriteByte(rangeStr[i])
+			continue
+		}
+		if i+1 < len(rangeStr) && rangeStr[i+1] == '\'' {
+			name.WriteByte('\'')
+			i++
+			continue
+		}
+		rest, ok := strings.CutPrefix(rangeStr[i+1:], "!")
+		if !ok {
+			return "", "", fmt.Errorf("expected ! after the quoted sheet name in '%s'", rangeStr)
+		}
+		return name.String(), rest, nil
+	}
+	return "", "", fmt.Errorf("unterminated quote in '%s'", rangeStr)
+}
+
+// r1c1Name matches names such as R1C1 or RC that the API could read as R1C1 references
+var r1c1Name = regexp.MustCompile(`(?i)^R[0-9]*C[0-9]*$`)
+
+// quoteSheetName single-quotes a sheet name for use in a range, doubling any quotes in it, unless it
+// is a plain ASCII word that cannot be mistaken for a cell, column, or row reference. Names such as
+// "Q1 2024", "O'Brien", "Résumé" or "Q1" would otherwise be rejected or misread by the API.
+func quoteSheetName(name string) string {
+	plain := name != ""
+	for i, r := range name {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9') {
+			plain = false
+			break
+		}
+	}
+	if plain {
+		if _, err := parseA1Endpoint(name); err != nil && !r1c1Name.MatchString(name) {
+			return name
+		}
+	}
+	return "'" + strings.ReplaceAll(name, "'", "''") + "'"
+}
+
+// parseGridRange converts an A1 range into a half-open grid range. It accepts single cells (B7),
+// rectangles (A1:C9, in either corner order), whole columns (A:C), whole rows (3:10), and columns
+// from a row down (A2:C). Unbounded sides are left 0, which the API reads as "to the edge of the sheet".
+func parseGridRange(sheetID int64, rangeStr string) (*sheets.GridRange, error) {
+	sheet, cells, err := splitSheetPrefix(rangeStr)
+	if err != nil {
+		return nil, err
+	}
+	if sheet != "" {
+		return nil, fmt.Errorf("range '%s' names sheet '%s'; pass the sheet name separately", rangeStr, sheet)
+	}
+	if cells == "" {
+		return nil, fmt.Errorf("range is empty")
+	}
+
+	startRef, endRef, isRange := strings.Cut(cells, ":")
+	start, err := parseA1Endpoint(startRef)
+	if err != nil {
+		return nil, err
+	}
+	end := start
+	if isRange {
+		if end, err = parseA1Endpoint(endRef); err != nil {
+			return nil, err
+		}
+	} else if start.col < 0 || start.row < 0 {
+		return nil, fmt.Errorf("'%s' is not a cell; use A:A for a whole column or 3:3 for a whole row", cells)
+	}
+
+	// Both sides name a column or neither does (3:10); an open end needs a column on each side (A2:C)
+	switch {
+	case (start.col < 0) != (end.col < 0),
+		start.row < 0 && end.row >= 0,
+		start.col < 0 && end.row < 0:
+		return nil, fmt.Errorf("invalid range '%s': use forms like B7, A1:C9, A:C, 3:10, or A2:C", cells)
+	}
+
+	gridRange := &sheets.GridRange{SheetId: sheetID}
+	if start.col >= 0 {
+		gridRange.StartColumnIndex = min(start.col, end.col)
+		gridRange.EndColumnIndex = max(start.col, end.col) + 1
+	}
+	switch {
+	case end.row >= 0:
+		gridRange.StartRowIndex = min(start.row, end.row)
+		gridRange.EndRowIndex = max(start.row, end.row) + 1
+	case start.row >= 0:
+		gridRange.StartRowIndex = start.row
+	}
+	return gridRange, nil
+}
+
+// rangeBounds is a range as its sheet and 1-based bounds
+type rangeBounds struct {
+	Sheet       string `json:"sheet"`
+	FirstRow    int64  `json:"firstRow"`
+	LastRow     int64  `json:"lastRow"`
+	FirstColumn string `json:"firstColumn"`
+	LastColumn  string `json:"lastColumn"`
+}
+
+// describeA1Range breaks a range returned by the API, such as 'Q1 2024'!A5:C7, into its sheet and
+// 1-based bounds; it returns nil for a range it cannot parse
+func describeA1Range(rangeStr string) *rangeBounds {
+	sheet, cells, err := splitSheetPrefix(rangeStr)
+	if err != nil {
+		return nil
+	}
+	r, err := parseGridRange(0, cells)
+	if err != nil || !isBounded(r) {
+		return nil
+	}
+	return &rangeBounds{
+		Sheet:       sheet,
+		FirstRow:    r.StartRowIndex + 1,
+		LastRow:     r.EndRowIndex,
+		FirstColumn: columnToLetter(r.StartColumnIndex),
+		LastColumn:  columnToLetter(r.EndColumnIndex - 1),
+	}
+}
+
+// columnToLetter converts a 0-based column index into its A1 column letters (0 -> A, 26 -> AA)
+func columnToLetter(index int64) string {
+	letters := ""
+	for index >= 0 {
+		letters = string(rune('A'+index%26)) + letters
+		index = index/26 - 1
+	}
+	return letters
+}
+
+// gridRangeToA1 converts a grid range into A1 notation (without the sheet name). Unbounded
+// ranges come back as whole columns (A:C, or A2:C from a row down) or whole rows (3:10), and a
+// fully unbounded range as "".
+func gridRangeToA1(r *sheets.GridRange) string {
+	switch {
+	case r.EndRowIndex == 0 && r.EndColumnIndex == 0:
+		return ""
+	case r.EndRowIndex == 0 && r.StartRowIndex > 0:
+		return fmt.Sprintf("%s%d:%s", columnToLetter(r.StartColumnIndex), r.StartRowIndex+1, columnToLetter(r.EndColumnIndex-1))
+	case r.EndRowIndex == 0:
+		return fmt.Sprintf("%s:%s", columnToLetter(r.StartColumnIndex), columnToLetter(r.EndColumnIndex-1))
+	case r.EndColumnIndex == 0:
+		return fmt.Sprintf("%d:%d", r.StartRowIndex+1, r.EndRowIndex)
+	}
+
+	start := fmt.Sprintf("%s%d", columnToLetter(r.StartColumnIndex), r.StartRowIndex+1)
+	end := fmt.Sprintf("%s%d", columnToLetter(r.EndColumnIndex-1), r.EndRowIndex)
+	return start + ":" + end
+}
+
+// isBounded reports whether a grid range has an end row and an end column
+func isBounded(r *sheets.GridRange) bool {
+	return r.EndRowIndex > 0 && r.EndColumnIndex > 0
+}
+
+// parseGridCoordinate converts a single A1 cell reference into a grid coordinate
+func parseGridCoordinate(sheetID int64, cell string) (*sheets.GridCoordinate, error) {
+	col, row, err := parseA1Notation(cell)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sheets.GridCoordinate{
+		SheetId:     sheetID,
+		RowIndex:    row,
+		ColumnIndex: col,
+	}, nil
+}
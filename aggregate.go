@@ -0,0 +1,269 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+var aggregateFunctions = []string{"sum", "avg", "min", "max", "count", "counta"}
+
+// columnStats accumulates the aggregates of one column. Like the spreadsheet functions, sum, avg, min,
+// max and count only look at numbers (numeric text included), while counta counts every non-empty cell.
+type columnStats struct {
+	sum      float64
+	numbers  int
+	nonEmpty int
+	min, max float64
+}
+
+func (c *columnStats) add(cell any) {
+	if formatCell(cell) == "" {
+		return
+	}
+	c.nonEmpty++
+	n, ok := cellNumber(cell)
+	if !ok {
+		return
+	}
+	if c.numbers == 0 || n < c.min {
+		c.min = n
+	}
+	if c.numbers == 0 || n > c.max {
+		c.max = n
+	}
+	c.sum += n
+	c.numbers++
+}
+
+// columnAggregates is the result of one column; only the requested aggregates are set
+type columnAggregates struct {
+	Column string   `json:"column"`
+	Letter string   `json:"letter"`
+	Sum    *float64 `json:"sum,omitempty"`
+	Avg    *float64 `json:"avg,omitempty"`
+	Min    *float64 `json:"min,omitempty"`
+	Max    *float64 `json:"max,omitempty"`
+	Count  *int     `json:"count,omitempty"`
+	CountA *int     `json:"counta,omitempty"`
+}
+
+// groupAggregates is the result of one group of rows sharing a group-by key
+type groupAggregates struct {
+	Key     map[string]any     `json:"key"`
+	Rows    int                `json:"rows"`
+	Columns []columnAggregates `json:"columns"`
+}
+
+// aggregateResult is what aggregate_range returns: the column results of all rows, or one entry
+// per group with group_by
+type aggregateResult struct {
+	SpreadsheetID string             `json:"spreadsheetId"`
+	Range         string             `json:"range"`
+	Functions     []string           `json:"functions"`
+	Rows          *int               `json:"rows,omitempty"`
+	Columns       []columnAggregates `json:"columns,omitzero"`
+	Groups        []groupAggregates  `json:"groups,omitzero"`
+}
+
+// result returns the requested aggregates; avg, min and max are left out for a column without numbers
+func (c *columnStats) result(functions []string) columnAggregates {
+	var result columnAggregates
+	for _, function := range functions {
+		switch function {
+		case "sum":
+			result.Sum = &c.sum
+		case "count":
+			result.Count = &c.numbers
+		case "counta":
+			result.CountA = &c.nonEmpty
+		case "avg":
+			if c.numbers > 0 {
+				avg := c.sum / float64(c.numbers)
+				result.Avg = &avg
+			}
+		case "min":
+			if c.numbers > 0 {
+				result.Min = &c.min
+			}
+		case "max":
+			if c.numbers > 0 {
+				result.Max = &c.max
+			}
+		}
+	}
+	return result
+}
+
+// aggregateGroup is the rows sharing one group-by key and the stats of each aggregated column
+type aggregateGroup struct {
+	key   []any
+	rows  int
+	stats []columnStats
+}
+
+func (s *SheetsMCPServer) handleAggregateRange(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID, sheet, rangeStr := parseCommonArgs(args)
+	headerRow := int(parseArgument(args, "header_row", float64(1)))
+
+	if spreadsheetID == "" || sheet == "" {
+		return respondWithError("spreadsheet_id and sheet are required")
+	}
+	if headerRow < 0 {
+		return respondWithError("header_row must be 0 (no headers) or a 1-based row number")
+	}
+
+	functions := slices.Clone(aggregateFunctions)
+	if raw, ok := args["functions"]; ok {
+		if err := convertToType(raw, &functions); err != nil {
+			return respondWithError(fmt.Sprintf("invalid functions format: %v", err))
+		}
+		for i, function := range functions {
+			functions[i] = strings.ToLower(function)
+			if !slices.Contains(aggregateFunctions, functions[i]) {
+				return respondWithError(fmt.Sprintf("invalid function '%s': must be one of %s", function, strings.Join(aggregateFunctions, ", ")))
+			}
+		}
+	}
+	var columnNames, groupByNames []string
+	if raw, ok := args["columns"]; ok {
+		if err := convertToType(raw, &columnNames); err != nil {
+			return respondWithError(fmt.Sprintf("invalid columns format: %v", err))
+		}
+	}
+	if raw, ok := args["group_by"]; ok {
+		if err := convertToType(raw, &groupByNames); err != nil {
+			return respondWithError(fmt.Sprintf("invalid group_by format: %v", err))
+		}
+	}
+
+	fullRange := buildFullRange(sheet, rangeStr)
+	valuesResult, err := s.sheetsService.Spreadsheets.Values.Get(spreadsheetID, fullRange).
+		ValueRenderOption("UNFORMATTED_VALUE").
+		DateTimeRenderOption("FORMATTED_STRING").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return s.respondWithAPIError("failed to get sheet values", err)
+	}
+	recordCellsRead(ctx, valuesResult.Values)
+
+	var headers []string
+	rows := valuesResult.Values
+	if headerRow > 0 {
+		if headerRow <= len(rows) {
+			for _, cell := range rows[headerRow-1] {
+				headers = append(headers, formatCell(cell))
+			}
+		}
+		rows = rows[min(headerRow, len(rows)):]
+	}
+	label := func(column int) string {
+		if column < len(headers) && headers[column] != "" {
+			return headers[column]
+		}
+		return columnToLetter(int64(column))
+	}
+
+	groupBy := make([]int, len(groupByNames))
+	for i, name := range groupByNames {
+		if groupBy[i], err = resolveColumn(headers, name); err != nil {
+			return respondWithError(err.Error())
+		}
+	}
+	var columns []int
+	for _, name := range columnNames {
+		column, err := resolveColumn(headers, name)
+		if err != nil {
+			return respondWithError(err.Error())
+		}
+		columns = append(columns, column)
+	}
+	// By default every column is aggregated except the ones the rows are grouped by
+	if len(columnNames) == 0 {
+		width := len(headers)
+		if headerRow == 0 {
+			for _, row := range rows {
+				width = max(width, len(row))
+			}
+		}
+		for column := range width {
+			if !slices.Contains(groupBy, column) {
+				columns = append(columns, column)
+			}
+		}
+	}
+
+	var keys []string
+	groups := make(map[string]*aggregateGroup)
+	for _, row := range rows {
+		if isEmptyRow(row) {
+			continue
+		}
+		key := make([]any, len(groupBy))
+		parts := make([]string, len(groupBy))
+		for i, column := range groupBy {
+			key[i] = cellAt(row, column)
+			parts[i] = formatCell(key[i])
+		}
+		id := strings.Join(parts, "\x00")
+		group, ok := groups[id]
+		if !ok {
+			group = &aggregateGroup{key: key, stats: make([]columnStats, len(columns))}
+			groups[id] = group
+			keys = append(keys, id)
+		}
+		group.rows++
+		for i, column := range columns {
+			group.stats[i].add(cellAt(row, column))
+		}
+	}
+
+	results := func(group *aggregateGroup) []columnAggregates {
+		out := make([]columnAggregates, len(columns))
+		for i, column := range columns {
+			out[i] = group.stats[i].result(functions)
+			out[i].Column = label(column)
+			out[i].Letter = columnToLetter(int64(column))
+		}
+		return out
+	}
+
+	response := aggregateResult{
+		SpreadsheetID: spreadsheetID,
+		Range:         fullRange,
+		Functions:     functions,
+	}
+	if len(groupBy) == 0 {
+		all, ok := groups[""]
+		if !ok {
+			all = &aggregateGroup{stats: make([]columnStats, len(columns))}
+		}
+		response.Rows = &all.rows
+		response.Columns = results(all)
+		return respondWithJSON(response)
+	}
+
+	out := make([]groupAggregates, 0, len(keys))
+	for _, id := range keys {
+		group := groups[id]
+		key := make(map[string]any, len(groupBy))
+		for i, column := range groupBy {
+			key[label(column)] = group.key[i]
+		}
+		out = append(out, groupAggregates{
+			Key:     key,
+			Rows:    group.rows,
+			Columns: results(group),
+		})
+	}
+	response.Groups = out
+	return respondWithJSON(response)
+}
@@ -0,0 +1,109 @@
+package main
+
+import "github.com/modelcontextprotocol/go-sdk/mcp"
+
+// toolHints describes how a mutating tool changes what it touches, for clients that decide which calls
+// need approval
+type toolHints struct {
+	// destructive tools overwrite, delete or revoke something; the others only add or make changes
+	// that are undone by a later call without losing anything
+	destructive bool
+	// idempotent tools leave things as they are when called again with the same arguments
+	idempotent bool
+}
+
+// mutatingToolHints describes every tool that is not in readOnlyTools; checkToolTables refuses to
+// start the server when a tool is in neither or both.
+var mutatingToolHints = map[string]toolHints{
+	"evaluate_formula":               {destructive: false, idempotent: true},
+	"update_cells":                   {destructive: true, idempotent: true},
+	"batch_update_cells":             {destructive: true, idempotent: true},
+	"add_rows":                       {destructive: false, idempotent: false},
+	"insert_rows_with_data":          {destructive: false, idempotent: false},
+	"add_columns":                    {destructive: false, idempotent: false},
+	"reorder_columns":                {destructive: false, idempotent: true},
+	"normalize_headers":              {destructive: true, idempotent: true},
+	"create_sheet":                   {destructive: false, idempotent: false},
+	"copy_sheet":                     {destructive: false, idempotent: false},
+	"duplicate_sheet":                {destructive: false, idempotent: false},
+	"rename_sheet":                   {destructive: false, idempotent: true},
+	"set_sheet_properties":           {destructive: false, idempotent: true},
+	"hide_sheet":                     {destructive: false, idempotent: true},
+	"unhide_sheet":                   {destructive: false, idempotent: true},
+	"delete_sheet":                   {destructive: true, idempotent: true},
+	"generate_change_digest":         {destructive: true, idempotent: false},
+	"create_spreadsheet":             {destructive: false, idempotent: false},
+	"delete_spreadsheet":             {destructive: true, idempotent: true},
+	"restore_spreadsheet":            {destructive: false, idempotent: true},
+	"star_spreadsheet":               {destructive: false, idempotent: true},
+	"unstar_spreadsheet":             {destructive: false, idempotent: true},
+	"rename_spreadsheet":             {destructive: false, idempotent: true},
+	"move_spreadsheet":               {destructive: false, idempotent: true},
+	"copy_spreadsheet":               {destructive: false, idempotent: false},
+	"import_xlsx":                    {destructive: false, idempotent: false},
+	"create_snapshot":                {destructive: false, idempotent: false},
+	"prune_snapshots":                {destructive: true, idempotent: true},
+	"share_spreadsheet":              {destructive: false, idempotent: true},
+	"remove_permission":              {destructive: true, idempotent: true},
+	"set_link_sharing":               {destructive: true, idempotent: true},
+	"revoke_all_external_access":     {destructive: true, idempotent: true},
+	"append_data":                    {destructive: false, idempotent: false},
+	"write_records":                  {destructive: true, idempotent: true},
+	"append_records":                 {destructive: false, idempotent: false},
+	"update_by_header":               {destructive: true, idempotent: true},
+	"consolidate_sheets":             {destructive: false, idempotent: false},
+	"clear_range":                    {destructive: true, idempotent: true},
+	"find_replace":                   {destructive: true, idempotent: false},
+	"sort_range":                     {destructive: true, idempotent: true},
+	"copy_range":                     {destructive: true, idempotent: false},
+	"copy_range_across_spreadsheets": {destructive: true, idempotent: false},
+	"set_dropdown_from_range":        {destructive: true, idempotent: true},
+	"auto_fill":                      {destructive: true, idempotent: true},
+	"add_data_source":                {destructive: false, idempotent: false},
+	"refresh_data_source":            {destructive: false, idempotent: true},
+	"delete_data_source":             {destructive: true, idempotent: true},
+	"format_cells":                   {destructive: true, idempotent: true},
+	"merge_cells":                    {destructive: true, idempotent: true},
+	"unmerge_cells":                  {destructive: false, idempotent: true},
+	"clear_formatting":               {destructive: true, idempotent: true},
+	"create_doc_summary":             {destructive: false, idempotent: false},
+	"draft_email_with_export":        {destructive: false, idempotent: false},
+	"export_spreadsheet":             {destructive: false, idempotent: false},
+	"reauthenticate":                 {destructive: false, idempotent: true},
+}
+
+// serverStateTools change only the server's own state, such as its sign-in or the exports it holds,
+// and never a spreadsheet. They skip dry_run, confirmation, audit and the write queue, and stay
+// available wherever reads are.
+var serverStateTools = map[string]bool{
+	"export_spreadsheet": true,
+	"reauthenticate":     true,
+}
+
+// writesSpreadsheets reports whether a tool may change a spreadsheet or its file
+func writesSpreadsheets(name string) bool {
+	return !readOnlyTools[name] && !serverStateTools[name]
+}
+
+// localTools only report on the server itself and never reach Google
+var localTools = map[string]bool{
+	"plan_operations":   true,
+	"cache_stats":       true,
+	"session_stats":     true,
+	"write_queue_stats": true,
+}
+
+// toolAnnotations returns the MCP hints announced for a tool. They are advice for clients' approval
+// policies; the server itself relies on readOnlyTools and CONFIRM_DESTRUCTIVE.
+func toolAnnotations(name string) *mcp.ToolAnnotations {
+	openWorld := !localTools[name]
+	if readOnlyTools[name] {
+		return &mcp.ToolAnnotations{ReadOnlyHint: true, IdempotentHint: true, OpenWorldHint: &openWorld}
+	}
+	hints := mutatingToolHints[name]
+	return &mcp.ToolAnnotations{
+		DestructiveHint: &hints.destructive,
+		IdempotentHint:  hints.idempotent,
+		OpenWorldHint:   &openWorld,
+	}
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"google.golang.org/api/sheets/v4"
+)
+
+// auditSheetTitle is the hidden sheet that audit entries are appended to with AUDIT_SHEET
+const auditSheetTitle = "_audit"
+
+// auditSheetHeaders are the columns of the audit sheet
+var auditSheetHeaders = []any{"Time", "Tool", "Sheet", "Range", "Cells Written", "Result", "Error", "Arguments"}
+
+// maxAuditCellText keeps the arguments column under the 50,000 character limit of a cell
+const maxAuditCellText = 49000
+
+// auditEntry is one mutating tool call
+type auditEntry struct {
+	Time          time.Time      `json:"time"`
+	Tool          string         `json:"tool"`
+	SpreadsheetID string         `json:"spreadsheetId,omitempty"`
+	Sheet         string         `json:"sheet,omitempty"`
+	Range         string         `json:"range,omitempty"`
+	CellsWritten  int64          `json:"cellsWritten"`
+	Result        string         `json:"result"`
+	Error         string         `json:"error,omitempty"`
+	Arguments     map[string]any `json:"arguments,omitempty"`
+}
+
+// auditLogResult is what get_audit_log returns
+type auditLogResult struct {
+	Source  string       `json:"source"`
+	Entries []auditEntry `json:"entries"`
+	Count   int          `json:"count"`
+}
+
+// auditLog appends entries to the JSONL file named by AUDIT_LOG_FILE
+type auditLog struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+// auditCellsKey carries the count of cells a mutating call has written so far
+type auditCellsKey struct{}
+
+// openAuditLog opens the audit file for appending, or returns nil when no file is configured
+func openAuditLog(path string) (*auditLog, error) {
+	if path == "" {
+		return nil, nil
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return &auditLog{path: path, file: file}, nil
+}
+
+func (l *auditLog) append(entry auditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err = l.file.Write(append(line, '\n'))
+	return err
+}
+
+// auditEnabled reports whether mutating calls are audited anywhere
+func (s *SheetsMCPServer) auditEnabled() bool {
+	return s.audit != nil || s.config.AuditSheet
+}
+
+// withAudit records every call of a mutating tool, successful or not, once it has finished. Dry runs
+// change nothing and are not recorded. A failure to write the audit entry is logged but does not fail
+// the call, whose change has already been made.
+func (s *SheetsMCPServer) withAudit(name string, handler mcp.ToolHandler) mcp.ToolHandler {
+	if !s.auditEnabled() {
+		return handler
+	}
+	return func(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if ctx.Value(dryRunKey{}) != nil {
+			return handler(ctx, request)
+		}
+
+		cells := &atomic.Int64{}
+		result, err := handler(context.WithValue(ctx, auditCellsKey{}, cells), request)
+
+		args, _ := getArgsFromRequest(request)
+		entry := auditEntry{
+			Time:          time.Now().UTC(),
+			Tool:          name,
+			SpreadsheetID: mutationTarget(args),
+			Sheet:         parseArgument(args, "sheet", ""),
+			Range:         parseArgument(args, "range", ""),
+			CellsWritten:  cells.Load(),
+			Result:        "ok",
+			Arguments:     args,
+		}
+		switch {
+		case err != nil:
+			entry.Result, entry.Error = "error", err.Error()
+		case isErrorResult(result):
+			entry.Result = "error"
+			entry.Error = errorMessage(result)
+		}
+
+		// The request may already be cancelled, but the change it made still has to be recorded
+		auditCtx := context.WithoutCancel(ctx)
+		if s.audit != nil {
+			if err := s.audit.append(entry); err != nil {
+				slog.ErrorContext(auditCtx, "failed to write audit log", "tool", name, "error", err)
+			}
+		}
+		if s.config.AuditSheet && entry.SpreadsheetID != "" {
+			if err := s.appendAuditRow(auditCtx, entry); err != nil {
+				slog.ErrorContext(auditCtx, "failed to write audit sheet", "tool", name, "spreadsheet_id", entry.SpreadsheetID, "error", err)
+			}
+		}
+		return result, err
+	}
+}
+
+// appendAuditRow appends an entry to the hidden audit sheet of the spreadsheet it changed, creating
+// the sheet on first use
+func (s *SheetsMCPServer) appendAuditRow(ctx context.Context, entry auditEntry) error {
+	arguments, _ := json.Marshal(entry.Arguments)
+	argumentText := string(arguments)
+	if len(argumentText) > maxAuditCellText {
+		argumentText = argumentText[:maxAuditCellText] + "…"
+	}
+	row := []any{entry.Time.Format(time.RFC3339), entry.Tool, entry.Sheet, entry.Range, entry.CellsWritten, entry.Result, entry.Error, argumentText}
+
+	appendRow := func() error {
+		_, err := s.sheetsService.Spreadsheets.Values.Append(entry.SpreadsheetID, buildFullRange(auditSheetTitle, "A:H"), &sheets.ValueRange{Values: [][]any{row}}).
+			ValueInputOption("RAW").
+			InsertDataOption("INSERT_ROWS").
+			Context(ctx).
+			Do()
+		return err
+	}
+	if err := appendRow(); err == nil || !strings.Contains(err.Error(), "Unable to parse range") {
+		return err
+	}
+
+	// Another call may create the sheet at the same moment; whichever loses sees "already exists"
+	requests := []*sheets.Request{{
+		AddSheet: &sheets.AddSheetRequest{Properties: &sheets.SheetProperties{
+			Title:          auditSheetTitle,
+			Hidden:         true,
+			GridProperties: &sheets.GridProperties{FrozenRowCount: 1, ColumnCount: int64(len(auditSheetHeaders))},
+		}},
+	}}
+	if _, err := s.executeBatchUpdate(ctx, entry.SpreadsheetID, requests); err != nil && !strings.Contains(err.Error(), "already exists") {
+		return err
+	}
+	if _, err := s.sheetsService.Spreadsheets.Values.Update(entry.SpreadsheetID, buildFullRange(auditSheetTitle, "A1:H1"), &sheets.ValueRange{Values: [][]any{auditSheetHeaders}}).
+		ValueInputOption("RAW").
+		Context(ctx).
+		Do(); err != nil {
+		return err
+	}
+	return appendRow()
+}
+
+func (s *SheetsMCPServer) handleGetAuditLog(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID := parseArgument(args, "spreadsheet_id", "")
+	tool := parseArgument(args, "tool", "")
+	limit := int(parseArgument(args, "limit", float64(100)))
+	if limit < 1 {
+		return respondWithError("limit must be at least 1")
+	}
+	var since time.Time
+	if text := parseArgument(args, "since", ""); text != "" {
+		if d, err := time.ParseDuration(text); err == nil {
+			since = time.Now().Add(-d)
+		} else if since, err = time.Parse(time.RFC3339, text); err != nil {
+			return respondWithError(fmt.Sprintf("invalid since '%s': use a time such as 2024-03-01T00:00:00Z or a duration such as 24h", text))
+		}
+	}
+
+	var entries []auditEntry
+	source := ""
+	switch {
+	case s.audit != nil:
+		source = s.audit.path
+		if entries, err = s.audit.read(); err != nil {
+			return respondWithError(fmt.Sprintf("failed to read audit log: %v", err))
+		}
+	case spreadsheetID == "":
+		return respondWithError("spreadsheet_id is required to read the audit sheet")
+	default:
+		source = buildFullRange(auditSheetTitle, "")
+		if entries, err = s.readAuditSheet(ctx, spreadsheetID); err != nil {
+			return s.respondWithAPIError("failed to read audit sheet", err)
+		}
+	}
+
+	// Sessions limited to some spreadsheets only see what was done to those
+	var allowed []string
+	if tenant := tenantFromContext(ctx); tenant != nil {
+		allowed = tenant.AllowedSpreadsheets
+	}
+	matched := make([]auditEntry, 0, min(limit, len(entries)))
+	for _, entry := range slices.Backward(entries) {
+		switch {
+		case spreadsheetID != "" && entry.SpreadsheetID != spreadsheetID,
+			tool != "" && entry.Tool != tool,
+			!since.IsZero() && entry.Time.Before(since),
+			len(allowed) > 0 && !slices.Contains(allowed, entry.SpreadsheetID):
+			continue
+		}
+		matched = append(matched, entry)
+		if len(matched) == limit {
+			break
+		}
+	}
+
+	return respondWithJSON(auditLogResult{
+		Source:  source,
+		Entries: matched,
+		Count:   len(matched),
+	})
+}
+
+// read returns every entry of the audit file, oldest first; lines that do not parse are skipped
+func (l *auditLog) read() ([]auditEntry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	file, err := os.Open(l.path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []auditEntry
+	scanner := bufio.NewScanner(file)
+	// Arguments of large writes make for long lines
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var entry auditEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) == nil {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// readAuditSheet returns the entries of a spreadsheet's audit sheet, oldest first
+func (s *SheetsMCPServer) readAuditSheet(ctx context.Context, spreadsheetID string) ([]auditEntry, error) {
+	result, err := s.sheetsService.Spreadsheets.Values.Get(spreadsheetID, buildFullRange(auditSheetTitle, "A2:H")).
+		ValueRenderOption("UNFORMATTED_VALUE").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]auditEntry, 0, len(result.Values))
+	for _, row := range result.Values {
+		entry := auditEntry{
+			SpreadsheetID: spreadsheetID,
+			Tool:          formatCell(cellAt(row, 1)),
+			Sheet:         formatCell(cellAt(row, 2)),
+			Range:         formatCell(cellAt(row, 3)),
+			Result:        formatCell(cellAt(row, 5)),
+			Error:         formatCell(cellAt(row, 6)),
+		}
+		entry.Time, _ = time.Parse(time.RFC3339, formatCell(cellAt(row, 0)))
+		entry.CellsWritten, _ = strconv.ParseInt(formatCell(cellAt(row, 4)), 10, 64)
+		json.Unmarshal([]byte(formatCell(cellAt(row, 7))), &entry.Arguments)
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
@@ -1,38 +1,180 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"net/http"
 	"os"
 
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
+	admin "google.golang.org/api/admin/directory/v1"
+	"google.golang.org/api/docs/v1"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/driveactivity/v2"
+	"google.golang.org/api/gmail/v1"
 	"google.golang.org/api/option"
 	"google.golang.org/api/sheets/v4"
 )
 
 const (
 	SheetsScope = "https://www.googleapis.com/auth/spreadsheets"
+	DriveScope  = "https://www.googleapis.com/auth/drive"
+	// SheetsReadOnlyScope and DriveReadOnlyScope replace the Sheets and Drive scopes in readonly mode
+	SheetsReadOnlyScope = sheets.SpreadsheetsReadonlyScope
+	DriveReadOnlyScope  = drive.DriveReadonlyScope
+	// DriveFileScope limits both APIs to files the app created or the user opened with it
+	DriveFileScope = drive.DriveFileScope
+	// DriveActivityScope is only requested when the change digest's Drive activity is enabled
+	DriveActivityScope = driveactivity.DriveActivityReadonlyScope
+
+	// GroupMembersScope is only requested when the groups directory is enabled
+	GroupMembersScope = admin.AdminDirectoryGroupMemberReadonlyScope
+	// BigQueryScope is only requested when BigQuery data sources are enabled
+	BigQueryScope = "https://www.googleapis.com/auth/bigquery.readonly"
+	// DocsScope is only requested when Google Docs export is enabled
+	DocsScope = docs.DocumentsScope
+	// GmailComposeScope is only requested when Gmail drafts are enabled
+	GmailComposeScope = gmail.GmailComposeScope
 )
 
-var requiredScopes = []string{SheetsScope}
+// Scope modes trade tools for narrower access; SCOPE_MODE picks one
+const (
+	// ScopeModeFull reads and writes every spreadsheet and Drive file the credentials can see
+	ScopeModeFull = "full"
+	// ScopeModeDriveFile only reaches spreadsheets the server created or the user opened with this
+	// OAuth client, and drops the tools that need wider Drive access
+	ScopeModeDriveFile = "drive_file"
+	// ScopeModeReadOnly requests read-only scopes and registers only the tools that never write
+	ScopeModeReadOnly = "readonly"
+	// ScopeModeSheetsOnly requests only the Sheets scope and runs without the Drive-backed tools
+	ScopeModeSheetsOnly = "sheets_only"
+)
+
+// scopeModeScopes are the scopes each mode requests before optional ones are added
+var scopeModeScopes = map[string][]string{
+	ScopeModeFull:       {SheetsScope, DriveScope},
+	ScopeModeDriveFile:  {DriveFileScope},
+	ScopeModeReadOnly:   {SheetsReadOnlyScope, DriveReadOnlyScope},
+	ScopeModeSheetsOnly: {SheetsScope},
+}
 
 type AuthConfig struct {
 	CredentialsConfig  string
 	ServiceAccountPath string
 	CredentialsPath    string
 	TokenPath          string
+	// OAuthCallbackPort is the loopback port the browser sign-in redirects to; 0 picks a free one
+	OAuthCallbackPort string
+	// ProfilesFile is a YAML file of named credentials that replace the ones above
+	ProfilesFile string
+	// Profile names the profile these credentials belong to, if any
+	Profile string
+
+	// GroupsDirectory enables the Admin SDK directory service used to list Google Group members
+	GroupsDirectory bool
+	// DirectoryAdminEmail is the Workspace admin a service account impersonates for directory lookups
+	DirectoryAdminEmail string
+	// BigQueryDataSources enables Connected Sheets data sources backed by BigQuery
+	BigQueryDataSources bool
+	// ScopeMode is one of the ScopeMode constants. It defaults to sheets_only, so Drive access is only
+	// requested when asked for; SHEETS_ONLY=true is the same as sheets_only
+	ScopeMode string
+	// DriveActivity enables the Drive Activity API, which adds who did what to change digests
+	DriveActivity bool
+	// DocsExport enables writing spreadsheet summaries to Google Docs
+	DocsExport bool
+	// GmailDrafts enables creating Gmail drafts with exported spreadsheets attached
+	GmailDrafts bool
+	// GmailUserEmail is the mailbox a service account impersonates to create drafts
+	GmailUserEmail string
+}
+
+// Services holds the Google API clients the server talks to; Drive is nil in Sheets-only mode, and
+// Activity, Directory, Docs, and Gmail are nil unless enabled
+type Services struct {
+	Sheets    *sheets.Service
+	Drive     *drive.Service
+	Activity  *driveactivity.Service
+	Directory *admin.Service
+	Docs      *docs.Service
+	Gmail     *gmail.Service
+
+	// HTTPClient is authorized like the services, for endpoints they do not wrap (such as export links)
+	HTTPClient *http.Client
+	// ServiceAccountEmail is set when authenticated as a service account; files must be shared with it
+	ServiceAccountEmail string
+	// TokenSource supplies the access tokens the HTTP client sends
+	TokenSource oauth2.TokenSource
+	// AuthMethod names how the server authenticated: service_account, oauth, or application_default
+	AuthMethod string
+	// Scopes are the OAuth scopes the server asked for
+	Scopes []string
 }
 
 func LoadAuthConfig() *AuthConfig {
-	return &AuthConfig{
+	ac := &AuthConfig{
 		CredentialsConfig:  os.Getenv("CREDENTIALS_CONFIG"),
 		ServiceAccountPath: os.Getenv("SERVICE_ACCOUNT_PATH"),
 		CredentialsPath:    getEnvOrDefault("CREDENTIALS_PATH", "credentials.json"),
 		TokenPath:          getEnvOrDefault("TOKEN_PATH", "token.json"),
+		OAuthCallbackPort:  getEnvOrDefault("OAUTH_CALLBACK_PORT", "0"),
+		ProfilesFile:       os.Getenv("PROFILES_FILE"),
+
+		GroupsDirectory:     os.Getenv("GROUPS_DIRECTORY") == "true",
+		DirectoryAdminEmail: os.Getenv("DIRECTORY_ADMIN_EMAIL"),
+		BigQueryDataSources: os.Getenv("BIGQUERY_DATA_SOURCES") == "true",
+		ScopeMode:           getEnvOrDefault("SCOPE_MODE", ScopeModeSheetsOnly),
+		DriveActivity:       os.Getenv("DRIVE_ACTIVITY") == "true",
+		DocsExport:          os.Getenv("DOCS_EXPORT") == "true",
+		GmailDrafts:         os.Getenv("GMAIL_DRAFTS") == "true",
+		GmailUserEmail:      os.Getenv("GMAIL_USER_EMAIL"),
 	}
+	if os.Getenv("SHEETS_ONLY") == "true" {
+		ac.ScopeMode = ScopeModeSheetsOnly
+	}
+	return ac
+}
+
+// scopes returns the OAuth scopes to request, including optional ones that are enabled
+func (ac *AuthConfig) scopes() []string {
+	scopes := append([]string{}, scopeModeScopes[ac.ScopeMode]...)
+	if ac.GroupsDirectory {
+		scopes = append(scopes, GroupMembersScope)
+	}
+	if ac.BigQueryDataSources {
+		scopes = append(scopes, BigQueryScope)
+	}
+	if ac.readsActivity() {
+		scopes = append(scopes, DriveActivityScope)
+	}
+	if ac.writesDocs() {
+		scopes = append(scopes, DocsScope)
+	}
+	if ac.writesGmail() {
+		scopes = append(scopes, GmailComposeScope)
+	}
+	return scopes
+}
+
+// readsActivity reports whether the Drive Activity API is enabled; it needs the wider Drive access of
+// full and readonly mode
+func (ac *AuthConfig) readsActivity() bool {
+	return ac.DriveActivity && (ac.ScopeMode == ScopeModeFull || ac.ScopeMode == ScopeModeReadOnly)
+}
+
+// writesDocs and writesGmail report whether Docs export and Gmail drafts are enabled; both only
+// create files, so readonly mode leaves them off
+func (ac *AuthConfig) writesDocs() bool {
+	return ac.DocsExport && ac.ScopeMode != ScopeModeReadOnly
+}
+
+func (ac *AuthConfig) writesGmail() bool {
+	return ac.GmailDrafts && ac.ScopeMode != ScopeModeReadOnly
 }
 
 func getEnvOrDefault(key, defaultValue string) string {
@@ -45,7 +187,7 @@ func getEnvOrDefault(key, defaultValue string) string {
 func (ac *AuthConfig) GetCredentials(ctx context.Context) (*oauth2.Token, []byte, error) {
 	// Priority 1: CREDENTIALS_CONFIG (Base64 encoded)
 	if ac.CredentialsConfig != "" {
-		fmt.Println("Using CREDENTIALS_CONFIG")
+		slog.Info("using credentials from CREDENTIALS_CONFIG")
 		credBytes, err := base64.StdEncoding.DecodeString(ac.CredentialsConfig)
 		if err != nil {
 			return nil, nil, fmt.Errorf("failed to decode CREDENTIALS_CONFIG: %w", err)
@@ -54,13 +196,14 @@ func (ac *AuthConfig) GetCredentials(ctx context.Context) (*oauth2.Token, []byte
 	}
 
 	// Priority 2: SERVICE_ACCOUNT_PATH or GOOGLE_APPLICATION_CREDENTIALS
+	// A profile names its credentials itself, so the environment cannot stand in for them
 	serviceAcctPath := ac.ServiceAccountPath
-	if serviceAcctPath == "" {
+	if serviceAcctPath == "" && ac.Profile == "" {
 		serviceAcctPath = os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
 	}
 
 	if serviceAcctPath != "" && fileExists(serviceAcctPath) {
-		fmt.Printf("Using service account: %s\n", serviceAcctPath)
+		slog.Info("using service account", "path", serviceAcctPath)
 		credBytes, err := os.ReadFile(serviceAcctPath)
 		if err != nil {
 			return nil, nil, fmt.Errorf("failed to read service account file: %w", err)
@@ -70,87 +213,191 @@ func (ac *AuthConfig) GetCredentials(ctx context.Context) (*oauth2.Token, []byte
 
 	// Priority 3: OAuth with CREDENTIALS_PATH
 	if fileExists(ac.CredentialsPath) {
-		fmt.Println("Using OAuth authentication flow")
+		slog.Info("using OAuth authentication flow", "credentials", ac.CredentialsPath)
 
 		credBytes, err := os.ReadFile(ac.CredentialsPath)
 		if err != nil {
 			return nil, nil, fmt.Errorf("failed to read credentials file: %w", err)
 		}
 
-		config, err := google.ConfigFromJSON(credBytes, requiredScopes...)
+		config, err := google.ConfigFromJSON(credBytes, ac.scopes()...)
 		if err != nil {
 			return nil, nil, fmt.Errorf("failed to parse credentials: %w", err)
 		}
 
+		// An expired access token is fine as long as the refresh token can renew it
 		token, err := ac.getTokenFromFile()
-		if err != nil || !token.Valid() {
-			token, err = ac.getTokenFromWeb(config)
+		if err != nil || (token.RefreshToken == "" && !token.Valid()) {
+			token, err = ac.getTokenFromWeb(ctx, config)
 			if err != nil {
 				return nil, nil, fmt.Errorf("failed to get OAuth token: %w", err)
 			}
 			if err := ac.saveToken(token); err != nil {
-				fmt.Printf("Warning: failed to save token: %v\n", err)
+				slog.Warn("failed to save token", "path", ac.TokenPath, "error", err)
 			}
 		}
 
 		return token, credBytes, nil
 	}
 
+	if ac.Profile != "" {
+		return nil, nil, fmt.Errorf("profile %s: no credentials found at %s", ac.Profile, cmp.Or(ac.ServiceAccountPath, ac.CredentialsPath))
+	}
+
 	// Priority 4: Application Default Credentials
-	fmt.Println("Attempting to use Application Default Credentials (ADC)")
-	fmt.Println("ADC will check: GOOGLE_APPLICATION_CREDENTIALS, gcloud auth, and metadata service")
+	slog.Info("attempting Application Default Credentials (GOOGLE_APPLICATION_CREDENTIALS, gcloud auth, metadata service)")
 
-	creds, err := google.FindDefaultCredentials(ctx, requiredScopes...)
+	creds, err := google.FindDefaultCredentials(ctx, ac.scopes()...)
 	if err != nil {
 		return nil, nil, fmt.Errorf("all authentication methods failed: %w", err)
 	}
 
-	fmt.Println("Successfully authenticated using ADC")
+	slog.Info("authenticated using Application Default Credentials")
 	return nil, creds.JSON, nil
 }
 
-func (ac *AuthConfig) CreateServices(ctx context.Context) (*sheets.Service, any, error) {
+// CreateServices builds the Google API clients with the retry policy and Sheets rate limit from config
+func (ac *AuthConfig) CreateServices(ctx context.Context, config *ServerConfig) (*Services, error) {
+	if _, ok := scopeModeScopes[ac.ScopeMode]; !ok {
+		return nil, fmt.Errorf("invalid SCOPE_MODE '%s': must be full, drive_file, readonly, or sheets_only", ac.ScopeMode)
+	}
+
 	token, credBytes, err := ac.GetCredentials(ctx)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	var opts []option.ClientOption
+	var tokenSource oauth2.TokenSource
+	authMethod := "application_default"
+	isServiceAccount := false
+	serviceAccountEmail := ""
 
 	if token == nil && credBytes != nil {
 		var credMap map[string]any
 		if err := json.Unmarshal(credBytes, &credMap); err == nil {
 			if credType, ok := credMap["type"].(string); ok && credType == "service_account" {
-				creds, err := google.CredentialsFromJSON(ctx, credBytes, requiredScopes...)
+				isServiceAccount = true
+				authMethod = "service_account"
+				serviceAccountEmail, _ = credMap["client_email"].(string)
+				creds, err := google.CredentialsFromJSON(ctx, credBytes, ac.scopes()...)
 				if err != nil {
-					return nil, nil, fmt.Errorf("failed to create service account credentials: %w", err)
+					return nil, fmt.Errorf("failed to create service account credentials: %w", err)
 				}
-				opts = append(opts, option.WithCredentials(creds))
+				tokenSource = creds.TokenSource
 			} else {
-				creds, err := google.CredentialsFromJSON(ctx, credBytes, requiredScopes...)
+				creds, err := google.CredentialsFromJSON(ctx, credBytes, ac.scopes()...)
 				if err != nil {
-					return nil, nil, fmt.Errorf("failed to create credentials: %w", err)
+					return nil, fmt.Errorf("failed to create credentials: %w", err)
 				}
-				opts = append(opts, option.WithCredentials(creds))
+				tokenSource = creds.TokenSource
 			}
 		} else {
-			return nil, nil, fmt.Errorf("failed to parse credentials JSON: %w", err)
+			return nil, fmt.Errorf("failed to parse credentials JSON: %w", err)
 		}
 	} else if token != nil && credBytes != nil {
-		config, err := google.ConfigFromJSON(credBytes, requiredScopes...)
+		config, err := google.ConfigFromJSON(credBytes, ac.scopes()...)
 		if err != nil {
-			return nil, nil, fmt.Errorf("failed to parse OAuth config: %w", err)
+			return nil, fmt.Errorf("failed to parse OAuth config: %w", err)
 		}
-		client := config.Client(ctx, token)
-		opts = append(opts, option.WithHTTPClient(client))
+		authMethod = "oauth"
+		tokenSource = newSavedTokenSource(ctx, ac, config, token)
 	}
 
+	if tokenSource == nil {
+		creds, err := google.FindDefaultCredentials(ctx, ac.scopes()...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create HTTP client: %w", err)
+		}
+		tokenSource = creds.TokenSource
+	}
+	httpClient := oauth2.NewClient(ctx, tokenSource)
+
+	// Every service shares the one client so that all API calls pass through the call count, the
+	// request log, the rate limit, retries and dry runs
+	httpClient = withAPILogging(withAPICallCount(httpClient))
+	if config.RateLimit {
+		httpClient = withSheetsRateLimit(httpClient, config.ReadQuotaPerMinute, config.WriteQuotaPerMinute)
+	}
+	httpClient = withRetries(httpClient, config.Retry)
+	httpClient = withDryRun(httpClient)
+	opts := []option.ClientOption{option.WithHTTPClient(httpClient)}
+
 	sheetsService, err := sheets.NewService(ctx, opts...)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create sheets service: %w", err)
+		return nil, fmt.Errorf("failed to create sheets service: %w", err)
+	}
+
+	services := &Services{
+		Sheets:     sheetsService,
+		HTTPClient: httpClient,
+
+		ServiceAccountEmail: serviceAccountEmail,
+		TokenSource:         tokenSource,
+		AuthMethod:          authMethod,
+		Scopes:              ac.scopes(),
 	}
 
-	return sheetsService, nil, nil
+	if ac.ScopeMode != ScopeModeSheetsOnly {
+		services.Drive, err = drive.NewService(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create drive service: %w", err)
+		}
+	}
+
+	if ac.readsActivity() {
+		services.Activity, err = driveactivity.NewService(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create drive activity service: %w", err)
+		}
+	}
+
+	if ac.writesDocs() {
+		services.Docs, err = docs.NewService(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create docs service: %w", err)
+		}
+	}
+
+	if ac.writesGmail() {
+		gmailOpts := opts
+		// Service accounts have no mailbox of their own and must impersonate a Workspace user
+		if isServiceAccount {
+			if ac.GmailUserEmail == "" {
+				return nil, fmt.Errorf("GMAIL_USER_EMAIL is required for Gmail drafts with a service account")
+			}
+			jwtConfig, err := google.JWTConfigFromJSON(credBytes, GmailComposeScope)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create gmail credentials: %w", err)
+			}
+			jwtConfig.Subject = ac.GmailUserEmail
+			gmailOpts = []option.ClientOption{option.WithHTTPClient(withDryRun(withRetries(withAPILogging(withAPICallCount(jwtConfig.Client(ctx))), config.Retry)))}
+		}
+
+		services.Gmail, err = gmail.NewService(ctx, gmailOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create gmail service: %w", err)
+		}
+	}
+
+	if ac.GroupsDirectory {
+		directoryOpts := opts
+		// Service accounts can only read the directory by impersonating a Workspace admin
+		if isServiceAccount && ac.DirectoryAdminEmail != "" {
+			jwtConfig, err := google.JWTConfigFromJSON(credBytes, GroupMembersScope)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create directory credentials: %w", err)
+			}
+			jwtConfig.Subject = ac.DirectoryAdminEmail
+			directoryOpts = []option.ClientOption{option.WithHTTPClient(withRetries(withAPILogging(withAPICallCount(jwtConfig.Client(ctx))), config.Retry))}
+		}
+
+		services.Directory, err = admin.NewService(ctx, directoryOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create directory service: %w", err)
+		}
+	}
+
+	return services, nil
 }
 
 func (ac *AuthConfig) getTokenFromFile() (*oauth2.Token, error) {
@@ -167,23 +414,6 @@ func (ac *AuthConfig) getTokenFromFile() (*oauth2.Token, error) {
 	return token, nil
 }
 
-func (ac *AuthConfig) getTokenFromWeb(config *oauth2.Config) (*oauth2.Token, error) {
-	authURL := config.AuthCodeURL("state-token", oauth2.AccessTypeOffline)
-	fmt.Printf("Go to the following link in your browser:\n%v\n", authURL)
-	fmt.Print("Enter authorization code: ")
-
-	var authCode string
-	if _, err := fmt.Scan(&authCode); err != nil {
-		return nil, fmt.Errorf("failed to read authorization code: %w", err)
-	}
-
-	token, err := config.Exchange(context.Background(), authCode)
-	if err != nil {
-		return nil, fmt.Errorf("failed to exchange authorization code: %w", err)
-	}
-	return token, nil
-}
-
 func (ac *AuthConfig) saveToken(token *oauth2.Token) error {
 	f, err := os.OpenFile(ac.TokenPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
 	if err != nil {
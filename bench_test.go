@@ -0,0 +1,437 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"google.golang.org/api/option"
+	"google.golang.org/api/sheets/v4"
+)
+
+// The benchmarks run offline on the hot paths of large-sheet handling, so performance work has a
+// baseline: go test -run '^$' -bench . -benchmem
+//
+// The tool call benchmarks go through the whole server against a fake Sheets API. The HTTP one is
+// also the load test: raise -cpu to add concurrent clients, e.g. -bench HTTP -cpu 1,8,32
+
+func BenchmarkParseGridRange(b *testing.B) {
+	ranges := []string{"B7", "A1:C9", "$a$2:$zz$10000", "A:C", "3:10", "A2:C"}
+	for b.Loop() {
+		for _, r := range ranges {
+			if _, err := parseGridRange(0, r); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkSplitSheetPrefix(b *testing.B) {
+	for b.Loop() {
+		if _, _, err := splitSheetPrefix("'Q1 ''24 Sales'!A1:C9"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCoerceCell(b *testing.B) {
+	cells := []any{"1234", "-0.5", "1,234,567.89", "00123", "TRUE", "", "Ada Lovelace", "NaN", 42.0}
+	for b.Loop() {
+		for _, cell := range cells {
+			coerceCell(cell)
+		}
+	}
+}
+
+func BenchmarkParseLocaleNumber(b *testing.B) {
+	cases := []struct {
+		text   string
+		format numberFormat
+	}{
+		{"1.234.567,89", getNumberFormat("de_DE")},
+		{"1 234,5", getNumberFormat("fr_FR")},
+		{"$1,234.56", getNumberFormat("en_US")},
+		{"12%", getNumberFormat("en_US")},
+		{"(1,000)", getNumberFormat("en_US")},
+	}
+	for b.Loop() {
+		for _, c := range cases {
+			parseLocaleNumber(c.text, c.format)
+		}
+	}
+}
+
+func BenchmarkConvertToValues(b *testing.B) {
+	data := make([]any, 10_000)
+	for i := range data {
+		data[i] = []any{fmt.Sprintf("row %d", i), float64(i), i%2 == 0, "2024-03-01"}
+	}
+	for b.Loop() {
+		if _, err := convertToValues(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRespondWithJSON(b *testing.B) {
+	values := make([][]any, 10_000)
+	for i := range values {
+		values[i] = []any{fmt.Sprintf("row %d", i), float64(i), i%2 == 0, "2024-03-01"}
+	}
+	result := sheetDataResult{SpreadsheetID: "bench", ValueRanges: []valueRangeResult{{Range: "Sheet1!A1:D10000", Values: values}}}
+	for b.Loop() {
+		if _, err := respondWithJSON(result); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPlanOperations(b *testing.B) {
+	operations := make([]map[string]any, 0, 300)
+	for i := range 300 {
+		spreadsheetID := fmt.Sprintf("sheet-%d", i%5)
+		tool := "update_cells"
+		if i%3 == 0 {
+			tool = "get_sheet_data"
+		}
+		operations = append(operations, map[string]any{
+			"tool":      tool,
+			"arguments": map[string]any{"spreadsheet_id": spreadsheetID, "sheet": "Data", "range": fmt.Sprintf("A%d:C%d", i+1, i+1)},
+		})
+	}
+	arguments, err := json.Marshal(map[string]any{"operations": operations})
+	if err != nil {
+		b.Fatal(err)
+	}
+	s := &SheetsMCPServer{config: &ServerConfig{ReadQuotaPerMinute: 60, WriteQuotaPerMinute: 60}, metadataCache: newSheetMetadataCache(0)}
+	request := &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Name: "plan_operations", Arguments: arguments}}
+	for b.Loop() {
+		result, err := s.handlePlanOperations(context.Background(), request)
+		if err != nil || result.IsError {
+			b.Fatal("plan_operations failed")
+		}
+	}
+}
+
+func BenchmarkReadXLSXWorksheet(b *testing.B) {
+	workbook := benchmarkWorkbook(b, 5_000, 10)
+	reader, err := zip.NewReader(bytes.NewReader(workbook), int64(len(workbook)))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(workbook)))
+	for b.Loop() {
+		if _, err := readXLSXWorksheet(reader, ""); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// benchmarkWorkbook builds an xlsx file in memory whose one sheet mixes shared strings and numbers
+func benchmarkWorkbook(b *testing.B, rows, columns int) []byte {
+	b.Helper()
+	var sheet strings.Builder
+	sheet.WriteString(`<?xml version="1.0" encoding="UTF-8"?><worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for r := range rows {
+		fmt.Fprintf(&sheet, `<row r="%d">`, r+1)
+		for c := range columns {
+			ref := fmt.Sprintf("%s%d", columnToLetter(int64(c)), r+1)
+			if c%2 == 0 {
+				fmt.Fprintf(&sheet, `<c r="%s" t="s"><v>%d</v></c>`, ref, c/2)
+			} else {
+				fmt.Fprintf(&sheet, `<c r="%s"><v>%d.5</v></c>`, ref, r*c)
+			}
+		}
+		sheet.WriteString(`</row>`)
+	}
+	sheet.WriteString(`</sheetData></worksheet>`)
+
+	var shared strings.Builder
+	shared.WriteString(`<?xml version="1.0" encoding="UTF-8"?><sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	for c := range (columns + 1) / 2 {
+		fmt.Fprintf(&shared, `<si><t>text %d</t></si>`, c)
+	}
+	shared.WriteString(`</sst>`)
+
+	files := map[string]string{
+		"xl/workbook.xml":            `<?xml version="1.0" encoding="UTF-8"?><workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Data" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<?xml version="1.0" encoding="UTF-8"?><Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`,
+		"xl/sharedStrings.xml":       shared.String(),
+		"xl/worksheets/sheet1.xml":   sheet.String(),
+	}
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := writer.Create(name)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			b.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		b.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// benchmarkRows is the number of data rows below the header row of the fake Sheets API's one sheet
+const benchmarkRows = 1000
+
+// fakeSheetsAPI answers the Sheets API calls the benchmarked tools make for a spreadsheet with one
+// sheet, Data, holding a header row and benchmarkRows rows of five columns
+func fakeSheetsAPI() http.Handler {
+	table := [][]any{{"ID", "Name", "Amount", "Paid", "Date"}}
+	for i := range benchmarkRows {
+		table = append(table, []any{fmt.Sprintf("ORD-%d", i), fmt.Sprintf("Customer %d", i%50), float64(i) * 1.5, i%2 == 0, "2024-03-01"})
+	}
+	spreadsheet := &sheets.Spreadsheet{
+		SpreadsheetId: "bench",
+		Properties:    &sheets.SpreadsheetProperties{Title: "Bench", Locale: "en_US", TimeZone: "UTC"},
+		Sheets: []*sheets.Sheet{{Properties: &sheets.SheetProperties{
+			SheetId:        0,
+			Title:          "Data",
+			SheetType:      "GRID",
+			GridProperties: &sheets.GridProperties{RowCount: benchmarkRows + 1, ColumnCount: 5},
+		}}},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v4/spreadsheets/{id}", func(w http.ResponseWriter, r *http.Request) {
+		writeBenchmarkJSON(w, spreadsheet)
+	})
+	mux.HandleFunc("GET /v4/spreadsheets/{id}/values/{range}", func(w http.ResponseWriter, r *http.Request) {
+		writeBenchmarkJSON(w, &sheets.ValueRange{Range: "Data!A1:E1001", MajorDimension: "ROWS", Values: table})
+	})
+	mux.HandleFunc("GET /v4/spreadsheets/{id}/values:batchGet", func(w http.ResponseWriter, r *http.Request) {
+		response := &sheets.BatchGetValuesResponse{SpreadsheetId: "bench"}
+		for _, rangeStr := range r.URL.Query()["ranges"] {
+			response.ValueRanges = append(response.ValueRanges, &sheets.ValueRange{Range: rangeStr, MajorDimension: "ROWS", Values: table})
+		}
+		writeBenchmarkJSON(w, response)
+	})
+	mux.HandleFunc("PUT /v4/spreadsheets/{id}/values/{range}", func(w http.ResponseWriter, r *http.Request) {
+		var body sheets.ValueRange
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		cells := 0
+		for _, row := range body.Values {
+			cells += len(row)
+		}
+		writeBenchmarkJSON(w, &sheets.UpdateValuesResponse{
+			SpreadsheetId: "bench",
+			UpdatedRange:  r.PathValue("range"),
+			UpdatedRows:   int64(len(body.Values)),
+			UpdatedCells:  int64(cells),
+		})
+	})
+	mux.HandleFunc("POST /v4/spreadsheets/{id}", func(w http.ResponseWriter, r *http.Request) {
+		// The batch update path is /v4/spreadsheets/{id}:batchUpdate, which the router sees as one segment
+		var body sheets.BatchUpdateSpreadsheetRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || !strings.HasSuffix(r.URL.Path, ":batchUpdate") {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		response := &sheets.BatchUpdateSpreadsheetResponse{SpreadsheetId: "bench"}
+		for _, request := range body.Requests {
+			reply := &sheets.Response{}
+			if request.FindReplace != nil {
+				reply.FindReplace = &sheets.FindReplaceResponse{OccurrencesChanged: benchmarkRows, ValuesChanged: benchmarkRows}
+			}
+			response.Replies = append(response.Replies, reply)
+		}
+		writeBenchmarkJSON(w, response)
+	})
+	return mux
+}
+
+func writeBenchmarkJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// newBenchmarkServer builds the server the way NewSheetsMCPServer does, with its Sheets client
+// pointed at a fake Sheets API and without Drive, so every tool runs through the same wrappers
+func newBenchmarkServer(b *testing.B) *SheetsMCPServer {
+	b.Helper()
+	fake := httptest.NewServer(fakeSheetsAPI())
+	b.Cleanup(fake.Close)
+
+	config, err := LoadServerConfig()
+	if err != nil {
+		b.Fatal(err)
+	}
+	httpClient := withDryRun(withRetries(withAPILogging(withAPICallCount(fake.Client())), config.Retry))
+	sheetsService, err := sheets.NewService(context.Background(), option.WithHTTPClient(httpClient), option.WithEndpoint(fake.URL+"/"))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	s := &SheetsMCPServer{
+		sheetsService:   sheetsService,
+		httpClient:      httpClient,
+		authMethod:      "service_account",
+		scopeMode:       ScopeModeSheetsOnly,
+		config:          config,
+		metadataCache:   newSheetMetadataCache(config.MetadataCacheTTL),
+		resultCache:     newResultCache(config.ResultCacheTTL),
+		writeQueue:      newWriteQueue(),
+		sessionStats:    newSessionStatsRegistry(),
+		exports:         newExportStore(),
+		spreadsheetList: newSpreadsheetListCache(),
+		toolNames:       make(map[string]bool),
+	}
+	s.mcpServer = mcp.NewServer(&mcp.Implementation{Name: "Google Spreadsheet", Version: "1.0.0"}, nil)
+	s.mcpServer.AddReceivingMiddleware(s.withSpreadsheetResources)
+	s.registerTools()
+	if err := s.checkToolTables(); err != nil {
+		b.Fatal(err)
+	}
+	return s
+}
+
+// connectBenchmarkClient opens an MCP session with the server over an in-memory transport
+func connectBenchmarkClient(b *testing.B, s *SheetsMCPServer) *mcp.ClientSession {
+	b.Helper()
+	ctx := context.Background()
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	serverSession, err := s.mcpServer.Connect(ctx, serverTransport, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { serverSession.Close() })
+	session, err := mcp.NewClient(&mcp.Implementation{Name: "bench", Version: "1.0.0"}, nil).Connect(ctx, clientTransport, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { session.Close() })
+	return session
+}
+
+func benchmarkToolCall(b *testing.B, tool string, args map[string]any, wantError bool) {
+	session := connectBenchmarkClient(b, newBenchmarkServer(b))
+	params := &mcp.CallToolParams{Name: tool, Arguments: args}
+	for b.Loop() {
+		result, err := session.CallTool(context.Background(), params)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if result.IsError != wantError {
+			b.Fatalf("%s: unexpected result %s", tool, benchmarkResultText(result))
+		}
+	}
+}
+
+func benchmarkResultText(result *mcp.CallToolResult) string {
+	if text, ok := result.Content[0].(*mcp.TextContent); ok {
+		return text.Text
+	}
+	return "non-text content"
+}
+
+func BenchmarkToolCallGetSheetData(b *testing.B) {
+	benchmarkToolCall(b, "get_sheet_data", map[string]any{"spreadsheet_id": "bench", "sheet": "Data"}, false)
+}
+
+func BenchmarkToolCallQuerySheet(b *testing.B) {
+	benchmarkToolCall(b, "query_sheet", map[string]any{
+		"spreadsheet_id": "bench",
+		"sheet":          "Data",
+		"query":          "SELECT Name, sum(Amount) AS Total WHERE Paid = true GROUP BY Name ORDER BY Total DESC LIMIT 10",
+	}, false)
+}
+
+func BenchmarkToolCallUpdateCells(b *testing.B) {
+	data := make([]any, 100)
+	for i := range data {
+		data[i] = []any{fmt.Sprintf("ORD-%d", i), "Customer", float64(i), true, "2024-03-01"}
+	}
+	benchmarkToolCall(b, "update_cells", map[string]any{"spreadsheet_id": "bench", "sheet": "Data", "range": "A2:E101", "data": data}, false)
+}
+
+func BenchmarkToolCallFindReplace(b *testing.B) {
+	benchmarkToolCall(b, "find_replace", map[string]any{"spreadsheet_id": "bench", "sheet": "Data", "find": "Customer", "replacement": "Client"}, false)
+}
+
+// Rejected arguments stop in withValidation, before any handler or API call
+func BenchmarkToolCallInvalidArguments(b *testing.B) {
+	benchmarkToolCall(b, "update_cells", map[string]any{"spreadsheet_id": "bench", "sheet": "Data", "range": 42}, true)
+}
+
+// BenchmarkHTTPToolCalls serves the tools with runHTTP over streamable HTTP and calls get_sheet_data
+// from as many concurrent clients as the parallelism allows, each with its own session
+func BenchmarkHTTPToolCalls(b *testing.B) {
+	logger := slog.Default()
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+	b.Cleanup(func() { slog.SetDefault(logger) })
+
+	s := newBenchmarkServer(b)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		b.Fatal(err)
+	}
+	s.config.HTTPAddr = listener.Addr().String()
+	listener.Close()
+	s.config.Transport = "http"
+	s.config.HTTPAuth = HTTPAuthConfig{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- s.runHTTP(ctx) }()
+	b.Cleanup(func() {
+		cancel()
+		if err := <-served; err != nil && !errors.Is(err, http.ErrServerClosed) {
+			b.Error(err)
+		}
+	})
+	endpoint := "http://" + s.config.HTTPAddr + mcpHTTPPath
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		conn, err := net.Dial("tcp", s.config.HTTPAddr)
+		if err == nil {
+			conn.Close()
+			break
+		}
+		if time.Now().After(deadline) {
+			b.Fatalf("server did not start: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	params := &mcp.CallToolParams{Name: "get_sheet_data", Arguments: map[string]any{"spreadsheet_id": "bench", "sheet": "Data", "range": "A1:E100"}}
+	b.RunParallel(func(pb *testing.PB) {
+		client := mcp.NewClient(&mcp.Implementation{Name: "bench", Version: "1.0.0"}, nil)
+		session, err := client.Connect(ctx, &mcp.StreamableClientTransport{Endpoint: endpoint}, nil)
+		if err != nil {
+			b.Error(err)
+			return
+		}
+		defer session.Close()
+		for pb.Next() {
+			result, err := session.CallTool(ctx, params)
+			if err != nil {
+				b.Error(err)
+				return
+			}
+			if result.IsError {
+				b.Errorf("get_sheet_data: unexpected result %s", benchmarkResultText(result))
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// sheetMetadataCache keeps the sheet properties of recently used spreadsheets so that
+// resolving sheet names to IDs does not cost an API call on every tool invocation. Entries are kept
+// per profile, since profiles may see a spreadsheet differently, and a change made through any
+// profile drops them all.
+type sheetMetadataCache struct {
+	mu sync.Mutex
+	// entries holds the metadata by spreadsheet ID, then profile
+	entries map[string]map[string]sheetMetadataEntry
+	ttl     time.Duration
+	hits    int64
+	misses  int64
+}
+
+type sheetMetadataEntry struct {
+	sheets    []*sheets.SheetProperties
+	fetchedAt time.Time
+}
+
+// CacheStats describes the current state of a cache
+type CacheStats struct {
+	Hits       int64  `json:"hits"`
+	Misses     int64  `json:"misses"`
+	Size       int    `json:"size"`
+	TTL        string `json:"ttl"`
+	Enabled    bool   `json:"enabled"`
+	HitRatePct int64  `json:"hitRatePct"`
+}
+
+func newSheetMetadataCache(ttl time.Duration) *sheetMetadataCache {
+	return &sheetMetadataCache{
+		ttl:     ttl,
+		entries: make(map[string]map[string]sheetMetadataEntry),
+	}
+}
+
+func (c *sheetMetadataCache) enabled() bool {
+	return c.ttl > 0
+}
+
+func (c *sheetMetadataCache) get(profile, spreadsheetID string) ([]*sheets.SheetProperties, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.enabled() {
+		c.misses++
+		return nil, false
+	}
+
+	entry, ok := c.entries[spreadsheetID][profile]
+	if !ok || time.Since(entry.fetchedAt) > c.ttl {
+		delete(c.entries[spreadsheetID], profile)
+		if len(c.entries[spreadsheetID]) == 0 {
+			delete(c.entries, spreadsheetID)
+		}
+		c.misses++
+		return nil, false
+	}
+
+	c.hits++
+	return entry.sheets, true
+}
+
+func (c *sheetMetadataCache) set(profile, spreadsheetID string, props []*sheets.SheetProperties) {
+	if !c.enabled() {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries[spreadsheetID] == nil {
+		c.entries[spreadsheetID] = make(map[string]sheetMetadataEntry)
+	}
+	c.entries[spreadsheetID][profile] = sheetMetadataEntry{
+		sheets:    props,
+		fetchedAt: time.Now(),
+	}
+}
+
+// contains reports whether fresh metadata is cached for a spreadsheet without counting as a lookup
+func (c *sheetMetadataCache) contains(profile, spreadsheetID string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[spreadsheetID][profile]
+	return ok && c.enabled() && time.Since(entry.fetchedAt) <= c.ttl
+}
+
+func (c *sheetMetadataCache) invalidate(spreadsheetID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, spreadsheetID)
+}
+
+func (c *sheetMetadataCache) stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := CacheStats{
+		Hits:    c.hits,
+		Misses:  c.misses,
+		Size:    c.size(),
+		TTL:     c.ttl.String(),
+		Enabled: c.enabled(),
+	}
+	if total := c.hits + c.misses; total > 0 {
+		stats.HitRatePct = c.hits * 100 / total
+	}
+	return stats
+}
+
+// size counts the cached entries of every profile; callers hold c.mu
+func (c *sheetMetadataCache) size() int {
+	size := 0
+	for _, byProfile := range c.entries {
+		size += len(byProfile)
+	}
+	return size
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"google.golang.org/api/googleapi"
+)
+
+// driveTools cannot work without Google Drive access and are not registered when it is unavailable
+var driveTools = map[string]bool{
+	"generate_change_digest":     true,
+	"list_revisions":             true,
+	"get_revision":               true,
+	"delete_spreadsheet":         true,
+	"restore_spreadsheet":        true,
+	"rename_spreadsheet":         true,
+	"star_spreadsheet":           true,
+	"unstar_spreadsheet":         true,
+	"move_spreadsheet":           true,
+	"copy_spreadsheet":           true,
+	"import_xlsx":                true,
+	"search_spreadsheets":        true,
+	"list_spreadsheets":          true,
+	"get_spreadsheet_metadata":   true,
+	"list_shared_drives":         true,
+	"export_spreadsheet":         true,
+	"create_snapshot":            true,
+	"list_snapshots":             true,
+	"prune_snapshots":            true,
+	"share_spreadsheet":          true,
+	"list_permissions":           true,
+	"remove_permission":          true,
+	"set_link_sharing":           true,
+	"revoke_all_external_access": true,
+	"draft_email_with_export":    true,
+}
+
+// driveFileTools need more Drive access than drive_file mode grants, such as the list of shared drives
+var driveFileTools = map[string]bool{
+	"list_shared_drives": true,
+}
+
+// scopeAllows reports whether the scope mode leaves a tool usable. Readonly mode keeps only the tools
+// that never write; sheets_only mode is covered by driveTools, since Drive is disabled.
+func (s *SheetsMCPServer) scopeAllows(name string) bool {
+	switch s.scopeMode {
+	case ScopeModeReadOnly:
+		return !writesSpreadsheets(name)
+	case ScopeModeDriveFile:
+		return !driveFileTools[name]
+	}
+	return true
+}
+
+// checkDriveAccess probes Drive once at startup and falls back to Sheets-only mode when the credentials
+// lack the Drive scope or the Drive API is disabled, instead of failing on every Drive call later.
+// Other failures, such as network errors, leave Drive enabled so they surface on the calls themselves.
+func (s *SheetsMCPServer) checkDriveAccess(ctx context.Context) {
+	if s.scopeMode == ScopeModeSheetsOnly {
+		s.disableDrive("SCOPE_MODE is sheets_only")
+		return
+	}
+
+	_, err := s.driveService.About.Get().Fields("user").Context(ctx).Do()
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusForbidden {
+		reason := apiErr.Message
+		if hint := s.apiErrorHint(err); hint != "" {
+			reason += ". " + hint
+		}
+		s.disableDrive(reason)
+	}
+}
+
+func (s *SheetsMCPServer) disableDrive(reason string) {
+	s.driveService = nil
+	s.activityService = nil
+	s.driveUnavailable = reason
+	slog.Warn("Google Drive is unavailable, running with Sheets tools only", "reason", reason)
+}
+
+// requireDrive returns an error explaining why a feature that needs Drive cannot be used, or nil
+func (s *SheetsMCPServer) requireDrive(feature string) error {
+	if s.driveService != nil {
+		return nil
+	}
+	return fmt.Errorf("%s needs Google Drive access, which is unavailable: %s", feature, s.driveUnavailable)
+}
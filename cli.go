@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const callUsage = `usage: sheets-mcp call <tool> [--args '<json>']
+
+Runs one tool and prints its result to stdout. The exit status is 1 when the tool reports an error.
+Pass --args - to read the arguments from stdin.
+`
+
+// runCall implements the call subcommand and returns the process exit status
+func runCall(ctx context.Context, argv []string) int {
+	flags := flag.NewFlagSet("call", flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, callUsage) }
+	argsJSON := flags.String("args", "{}", "tool arguments as a JSON object, or - to read them from stdin")
+
+	// Accept the tool name before or after the flags
+	tool := ""
+	if len(argv) > 0 && !strings.HasPrefix(argv[0], "-") {
+		tool, argv = argv[0], argv[1:]
+	}
+	if err := flags.Parse(argv); err != nil {
+		return 2
+	}
+	if tool == "" && flags.NArg() > 0 {
+		tool = flags.Arg(0)
+	}
+	if tool == "" {
+		flags.Usage()
+		return 2
+	}
+
+	raw := []byte(*argsJSON)
+	if *argsJSON == "-" {
+		var err error
+		if raw, err = io.ReadAll(os.Stdin); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to read arguments: %v\n", err)
+			return 2
+		}
+	}
+	var args map[string]any
+	if err := json.Unmarshal(raw, &args); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid --args: must be a JSON object: %v\n", err)
+		return 2
+	}
+
+	srv, err := NewSheetsMCPServer(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create sheets MCP server: %v\n", err)
+		return 1
+	}
+
+	result, err := srv.callTool(ctx, tool, args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to call %s: %v\n", tool, err)
+		return 1
+	}
+
+	// The text of a structured result is only a summary, so the structured content is printed instead
+	if result.StructuredContent != nil && !isErrorResult(result) {
+		data, err := json.Marshal(result.StructuredContent)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to print result: %v\n", err)
+			return 1
+		}
+		fmt.Println(string(data))
+		return 0
+	}
+	for _, content := range result.Content {
+		if text, ok := content.(*mcp.TextContent); ok {
+			fmt.Println(text.Text)
+			continue
+		}
+		data, err := json.Marshal(content)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to print result: %v\n", err)
+			return 1
+		}
+		fmt.Println(string(data))
+	}
+
+	if isErrorResult(result) {
+		return 1
+	}
+	return 0
+}
+
+// callTool runs one tool through an in-memory client session, so the call goes through the same
+// wrappers (write queue, caches, tenant settings) as calls from an MCP client
+func (s *SheetsMCPServer) callTool(ctx context.Context, tool string, args map[string]any) (*mcp.CallToolResult, error) {
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	serverSession, err := s.mcpServer.Connect(ctx, serverTransport, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer serverSession.Close()
+
+	client := mcp.NewClient(&mcp.Implementation{Name: "sheets-mcp-cli", Version: "1.0.0"}, nil)
+	clientSession, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer clientSession.Close()
+
+	return clientSession.CallTool(ctx, &mcp.CallToolParams{Name: tool, Arguments: args})
+}
@@ -0,0 +1,364 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ServerConfig struct {
+	// Transport is stdio (one client per process), or http (streamable HTTP) or sse served on HTTPAddr
+	Transport string
+	HTTPAddr  string
+	// AllowedHosts are the Host headers HTTP requests may carry, and AllowedOrigins the browser origins
+	// besides those of AllowedHosts, so a web page cannot reach the server through DNS rebinding
+	AllowedHosts   []string
+	AllowedOrigins []string
+	// FileRoot is the directory file_path arguments must stay within; over HTTP they are refused without it
+	FileRoot string
+	// KeepAlive is how often HTTP and SSE clients are pinged, so dead connections are noticed and closed
+	KeepAlive time.Duration
+	// ToolTimeout bounds each tool call; ToolTimeouts overrides it for individual tools
+	ToolTimeout  time.Duration
+	ToolTimeouts map[string]time.Duration
+
+	// MaxResponseCells caps the cells a single read returns; 0 means no cap
+	MaxResponseCells   int64
+	MetadataCacheTTL   time.Duration
+	ResultCacheTTL     time.Duration
+	ProtectHeaderRows  int64
+	ResourceSigningKey []byte
+	DriveFolderID      string
+	SharedDriveID      string
+	Sharing            SharingPolicy
+	// EnabledTools, when not empty, are the only tools registered; DisabledTools are never registered
+	EnabledTools  []string
+	DisabledTools []string
+	// SanitizeInput is the default of the write tools' sanitize_input argument
+	SanitizeInput bool
+	// LocaleNumbers parses locale-formatted numbers on writes that name no locale, using the spreadsheet's locale
+	LocaleNumbers bool
+	// DryRun makes every mutating tool call a dry run that returns the requests instead of sending them
+	DryRun bool
+	// AuditLogFile is the JSONL file every mutating tool call is recorded in ("" disables it)
+	AuditLogFile string
+	// AuditSheet records every mutating tool call in a hidden _audit sheet of the spreadsheet it changed
+	AuditSheet bool
+	// ConfirmDestructive makes irreversible calls ask the user for confirmation through MCP elicitation
+	ConfirmDestructive bool
+	// ConfirmClearCells is how many cells clear_range may clear without confirmation (0 never asks)
+	ConfirmClearCells int64
+	Snapshots         SnapshotRetention
+	HTTPAuth          HTTPAuthConfig
+	Retry             RetryPolicy
+	// Tenants are the TENANTS_FILE tenants HTTP clients are bound to by their credentials
+	Tenants map[string]*tenantConfig
+	// Tenant names the tenant the stdio client runs as
+	Tenant string
+
+	// Per-minute Sheets API request budgets (the API defaults per user); the batching planner warns
+	// about plans that exceed them and, with RateLimit on, requests are held back to stay within them
+	ReadQuotaPerMinute  int64
+	WriteQuotaPerMinute int64
+	RateLimit           bool
+}
+
+// SharingPolicy limits what the sharing tools may grant, so a prompt cannot overshare a spreadsheet
+type SharingPolicy struct {
+	DefaultRole     string
+	AllowedRoles    []string
+	InternalDomains []string
+	ExternalRoles   []string
+	AllowAnyone     bool
+}
+
+// SnapshotRetention decides which snapshots prune_snapshots keeps: the newest of each day for
+// DailyDays, then the newest of each ISO week for WeeklyDays; anything older is pruned
+type SnapshotRetention struct {
+	DailyDays  int64
+	WeeklyDays int64
+}
+
+// HTTPAuthConfig decides how HTTP clients authenticate; with no tokens and no issuer, HTTP is unauthenticated
+type HTTPAuthConfig struct {
+	// Tokens are static bearer tokens, each identifying one client
+	Tokens []string
+	// OIDCIssuer verifies bearer tokens as JWTs signed with the issuer's published keys
+	OIDCIssuer string
+	// OIDCClientID is the audience OIDC tokens must be issued for, so tokens the issuer made for other
+	// applications are refused
+	OIDCClientID string
+	// OIDCAllowed are the OIDC users let in, by subject, email address, or @domain of their email; any
+	// user the issuer knows could otherwise connect
+	OIDCAllowed []string
+	// RateLimitPerMinute caps the requests of each client; 0 means unlimited
+	RateLimitPerMinute int64
+}
+
+func (c HTTPAuthConfig) enabled() bool {
+	return len(c.Tokens) > 0 || c.OIDCIssuer != ""
+}
+
+func LoadServerConfig() (*ServerConfig, error) {
+	metadataCacheTTL, err := getEnvDuration("METADATA_CACHE_TTL", time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	resultCacheTTL, err := getEnvDuration("RESULT_CACHE_TTL", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	maxResponseCells, err := getEnvInt("MAX_RESPONSE_CELLS", 20000)
+	if err != nil {
+		return nil, err
+	}
+
+	protectHeaderRows, err := getEnvInt("PROTECT_HEADER_ROWS", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	confirmClearCells, err := getEnvInt("CONFIRM_CLEAR_CELLS", 1000)
+	if err != nil {
+		return nil, err
+	}
+
+	readQuota, err := getEnvInt("SHEETS_READ_QUOTA_PER_MINUTE", 60)
+	if err != nil {
+		return nil, err
+	}
+
+	writeQuota, err := getEnvInt("SHEETS_WRITE_QUOTA_PER_MINUTE", 60)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := strings.ToLower(getEnvOrDefault("MCP_TRANSPORT", "stdio"))
+	if transport != "stdio" && transport != "http" && transport != "sse" {
+		return nil, fmt.Errorf("invalid MCP_TRANSPORT: %s (use stdio, http, or sse)", transport)
+	}
+
+	httpAddr := getEnvOrDefault("MCP_HTTP_ADDR", "localhost:8080")
+	allowedHosts := getEnvList("MCP_ALLOWED_HOSTS", nil)
+	if allowedHosts == nil {
+		allowedHosts = []string{"localhost", "127.0.0.1", "::1"}
+		if host, _, err := net.SplitHostPort(httpAddr); err == nil && host != "" && !net.ParseIP(host).IsUnspecified() {
+			allowedHosts = append(allowedHosts, strings.ToLower(host))
+		}
+	}
+
+	fileRoot := os.Getenv("FILE_ROOT")
+	if fileRoot != "" {
+		if fileRoot, err = filepath.Abs(fileRoot); err != nil {
+			return nil, fmt.Errorf("invalid FILE_ROOT: %w", err)
+		}
+	}
+
+	keepAlive, err := getEnvDuration("MCP_KEEPALIVE", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	toolTimeout, err := getEnvDuration("TOOL_TIMEOUT", 2*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	toolTimeouts := make(map[string]time.Duration)
+	for _, item := range getEnvList("TOOL_TIMEOUTS", nil) {
+		name, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid TOOL_TIMEOUTS entry '%s': use tool=duration", item)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid TOOL_TIMEOUTS entry '%s': %w", item, err)
+		}
+		toolTimeouts[strings.TrimSpace(name)] = d
+	}
+
+	snapshots := SnapshotRetention{}
+	if snapshots.DailyDays, err = getEnvInt("SNAPSHOT_KEEP_DAILY_DAYS", 7); err != nil {
+		return nil, err
+	}
+	if snapshots.WeeklyDays, err = getEnvInt("SNAPSHOT_KEEP_WEEKLY_DAYS", 31); err != nil {
+		return nil, err
+	}
+
+	retry := RetryPolicy{}
+	if retry.MaxRetries, err = getEnvInt("API_MAX_RETRIES", 5); err != nil {
+		return nil, err
+	}
+	if retry.BaseDelay, err = getEnvDuration("API_RETRY_BASE_DELAY", time.Second); err != nil {
+		return nil, err
+	}
+	if retry.MaxDelay, err = getEnvDuration("API_RETRY_MAX_DELAY", 32*time.Second); err != nil {
+		return nil, err
+	}
+
+	httpAuth := HTTPAuthConfig{
+		OIDCIssuer:   strings.TrimSuffix(os.Getenv("HTTP_AUTH_OIDC_ISSUER"), "/"),
+		OIDCClientID: os.Getenv("HTTP_AUTH_OIDC_CLIENT_ID"),
+	}
+	// Tokens are secrets and case-sensitive, so they cannot go through getEnvList
+	for _, token := range strings.Split(os.Getenv("HTTP_AUTH_TOKENS"), ",") {
+		if token = strings.TrimSpace(token); token != "" {
+			httpAuth.Tokens = append(httpAuth.Tokens, token)
+		}
+	}
+	if httpAuth.RateLimitPerMinute, err = getEnvInt("HTTP_AUTH_RATE_LIMIT", 0); err != nil {
+		return nil, err
+	}
+	// Subjects are case-sensitive, so the list cannot go through getEnvList either
+	for _, allowed := range strings.Split(os.Getenv("HTTP_AUTH_OIDC_ALLOWED"), ",") {
+		if allowed = strings.TrimSpace(allowed); allowed != "" {
+			httpAuth.OIDCAllowed = append(httpAuth.OIDCAllowed, allowed)
+		}
+	}
+	if httpAuth.OIDCIssuer != "" && len(httpAuth.OIDCAllowed) == 0 {
+		return nil, fmt.Errorf("HTTP_AUTH_OIDC_ISSUER needs HTTP_AUTH_OIDC_ALLOWED to say which of the issuer's users may connect")
+	}
+	if httpAuth.OIDCIssuer != "" && httpAuth.OIDCClientID == "" {
+		return nil, fmt.Errorf("HTTP_AUTH_OIDC_ISSUER needs HTTP_AUTH_OIDC_CLIENT_ID, the audience its tokens must be issued for")
+	}
+
+	tenants, err := loadTenants(os.Getenv("TENANTS_FILE"))
+	if err != nil {
+		return nil, err
+	}
+	tenant := os.Getenv("TENANT")
+	if tenant != "" {
+		if transport != "stdio" {
+			return nil, fmt.Errorf("TENANT only applies to MCP_TRANSPORT=stdio; HTTP clients are bound to tenants by their credentials")
+		}
+		if _, ok := tenants[tenant]; !ok {
+			return nil, fmt.Errorf("invalid TENANT: %s is not defined in TENANTS_FILE", tenant)
+		}
+	}
+	if tenants != nil && transport != "stdio" && !httpAuth.enabled() {
+		return nil, fmt.Errorf("TENANTS_FILE needs HTTP_AUTH_TOKENS or HTTP_AUTH_OIDC_ISSUER to bind HTTP clients to tenants")
+	}
+
+	sharing := SharingPolicy{
+		DefaultRole:     strings.ToLower(getEnvOrDefault("SHARING_DEFAULT_ROLE", "writer")),
+		AllowedRoles:    getEnvList("SHARING_ALLOWED_ROLES", []string{"reader", "commenter", "writer"}),
+		InternalDomains: getEnvList("SHARING_INTERNAL_DOMAINS", nil),
+		ExternalRoles:   getEnvList("SHARING_EXTERNAL_ROLES", []string{"reader", "commenter"}),
+		AllowAnyone:     os.Getenv("SHARING_ALLOW_ANYONE") == "true",
+	}
+	if !shareRoles[sharing.DefaultRole] {
+		return nil, fmt.Errorf("invalid SHARING_DEFAULT_ROLE: %s", sharing.DefaultRole)
+	}
+
+	return &ServerConfig{
+		Transport: transport,
+		HTTPAddr:  httpAddr,
+		KeepAlive: keepAlive,
+
+		AllowedHosts:   allowedHosts,
+		AllowedOrigins: getEnvList("MCP_ALLOWED_ORIGINS", nil),
+		FileRoot:       fileRoot,
+
+		ToolTimeout:  toolTimeout,
+		ToolTimeouts: toolTimeouts,
+
+		MaxResponseCells:   maxResponseCells,
+		MetadataCacheTTL:   metadataCacheTTL,
+		ResultCacheTTL:     resultCacheTTL,
+		ProtectHeaderRows:  protectHeaderRows,
+		ResourceSigningKey: []byte(os.Getenv("RESOURCE_SIGNING_KEY")),
+		DriveFolderID:      os.Getenv("DRIVE_FOLDER_ID"),
+		SharedDriveID:      os.Getenv("SHARED_DRIVE_ID"),
+		Sharing:            sharing,
+		EnabledTools:       getEnvList("ENABLED_TOOLS", nil),
+		DisabledTools:      getEnvList("DISABLED_TOOLS", nil),
+		SanitizeInput:      os.Getenv("SANITIZE_INPUT") == "true",
+		LocaleNumbers:      os.Getenv("LOCALE_NUMBERS") == "true",
+		DryRun:             os.Getenv("DRY_RUN") == "true",
+		AuditLogFile:       os.Getenv("AUDIT_LOG_FILE"),
+		AuditSheet:         os.Getenv("AUDIT_SHEET") == "true",
+		ConfirmDestructive: os.Getenv("CONFIRM_DESTRUCTIVE") == "true",
+		ConfirmClearCells:  confirmClearCells,
+		Snapshots:          snapshots,
+		HTTPAuth:           httpAuth,
+		Retry:              retry,
+		Tenants:            tenants,
+		Tenant:             tenant,
+
+		ReadQuotaPerMinute:  readQuota,
+		WriteQuotaPerMinute: writeQuota,
+		RateLimit:           os.Getenv("SHEETS_RATE_LIMIT") != "false",
+	}, nil
+}
+
+// toolEnabled reports whether the ENABLED_TOOLS and DISABLED_TOOLS settings let a tool be registered
+func (c *ServerConfig) toolEnabled(name string) bool {
+	if len(c.EnabledTools) > 0 && !slices.Contains(c.EnabledTools, name) {
+		return false
+	}
+	return !slices.Contains(c.DisabledTools, name)
+}
+
+// toolTimeout returns how long a call to the tool may take; 0 means no limit
+func (c *ServerConfig) toolTimeout(name string) time.Duration {
+	if d, ok := c.ToolTimeouts[name]; ok {
+		return d
+	}
+	return c.ToolTimeout
+}
+
+// getEnvDuration reads a Go duration (e.g. "30s", "5m") from the environment; "0" disables the feature it controls
+func getEnvDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	if value == "0" {
+		return 0, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return d, nil
+}
+
+func getEnvInt(key string, defaultValue int64) (int64, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return n, nil
+}
+
+// getEnvList reads a comma-separated, case-insensitive list; "none" yields an empty list
+func getEnvList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	if strings.EqualFold(value, "none") {
+		return []string{}
+	}
+
+	var items []string
+	for item := range strings.SplitSeq(value, ",") {
+		if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
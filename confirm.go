@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// destructiveAction describes what a call would irreversibly do when it needs the user's confirmation
+// under CONFIRM_DESTRUCTIVE, or returns "" when it may run straight away
+func (s *SheetsMCPServer) destructiveAction(name string, args map[string]any) string {
+	spreadsheetID, sheet, rangeStr := parseCommonArgs(args)
+	switch name {
+	case "delete_sheet":
+		return fmt.Sprintf("Delete sheet '%s' of spreadsheet %s with all its data", sheet, spreadsheetID)
+	case "delete_spreadsheet":
+		// A trashed spreadsheet can be brought back with restore_spreadsheet
+		if parseArgument(args, "permanent", false) {
+			return fmt.Sprintf("Permanently delete spreadsheet %s", spreadsheetID)
+		}
+	case "find_replace":
+		if parseArgument(args, "all_sheets", false) {
+			return fmt.Sprintf("Replace every '%s' in all sheets of spreadsheet %s", parseArgument(args, "find", ""), spreadsheetID)
+		}
+	case "clear_range":
+		r, err := parseGridRange(0, rangeStr)
+		// An invalid range is rejected by the handler before anything is cleared
+		if err != nil || s.config.ConfirmClearCells <= 0 {
+			return ""
+		}
+		if !isBounded(r) {
+			return fmt.Sprintf("Clear all of %s in sheet '%s' of spreadsheet %s", rangeStr, sheet, spreadsheetID)
+		}
+		if cells := (r.EndRowIndex - r.StartRowIndex) * (r.EndColumnIndex - r.StartColumnIndex); cells > s.config.ConfirmClearCells {
+			return fmt.Sprintf("Clear %d cells (%s) in sheet '%s' of spreadsheet %s", cells, rangeStr, sheet, spreadsheetID)
+		}
+	}
+	return ""
+}
+
+// withConfirmation asks the user, through an MCP elicitation request, to confirm calls that would
+// irreversibly destroy data before they run. Clients that cannot show such a prompt get an error
+// instead, since the operator asked for a person to approve these calls. Dry runs are not prompted.
+func (s *SheetsMCPServer) withConfirmation(name string, handler mcp.ToolHandler) mcp.ToolHandler {
+	if !s.config.ConfirmDestructive {
+		return handler
+	}
+	return func(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// A dry run destroys nothing, so there is nothing to approve
+		if ctx.Value(dryRunKey{}) != nil {
+			return handler(ctx, request)
+		}
+		args, err := getArgsFromRequest(request)
+		if err != nil {
+			return respondWithError(err.Error())
+		}
+		action := s.destructiveAction(name, args)
+		if action == "" {
+			return handler(ctx, request)
+		}
+
+		session := request.Session
+		if session == nil || session.InitializeParams() == nil || session.InitializeParams().Capabilities == nil ||
+			session.InitializeParams().Capabilities.Elicitation == nil {
+			return respondWithToolError(toolError{Code: errorNotConfirmed, Message: fmt.Sprintf("%s needs the user's confirmation, but this client cannot ask for it (no elicitation support); use dry_run to preview the call instead", action)})
+		}
+
+		result, err := session.Elicit(ctx, &mcp.ElicitParams{
+			Message: action + ". This cannot be undone. Continue?",
+			RequestedSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"confirm": map[string]any{"type": "boolean", "title": "Confirm", "description": "Check to go ahead"},
+				},
+				"required": []string{"confirm"},
+			},
+		})
+		if err != nil {
+			return respondWithError(fmt.Sprintf("could not ask the user to confirm: %v", err))
+		}
+		if result.Action != "accept" || result.Content["confirm"] != true {
+			return respondWithToolError(toolError{Code: errorNotConfirmed, Message: fmt.Sprintf("%s was not confirmed by the user; nothing was changed", name)})
+		}
+		return handler(ctx, request)
+	}
+}
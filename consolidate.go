@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/api/sheets/v4"
+)
+
+// consolidateSource is one sheet or range whose rows consolidate_sheets copies into the destination
+type consolidateSource struct {
+	SpreadsheetID string `json:"spreadsheet_id"`
+	Sheet         string `json:"sheet"`
+	Range         string `json:"range"`
+	Label         string `json:"label"`
+
+	headers []string
+	rows    [][]any
+}
+
+// consolidatedSource reports how many rows one source contributed
+type consolidatedSource struct {
+	SpreadsheetID string `json:"spreadsheetId"`
+	Sheet         string `json:"sheet"`
+	Range         string `json:"range"`
+	Rows          int    `json:"rows"`
+	Duplicates    *int   `json:"duplicates,omitempty"`
+}
+
+// consolidateResult is what consolidate_sheets returns
+type consolidateResult struct {
+	SpreadsheetID     string               `json:"spreadsheetId"`
+	Sheet             string               `json:"sheet"`
+	Sources           []consolidatedSource `json:"sources"`
+	AppendedRows      int                  `json:"appendedRows"`
+	SkippedDuplicates *int                 `json:"skippedDuplicates,omitempty"`
+	AddedHeaders      []string             `json:"addedHeaders,omitempty"`
+	UpdatedRange      string               `json:"updatedRange"`
+}
+
+func (s *SheetsMCPServer) handleConsolidateSheets(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID, sheet, _ := parseCommonArgs(args)
+	headerRow := int64(parseArgument(args, "header_row", float64(1)))
+	sourceColumn := strings.TrimSpace(parseArgument(args, "source_column", ""))
+	dedupeKey := strings.TrimSpace(parseArgument(args, "dedupe_key", ""))
+	matchCase := parseArgument(args, "match_case", false)
+
+	if spreadsheetID == "" || sheet == "" {
+		return respondWithError("spreadsheet_id and sheet are required")
+	}
+	if headerRow < 1 {
+		return respondWithError("header_row must be at least 1")
+	}
+	var sources []*consolidateSource
+	if err := convertToType(args["sources"], &sources); err != nil || len(sources) == 0 {
+		return respondWithError("sources must be a non-empty array of {spreadsheet_id, sheet, range, label} objects")
+	}
+	for i, source := range sources {
+		if source.SpreadsheetID == "" {
+			source.SpreadsheetID = spreadsheetID
+		}
+		if source.Sheet == "" {
+			return respondWithError(fmt.Sprintf("sources[%d] needs a sheet", i))
+		}
+		if source.Label == "" {
+			source.Label = source.Sheet
+		}
+	}
+	valueInput, err := parseValueInputOption(args)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+
+	// Sources in the same spreadsheet share one batchGet, and spreadsheets are fetched concurrently.
+	// Unformatted values keep numbers as numbers; dates come back as text the destination parses again.
+	var order []string
+	bySpreadsheet := make(map[string][]*consolidateSource)
+	for _, source := range sources {
+		if _, ok := bySpreadsheet[source.SpreadsheetID]; !ok {
+			order = append(order, source.SpreadsheetID)
+		}
+		bySpreadsheet[source.SpreadsheetID] = append(bySpreadsheet[source.SpreadsheetID], source)
+	}
+	// Progress counts each spreadsheet read and then the append
+	progress := newProgressReporter(request, float64(len(order)+1))
+	var group errgroup.Group
+	group.SetLimit(maxParallelSpreadsheets)
+	for _, id := range order {
+		group.Go(func() error {
+			batch := bySpreadsheet[id]
+			ranges := make([]string, len(batch))
+			for i, source := range batch {
+				ranges[i] = buildFullRange(source.Sheet, source.Range)
+			}
+			result, err := s.sheetsService.Spreadsheets.Values.BatchGet(id).
+				Ranges(ranges...).
+				ValueRenderOption("UNFORMATTED_VALUE").
+				DateTimeRenderOption("FORMATTED_STRING").
+				Context(ctx).
+				Do()
+			if err != nil {
+				return fmt.Errorf("spreadsheet %s: %w", id, err)
+			}
+			rows := 0
+			for i, source := range batch {
+				var values [][]any
+				if i < len(result.ValueRanges) {
+					values = result.ValueRanges[i].Values
+				}
+				recordCellsRead(ctx, values)
+				if int64(len(values)) >= headerRow {
+					source.headers = recordKeys(values[headerRow-1])
+					source.rows = values[headerRow:]
+					rows += len(source.rows)
+				}
+			}
+			progress.add(ctx, 1, fmt.Sprintf("read %d rows from spreadsheet %s", rows, id))
+			return nil
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return s.respondWithAPIError("failed to read sources", err)
+	}
+
+	destHeaders, err := s.readHeaderRow(ctx, spreadsheetID, sheet, headerRow)
+	if err != nil {
+		return s.respondWithAPIError("failed to read destination header row", err)
+	}
+
+	// Source columns are matched to destination headers ignoring case; headers the destination lacks
+	// are added after its last header, in the order the sources list them
+	headers := destHeaders
+	column := func(name string) int {
+		if i := findHeader(headers, name); i >= 0 {
+			return i
+		}
+		headers = append(headers, name)
+		return len(headers) - 1
+	}
+	mappings := make([][]int, len(sources))
+	for i, source := range sources {
+		mappings[i] = make([]int, len(source.headers))
+		for j, header := range source.headers {
+			mappings[i][j] = column(header)
+		}
+	}
+	labelColumn := -1
+	if sourceColumn != "" {
+		labelColumn = column(sourceColumn)
+	}
+	keyColumn := -1
+	if dedupeKey != "" {
+		if keyColumn = findHeader(headers, dedupeKey); keyColumn < 0 {
+			return respondWithError(fmt.Sprintf("dedupe_key '%s' matches no header of the sources or destination", dedupeKey))
+		}
+	}
+
+	// Rows whose key is already in the destination, or came from an earlier source, are skipped;
+	// rows with an empty key are always kept
+	normalize := func(cell any) string {
+		text := strings.TrimSpace(formatCell(cell))
+		if !matchCase {
+			text = strings.ToLower(text)
+		}
+		return text
+	}
+	seen := make(map[string]bool)
+	if keyColumn >= 0 && keyColumn < len(destHeaders) {
+		keyRange := buildFullRange(sheet, fmt.Sprintf("%s%d:%s", columnToLetter(int64(keyColumn)), headerRow+1, columnToLetter(int64(keyColumn))))
+		existing, err := s.sheetsService.Spreadsheets.Values.Get(spreadsheetID, keyRange).
+			ValueRenderOption("UNFORMATTED_VALUE").
+			DateTimeRenderOption("FORMATTED_STRING").
+			Context(ctx).
+			Do()
+		if err != nil {
+			return s.respondWithAPIError("failed to read destination keys", err)
+		}
+		recordCellsRead(ctx, existing.Values)
+		for _, row := range existing.Values {
+			if key := normalize(cellAt(row, 0)); key != "" {
+				seen[key] = true
+			}
+		}
+	}
+
+	var rows [][]any
+	duplicates := 0
+	summaries := make([]consolidatedSource, len(sources))
+	for i, source := range sources {
+		copied, skipped := 0, 0
+		for _, sourceRow := range source.rows {
+			if isEmptyRow(sourceRow) {
+				continue
+			}
+			row := make([]any, len(headers))
+			for j, cell := range sourceRow {
+				if j < len(mappings[i]) {
+					row[mappings[i][j]] = cell
+				}
+			}
+			if labelColumn >= 0 {
+				row[labelColumn] = source.Label
+			}
+			if keyColumn >= 0 {
+				if key := normalize(row[keyColumn]); key != "" {
+					if seen[key] {
+						skipped++
+						continue
+					}
+					seen[key] = true
+				}
+			}
+			rows = append(rows, row)
+			copied++
+		}
+		duplicates += skipped
+		summaries[i] = consolidatedSource{
+			SpreadsheetID: source.SpreadsheetID,
+			Sheet:         source.Sheet,
+			Range:         source.Range,
+			Rows:          copied,
+		}
+		if keyColumn >= 0 {
+			summaries[i].Duplicates = &skipped
+		}
+	}
+
+	response := consolidateResult{
+		SpreadsheetID: spreadsheetID,
+		Sheet:         sheet,
+		Sources:       summaries,
+		AppendedRows:  len(rows),
+	}
+	if keyColumn >= 0 {
+		response.SkippedDuplicates = &duplicates
+	}
+	if len(rows) == 0 {
+		progress.add(ctx, 1, "no rows to append")
+		return respondWithJSON(response)
+	}
+
+	// New headers go in first so that the appended rows land in a table that already has them
+	width := int64(len(headers))
+	if added := headers[len(destHeaders):]; len(added) > 0 {
+		cells := make([]any, len(added))
+		for i, header := range added {
+			cells[i] = header
+		}
+		headerRange := buildFullRange(sheet, fmt.Sprintf("%s%d:%s%d",
+			columnToLetter(int64(len(destHeaders))), headerRow, columnToLetter(width-1), headerRow))
+		if _, err := s.sheetsService.Spreadsheets.Values.Update(spreadsheetID, headerRange, &sheets.ValueRange{Values: [][]any{cells}}).
+			ValueInputOption("RAW").
+			Context(ctx).
+			Do(); err != nil {
+			return s.respondWithAPIError("failed to add header columns", err)
+		}
+		response.AddedHeaders = added
+	}
+
+	s.sanitizeInput(args, valueInput, rows)
+	tableRange := buildFullRange(sheet, fmt.Sprintf("A%d:%s", headerRow, columnToLetter(width-1)))
+	result, err := s.sheetsService.Spreadsheets.Values.Append(spreadsheetID, tableRange, &sheets.ValueRange{Values: rows}).
+		ValueInputOption(valueInput).
+		InsertDataOption("INSERT_ROWS").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return s.respondWithAPIError("failed to append consolidated rows", err)
+	}
+
+	if result.Updates != nil {
+		recordCellsWritten(ctx, result.Updates.UpdatedCells)
+		response.UpdatedRange = result.Updates.UpdatedRange
+	}
+	progress.add(ctx, 1, fmt.Sprintf("appended %d rows", len(rows)))
+	return respondWithJSON(response)
+}
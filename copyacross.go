@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"google.golang.org/api/sheets/v4"
+)
+
+// copyAcrossResult is what copy_range_across_spreadsheets returns
+type copyAcrossResult struct {
+	SpreadsheetID            string `json:"spreadsheetId"`
+	SourceRange              string `json:"sourceRange"`
+	DestinationSpreadsheetID string `json:"destinationSpreadsheetId"`
+	Columns                  []any  `json:"columns"`
+	Rows                     int    `json:"rows"`
+	FilteredOut              *int   `json:"filteredOut,omitempty"`
+	UpdatedRange             string `json:"updatedRange"`
+}
+
+func (s *SheetsMCPServer) handleCopyRangeAcrossSpreadsheets(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID, sheet, rangeStr := parseCommonArgs(args)
+	destID := parseArgument(args, "destination_spreadsheet_id", spreadsheetID)
+	destSheet := parseArgument(args, "destination_sheet", "")
+	destRange := parseArgument(args, "destination_range", "A1")
+	appendRows := parseArgument(args, "append", false)
+	headerRow := int(parseArgument(args, "header_row", float64(1)))
+	includeHeaders := parseArgument(args, "include_headers", !appendRows)
+	where := strings.TrimSpace(parseArgument(args, "where", ""))
+	matchCase := parseArgument(args, "match_case", false)
+
+	if spreadsheetID == "" || sheet == "" || destSheet == "" {
+		return respondWithError("spreadsheet_id, sheet, and destination_sheet are required")
+	}
+	if headerRow < 0 {
+		return respondWithError("header_row must be 0 (no headers) or a 1-based row number")
+	}
+	var columnNames []string
+	if raw, ok := args["columns"]; ok {
+		if err := convertToType(raw, &columnNames); err != nil {
+			return respondWithError(fmt.Sprintf("invalid columns format: %v", err))
+		}
+	}
+	var rename map[string]string
+	if raw, ok := args["rename"]; ok {
+		if err := convertToType(raw, &rename); err != nil {
+			return respondWithError(fmt.Sprintf("invalid rename format: %v", err))
+		}
+	}
+	valueInput, err := parseValueInputOption(args)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	var start *sheets.GridCoordinate
+	if !appendRows {
+		if start, err = parseGridCoordinate(0, destRange); err != nil {
+			return respondWithError(fmt.Sprintf("destination_range must be a single top-left cell: %v", err))
+		}
+	}
+
+	fullRange := buildFullRange(sheet, rangeStr)
+	// Unformatted numbers compare as numbers in the filter, while dates stay readable text
+	valuesResult, err := s.sheetsService.Spreadsheets.Values.Get(spreadsheetID, fullRange).
+		ValueRenderOption("UNFORMATTED_VALUE").
+		DateTimeRenderOption("FORMATTED_STRING").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return s.respondWithAPIError("failed to get source values", err)
+	}
+	recordCellsRead(ctx, valuesResult.Values)
+
+	var headers []string
+	rows := valuesResult.Values
+	if headerRow > 0 {
+		if headerRow <= len(rows) {
+			for _, cell := range rows[headerRow-1] {
+				headers = append(headers, formatCell(cell))
+			}
+		}
+		rows = rows[min(headerRow, len(rows)):]
+	}
+
+	// Columns are copied in the order given, or all of them in source order
+	var columns []int
+	for _, name := range columnNames {
+		column, err := resolveColumn(headers, name)
+		if err != nil {
+			return respondWithError(err.Error())
+		}
+		columns = append(columns, column)
+	}
+	if len(columnNames) == 0 {
+		width := len(headers)
+		for _, row := range rows {
+			width = max(width, len(row))
+		}
+		for column := range width {
+			columns = append(columns, column)
+		}
+	}
+
+	labels := make([]any, len(columns))
+	for i, column := range columns {
+		if column < len(headers) && headers[column] != "" {
+			labels[i] = headers[column]
+		} else {
+			labels[i] = columnToLetter(int64(column))
+		}
+	}
+	for from, to := range rename {
+		column, err := resolveColumn(headers, from)
+		if err != nil {
+			return respondWithError(fmt.Sprintf("rename: %v", err))
+		}
+		found := false
+		for i := range columns {
+			if columns[i] == column {
+				labels[i] = to
+				found = true
+			}
+		}
+		if !found {
+			return respondWithError(fmt.Sprintf("rename: column '%s' is not among the copied columns", from))
+		}
+	}
+
+	// The filter uses the WHERE syntax of query_sheet, evaluated against the source columns
+	var condition queryCondition
+	if where != "" {
+		parsed, err := parseQuery("WHERE "+where, headers, matchCase)
+		if err != nil {
+			return respondWithError(fmt.Sprintf("invalid where: %v", err))
+		}
+		if len(parsed.groupBy) > 0 || len(parsed.orderBy) > 0 || parsed.limit >= 0 || parsed.offset > 0 {
+			return respondWithError("invalid where: only a condition is allowed, not GROUP BY, ORDER BY, LIMIT or OFFSET")
+		}
+		condition = parsed.where
+	}
+
+	out := make([][]any, 0, len(rows)+1)
+	if includeHeaders {
+		out = append(out, labels)
+	}
+	filtered := 0
+	for _, row := range rows {
+		if isEmptyRow(row) {
+			continue
+		}
+		if condition != nil && !condition(row) {
+			filtered++
+			continue
+		}
+		copied := make([]any, len(columns))
+		for i, column := range columns {
+			copied[i] = cellAt(row, column)
+		}
+		out = append(out, copied)
+	}
+	dataRows := len(out)
+	if includeHeaders {
+		dataRows--
+	}
+
+	response := copyAcrossResult{
+		SpreadsheetID:            spreadsheetID,
+		SourceRange:              fullRange,
+		DestinationSpreadsheetID: destID,
+		Columns:                  labels,
+		Rows:                     dataRows,
+	}
+	if condition != nil {
+		response.FilteredOut = &filtered
+	}
+	if len(out) == 0 || len(columns) == 0 {
+		return respondWithJSON(response)
+	}
+	s.sanitizeInput(args, valueInput, out)
+
+	if appendRows {
+		tableRange := buildFullRange(destSheet, fmt.Sprintf("A1:%s", columnToLetter(int64(len(columns)-1))))
+		result, err := s.sheetsService.Spreadsheets.Values.Append(destID, tableRange, &sheets.ValueRange{Values: out}).
+			ValueInputOption(valueInput).
+			InsertDataOption("INSERT_ROWS").
+			Context(ctx).
+			Do()
+		if err != nil {
+			return s.respondWithAPIError("failed to append to destination", err)
+		}
+		if result.Updates != nil {
+			recordCellsWritten(ctx, result.Updates.UpdatedCells)
+			response.UpdatedRange = result.Updates.UpdatedRange
+		}
+		return respondWithJSON(response)
+	}
+
+	target := buildFullRange(destSheet, gridRangeToA1(&sheets.GridRange{
+		StartRowIndex:    start.RowIndex,
+		EndRowIndex:      start.RowIndex + int64(len(out)),
+		StartColumnIndex: start.ColumnIndex,
+		EndColumnIndex:   start.ColumnIndex + int64(len(columns)),
+	}))
+	result, err := s.sheetsService.Spreadsheets.Values.Update(destID, target, &sheets.ValueRange{Values: out}).
+		ValueInputOption(valueInput).
+		Context(ctx).
+		Do()
+	if err != nil {
+		return s.respondWithAPIError("failed to write destination", err)
+	}
+	recordCellsWritten(ctx, result.UpdatedCells)
+	response.UpdatedRange = result.UpdatedRange
+	return respondWithJSON(response)
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"google.golang.org/api/sheets/v4"
+)
+
+// dataSourceResult describes a data source that was added, deleted or listed
+type dataSourceResult struct {
+	Success      bool   `json:"success,omitempty"`
+	DataSourceID string `json:"dataSourceId"`
+	SheetID      *int64 `json:"sheetId,omitempty"`
+	Status       any    `json:"status,omitempty"`
+	ProjectID    string `json:"projectId,omitempty"`
+	Table        any    `json:"table,omitempty"`
+	Query        string `json:"query,omitempty"`
+}
+
+// dataSourceStatus is the execution status of one refreshed data source object
+type dataSourceStatus struct {
+	Reference any `json:"reference"`
+	Status    any `json:"status"`
+}
+
+// refreshDataSourceResult is what refresh_data_source returns
+type refreshDataSourceResult struct {
+	Refreshed int                `json:"refreshed"`
+	Statuses  []dataSourceStatus `json:"statuses"`
+}
+
+func (s *SheetsMCPServer) handleAddDataSource(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID := parseArgument(args, "spreadsheet_id", "")
+	projectID := parseArgument(args, "project_id", "")
+	datasetID := parseArgument(args, "dataset_id", "")
+	tableID := parseArgument(args, "table_id", "")
+	tableProjectID := parseArgument(args, "table_project_id", "")
+	query := parseArgument(args, "query", "")
+
+	if spreadsheetID == "" || projectID == "" {
+		return respondWithError("spreadsheet_id and project_id are required")
+	}
+
+	spec := &sheets.BigQueryDataSourceSpec{ProjectId: projectID}
+	switch {
+	case query != "" && tableID != "":
+		return respondWithError("provide either query or dataset_id and table_id, not both")
+	case query != "":
+		spec.QuerySpec = &sheets.BigQueryQuerySpec{RawQuery: query}
+	case datasetID != "" && tableID != "":
+		spec.TableSpec = &sheets.BigQueryTableSpec{
+			TableProjectId: tableProjectID,
+			DatasetId:      datasetID,
+			TableId:        tableID,
+		}
+	default:
+		return respondWithError("either query or dataset_id and table_id are required")
+	}
+
+	requests := []*sheets.Request{
+		{
+			AddDataSource: &sheets.AddDataSourceRequest{
+				DataSource: &sheets.DataSource{
+					Spec: &sheets.DataSourceSpec{BigQuery: spec},
+				},
+			},
+		},
+	}
+
+	result, err := s.executeBatchUpdate(ctx, spreadsheetID, requests)
+	if err != nil {
+		return s.respondWithAPIError("failed to add data source", err)
+	}
+
+	if len(result.Replies) == 0 || result.Replies[0].AddDataSource == nil || result.Replies[0].AddDataSource.DataSource == nil {
+		return respondWithError("the API reply does not describe the added data source")
+	}
+	added := result.Replies[0].AddDataSource
+	response := dataSourceResult{
+		DataSourceID: added.DataSource.DataSourceId,
+		SheetID:      &added.DataSource.SheetId,
+		Status:       added.DataExecutionStatus,
+	}
+
+	return respondWithJSON(response)
+}
+
+func (s *SheetsMCPServer) handleRefreshDataSource(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID := parseArgument(args, "spreadsheet_id", "")
+	dataSourceID := parseArgument(args, "data_source_id", "")
+	force := parseArgument(args, "force", false)
+
+	if spreadsheetID == "" {
+		return respondWithError("spreadsheet_id is required")
+	}
+
+	refresh := &sheets.RefreshDataSourceRequest{
+		DataSourceId: dataSourceID,
+		Force:        force,
+	}
+	// Without a data source ID every data source object in the spreadsheet is refreshed
+	if dataSourceID == "" {
+		refresh.IsAll = true
+	}
+
+	requests := []*sheets.Request{
+		{
+			RefreshDataSource: refresh,
+		},
+	}
+
+	result, err := s.executeBatchUpdate(ctx, spreadsheetID, requests)
+	if err != nil {
+		return s.respondWithAPIError("failed to refresh data source", err)
+	}
+
+	if len(result.Replies) == 0 || result.Replies[0].RefreshDataSource == nil {
+		return respondWithError("the API reply does not describe the refreshed data sources")
+	}
+	var statuses []dataSourceStatus
+	for _, status := range result.Replies[0].RefreshDataSource.Statuses {
+		statuses = append(statuses, dataSourceStatus{
+			Reference: status.Reference,
+			Status:    status.DataExecutionStatus,
+		})
+	}
+
+	response := refreshDataSourceResult{
+		Refreshed: len(statuses),
+		Statuses:  statuses,
+	}
+
+	return respondWithJSON(response)
+}
+
+func (s *SheetsMCPServer) handleDeleteDataSource(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID := parseArgument(args, "spreadsheet_id", "")
+	dataSourceID := parseArgument(args, "data_source_id", "")
+
+	if spreadsheetID == "" || dataSourceID == "" {
+		return respondWithError("spreadsheet_id and data_source_id are required")
+	}
+
+	requests := []*sheets.Request{
+		{
+			DeleteDataSource: &sheets.DeleteDataSourceRequest{
+				DataSourceId: dataSourceID,
+			},
+		},
+	}
+
+	if _, err := s.executeBatchUpdate(ctx, spreadsheetID, requests); err != nil {
+		return s.respondWithAPIError("failed to delete data source", err)
+	}
+
+	response := dataSourceResult{
+		Success:      true,
+		DataSourceID: dataSourceID,
+	}
+
+	return respondWithJSON(response)
+}
+
+func (s *SheetsMCPServer) handleListDataSources(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID := parseArgument(args, "spreadsheet_id", "")
+
+	if spreadsheetID == "" {
+		return respondWithError("spreadsheet_id is required")
+	}
+
+	spreadsheet, err := s.sheetsService.Spreadsheets.Get(spreadsheetID).
+		Fields("dataSources").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return s.respondWithAPIError("failed to get data sources", err)
+	}
+
+	dataSources := make([]dataSourceResult, 0, len(spreadsheet.DataSources))
+	for _, ds := range spreadsheet.DataSources {
+		entry := dataSourceResult{
+			DataSourceID: ds.DataSourceId,
+			SheetID:      &ds.SheetId,
+		}
+		if ds.Spec != nil && ds.Spec.BigQuery != nil {
+			bq := ds.Spec.BigQuery
+			entry.ProjectID = bq.ProjectId
+			if bq.TableSpec != nil {
+				entry.Table = bq.TableSpec
+			}
+			if bq.QuerySpec != nil {
+				entry.Query = bq.QuerySpec.RawQuery
+			}
+		}
+		dataSources = append(dataSources, entry)
+	}
+
+	return respondWithJSON(dataSources)
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"time"
+
+	// Spreadsheet time zones must resolve on hosts without a zoneinfo database
+	_ "time/tzdata"
+)
+
+// serialEpoch is day 0 of spreadsheet date serial numbers; 1.5 is noon on 31 December 1899
+var serialEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// isoDate matches ISO-8601 dates and date-times such as 2024-03-01, 2024-03-01T09:30 or
+// 2024-03-01T09:30:00.5+01:00
+var isoDate = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})(?:[T ](\d{2}:\d{2}(?::\d{2}(?:\.\d+)?)?)(Z|[+-]\d{2}:?\d{2})?)?$`)
+
+// serialToTime converts a serial number into the wall-clock time it stands for in loc (UTC when nil)
+func serialToTime(serial float64, loc *time.Location) time.Time {
+	if loc == nil {
+		loc = time.UTC
+	}
+	seconds := int64(math.Round(serial * 86400))
+	t := serialEpoch.Add(time.Duration(seconds) * time.Second)
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, loc)
+}
+
+// timeToSerial converts the wall-clock time of t into a serial number
+func timeToSerial(t time.Time) float64 {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	return wall.Sub(serialEpoch).Seconds() / 86400
+}
+
+// formatSerial renders a serial number as ISO-8601 according to the cell's number format type:
+// DATE as 2024-03-01, TIME as 09:30:00, and DATE_TIME with the offset of the spreadsheet time zone
+// when it is known
+func formatSerial(serial float64, formatType string, loc *time.Location) string {
+	t := serialToTime(serial, loc)
+	switch formatType {
+	case "DATE":
+		return t.Format(time.DateOnly)
+	case "TIME":
+		return t.Format(time.TimeOnly)
+	}
+	if loc == nil {
+		return t.Format("2006-01-02T15:04:05")
+	}
+	return t.Format(time.RFC3339)
+}
+
+// spreadsheetLocation loads the time zone a spreadsheet interprets dates in, or returns nil when the
+// zone is unknown to this system
+func spreadsheetLocation(timeZone string) *time.Location {
+	if timeZone == "" {
+		return nil
+	}
+	loc, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return nil
+	}
+	return loc
+}
+
+// isoDatesForRead replaces the cells of values that hold dates, times or date-times with ISO-8601
+// text. Value reads carry no formats, so one metadata read of the same range tells which cells are
+// dates and what their serial numbers are; values must start at the top-left cell of rangeStr.
+func (s *SheetsMCPServer) isoDatesForRead(ctx context.Context, spreadsheetID, rangeStr string, values [][]any, majorDimension string) error {
+	spreadsheet, err := s.sheetsService.Spreadsheets.Get(spreadsheetID).
+		Ranges(rangeStr).
+		Fields("properties.timeZone,sheets(data(rowData(values(effectiveValue/numberValue,effectiveFormat/numberFormat/type))))").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return err
+	}
+	if len(spreadsheet.Sheets) == 0 || len(spreadsheet.Sheets[0].Data) == 0 {
+		return nil
+	}
+	var loc *time.Location
+	if spreadsheet.Properties != nil {
+		loc = spreadsheetLocation(spreadsheet.Properties.TimeZone)
+	}
+
+	for r, rowData := range spreadsheet.Sheets[0].Data[0].RowData {
+		for c, cell := range rowData.Values {
+			if cell.EffectiveValue == nil || cell.EffectiveValue.NumberValue == nil ||
+				cell.EffectiveFormat == nil || cell.EffectiveFormat.NumberFormat == nil {
+				continue
+			}
+			formatType := cell.EffectiveFormat.NumberFormat.Type
+			if formatType != "DATE" && formatType != "TIME" && formatType != "DATE_TIME" {
+				continue
+			}
+			i, j := r, c
+			if majorDimension == "COLUMNS" {
+				i, j = c, r
+			}
+			if i < len(values) && j < len(values[i]) {
+				values[i][j] = formatSerial(*cell.EffectiveValue.NumberValue, formatType, loc)
+			}
+		}
+	}
+	return nil
+}
+
+// isoDateWriter converts ISO-8601 strings in values about to be written. With USER_ENTERED they
+// become text such as 2024-03-01 09:30:00 that Sheets parses as a date in any locale; with RAW,
+// where text stays text, they become serial numbers. Date-times with an offset or Z are shifted
+// into the spreadsheet's time zone, which is looked up once when first needed.
+type isoDateWriter struct {
+	server        *SheetsMCPServer
+	spreadsheetID string
+	valueInput    string
+	loc           *time.Location
+	looked        bool
+}
+
+// isoDateWriterFor returns the converter for a write, or nil when iso_dates is not set
+func (s *SheetsMCPServer) isoDateWriterFor(args map[string]any, spreadsheetID, valueInput string) *isoDateWriter {
+	if !parseArgument(args, "iso_dates", false) {
+		return nil
+	}
+	return &isoDateWriter{server: s, spreadsheetID: spreadsheetID, valueInput: valueInput}
+}
+
+func (w *isoDateWriter) convert(ctx context.Context, values [][]any) error {
+	if w == nil {
+		return nil
+	}
+	for _, row := range values {
+		for i, cell := range row {
+			text, ok := cell.(string)
+			if !ok {
+				continue
+			}
+			match := isoDate.FindStringSubmatch(text)
+			if match == nil {
+				continue
+			}
+			t, err := w.parse(ctx, match)
+			if err != nil {
+				return fmt.Errorf("invalid date '%s': %w", text, err)
+			}
+
+			switch {
+			case w.valueInput == "RAW":
+				row[i] = timeToSerial(t)
+			case match[2] == "":
+				row[i] = t.Format(time.DateOnly)
+			default:
+				row[i] = t.Format(time.DateTime)
+			}
+		}
+	}
+	return nil
+}
+
+// parse reads a matched ISO-8601 string and returns its wall-clock time in the spreadsheet's zone
+func (w *isoDateWriter) parse(ctx context.Context, match []string) (time.Time, error) {
+	date, clock, zone := match[1], match[2], match[3]
+	if clock == "" {
+		return time.Parse(time.DateOnly, date)
+	}
+	if len(clock) == len("15:04") {
+		clock += ":00"
+	}
+	if zone == "" {
+		return time.Parse("2006-01-02T15:04:05.999999999", date+"T"+clock)
+	}
+
+	if zone != "Z" && len(zone) == len("+0100") {
+		zone = zone[:3] + ":" + zone[3:]
+	}
+	t, err := time.Parse(time.RFC3339Nano, date+"T"+clock+zone)
+	if err != nil {
+		return t, err
+	}
+	if !w.looked {
+		spreadsheet, err := w.server.sheetsService.Spreadsheets.Get(w.spreadsheetID).
+			Fields("properties.timeZone").
+			Context(ctx).
+			Do()
+		if err != nil {
+			return t, fmt.Errorf("failed to get spreadsheet time zone: %w", err)
+		}
+		w.loc = spreadsheetLocation(spreadsheet.Properties.TimeZone)
+		w.looked = true
+	}
+	if w.loc == nil {
+		return t, fmt.Errorf("the spreadsheet time zone is unknown here; leave out the offset")
+	}
+	return t.In(w.loc), nil
+}
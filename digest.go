@@ -0,0 +1,477 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/driveactivity/v2"
+	"google.golang.org/api/sheets/v4"
+)
+
+const xlsxMimeType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// maxDigestActivities bounds how many Drive activity entries a digest lists
+const maxDigestActivities = 200
+
+// cellChange is one cell whose value differs between the start and the end of a digest window
+type cellChange struct {
+	Cell   string `json:"cell"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// sheetChanges summarizes the value changes of one sheet
+type sheetChanges struct {
+	Sheet   string       `json:"sheet"`
+	Status  string       `json:"status"`
+	Count   int          `json:"changedCells"`
+	Changes []cellChange `json:"changes"`
+}
+
+// digestResult is what generate_change_digest returns: the digest as Markdown, or where it was written
+type digestResult struct {
+	SpreadsheetID string `json:"spreadsheetId"`
+	Since         string `json:"since"`
+	Until         string `json:"until"`
+	Revisions     int    `json:"revisions"`
+	// Activities is left out when DRIVE_ACTIVITY is off
+	Activities   *int   `json:"activities,omitempty"`
+	ChangedCells int    `json:"changedCells"`
+	Note         string `json:"note,omitempty"`
+	Sheet        string `json:"sheet,omitempty"`
+	Rows         int    `json:"rows,omitempty"`
+	Digest       string `json:"digest,omitempty"`
+}
+
+// parseTimeArgument accepts an RFC 3339 timestamp, a YYYY-MM-DD date, or a duration meaning "that long ago"
+func parseTimeArgument(value string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time '%s': use RFC 3339, YYYY-MM-DD, or a duration such as 168h", value)
+}
+
+func (s *SheetsMCPServer) handleGenerateChangeDigest(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID := parseArgument(args, "spreadsheet_id", "")
+	sinceArg := parseArgument(args, "since", "168h")
+	untilArg := parseArgument(args, "until", "")
+	output := parseArgument(args, "output", "markdown")
+	digestSheet := parseArgument(args, "digest_sheet", "Digest")
+	maxChanges := max(1, int(parseArgument(args, "max_changes", float64(50))))
+
+	if spreadsheetID == "" {
+		return respondWithError("spreadsheet_id is required")
+	}
+	if output != "markdown" && output != "sheet" {
+		return respondWithError(fmt.Sprintf("invalid output '%s': use markdown or sheet", output))
+	}
+
+	now := time.Now()
+	since, err := parseTimeArgument(sinceArg, now)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	until := now
+	if untilArg != "" {
+		if until, err = parseTimeArgument(untilArg, now); err != nil {
+			return respondWithError(err.Error())
+		}
+	}
+	if !since.Before(until) {
+		return respondWithError("since must be before until")
+	}
+
+	spreadsheet, err := s.sheetsService.Spreadsheets.Get(spreadsheetID).
+		Fields("properties.title").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return s.respondWithAPIError("failed to get spreadsheet", err)
+	}
+
+	revisions, err := s.driveService.Revisions.List(spreadsheetID).
+		Fields("revisions(id,modifiedTime,lastModifyingUser(displayName,emailAddress),exportLinks)").
+		PageSize(1000).
+		Context(ctx).
+		Do()
+	if err != nil {
+		return s.respondWithAPIError("failed to list revisions", err)
+	}
+
+	// The baseline is the last revision saved before the window opened
+	var baseline *drive.Revision
+	var windowRevisions []*drive.Revision
+	for _, rev := range revisions.Revisions {
+		modified, err := time.Parse(time.RFC3339, rev.ModifiedTime)
+		if err != nil {
+			continue
+		}
+		switch {
+		case modified.Before(since):
+			baseline = rev
+		case !modified.After(until):
+			windowRevisions = append(windowRevisions, rev)
+		}
+	}
+
+	// Drive activity is only part of the digest when DRIVE_ACTIVITY is on
+	var activities []*driveactivity.DriveActivity
+	if s.activityService != nil {
+		if activities, err = s.queryActivity(ctx, spreadsheetID, since, until); err != nil {
+			return s.respondWithAPIError("failed to query drive activity", err)
+		}
+	}
+
+	var changes []sheetChanges
+	note := ""
+	switch {
+	case baseline == nil:
+		note = "The spreadsheet has no revision before the window, so value changes are not compared."
+	case len(windowRevisions) == 0:
+		note = "No revisions were saved in the window, so no values changed."
+	default:
+		// The window ends at the last revision saved in it
+		changes, err = s.diffRevisions(baseline, windowRevisions[len(windowRevisions)-1], maxChanges)
+		if err != nil {
+			return s.respondWithAPIError("failed to compare values", err)
+		}
+	}
+
+	totalChanged := 0
+	for _, sc := range changes {
+		totalChanged += sc.Count
+	}
+
+	response := digestResult{
+		SpreadsheetID: spreadsheetID,
+		Since:         since.UTC().Format(time.RFC3339),
+		Until:         until.UTC().Format(time.RFC3339),
+		Revisions:     len(windowRevisions),
+		ChangedCells:  totalChanged,
+		Note:          note,
+	}
+	if s.activityService != nil {
+		response.Activities = new(int)
+		*response.Activities = len(activities)
+	}
+
+	if output == "sheet" {
+		rows := digestRows(windowRevisions, activities, changes)
+		if err := s.writeDigestSheet(ctx, spreadsheetID, digestSheet, rows); err != nil {
+			return s.respondWithAPIError("failed to write digest sheet", err)
+		}
+		response.Sheet = digestSheet
+		response.Rows = len(rows)
+		return respondWithJSON(response)
+	}
+
+	response.Digest = digestMarkdown(spreadsheet.Properties.Title, since, until, windowRevisions, activities, s.activityService != nil, changes, note)
+	return respondWithJSON(response)
+}
+
+// queryActivity returns the Drive activity recorded on a file within a time window, newest first
+func (s *SheetsMCPServer) queryActivity(ctx context.Context, fileID string, since, until time.Time) ([]*driveactivity.DriveActivity, error) {
+	query := &driveactivity.QueryDriveActivityRequest{
+		ItemName: "items/" + fileID,
+		Filter: fmt.Sprintf(`time >= "%s" AND time <= "%s"`,
+			since.UTC().Format(time.RFC3339), until.UTC().Format(time.RFC3339)),
+		PageSize: 100,
+	}
+
+	var activities []*driveactivity.DriveActivity
+	for len(activities) < maxDigestActivities {
+		result, err := s.activityService.Activity.Query(query).Context(ctx).Do()
+		if err != nil {
+			return nil, err
+		}
+		activities = append(activities, result.Activities...)
+		if result.NextPageToken == "" {
+			break
+		}
+		query.PageToken = result.NextPageToken
+	}
+
+	return activities[:min(len(activities), maxDigestActivities)], nil
+}
+
+// diffRevisions compares the values of every sheet in two exported revisions of a spreadsheet
+func (s *SheetsMCPServer) diffRevisions(from, to *drive.Revision, maxChanges int) ([]sheetChanges, error) {
+	before, err := s.downloadRevision(from)
+	if err != nil {
+		return nil, err
+	}
+	after, err := s.downloadRevision(to)
+	if err != nil {
+		return nil, err
+	}
+
+	titles, err := xlsxSheetNames(after)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []sheetChanges
+	for _, title := range titles {
+		afterRows, err := readXLSXWorksheet(after, title)
+		if err != nil {
+			return nil, err
+		}
+		beforeRows, err := readXLSXWorksheet(before, title)
+		status := "modified"
+		if errors.Is(err, errWorksheetNotFound) {
+			status = "added"
+		} else if err != nil {
+			return nil, err
+		}
+
+		sc := sheetChanges{Sheet: title, Status: status, Changes: []cellChange{}}
+		for r := 0; r < max(len(beforeRows), len(afterRows)); r++ {
+			var beforeRow, afterRow []string
+			if r < len(beforeRows) {
+				beforeRow = beforeRows[r]
+			}
+			if r < len(afterRows) {
+				afterRow = afterRows[r]
+			}
+
+			for c := 0; c < max(len(beforeRow), len(afterRow)); c++ {
+				beforeValue, afterValue := "", ""
+				if c < len(beforeRow) {
+					beforeValue = beforeRow[c]
+				}
+				if c < len(afterRow) {
+					afterValue = afterRow[c]
+				}
+				if cellValuesEqual(beforeValue, afterValue) {
+					continue
+				}
+
+				sc.Count++
+				if len(sc.Changes) < maxChanges {
+					sc.Changes = append(sc.Changes, cellChange{
+						Cell:   fmt.Sprintf("%s%d", columnToLetter(int64(c)), r+1),
+						Before: beforeValue,
+						After:  afterValue,
+					})
+				}
+			}
+		}
+
+		if sc.Count > 0 || status == "added" {
+			results = append(results, sc)
+		}
+	}
+
+	return results, nil
+}
+
+// downloadRevision exports a revision as xlsx and opens it in memory
+func (s *SheetsMCPServer) downloadRevision(revision *drive.Revision) (*zip.Reader, error) {
+	link := revision.ExportLinks[xlsxMimeType]
+	if link == "" {
+		return nil, fmt.Errorf("revision %s cannot be exported as xlsx", revision.Id)
+	}
+	return s.downloadWorkbook(link)
+}
+
+// downloadWorkbook fetches an xlsx export link with the server's credentials and opens it in memory
+func (s *SheetsMCPServer) downloadWorkbook(link string) (*zip.Reader, error) {
+	resp, err := s.httpClient.Get(link)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export revision: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to export revision: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read revision export: %w", err)
+	}
+
+	return zip.NewReader(bytes.NewReader(data), int64(len(data)))
+}
+
+// activityAction names the primary action of a Drive activity, e.g. "edit" or "permissionChange"
+func activityAction(activity *driveactivity.DriveActivity) string {
+	data, err := json.Marshal(activity.PrimaryActionDetail)
+	if err != nil {
+		return "unknown"
+	}
+	var detail map[string]json.RawMessage
+	if err := json.Unmarshal(data, &detail); err != nil || len(detail) == 0 {
+		return "unknown"
+	}
+	keys := make([]string, 0, len(detail))
+	for key := range detail {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return strings.Join(keys, ",")
+}
+
+// activityTime returns when an activity happened, whether it was a single moment or a range
+func activityTime(activity *driveactivity.DriveActivity) string {
+	if activity.Timestamp != "" {
+		return activity.Timestamp
+	}
+	if activity.TimeRange != nil {
+		return activity.TimeRange.EndTime
+	}
+	return ""
+}
+
+// activityActors describes who performed an activity; Drive Activity only exposes people IDs
+func activityActors(activity *driveactivity.DriveActivity) string {
+	var actors []string
+	for _, actor := range activity.Actors {
+		switch {
+		case actor.User != nil && actor.User.KnownUser != nil:
+			if actor.User.KnownUser.IsCurrentUser {
+				actors = append(actors, "me")
+			} else {
+				actors = append(actors, actor.User.KnownUser.PersonName)
+			}
+		case actor.Administrator != nil:
+			actors = append(actors, "administrator")
+		case actor.Anonymous != nil:
+			actors = append(actors, "anonymous")
+		case actor.System != nil:
+			actors = append(actors, "system")
+		default:
+			actors = append(actors, "unknown user")
+		}
+	}
+	return strings.Join(actors, ", ")
+}
+
+func revisionUser(rev *drive.Revision) string {
+	if rev.LastModifyingUser == nil {
+		return "unknown user"
+	}
+	if rev.LastModifyingUser.EmailAddress != "" {
+		return fmt.Sprintf("%s <%s>", rev.LastModifyingUser.DisplayName, rev.LastModifyingUser.EmailAddress)
+	}
+	return rev.LastModifyingUser.DisplayName
+}
+
+func digestMarkdown(title string, since, until time.Time, revisions []*drive.Revision, activities []*driveactivity.DriveActivity, withActivity bool, changes []sheetChanges, note string) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Change digest: %s\n\n", title)
+	fmt.Fprintf(&b, "%s to %s\n\n", since.UTC().Format(time.RFC3339), until.UTC().Format(time.RFC3339))
+
+	editors := map[string]int{}
+	for _, rev := range revisions {
+		editors[revisionUser(rev)]++
+	}
+	fmt.Fprintf(&b, "## Revisions (%d)\n\n", len(revisions))
+	if len(editors) == 0 {
+		b.WriteString("No revisions in this window.\n")
+	}
+	names := make([]string, 0, len(editors))
+	for name := range editors {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		fmt.Fprintf(&b, "- %s: %d revision(s)\n", name, editors[name])
+	}
+
+	if withActivity {
+		fmt.Fprintf(&b, "\n## Activity (%d)\n\n", len(activities))
+		if len(activities) == 0 {
+			b.WriteString("No Drive activity in this window.\n")
+		}
+		for _, activity := range activities {
+			fmt.Fprintf(&b, "- %s: %s by %s\n", activityTime(activity), activityAction(activity), activityActors(activity))
+		}
+	}
+
+	b.WriteString("\n## Value changes\n\n")
+	if note != "" {
+		b.WriteString(note + "\n")
+	} else if len(changes) == 0 {
+		b.WriteString("No cell values changed.\n")
+	}
+	for _, sc := range changes {
+		fmt.Fprintf(&b, "### %s (%s, %d cell(s) changed)\n\n", sc.Sheet, sc.Status, sc.Count)
+		if len(sc.Changes) == 0 {
+			continue
+		}
+		b.WriteString("| Cell | Before | After |\n|------|--------|-------|\n")
+		for _, change := range sc.Changes {
+			fmt.Fprintf(&b, "| %s | %s | %s |\n", change.Cell, escapeMarkdownCell(change.Before), escapeMarkdownCell(change.After))
+		}
+		if sc.Count > len(sc.Changes) {
+			fmt.Fprintf(&b, "\n_%d more change(s) not shown._\n", sc.Count-len(sc.Changes))
+		}
+		b.WriteString("\n")
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// escapeMarkdownCell keeps a value on one line and from breaking the table it is printed in
+func escapeMarkdownCell(value string) string {
+	value = strings.ReplaceAll(value, "|", `\|`)
+	return strings.ReplaceAll(value, "\n", " ")
+}
+
+// digestRows lays a digest out as a table with a header row for writing to a sheet
+func digestRows(revisions []*drive.Revision, activities []*driveactivity.DriveActivity, changes []sheetChanges) [][]any {
+	rows := [][]any{{"Type", "Time / Cell", "Detail", "Before", "After"}}
+	for _, rev := range revisions {
+		rows = append(rows, []any{"revision", rev.ModifiedTime, revisionUser(rev), "", ""})
+	}
+	for _, activity := range activities {
+		rows = append(rows, []any{"activity", activityTime(activity), activityAction(activity) + " by " + activityActors(activity), "", ""})
+	}
+	for _, sc := range changes {
+		for _, change := range sc.Changes {
+			rows = append(rows, []any{"change", buildFullRange(sc.Sheet, change.Cell), sc.Status, change.Before, change.After})
+		}
+	}
+	return rows
+}
+
+// writeDigestSheet replaces the contents of the digest sheet, creating it when needed
+func (s *SheetsMCPServer) writeDigestSheet(ctx context.Context, spreadsheetID, sheet string, rows [][]any) error {
+	if _, err := s.ensureSheet(ctx, spreadsheetID, sheet); err != nil {
+		return err
+	}
+
+	if _, err := s.sheetsService.Spreadsheets.Values.Clear(spreadsheetID, buildFullRange(sheet, ""), &sheets.ClearValuesRequest{}).Context(ctx).Do(); err != nil {
+		return err
+	}
+
+	valueRange := &sheets.ValueRange{Values: rows}
+	_, err := s.sheetsService.Spreadsheets.Values.Update(spreadsheetID, buildFullRange(sheet, "A1"), valueRange).
+		ValueInputOption("RAW").
+		Context(ctx).
+		Do()
+	return err
+}
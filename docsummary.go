@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"unicode/utf16"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"google.golang.org/api/docs/v1"
+)
+
+// maxDocSummaryColumns bounds how wide the per-sheet preview tables get
+const maxDocSummaryColumns = 10
+
+// docLength returns the length of text in the UTF-16 code units that Docs indexes count
+func docLength(text string) int64 {
+	return int64(len(utf16.Encode([]rune(text))))
+}
+
+// docEndIndex returns the index just before the final newline of a document body, where new content is appended
+func (s *SheetsMCPServer) docEndIndex(ctx context.Context, documentID string) (int64, *docs.Document, error) {
+	doc, err := s.docsService.Documents.Get(documentID).Context(ctx).Do()
+	if err != nil {
+		return 0, nil, err
+	}
+	content := doc.Body.Content
+	return content[len(content)-1].EndIndex - 1, doc, nil
+}
+
+// appendDocSection appends a heading, an optional paragraph, and an optional table of values to a document
+func (s *SheetsMCPServer) appendDocSection(ctx context.Context, documentID, heading, text string, values [][]string) error {
+	start, _, err := s.docEndIndex(ctx, documentID)
+	if err != nil {
+		return err
+	}
+
+	var requests []*docs.Request
+	content := ""
+	if heading != "" {
+		content += heading + "\n"
+		requests = append(requests, &docs.Request{
+			UpdateParagraphStyle: &docs.UpdateParagraphStyleRequest{
+				Range:          &docs.Range{StartIndex: start, EndIndex: start + docLength(heading) + 1},
+				ParagraphStyle: &docs.ParagraphStyle{NamedStyleType: "HEADING_2"},
+				Fields:         "namedStyleType",
+			},
+		})
+	}
+	if text != "" {
+		content += text + "\n"
+		if heading != "" {
+			offset := start + docLength(heading) + 1
+			requests = append(requests, &docs.Request{
+				UpdateParagraphStyle: &docs.UpdateParagraphStyleRequest{
+					Range:          &docs.Range{StartIndex: offset, EndIndex: offset + docLength(text) + 1},
+					ParagraphStyle: &docs.ParagraphStyle{NamedStyleType: "NORMAL_TEXT"},
+					Fields:         "namedStyleType",
+				},
+			})
+		}
+	}
+
+	requests = append([]*docs.Request{
+		{InsertText: &docs.InsertTextRequest{Location: &docs.Location{Index: start}, Text: content}},
+	}, requests...)
+
+	columns := 0
+	for _, row := range values {
+		columns = max(columns, len(row))
+	}
+	if len(values) > 0 && columns > 0 {
+		requests = append(requests, &docs.Request{
+			InsertTable: &docs.InsertTableRequest{
+				Rows:                 int64(len(values)),
+				Columns:              int64(columns),
+				EndOfSegmentLocation: &docs.EndOfSegmentLocation{},
+			},
+		})
+	}
+
+	if _, err := s.docsService.Documents.BatchUpdate(documentID, &docs.BatchUpdateDocumentRequest{Requests: requests}).Context(ctx).Do(); err != nil {
+		return err
+	}
+	if len(values) == 0 || columns == 0 {
+		return nil
+	}
+
+	return s.fillLastTable(ctx, documentID, values)
+}
+
+// fillLastTable writes values into the empty table at the end of a document. Cells are filled from
+// the last to the first so that inserting text does not shift the indexes of cells still to be filled.
+func (s *SheetsMCPServer) fillLastTable(ctx context.Context, documentID string, values [][]string) error {
+	_, doc, err := s.docEndIndex(ctx, documentID)
+	if err != nil {
+		return err
+	}
+
+	var table *docs.Table
+	for _, element := range slices.Backward(doc.Body.Content) {
+		if element.Table != nil {
+			table = element.Table
+			break
+		}
+	}
+	if table == nil {
+		return fmt.Errorf("inserted table not found in document")
+	}
+
+	var requests []*docs.Request
+	for r := len(table.TableRows) - 1; r >= 0; r-- {
+		cells := table.TableRows[r].TableCells
+		for c := len(cells) - 1; c >= 0; c-- {
+			if r >= len(values) || c >= len(values[r]) || values[r][c] == "" || len(cells[c].Content) == 0 {
+				continue
+			}
+			requests = append(requests, &docs.Request{
+				InsertText: &docs.InsertTextRequest{
+					Location: &docs.Location{Index: cells[c].Content[0].StartIndex},
+					Text:     values[r][c],
+				},
+			})
+		}
+	}
+	if len(requests) == 0 {
+		return nil
+	}
+
+	_, err = s.docsService.Documents.BatchUpdate(documentID, &docs.BatchUpdateDocumentRequest{Requests: requests}).Context(ctx).Do()
+	return err
+}
+
+// docSummaryResult identifies the document create_doc_summary wrote
+type docSummaryResult struct {
+	DocumentID string   `json:"documentId"`
+	Title      string   `json:"title"`
+	URL        string   `json:"url"`
+	Sheets     []string `json:"sheets"`
+	Warning    string   `json:"warning,omitempty"`
+}
+
+func (s *SheetsMCPServer) handleCreateDocSummary(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID := parseArgument(args, "spreadsheet_id", "")
+	title := parseArgument(args, "title", "")
+	summaryText := parseArgument(args, "summary_text", "")
+	previewRows := max(0, int(parseArgument(args, "preview_rows", float64(10))))
+	folderID := parseArgument(args, "folder_id", s.driveFolder(ctx))
+
+	if spreadsheetID == "" {
+		return respondWithError("spreadsheet_id is required")
+	}
+
+	var sheetNames []string
+	if raw, ok := args["sheets"]; ok {
+		if err := convertToType(raw, &sheetNames); err != nil {
+			return respondWithError(fmt.Sprintf("invalid sheets format: %v", err))
+		}
+	}
+
+	spreadsheet, err := s.sheetsService.Spreadsheets.Get(spreadsheetID).
+		Fields("properties.title,spreadsheetUrl,sheets.properties(title,sheetType,gridProperties)").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return s.respondWithAPIError("failed to get spreadsheet", err)
+	}
+
+	if title == "" {
+		title = spreadsheet.Properties.Title + " summary"
+	}
+
+	doc, err := s.docsService.Documents.Create(&docs.Document{Title: title}).Context(ctx).Do()
+	if err != nil {
+		return s.respondWithAPIError("failed to create document", err)
+	}
+	documentID := doc.DocumentId
+
+	intro := fmt.Sprintf("Summary of %s (%s)", spreadsheet.Properties.Title, spreadsheet.SpreadsheetUrl)
+	if summaryText != "" {
+		intro += "\n\n" + summaryText
+	}
+	if err := s.appendDocSection(ctx, documentID, "", intro, nil); err != nil {
+		return s.respondWithAPIError(fmt.Sprintf("created document %s, but failed to write the introduction", documentID), err)
+	}
+
+	summarized := []string{}
+	for _, sheet := range spreadsheet.Sheets {
+		props := sheet.Properties
+		if props.SheetType != "" && props.SheetType != "GRID" {
+			continue
+		}
+		if len(sheetNames) > 0 && !slices.Contains(sheetNames, props.Title) {
+			continue
+		}
+
+		info := ""
+		if props.GridProperties != nil {
+			info = fmt.Sprintf("%d rows × %d columns", props.GridProperties.RowCount, props.GridProperties.ColumnCount)
+		}
+
+		var preview [][]string
+		if previewRows > 0 {
+			previewRange := buildFullRange(props.Title, fmt.Sprintf("A1:%s%d", columnToLetter(maxDocSummaryColumns-1), previewRows))
+			valuesResult, err := s.sheetsService.Spreadsheets.Values.Get(spreadsheetID, previewRange).Context(ctx).Do()
+			if err != nil {
+				return s.respondWithAPIError(fmt.Sprintf("failed to read sheet '%s'", props.Title), err)
+			}
+			for _, row := range valuesResult.Values {
+				cells := make([]string, len(row))
+				for i, cell := range row {
+					cells[i] = formatCell(cell)
+				}
+				preview = append(preview, cells)
+			}
+		}
+
+		if err := s.appendDocSection(ctx, documentID, props.Title, info, preview); err != nil {
+			return s.respondWithAPIError(fmt.Sprintf("created document %s, but failed to write sheet '%s'", documentID, props.Title), err)
+		}
+		summarized = append(summarized, props.Title)
+	}
+
+	response := docSummaryResult{
+		DocumentID: documentID,
+		Title:      title,
+		URL:        fmt.Sprintf("https://docs.google.com/document/d/%s/edit", documentID),
+		Sheets:     summarized,
+	}
+
+	if folderID != "" {
+		if err := s.requireDrive("filing into a folder"); err != nil {
+			response.Warning = err.Error()
+		} else if err := s.moveToFolder(ctx, documentID, folderID); err != nil {
+			return s.respondWithAPIError(fmt.Sprintf("created document %s, but failed to move it to folder %s", documentID, folderID), err)
+		}
+	}
+
+	return respondWithJSON(response)
+}
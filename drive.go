@@ -0,0 +1,1253 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/sheets/v4"
+)
+
+// shareRecipient is one entry of the share_spreadsheet recipients list
+type shareRecipient struct {
+	EmailAddress string `json:"email_address"`
+	Role         string `json:"role"`
+	Type         string `json:"type"`
+}
+
+var shareRoles = map[string]bool{"reader": true, "commenter": true, "writer": true}
+
+var shareTypes = map[string]bool{"user": true, "group": true}
+
+// check reports why the policy forbids granting role to a grantee of the given permission type.
+// For users and groups the grantee is an email address, for domain permissions the domain itself.
+func (p SharingPolicy) check(permissionType, role, grantee string) error {
+	if !slices.Contains(p.AllowedRoles, role) {
+		return fmt.Errorf("role '%s' is not allowed by the sharing policy", role)
+	}
+
+	if permissionType == "anyone" {
+		if !p.AllowAnyone {
+			return fmt.Errorf("anyone-with-link sharing is disabled by the sharing policy")
+		}
+		return nil
+	}
+
+	if len(p.InternalDomains) == 0 {
+		return nil
+	}
+
+	domain := strings.ToLower(grantee)
+	if at := strings.LastIndex(domain, "@"); at >= 0 {
+		domain = domain[at+1:]
+	}
+	if slices.Contains(p.InternalDomains, domain) {
+		return nil
+	}
+
+	if !slices.Contains(p.ExternalRoles, role) {
+		if len(p.ExternalRoles) == 0 {
+			return fmt.Errorf("sharing outside %s is disabled by the sharing policy", strings.Join(p.InternalDomains, ", "))
+		}
+		return fmt.Errorf("role '%s' cannot be granted outside %s (allowed: %s)", role, strings.Join(p.InternalDomains, ", "), strings.Join(p.ExternalRoles, ", "))
+	}
+	return nil
+}
+
+func (s *SheetsMCPServer) handleShareSpreadsheet(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID := parseArgument(args, "spreadsheet_id", "")
+	sendNotification := parseArgument(args, "send_notification", true)
+	recipientsRaw, ok := args["recipients"]
+
+	if spreadsheetID == "" || !ok {
+		return respondWithError("spreadsheet_id and recipients are required")
+	}
+
+	var recipients []shareRecipient
+	if err := convertToType(recipientsRaw, &recipients); err != nil {
+		return respondWithError(fmt.Sprintf("invalid recipients format: %v", err))
+	}
+
+	var successes, failures []shareOutcome
+
+	for _, recipient := range recipients {
+		if recipient.Role == "" {
+			recipient.Role = s.config.Sharing.DefaultRole
+		}
+		if recipient.Type == "" {
+			recipient.Type = "user"
+		}
+
+		failure := func(msg string) {
+			failures = append(failures, shareOutcome{
+				EmailAddress: recipient.EmailAddress,
+				Type:         recipient.Type,
+				Error:        msg,
+			})
+		}
+
+		switch {
+		case recipient.EmailAddress == "":
+			failure("email_address is required")
+			continue
+		case !shareRoles[recipient.Role]:
+			failure(fmt.Sprintf("invalid role '%s': use reader, commenter, or writer", recipient.Role))
+			continue
+		case !shareTypes[recipient.Type]:
+			failure(fmt.Sprintf("invalid type '%s': use user or group", recipient.Type))
+			continue
+		}
+
+		if err := s.config.Sharing.check(recipient.Type, recipient.Role, recipient.EmailAddress); err != nil {
+			failure(err.Error())
+			continue
+		}
+
+		permission := &drive.Permission{
+			Type:         recipient.Type,
+			Role:         recipient.Role,
+			EmailAddress: recipient.EmailAddress,
+		}
+
+		result, err := s.driveService.Permissions.Create(spreadsheetID, permission).
+			SendNotificationEmail(sendNotification).
+			SupportsAllDrives(true).
+			Fields("id").
+			Context(ctx).
+			Do()
+		if err != nil {
+			failure(fmt.Sprintf("failed to share: %v", err))
+			continue
+		}
+
+		successes = append(successes, shareOutcome{
+			EmailAddress: recipient.EmailAddress,
+			Role:         recipient.Role,
+			Type:         recipient.Type,
+			PermissionID: result.Id,
+		})
+	}
+
+	response := shareResult{
+		Successes: successes,
+		Failures:  failures,
+	}
+
+	return respondWithJSON(response)
+}
+
+func (s *SheetsMCPServer) handleSetLinkSharing(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID := parseArgument(args, "spreadsheet_id", "")
+	permissionType := parseArgument(args, "type", "")
+	role := parseArgument(args, "role", "reader")
+	domain := strings.ToLower(parseArgument(args, "domain", ""))
+	allowFileDiscovery := parseArgument(args, "allow_file_discovery", false)
+
+	if spreadsheetID == "" || permissionType == "" {
+		return respondWithError("spreadsheet_id and type are required")
+	}
+	if permissionType != "anyone" && permissionType != "domain" {
+		return respondWithError(fmt.Sprintf("invalid type '%s': use anyone or domain", permissionType))
+	}
+	// Link sharing reaches people nobody picked by name, so it never grants edit access
+	if role != "reader" && role != "commenter" {
+		return respondWithError(fmt.Sprintf("invalid role '%s': link sharing allows reader or commenter", role))
+	}
+	if permissionType == "domain" {
+		if domain == "" && len(s.config.Sharing.InternalDomains) > 0 {
+			domain = s.config.Sharing.InternalDomains[0]
+		}
+		if domain == "" {
+			return respondWithError("domain is required for domain sharing when SHARING_INTERNAL_DOMAINS is not set")
+		}
+	}
+
+	if err := s.config.Sharing.check(permissionType, role, domain); err != nil {
+		return respondWithError(err.Error())
+	}
+
+	permission := &drive.Permission{
+		Type:               permissionType,
+		Role:               role,
+		Domain:             domain,
+		AllowFileDiscovery: allowFileDiscovery,
+		ForceSendFields:    []string{"AllowFileDiscovery"},
+	}
+
+	result, err := s.driveService.Permissions.Create(spreadsheetID, permission).
+		SupportsAllDrives(true).
+		Fields("id").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return s.respondWithAPIError("failed to set link sharing", err)
+	}
+
+	file, err := s.driveService.Files.Get(spreadsheetID).SupportsAllDrives(true).Fields("webViewLink").Context(ctx).Do()
+	if err != nil {
+		return s.respondWithAPIError("shared the spreadsheet, but failed to get its link", err)
+	}
+
+	response := linkSharingResult{
+		SpreadsheetID:      spreadsheetID,
+		PermissionID:       result.Id,
+		Type:               permissionType,
+		Role:               role,
+		Domain:             domain,
+		AllowFileDiscovery: allowFileDiscovery,
+		WebViewLink:        file.WebViewLink,
+	}
+
+	return respondWithJSON(response)
+}
+
+func (s *SheetsMCPServer) handleListPermissions(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID := parseArgument(args, "spreadsheet_id", "")
+
+	if spreadsheetID == "" {
+		return respondWithError("spreadsheet_id is required")
+	}
+
+	var permissions []permissionEntry
+	pageToken := ""
+	for {
+		call := s.driveService.Permissions.List(spreadsheetID).
+			SupportsAllDrives(true).
+			Fields("nextPageToken,permissions(id,type,role,emailAddress,domain,displayName,permissionDetails(inherited))")
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+		result, err := call.Context(ctx).Do()
+		if err != nil {
+			return s.respondWithAPIError("failed to list permissions", err)
+		}
+
+		for _, permission := range result.Permissions {
+			permissions = append(permissions, permissionEntry{
+				PermissionID: permission.Id,
+				Type:         permission.Type,
+				Role:         permission.Role,
+				EmailAddress: permission.EmailAddress,
+				Domain:       permission.Domain,
+				DisplayName:  permission.DisplayName,
+				Inherited:    slices.ContainsFunc(permission.PermissionDetails, func(d *drive.PermissionPermissionDetails) bool { return d.Inherited }),
+			})
+		}
+
+		if result.NextPageToken == "" {
+			break
+		}
+		pageToken = result.NextPageToken
+	}
+
+	return respondWithJSON(permissionsResult{
+		SpreadsheetID: spreadsheetID,
+		Permissions:   permissions,
+	})
+}
+
+func (s *SheetsMCPServer) handleRemovePermission(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID := parseArgument(args, "spreadsheet_id", "")
+	permissionID := parseArgument(args, "permission_id", "")
+	emailAddress := strings.ToLower(parseArgument(args, "email_address", ""))
+
+	if spreadsheetID == "" {
+		return respondWithError("spreadsheet_id is required")
+	}
+	if (permissionID == "") == (emailAddress == "") {
+		return respondWithError("exactly one of permission_id or email_address is required")
+	}
+
+	if permissionID == "" {
+		call := s.driveService.Permissions.List(spreadsheetID).
+			SupportsAllDrives(true).
+			Fields("permissions(id,emailAddress)")
+		result, err := call.Context(ctx).Do()
+		if err != nil {
+			return s.respondWithAPIError("failed to list permissions", err)
+		}
+		for _, permission := range result.Permissions {
+			if strings.ToLower(permission.EmailAddress) == emailAddress {
+				permissionID = permission.Id
+				break
+			}
+		}
+		if permissionID == "" {
+			return respondWithError(fmt.Sprintf("%s has no permission on the spreadsheet", emailAddress))
+		}
+	}
+
+	if err := s.driveService.Permissions.Delete(spreadsheetID, permissionID).SupportsAllDrives(true).Context(ctx).Do(); err != nil {
+		return s.respondWithAPIError("failed to remove permission", err)
+	}
+
+	return respondWithJSON(permissionEntry{
+		SpreadsheetID: spreadsheetID,
+		PermissionID:  permissionID,
+		EmailAddress:  emailAddress,
+		Removed:       true,
+	})
+}
+
+// externalPermission reports why a permission grants access outside the allowed domains, or "" when it does not.
+// Owners are never reported, since Drive does not allow removing them.
+func externalPermission(permission *drive.Permission, allowedDomains []string) string {
+	if permission.Role == "owner" {
+		return ""
+	}
+
+	switch permission.Type {
+	case "anyone":
+		return "anyone with the link"
+	case "domain":
+		if !slices.Contains(allowedDomains, strings.ToLower(permission.Domain)) {
+			return fmt.Sprintf("everyone at %s", permission.Domain)
+		}
+	case "user", "group":
+		email := strings.ToLower(permission.EmailAddress)
+		at := strings.LastIndex(email, "@")
+		if at < 0 || !slices.Contains(allowedDomains, email[at+1:]) {
+			return fmt.Sprintf("%s outside the allowed domains", permission.Type)
+		}
+	}
+	return ""
+}
+
+// revokeExternalAccess removes the external permissions of one file and appends what it did to the report
+func (s *SheetsMCPServer) revokeExternalAccess(ctx context.Context, fileID, fileName string, allowedDomains []string, dryRun bool, removed, failures *[]revokedPermission) error {
+	var permissions []*drive.Permission
+	pageToken := ""
+	for {
+		call := s.driveService.Permissions.List(fileID).
+			SupportsAllDrives(true).
+			Fields("nextPageToken,permissions(id,type,role,emailAddress,domain,permissionDetails(inherited))")
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+		result, err := call.Context(ctx).Do()
+		if err != nil {
+			return err
+		}
+		permissions = append(permissions, result.Permissions...)
+		if result.NextPageToken == "" {
+			break
+		}
+		pageToken = result.NextPageToken
+	}
+
+	for _, permission := range permissions {
+		reason := externalPermission(permission, allowedDomains)
+		if reason == "" {
+			continue
+		}
+		// Permissions inherited from a shared drive folder can only be removed where they are granted
+		if len(permission.PermissionDetails) > 0 && !slices.ContainsFunc(permission.PermissionDetails, func(d *drive.PermissionPermissionDetails) bool { return !d.Inherited }) {
+			continue
+		}
+
+		entry := revokedPermission{
+			FileID:       fileID,
+			FileName:     fileName,
+			PermissionID: permission.Id,
+			Type:         permission.Type,
+			Role:         permission.Role,
+			Reason:       reason,
+			EmailAddress: permission.EmailAddress,
+			Domain:       permission.Domain,
+		}
+
+		if !dryRun {
+			if err := s.driveService.Permissions.Delete(fileID, permission.Id).SupportsAllDrives(true).Context(ctx).Do(); err != nil {
+				entry.Error = err.Error()
+				*failures = append(*failures, entry)
+				continue
+			}
+		}
+		*removed = append(*removed, entry)
+	}
+	return nil
+}
+
+func (s *SheetsMCPServer) handleRevokeAllExternalAccess(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	fileID := parseArgument(args, "file_id", "")
+	includeContents := parseArgument(args, "include_contents", true)
+	dryRun := s.config.DryRun || parseArgument(args, "dry_run", false)
+
+	if fileID == "" {
+		return respondWithError("file_id is required")
+	}
+
+	allowedDomains := s.config.Sharing.InternalDomains
+	if raw, ok := args["allowed_domains"]; ok {
+		if err := convertToType(raw, &allowedDomains); err != nil {
+			return respondWithError(fmt.Sprintf("invalid allowed_domains format: %v", err))
+		}
+		for i, domain := range allowedDomains {
+			allowedDomains[i] = strings.ToLower(domain)
+		}
+	}
+	if len(allowedDomains) == 0 {
+		return respondWithError("allowed_domains is required when SHARING_INTERNAL_DOMAINS is not set")
+	}
+
+	file, err := s.driveService.Files.Get(fileID).SupportsAllDrives(true).Fields("id,name,mimeType").Context(ctx).Do()
+	if err != nil {
+		return s.respondWithAPIError("failed to get file", err)
+	}
+
+	removed := []revokedPermission{}
+	failures := []revokedPermission{}
+	if err := s.revokeExternalAccess(ctx, file.Id, file.Name, allowedDomains, dryRun, &removed, &failures); err != nil {
+		return s.respondWithAPIError("failed to list permissions", err)
+	}
+
+	// For a folder, the files directly inside it are cleaned up as well
+	files := 1
+	if file.MimeType == folderMimeType && includeContents {
+		query := fmt.Sprintf("'%s' in parents and trashed = false", escapeDriveQuery(file.Id))
+		err := s.driveService.Files.List().
+			Q(query).
+			SupportsAllDrives(true).
+			IncludeItemsFromAllDrives(true).
+			Fields("nextPageToken,files(id,name)").
+			Pages(ctx, func(page *drive.FileList) error {
+				for _, child := range page.Files {
+					files++
+					if err := s.revokeExternalAccess(ctx, child.Id, child.Name, allowedDomains, dryRun, &removed, &failures); err != nil {
+						failures = append(failures, revokedPermission{FileID: child.Id, FileName: child.Name, Error: err.Error()})
+					}
+				}
+				return nil
+			})
+		if err != nil {
+			return s.respondWithAPIError("failed to list folder contents", err)
+		}
+	}
+
+	response := revokeResult{
+		FileID:         file.Id,
+		AllowedDomains: allowedDomains,
+		DryRun:         dryRun,
+		FilesChecked:   files,
+		Removed:        removed,
+		Failures:       failures,
+	}
+
+	return respondWithJSON(response)
+}
+
+func (s *SheetsMCPServer) handleListGroupMembers(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	groupEmail := parseArgument(args, "group_email", "")
+	includeDerived := parseArgument(args, "include_derived", false)
+
+	if groupEmail == "" {
+		return respondWithError("group_email is required")
+	}
+
+	var members []groupMember
+	pageToken := ""
+	for {
+		call := s.directoryService.Members.List(groupEmail).
+			IncludeDerivedMembership(includeDerived).
+			MaxResults(200)
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+
+		result, err := call.Context(ctx).Do()
+		if err != nil {
+			return s.respondWithAPIError("failed to list group members", err)
+		}
+
+		for _, member := range result.Members {
+			members = append(members, groupMember{
+				Email:  member.Email,
+				Role:   member.Role,
+				Type:   member.Type,
+				Status: member.Status,
+			})
+		}
+
+		if result.NextPageToken == "" {
+			break
+		}
+		pageToken = result.NextPageToken
+	}
+
+	response := groupMembersResult{
+		Group:   groupEmail,
+		Members: members,
+		Count:   len(members),
+	}
+
+	return respondWithJSON(response)
+}
+
+func (s *SheetsMCPServer) handleDeleteSpreadsheet(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID := parseArgument(args, "spreadsheet_id", "")
+	permanent := parseArgument(args, "permanent", false)
+
+	if spreadsheetID == "" {
+		return respondWithError("spreadsheet_id is required")
+	}
+
+	defer s.metadataCache.invalidate(spreadsheetID)
+
+	if permanent {
+		if err := s.driveService.Files.Delete(spreadsheetID).SupportsAllDrives(true).Context(ctx).Do(); err != nil {
+			return s.respondWithAPIError("failed to delete spreadsheet", err)
+		}
+	} else {
+		_, err := s.driveService.Files.Update(spreadsheetID, &drive.File{Trashed: true}).
+			SupportsAllDrives(true).
+			Fields("id").
+			Context(ctx).
+			Do()
+		if err != nil {
+			return s.respondWithAPIError("failed to move spreadsheet to trash", err)
+		}
+	}
+
+	response := driveFileResult{
+		Success:       true,
+		SpreadsheetID: spreadsheetID,
+		Permanent:     &permanent,
+	}
+
+	return respondWithJSON(response)
+}
+
+func (s *SheetsMCPServer) handleRestoreSpreadsheet(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID := parseArgument(args, "spreadsheet_id", "")
+
+	if spreadsheetID == "" {
+		return respondWithError("spreadsheet_id is required")
+	}
+
+	// Trashed=false is the zero value, so it has to be sent explicitly
+	file := &drive.File{Trashed: false, ForceSendFields: []string{"Trashed"}}
+
+	result, err := s.driveService.Files.Update(spreadsheetID, file).
+		SupportsAllDrives(true).
+		Fields("id,name,webViewLink").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return s.respondWithAPIError("failed to restore spreadsheet", err)
+	}
+
+	response := driveFileResult{
+		Success:       true,
+		SpreadsheetID: result.Id,
+		Title:         result.Name,
+		URL:           result.WebViewLink,
+	}
+
+	return respondWithJSON(response)
+}
+
+// moveToFolder makes folderID the only parent of a file
+func (s *SheetsMCPServer) moveToFolder(ctx context.Context, fileID, folderID string) error {
+	file, err := s.driveService.Files.Get(fileID).
+		SupportsAllDrives(true).
+		Fields("parents").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.driveService.Files.Update(fileID, &drive.File{}).
+		AddParents(folderID).
+		RemoveParents(strings.Join(file.Parents, ",")).
+		SupportsAllDrives(true).
+		Fields("id").
+		Context(ctx).
+		Do()
+	return err
+}
+
+func (s *SheetsMCPServer) handleStarSpreadsheet(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.setStarred(ctx, request, true)
+}
+
+func (s *SheetsMCPServer) handleUnstarSpreadsheet(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.setStarred(ctx, request, false)
+}
+
+// setStarred adds a spreadsheet to or removes it from the starred files of the authenticated account
+func (s *SheetsMCPServer) setStarred(ctx context.Context, request *mcp.CallToolRequest, starred bool) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID := parseArgument(args, "spreadsheet_id", "")
+
+	if spreadsheetID == "" {
+		return respondWithError("spreadsheet_id is required")
+	}
+
+	// Starred=false is the zero value, so it has to be sent explicitly
+	file := &drive.File{Starred: starred, ForceSendFields: []string{"Starred"}}
+
+	result, err := s.driveService.Files.Update(spreadsheetID, file).
+		SupportsAllDrives(true).
+		Fields("id,name,starred,webViewLink").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return s.respondWithAPIError("failed to update starred status", err)
+	}
+
+	response := driveFileResult{
+		Success:       true,
+		SpreadsheetID: result.Id,
+		Title:         result.Name,
+		Starred:       &result.Starred,
+		URL:           result.WebViewLink,
+	}
+
+	return respondWithJSON(response)
+}
+
+func (s *SheetsMCPServer) handleRenameSpreadsheet(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID := parseArgument(args, "spreadsheet_id", "")
+	title := parseArgument(args, "title", "")
+
+	if spreadsheetID == "" || title == "" {
+		return respondWithError("spreadsheet_id and title are required")
+	}
+
+	result, err := s.driveService.Files.Update(spreadsheetID, &drive.File{Name: title}).
+		SupportsAllDrives(true).
+		Fields("id,name").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return s.respondWithAPIError("failed to rename spreadsheet", err)
+	}
+
+	response := driveFileResult{
+		Success:       true,
+		SpreadsheetID: result.Id,
+		Title:         result.Name,
+	}
+
+	return respondWithJSON(response)
+}
+
+func (s *SheetsMCPServer) handleMoveSpreadsheet(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID := parseArgument(args, "spreadsheet_id", "")
+	folderID := parseArgument(args, "folder_id", "")
+
+	if spreadsheetID == "" || folderID == "" {
+		return respondWithError("spreadsheet_id and folder_id are required")
+	}
+
+	if err := s.moveToFolder(ctx, spreadsheetID, folderID); err != nil {
+		return s.respondWithAPIError("failed to move spreadsheet", err)
+	}
+
+	response := driveFileResult{
+		Success:       true,
+		SpreadsheetID: spreadsheetID,
+		FolderID:      folderID,
+	}
+
+	return respondWithJSON(response)
+}
+
+func (s *SheetsMCPServer) handleCopySpreadsheet(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID := parseArgument(args, "spreadsheet_id", "")
+	title := parseArgument(args, "title", "")
+	folderID := parseArgument(args, "folder_id", s.driveFolder(ctx))
+
+	if spreadsheetID == "" || title == "" {
+		return respondWithError("spreadsheet_id and title are required")
+	}
+
+	var clearSheets []string
+	if raw, ok := args["clear_sheets"]; ok {
+		if err := convertToType(raw, &clearSheets); err != nil {
+			return respondWithError(fmt.Sprintf("invalid clear_sheets format: %v", err))
+		}
+	}
+
+	file := &drive.File{Name: title}
+	if folderID != "" {
+		file.Parents = []string{folderID}
+	}
+
+	result, err := s.driveService.Files.Copy(spreadsheetID, file).
+		SupportsAllDrives(true).
+		Fields("id,name,webViewLink").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return s.respondWithAPIError("failed to copy spreadsheet", err)
+	}
+
+	// Templates usually carry sample rows that should not end up in the copy
+	for _, sheet := range clearSheets {
+		if _, err := s.sheetsService.Spreadsheets.Values.Clear(result.Id, buildFullRange(sheet, ""), &sheets.ClearValuesRequest{}).Context(ctx).Do(); err != nil {
+			return s.respondWithAPIError(fmt.Sprintf("copied spreadsheet to %s, but failed to clear sheet '%s'", result.Id, sheet), err)
+		}
+	}
+
+	response := driveFileResult{
+		SpreadsheetID: result.Id,
+		Title:         result.Name,
+		URL:           result.WebViewLink,
+		FolderID:      folderID,
+		ClearedSheets: clearSheets,
+	}
+
+	return respondWithJSON(response)
+}
+
+func (s *SheetsMCPServer) handleGetSpreadsheetMetadata(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID := parseArgument(args, "spreadsheet_id", "")
+
+	if spreadsheetID == "" {
+		return respondWithError("spreadsheet_id is required")
+	}
+
+	file, err := s.driveService.Files.Get(spreadsheetID).
+		SupportsAllDrives(true).
+		Fields("id,name,mimeType,owners(displayName,emailAddress),createdTime,modifiedTime,lastModifyingUser(displayName,emailAddress),size,quotaBytesUsed,webViewLink,parents,trashed").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return s.respondWithAPIError("failed to get spreadsheet metadata", err)
+	}
+
+	owners := []driveUser{}
+	for _, owner := range file.Owners {
+		owners = append(owners, driveUser{DisplayName: owner.DisplayName, EmailAddress: owner.EmailAddress})
+	}
+
+	// Native spreadsheets have no file size; the storage they use is the closest equivalent
+	size := file.Size
+	if size == 0 {
+		size = file.QuotaBytesUsed
+	}
+
+	response := spreadsheetMetadata{
+		SpreadsheetID: file.Id,
+		Name:          file.Name,
+		MimeType:      file.MimeType,
+		Owners:        owners,
+		CreatedTime:   file.CreatedTime,
+		ModifiedTime:  file.ModifiedTime,
+		Size:          size,
+		WebViewLink:   file.WebViewLink,
+		Parents:       file.Parents,
+		Trashed:       file.Trashed,
+	}
+	if file.LastModifyingUser != nil {
+		response.LastModifyingUser = &driveUser{
+			DisplayName:  file.LastModifyingUser.DisplayName,
+			EmailAddress: file.LastModifyingUser.EmailAddress,
+		}
+	}
+
+	return respondWithJSON(response)
+}
+
+// importChunkSize is the upload chunk size; files larger than this are uploaded resumably
+const importChunkSize = 8 << 20
+
+func (s *SheetsMCPServer) handleImportXLSX(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	filePath := parseArgument(args, "file_path", "")
+	title := parseArgument(args, "title", "")
+	folderID := parseArgument(args, "folder_id", s.driveFolder(ctx))
+
+	if filePath == "" {
+		return respondWithError("file_path is required")
+	}
+	if title == "" {
+		title = strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+	}
+
+	localPath, err := s.localPath(filePath)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	f, err := os.Open(localPath)
+	if err != nil {
+		return respondWithError(fmt.Sprintf("failed to open file: %v", err))
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return respondWithError(fmt.Sprintf("failed to open file: %v", err))
+	}
+	progress := newProgressReporter(request, float64(info.Size()))
+
+	// Setting the Google Sheets MIME type on the metadata makes Drive convert the upload
+	file := &drive.File{Name: title, MimeType: spreadsheetMimeType}
+	if folderID != "" {
+		file.Parents = []string{folderID}
+	}
+
+	result, err := s.driveService.Files.Create(file).
+		Context(ctx).
+		Media(f, googleapi.ContentType(xlsxMimeType), googleapi.ChunkSize(importChunkSize)).
+		// Only resumable uploads, of files over importChunkSize, report their progress
+		ProgressUpdater(func(current, total int64) {
+			progress.set(ctx, float64(current), fmt.Sprintf("uploaded %d of %d bytes", current, info.Size()))
+		}).
+		SupportsAllDrives(true).
+		Fields("id,name,webViewLink").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return s.respondWithAPIError("failed to import file", err)
+	}
+
+	response := driveFileResult{
+		SpreadsheetID: result.Id,
+		Title:         result.Name,
+		URL:           result.WebViewLink,
+		FolderID:      folderID,
+	}
+
+	return respondWithJSON(response)
+}
+
+const (
+	spreadsheetMimeType = "application/vnd.google-apps.spreadsheet"
+	folderMimeType      = "application/vnd.google-apps.folder"
+)
+
+// inSharedDrive restricts a file listing to one shared drive; with no drive ID it searches everything the
+// credentials can see
+func inSharedDrive(call *drive.FilesListCall, sharedDriveID string) *drive.FilesListCall {
+	if sharedDriveID == "" {
+		return call
+	}
+	return call.Corpora("drive").DriveId(sharedDriveID)
+}
+
+func (s *SheetsMCPServer) handleListSharedDrives(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	nameContains := parseArgument(args, "name_contains", "")
+	pageSize := int64(parseArgument(args, "page_size", float64(50)))
+	pageToken := parseArgument(args, "page_token", "")
+
+	if pageSize < 1 || pageSize > 100 {
+		return respondWithError("page_size must be between 1 and 100")
+	}
+
+	call := s.driveService.Drives.List().
+		PageSize(pageSize).
+		Fields("nextPageToken,drives(id,name,createdTime)")
+	if nameContains != "" {
+		call = call.Q(fmt.Sprintf("name contains '%s'", escapeDriveQuery(nameContains)))
+	}
+	if pageToken != "" {
+		call = call.PageToken(pageToken)
+	}
+
+	result, err := call.Context(ctx).Do()
+	if err != nil {
+		return s.respondWithAPIError("failed to list shared drives", err)
+	}
+
+	// A tenant only sees the shared drive that is its folder, if any
+	tenant := tenantFromContext(ctx)
+	drives := make([]sharedDrive, 0, len(result.Drives))
+	for _, d := range result.Drives {
+		if tenant != nil && (tenant.FolderID != "" || len(tenant.AllowedSpreadsheets) > 0) && d.Id != tenant.FolderID {
+			continue
+		}
+		drives = append(drives, sharedDrive{
+			SharedDriveID: d.Id,
+			Name:          d.Name,
+			CreatedTime:   d.CreatedTime,
+			Selected:      d.Id == s.config.SharedDriveID,
+		})
+	}
+
+	response := sharedDrivesResult{
+		SharedDrives:  drives,
+		NextPageToken: result.NextPageToken,
+	}
+
+	return respondWithJSON(response)
+}
+
+// escapeDriveQuery quotes a value for use inside a Drive query string literal
+func escapeDriveQuery(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	return strings.ReplaceAll(value, `'`, `\'`)
+}
+
+// handleListSpreadsheets lists the spreadsheets in the folder the server works in, which is the
+// session's tenant folder, DRIVE_FOLDER_ID, or SHARED_DRIVE_ID in that order
+func (s *SheetsMCPServer) handleListSpreadsheets(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	folderID := parseArgument(args, "folder_id", s.driveFolder(ctx))
+	starred := parseArgument(args, "starred", false)
+	pageSize := int64(parseArgument(args, "page_size", float64(100)))
+	pageToken := parseArgument(args, "page_token", "")
+
+	if pageSize < 1 || pageSize > 1000 {
+		return respondWithError("page_size must be between 1 and 1000")
+	}
+
+	query := fmt.Sprintf("mimeType = '%s' and trashed = false", spreadsheetMimeType)
+	if folderID != "" {
+		query += fmt.Sprintf(" and '%s' in parents", escapeDriveQuery(folderID))
+	}
+	if starred {
+		query += " and starred = true"
+	}
+
+	call := s.driveService.Files.List().
+		Q(query).
+		PageSize(pageSize).
+		OrderBy("name").
+		SupportsAllDrives(true).
+		IncludeItemsFromAllDrives(true).
+		Fields("nextPageToken,files(id,name,modifiedTime,webViewLink)")
+	if pageToken != "" {
+		call = call.PageToken(pageToken)
+	}
+
+	result, err := call.Context(ctx).Do()
+	if err != nil {
+		return s.respondWithAPIError("failed to list spreadsheets", err)
+	}
+
+	tenant := tenantFromContext(ctx)
+	files := make([]spreadsheetFile, 0, len(result.Files))
+	for _, file := range result.Files {
+		if !tenant.allows(file.Id) {
+			continue
+		}
+		files = append(files, spreadsheetFile{
+			SpreadsheetID: file.Id,
+			Title:         file.Name,
+			ModifiedTime:  file.ModifiedTime,
+			URL:           file.WebViewLink,
+		})
+	}
+
+	response := spreadsheetListResult{
+		Spreadsheets:  files,
+		NextPageToken: result.NextPageToken,
+		FolderID:      folderID,
+	}
+	return respondWithJSON(response)
+}
+
+func (s *SheetsMCPServer) handleSearchSpreadsheets(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	nameContains := parseArgument(args, "name_contains", "")
+	modifiedAfter := parseArgument(args, "modified_after", "")
+	owner := parseArgument(args, "owner", "")
+	// A tenant's searches stay inside its folder
+	folderID := parseArgument(args, "folder_id", "")
+	if tenant := tenantFromContext(ctx); tenant != nil && tenant.FolderID != "" {
+		folderID = tenant.FolderID
+	}
+	starred := parseArgument(args, "starred", false)
+	sharedDriveID := parseArgument(args, "shared_drive_id", s.config.SharedDriveID)
+	pageSize := int64(parseArgument(args, "page_size", float64(50)))
+	pageToken := parseArgument(args, "page_token", "")
+
+	if pageSize < 1 || pageSize > 1000 {
+		return respondWithError("page_size must be between 1 and 1000")
+	}
+
+	conditions := []string{
+		fmt.Sprintf("mimeType = '%s'", spreadsheetMimeType),
+		"trashed = false",
+	}
+	if nameContains != "" {
+		conditions = append(conditions, fmt.Sprintf("name contains '%s'", escapeDriveQuery(nameContains)))
+	}
+	if modifiedAfter != "" {
+		after, err := parseTimeArgument(modifiedAfter, time.Now())
+		if err != nil {
+			return respondWithError(fmt.Sprintf("invalid modified_after: %v", err))
+		}
+		conditions = append(conditions, fmt.Sprintf("modifiedTime > '%s'", after.UTC().Format(time.RFC3339)))
+	}
+	if owner != "" {
+		conditions = append(conditions, fmt.Sprintf("'%s' in owners", escapeDriveQuery(owner)))
+	}
+	if folderID != "" {
+		conditions = append(conditions, fmt.Sprintf("'%s' in parents", escapeDriveQuery(folderID)))
+	}
+	if starred {
+		conditions = append(conditions, "starred = true")
+	}
+	query := strings.Join(conditions, " and ")
+
+	call := s.driveService.Files.List().
+		Q(query).
+		PageSize(pageSize).
+		OrderBy("modifiedTime desc").
+		SupportsAllDrives(true).
+		IncludeItemsFromAllDrives(true).
+		Fields("nextPageToken,files(id,name,modifiedTime,owners(displayName,emailAddress),starred,webViewLink)")
+	call = inSharedDrive(call, sharedDriveID)
+	if pageToken != "" {
+		call = call.PageToken(pageToken)
+	}
+
+	result, err := call.Context(ctx).Do()
+	if err != nil {
+		return s.respondWithAPIError("failed to search spreadsheets", err)
+	}
+
+	tenant := tenantFromContext(ctx)
+	files := make([]spreadsheetFile, 0, len(result.Files))
+	for _, file := range result.Files {
+		if !tenant.allows(file.Id) {
+			continue
+		}
+		owners := make([]string, 0, len(file.Owners))
+		for _, o := range file.Owners {
+			owners = append(owners, o.EmailAddress)
+		}
+		files = append(files, spreadsheetFile{
+			SpreadsheetID: file.Id,
+			Title:         file.Name,
+			ModifiedTime:  file.ModifiedTime,
+			Owners:        owners,
+			Starred:       &file.Starred,
+			URL:           file.WebViewLink,
+		})
+	}
+
+	response := spreadsheetListResult{
+		Query:         query,
+		Spreadsheets:  files,
+		NextPageToken: result.NextPageToken,
+		SharedDriveID: sharedDriveID,
+	}
+
+	return respondWithJSON(response)
+}
+
+// shareOutcome is the permission share_spreadsheet granted one recipient, or why it could not
+type shareOutcome struct {
+	EmailAddress string `json:"emailAddress"`
+	Role         string `json:"role,omitempty"`
+	Type         string `json:"type"`
+	PermissionID string `json:"permissionId,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// shareResult splits the recipients of share_spreadsheet into those shared with and those not
+type shareResult struct {
+	Successes []shareOutcome `json:"successes"`
+	Failures  []shareOutcome `json:"failures"`
+}
+
+// linkSharingResult is the anyone or domain permission set_link_sharing granted
+type linkSharingResult struct {
+	SpreadsheetID      string `json:"spreadsheetId"`
+	PermissionID       string `json:"permissionId"`
+	Type               string `json:"type"`
+	Role               string `json:"role"`
+	Domain             string `json:"domain,omitempty"`
+	AllowFileDiscovery bool   `json:"allowFileDiscovery"`
+	WebViewLink        string `json:"webViewLink"`
+}
+
+// permissionEntry is one permission listed by list_permissions, or the one remove_permission removed
+type permissionEntry struct {
+	SpreadsheetID string `json:"spreadsheetId,omitempty"`
+	PermissionID  string `json:"permissionId"`
+	Type          string `json:"type,omitempty"`
+	Role          string `json:"role,omitempty"`
+	EmailAddress  string `json:"emailAddress,omitempty"`
+	Domain        string `json:"domain,omitempty"`
+	DisplayName   string `json:"displayName,omitempty"`
+	Inherited     bool   `json:"inherited,omitempty"`
+	Removed       bool   `json:"removed,omitempty"`
+}
+
+// permissionsResult lists the permissions of a spreadsheet
+type permissionsResult struct {
+	SpreadsheetID string            `json:"spreadsheetId"`
+	Permissions   []permissionEntry `json:"permissions"`
+}
+
+// revokedPermission is an external permission revoke_all_external_access removed, would remove, or
+// failed on
+type revokedPermission struct {
+	FileID       string `json:"fileId"`
+	FileName     string `json:"fileName"`
+	PermissionID string `json:"permissionId,omitempty"`
+	Type         string `json:"type,omitempty"`
+	Role         string `json:"role,omitempty"`
+	Reason       string `json:"reason,omitempty"`
+	EmailAddress string `json:"emailAddress,omitempty"`
+	Domain       string `json:"domain,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// revokeResult reports what revoke_all_external_access removed from a file or folder
+type revokeResult struct {
+	FileID         string              `json:"fileId"`
+	AllowedDomains []string            `json:"allowedDomains"`
+	DryRun         bool                `json:"dryRun"`
+	FilesChecked   int                 `json:"filesChecked"`
+	Removed        []revokedPermission `json:"removed"`
+	Failures       []revokedPermission `json:"failures"`
+}
+
+// groupMember is one member of a Google Group
+type groupMember struct {
+	Email  string `json:"email"`
+	Role   string `json:"role"`
+	Type   string `json:"type"`
+	Status string `json:"status"`
+}
+
+// groupMembersResult lists the members of a Google Group
+type groupMembersResult struct {
+	Group   string        `json:"group"`
+	Members []groupMember `json:"members"`
+	Count   int           `json:"count"`
+}
+
+// driveFileResult identifies a spreadsheet a Drive tool deleted, restored, starred, renamed, moved,
+// copied or imported
+type driveFileResult struct {
+	Success       bool     `json:"success,omitempty"`
+	SpreadsheetID string   `json:"spreadsheetId"`
+	Title         string   `json:"title,omitempty"`
+	Starred       *bool    `json:"starred,omitempty"`
+	Permanent     *bool    `json:"permanent,omitempty"`
+	URL           string   `json:"url,omitempty"`
+	FolderID      string   `json:"folderId,omitempty"`
+	ClearedSheets []string `json:"clearedSheets,omitempty"`
+}
+
+// driveUser is the owner or last editor of a file
+type driveUser struct {
+	DisplayName  string `json:"displayName"`
+	EmailAddress string `json:"emailAddress"`
+}
+
+// spreadsheetMetadata is the Drive metadata of a spreadsheet
+type spreadsheetMetadata struct {
+	SpreadsheetID     string      `json:"spreadsheetId"`
+	Name              string      `json:"name"`
+	MimeType          string      `json:"mimeType"`
+	Owners            []driveUser `json:"owners"`
+	CreatedTime       string      `json:"createdTime"`
+	ModifiedTime      string      `json:"modifiedTime"`
+	Size              int64       `json:"size"`
+	WebViewLink       string      `json:"webViewLink"`
+	Parents           []string    `json:"parents"`
+	Trashed           bool        `json:"trashed"`
+	LastModifyingUser *driveUser  `json:"lastModifyingUser,omitempty"`
+}
+
+// sharedDrive is one shared drive the credentials can see
+type sharedDrive struct {
+	SharedDriveID string `json:"sharedDriveId"`
+	Name          string `json:"name"`
+	CreatedTime   string `json:"createdTime"`
+	Selected      bool   `json:"selected"`
+}
+
+// sharedDrivesResult is a page of shared drives
+type sharedDrivesResult struct {
+	SharedDrives  []sharedDrive `json:"sharedDrives"`
+	NextPageToken string        `json:"nextPageToken"`
+}
+
+// spreadsheetFile is one spreadsheet found by list_spreadsheets or search_spreadsheets
+type spreadsheetFile struct {
+	SpreadsheetID string   `json:"spreadsheetId"`
+	Title         string   `json:"title"`
+	ModifiedTime  string   `json:"modifiedTime"`
+	Owners        []string `json:"owners,omitzero"`
+	Starred       *bool    `json:"starred,omitempty"`
+	URL           string   `json:"url"`
+}
+
+// spreadsheetListResult is a page of spreadsheets
+type spreadsheetListResult struct {
+	Query         string            `json:"query,omitempty"`
+	Spreadsheets  []spreadsheetFile `json:"spreadsheets"`
+	NextPageToken string            `json:"nextPageToken"`
+	FolderID      string            `json:"folderId,omitempty"`
+	SharedDriveID string            `json:"sharedDriveId,omitempty"`
+}
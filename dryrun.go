@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// dryRunKey carries the recorder of a dry-run tool call in its context
+type dryRunKey struct{}
+
+// dryRunRecorder collects the mutating API requests a tool call would have sent
+type dryRunRecorder struct {
+	mu       sync.Mutex
+	requests []map[string]any
+}
+
+func (r *dryRunRecorder) record(req map[string]any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, req)
+}
+
+// dryRunTransport is an http.RoundTripper that, during a dry run, records mutating requests and
+// answers them with an empty success instead of sending them. Reads still go out, so handlers can
+// resolve sheet IDs, headers and ranges exactly as they would for real.
+type dryRunTransport struct {
+	next http.RoundTripper
+}
+
+// withDryRun returns a copy of client that holds back mutating requests made during a dry run
+func withDryRun(client *http.Client) *http.Client {
+	next := client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	recording := *client
+	recording.Transport = &dryRunTransport{next: next}
+	return &recording
+}
+
+func (t *dryRunTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorder, _ := req.Context().Value(dryRunKey{}).(*dryRunRecorder)
+	if recorder == nil || isAPIRead(req) {
+		return t.next.RoundTrip(req)
+	}
+
+	recorded := map[string]any{
+		"method": req.Method,
+		"url":    req.URL.String(),
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		var payload any
+		if strings.HasPrefix(req.Header.Get("Content-Type"), "application/json") && json.Unmarshal(body, &payload) == nil {
+			recorded["body"] = payload
+		} else if len(body) > 0 {
+			// Uploads are summarized rather than echoed back
+			recorded["contentType"] = req.Header.Get("Content-Type")
+			recorded["bytes"] = len(body)
+		}
+	}
+	recorder.record(recorded)
+
+	return &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Proto:      req.Proto,
+		ProtoMajor: req.ProtoMajor,
+		ProtoMinor: req.ProtoMinor,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader([]byte("{}"))),
+		Request:    req,
+	}, nil
+}
+
+// isAPIRead reports whether a Google API request only reads: any GET, the Sheets data-filter
+// reads, and Drive Activity queries
+func isAPIRead(req *http.Request) bool {
+	return isSheetsRead(req) || req.URL.Host == "driveactivity.googleapis.com"
+}
+
+// withDryRun runs a mutating tool without letting its writes reach Google when the call sets dry_run
+// or the server runs with DRY_RUN. The handler validates its arguments and performs its reads as
+// usual; its writes are recorded and returned instead of its normal result. Tools that declare their
+// own dry_run report what they would do themselves, so their result is kept, and the recorder only
+// holds back any write they send regardless.
+func (s *SheetsMCPServer) withDryRun(ownDryRun bool, handler mcp.ToolHandler) mcp.ToolHandler {
+	return func(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArgsFromRequest(request)
+		if err != nil {
+			return respondWithError(err.Error())
+		}
+		// DRY_RUN cannot be switched off per call, so an agent cannot opt out of review
+		if !s.config.DryRun && !parseArgument(args, "dry_run", false) {
+			return handler(ctx, request)
+		}
+
+		recorder := &dryRunRecorder{}
+		result, err := runDryRun(ctx, recorder, handler, request)
+		if err != nil || ownDryRun {
+			return result, err
+		}
+		// Without any recorded write the handler stopped early, typically on invalid arguments
+		if len(recorder.requests) == 0 && isErrorResult(result) {
+			return result, nil
+		}
+
+		response := map[string]any{
+			"dryRun":   true,
+			"requests": recorder.requests,
+		}
+		// A later step that reads back what an earlier write created cannot work without the write,
+		// so requests after that point are missing
+		if isErrorResult(result) {
+			if text, ok := result.Content[0].(*mcp.TextContent); ok {
+				response["stoppedAt"] = json.RawMessage(text.Text)
+			}
+		}
+		return respondWithJSON(response)
+	}
+}
+
+// runDryRun calls handler with the recorder in its context. Handlers may rely on details of a write's
+// reply, such as the ID of a created object, that the empty stand-in reply lacks, and the SDK does not
+// recover from handler panics; a handler that fails on that is stopped there with an error result
+// instead of taking the server down.
+func runDryRun(ctx context.Context, recorder *dryRunRecorder, handler mcp.ToolHandler, request *mcp.CallToolRequest) (result *mcp.CallToolResult, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			slog.DebugContext(ctx, "dry run stopped by a missing reply", "panic", recovered)
+			result, err = respondWithError("the next step needs the reply of a write that was not sent")
+		}
+	}()
+	return handler(context.WithValue(ctx, dryRunKey{}, recorder), request)
+}
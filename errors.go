@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/googleapi"
+)
+
+// Error codes of failed calls, so agents can tell failures apart without parsing the message
+const (
+	// errorInvalidRequest is the default: the call cannot be served as made, e.g. a missing argument
+	errorInvalidRequest = "invalid_request"
+	errorNotConfirmed   = "not_confirmed"
+	errorFormula        = "formula_error"
+	errorTimeout        = "timeout"
+	errorCancelled      = "cancelled"
+
+	// The rest classify failed Google API calls
+	errorInvalidArgument = "invalid_argument"
+	errorUnauthenticated = "unauthenticated"
+	errorPermission      = "permission_denied"
+	errorNotFound        = "not_found"
+	errorQuota           = "quota_exceeded"
+	errorUnavailable     = "unavailable"
+	errorAPI             = "api_error"
+)
+
+// toolError is the error object of a failed call
+type toolError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Retryable calls may succeed when repeated unchanged after a short wait
+	Retryable bool `json:"retryable"`
+	// GoogleAPIStatus is the HTTP status of the Google API reply the call failed on
+	GoogleAPIStatus int    `json:"google_api_status,omitempty"`
+	Hint            string `json:"hint,omitempty"`
+}
+
+// errorResult is the result of a failed call
+type errorResult struct {
+	Error toolError `json:"error"`
+}
+
+// respondWithToolError reports a failed call, with IsError set so that clients need not inspect the text
+func respondWithToolError(failure toolError) (*mcp.CallToolResult, error) {
+	result, err := respondWithJSON(errorResult{Error: failure})
+	if err != nil {
+		return nil, err
+	}
+	result.IsError = true
+	return result, nil
+}
+
+// errorMessage returns the message of a failed call's result
+func errorMessage(result *mcp.CallToolResult) string {
+	if len(result.Content) == 0 {
+		return ""
+	}
+	text, ok := result.Content[0].(*mcp.TextContent)
+	if !ok {
+		return ""
+	}
+	var failed errorResult
+	if err := json.Unmarshal([]byte(text.Text), &failed); err != nil || failed.Error.Message == "" {
+		return text.Text
+	}
+	return failed.Error.Message
+}
+
+// respondWithAPIError reports a failed Google API call together with guidance on how to fix it
+func (s *SheetsMCPServer) respondWithAPIError(action string, err error) (*mcp.CallToolResult, error) {
+	failure := classifyAPIError(err)
+	failure.Message = fmt.Sprintf("%s: %v", action, err)
+	failure.Hint = s.apiErrorHint(err)
+	return respondWithToolError(failure)
+}
+
+// rateLimitReasons are the reasons Google gives when it refuses a request for quota with 403 rather than 429
+var rateLimitReasons = []string{"rateLimitExceeded", "userRateLimitExceeded", "quotaExceeded", "dailyLimitExceeded"}
+
+// classifyAPIError returns the code, status and retryability of a failed Google API call
+func classifyAPIError(err error) toolError {
+	var apiErr *googleapi.Error
+	var retrieveErr *oauth2.RetrieveError
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return toolError{Code: errorTimeout, Retryable: true}
+	case errors.Is(err, context.Canceled):
+		return toolError{Code: errorCancelled}
+	case errors.As(err, &apiErr):
+	// A token that cannot be refreshed arrives wrapped in a *url.Error, which also passes for a net.Error
+	case errors.As(err, &retrieveErr):
+		return toolError{Code: errorUnauthenticated}
+	case errors.As(err, &netErr):
+		return toolError{Code: errorUnavailable, Retryable: true}
+	default:
+		return toolError{Code: errorAPI}
+	}
+
+	failure := toolError{GoogleAPIStatus: apiErr.Code}
+	rateLimited := slices.ContainsFunc(apiErr.Errors, func(item googleapi.ErrorItem) bool {
+		return slices.Contains(rateLimitReasons, item.Reason)
+	})
+	switch {
+	case apiErr.Code == http.StatusTooManyRequests || rateLimited:
+		failure.Code, failure.Retryable = errorQuota, true
+	case apiErr.Code == http.StatusUnauthorized:
+		failure.Code = errorUnauthenticated
+	case apiErr.Code == http.StatusForbidden:
+		failure.Code = errorPermission
+	case apiErr.Code == http.StatusNotFound:
+		failure.Code = errorNotFound
+	case apiErr.Code == http.StatusBadRequest:
+		failure.Code = errorInvalidArgument
+	case apiErr.Code >= http.StatusInternalServerError:
+		failure.Code, failure.Retryable = errorUnavailable, true
+	default:
+		failure.Code = errorAPI
+	}
+	return failure
+}
+
+// apiErrorHint translates a Google API error into an actionable next step. Bare Google errors
+// ("The caller does not have permission") rarely tell an agent what to do, so the common
+// causes are recognized from the status code and message. It returns "" when nothing applies.
+func (s *SheetsMCPServer) apiErrorHint(err error) string {
+	var retrieveErr *oauth2.RetrieveError
+	if errors.As(err, &retrieveErr) {
+		if s.authMethod == "oauth" {
+			return "The OAuth token could not be refreshed, usually because it was revoked or has expired. Call reauthenticate and ask the user to sign in, then retry."
+		}
+		return "No access token could be obtained. Check that the service account key has not been revoked or deleted."
+	}
+
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return ""
+	}
+
+	text := strings.ToLower(apiErr.Message + " " + apiErr.Body)
+	for _, item := range apiErr.Errors {
+		text += " " + strings.ToLower(item.Reason+" "+item.Message)
+	}
+
+	switch {
+	case apiErr.Code == http.StatusBadRequest && strings.Contains(text, "unable to parse range"):
+		return "Check the sheet name and A1 range. Sheet names with spaces or punctuation must be quoted, e.g. 'Q1 Sales'!A1:B2; use list_sheets to see the exact sheet names."
+	case apiErr.Code == http.StatusBadRequest && strings.Contains(text, "exceeds grid limits"):
+		return "The range reaches past the last row or column of the sheet. Use add_rows or add_columns to grow the sheet, or shrink the range."
+	case apiErr.Code == http.StatusBadRequest && strings.Contains(text, "no grid with id"):
+		return "The sheet no longer exists, probably renamed or deleted mid-session. Call list_sheets, or retry with force_refresh: true."
+	case apiErr.Code == http.StatusUnauthorized:
+		return "The credentials are invalid or expired. For OAuth, call reauthenticate and ask the user to sign in again; for service accounts, check that the key has not been revoked."
+	case strings.Contains(text, "service_disabled") || strings.Contains(text, "accessnotconfigured") || strings.Contains(text, "has not been used in project"):
+		return "The Google API needed for this call is not enabled in the Cloud project behind these credentials. Enable it under APIs & Services > Library, wait a few minutes, and retry."
+	case strings.Contains(text, "insufficient authentication scopes") || strings.Contains(text, "access_token_scope_insufficient") || strings.Contains(text, "insufficientpermissions"):
+		return "The credentials were granted without the scope this call needs. For OAuth, delete the token file (TOKEN_PATH) and restart so the new scopes are requested."
+	case apiErr.Code == http.StatusTooManyRequests || strings.Contains(text, "ratelimitexceeded") || strings.Contains(text, "quota exceeded"):
+		return "Google API quota exceeded. Wait about a minute before retrying, and combine reads with get_multiple_sheet_data or batch_update_cells to make fewer requests."
+	case apiErr.Code == http.StatusForbidden && strings.Contains(text, "protected"):
+		return "The cells are protected in the spreadsheet. Ask an editor of the protected range to grant access or remove the protection."
+	case apiErr.Code == http.StatusForbidden:
+		if s.serviceAccountEmail != "" {
+			return fmt.Sprintf("Share the spreadsheet (or its folder) with the service account %s, as Editor if the call writes.", s.serviceAccountEmail)
+		}
+		return "The signed-in account cannot access this file. Ask the owner to share it with this account, as Editor if the call writes."
+	case apiErr.Code == http.StatusNotFound:
+		if s.serviceAccountEmail != "" {
+			return fmt.Sprintf("Check the ID (the part of the URL between /d/ and /edit) and that the file is shared with the service account %s; files it cannot see are reported as not found.", s.serviceAccountEmail)
+		}
+		return "Check the ID (the part of the URL between /d/ and /edit) and that this account can open the file; files it cannot see are reported as not found."
+	case apiErr.Code >= http.StatusInternalServerError:
+		return "Google returned a temporary server error. Retry the call shortly."
+	}
+	return ""
+}
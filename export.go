@@ -0,0 +1,252 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	// maxInlineExportBytes is the largest export returned directly in the tool result
+	maxInlineExportBytes = 1 << 20
+	// maxExportResources bounds how many large exports are kept in memory as resources
+	maxExportResources = 4
+)
+
+// exportFormats maps the export formats to their MIME types; csv and tsv export a single sheet
+var exportFormats = map[string]string{
+	"xlsx": xlsxMimeType,
+	"ods":  "application/vnd.oasis.opendocument.spreadsheet",
+	"pdf":  "application/pdf",
+	"csv":  "text/csv",
+	"tsv":  "text/tab-separated-values",
+}
+
+// exportStore keeps exports too large to inline, serving each as a resource until it is evicted. An
+// export belongs to the session that made it, and no other session can list or read it.
+type exportStore struct {
+	mu      sync.Mutex
+	entries map[string]exportEntry
+	order   []string
+}
+
+type exportEntry struct {
+	data    []byte
+	session *mcp.ServerSession
+}
+
+func newExportStore() *exportStore {
+	return &exportStore{entries: make(map[string]exportEntry)}
+}
+
+// add stores an export for a session and returns the URIs evicted to make room for it
+func (e *exportStore) add(uri string, data []byte, session *mcp.ServerSession) []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.entries[uri] = exportEntry{data: data, session: session}
+	e.order = append(e.order, uri)
+
+	var evicted []string
+	for len(e.order) > maxExportResources {
+		evicted = append(evicted, e.order[0])
+		delete(e.entries, e.order[0])
+		e.order = e.order[1:]
+	}
+	return evicted
+}
+
+// get returns an export when it belongs to the session asking for it
+func (e *exportStore) get(uri string, session *mcp.ServerSession) ([]byte, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	entry, ok := e.entries[uri]
+	if !ok || entry.session != session {
+		return nil, false
+	}
+	return entry.data, true
+}
+
+// hidden reports whether a resource is an export another session made
+func (e *exportStore) hidden(uri string, session *mcp.ServerSession) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	entry, ok := e.entries[uri]
+	return ok && entry.session != session
+}
+
+// exportURL builds the spreadsheet export endpoint URL; gid selects the sheet for single-sheet formats
+func exportURL(spreadsheetID, format string, gid int64, singleSheet bool) string {
+	query := url.Values{"format": {format}}
+	if singleSheet {
+		query.Set("gid", fmt.Sprint(gid))
+	}
+	return fmt.Sprintf("https://docs.google.com/spreadsheets/d/%s/export?%s", url.PathEscape(spreadsheetID), query.Encode())
+}
+
+// openExport starts downloading an export of a spreadsheet and returns its body along with the
+// exported sheet, which is empty when the whole spreadsheet is exported
+func (s *SheetsMCPServer) openExport(ctx context.Context, spreadsheetID, format, sheet string) (io.ReadCloser, string, error) {
+	// csv and tsv only ever contain one sheet; pdf is limited to one when a sheet is named
+	singleSheet := format == "csv" || format == "tsv" || (format == "pdf" && sheet != "")
+	var gid int64
+	if singleSheet {
+		props, err := s.getSheetProperties(ctx, spreadsheetID)
+		if err != nil {
+			return nil, "", err
+		}
+		if sheet == "" {
+			sheet = props[0].Title
+		}
+		if gid, err = s.getSheetID(ctx, spreadsheetID, sheet); err != nil {
+			return nil, "", err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, exportURL(spreadsheetID, format, gid, singleSheet), nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to build export request: %w", err)
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, "", fmt.Errorf("export returned %s", resp.Status)
+	}
+
+	return resp.Body, sheet, nil
+}
+
+func (s *SheetsMCPServer) handleExportSpreadsheet(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID := parseArgument(args, "spreadsheet_id", "")
+	format := parseArgument(args, "format", "xlsx")
+	sheet := parseArgument(args, "sheet", "")
+
+	if spreadsheetID == "" {
+		return respondWithError("spreadsheet_id is required")
+	}
+	mimeType, ok := exportFormats[format]
+	if !ok {
+		return respondWithError(fmt.Sprintf("invalid format '%s': must be xlsx, ods, pdf, csv, or tsv", format))
+	}
+
+	body, sheet, err := s.openExport(ctx, spreadsheetID, format, sheet)
+	if err != nil {
+		return s.respondWithAPIError("failed to export spreadsheet", err)
+	}
+	defer body.Close()
+
+	// The export's length is rarely known ahead, so progress counts the bytes downloaded without a total
+	var reader io.Reader = body
+	if progress := newProgressReporter(request, 0); progress != nil {
+		reader = &progressReader{ctx: ctx, reader: body, progress: progress, message: func(read int64) string {
+			return fmt.Sprintf("downloaded %d bytes", read)
+		}}
+	}
+
+	response := exportResult{
+		SpreadsheetID: spreadsheetID,
+		Format:        format,
+		MimeType:      mimeType,
+		Sheet:         sheet,
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return respondWithError(fmt.Sprintf("failed to read export: %v", err))
+	}
+	response.Size = int64(len(data))
+
+	if len(data) <= maxInlineExportBytes {
+		if format == "csv" || format == "tsv" {
+			response.Encoding = "text"
+			response.Content = string(data)
+		} else {
+			response.Encoding = "base64"
+			response.Content = base64.StdEncoding.EncodeToString(data)
+		}
+		return respondWithJSON(response)
+	}
+
+	uri, err := s.addExportResource(request.Session, spreadsheetID, format, mimeType, data)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	response.ResourceURI = uri
+	response.Note = fmt.Sprintf("the export is larger than %d bytes; read it from resourceUri", maxInlineExportBytes)
+
+	return respondWithJSON(response)
+}
+
+// addExportResource keeps a large export in memory and registers it as a resource of the session,
+// evicting the oldest ones
+func (s *SheetsMCPServer) addExportResource(session *mcp.ServerSession, spreadsheetID, format, mimeType string, data []byte) (string, error) {
+	token := make([]byte, 8)
+	if _, err := rand.Read(token); err != nil {
+		return "", fmt.Errorf("failed to name export: %w", err)
+	}
+	uri := fmt.Sprintf("export://%s/%s.%s", spreadsheetID, hex.EncodeToString(token), format)
+
+	if evicted := s.exports.add(uri, data, session); len(evicted) > 0 {
+		s.mcpServer.RemoveResources(evicted...)
+	}
+	size := int64(len(data))
+	s.mcpServer.AddResource(&mcp.Resource{
+		URI:         uri,
+		Name:        fmt.Sprintf("%s.%s", spreadsheetID, format),
+		Description: "Spreadsheet export from export_spreadsheet",
+		MIMEType:    mimeType,
+		Size:        size,
+	}, s.handleReadExport)
+
+	return uri, nil
+}
+
+func (s *SheetsMCPServer) handleReadExport(ctx context.Context, request *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	uri := request.Params.URI
+	data, ok := s.exports.get(uri, request.Session)
+	if !ok {
+		return nil, mcp.ResourceNotFoundError(uri)
+	}
+
+	format := strings.TrimPrefix(path.Ext(uri), ".")
+	contents := &mcp.ResourceContents{URI: uri, MIMEType: exportFormats[format]}
+	if format == "csv" || format == "tsv" {
+		contents.Text = string(data)
+	} else {
+		contents.Blob = data
+	}
+
+	return &mcp.ReadResourceResult{Contents: []*mcp.ResourceContents{contents}}, nil
+}
+
+// exportResult describes an export: its content, or the resource holding it
+type exportResult struct {
+	SpreadsheetID string `json:"spreadsheetId"`
+	Format        string `json:"format"`
+	MimeType      string `json:"mimeType"`
+	Sheet         string `json:"sheet,omitempty"`
+	Size          int64  `json:"size"`
+	Encoding      string `json:"encoding,omitempty"`
+	Content       string `json:"content,omitempty"`
+	ResourceURI   string `json:"resourceUri,omitempty"`
+	Note          string `json:"note,omitempty"`
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"google.golang.org/api/sheets/v4"
+)
+
+// scratchSheetPrefix names the hidden sheets evaluate_formula creates and removes again
+const scratchSheetPrefix = "_mcp_scratch_"
+
+// formulaErrors are the error values a formula can evaluate to
+var formulaErrors = []string{"#N/A", "#DIV/0!", "#VALUE!", "#REF!", "#NAME?", "#NUM!", "#NULL!", "#ERROR!"}
+
+func (s *SheetsMCPServer) handleEvaluateFormula(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID := parseArgument(args, "spreadsheet_id", "")
+	formula := strings.TrimSpace(parseArgument(args, "formula", ""))
+
+	if spreadsheetID == "" || formula == "" {
+		return respondWithError("spreadsheet_id and formula are required")
+	}
+	if !strings.HasPrefix(formula, "=") {
+		formula = "=" + formula
+	}
+	valueRender, dateTimeRender, err := parseRenderOptions(args)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	if valueRender == "FORMULA" {
+		return respondWithError("value_render_option must be FORMATTED_VALUE or UNFORMATTED_VALUE")
+	}
+
+	// Each evaluation gets its own hidden sheet, created with the formula already in A1, so concurrent
+	// evaluations cannot see each other's cells and nothing is left behind in the user's sheets
+	sheetID := rand.Int64N(math.MaxInt32-1) + 1
+	title := fmt.Sprintf("%s%d", scratchSheetPrefix, sheetID)
+	requests := []*sheets.Request{
+		{
+			AddSheet: &sheets.AddSheetRequest{
+				Properties: &sheets.SheetProperties{
+					SheetId: sheetID,
+					Title:   title,
+					Hidden:  true,
+				},
+			},
+		},
+		{
+			UpdateCells: &sheets.UpdateCellsRequest{
+				Start: &sheets.GridCoordinate{SheetId: sheetID},
+				Rows: []*sheets.RowData{{
+					Values: []*sheets.CellData{{UserEnteredValue: &sheets.ExtendedValue{FormulaValue: &formula}}},
+				}},
+				Fields: "userEnteredValue",
+			},
+		},
+	}
+	if _, err := s.executeBatchUpdate(ctx, spreadsheetID, requests); err != nil {
+		return s.respondWithAPIError("failed to create scratch sheet", err)
+	}
+	// Remove the sheet even when the call timed out or was cancelled
+	defer func() {
+		cleanup := []*sheets.Request{{DeleteSheet: &sheets.DeleteSheetRequest{SheetId: sheetID}}}
+		if _, err := s.executeBatchUpdate(context.WithoutCancel(ctx), spreadsheetID, cleanup); err != nil {
+			slog.Warn("failed to delete scratch sheet", "spreadsheet_id", spreadsheetID, "sheet", title, "error", err)
+		}
+	}()
+
+	// Reading the whole sheet also picks up array results that spill beyond A1
+	valuesResult, err := s.sheetsService.Spreadsheets.Values.Get(spreadsheetID, buildFullRange(title, "")).
+		ValueRenderOption(valueRender).
+		DateTimeRenderOption(dateTimeRender).
+		Context(ctx).
+		Do()
+	if err != nil {
+		return s.respondWithAPIError("failed to read formula result", err)
+	}
+
+	response := formulaResult{
+		SpreadsheetID: spreadsheetID,
+		Formula:       formula,
+	}
+	values := valuesResult.Values
+	if len(values) > 0 && len(values[0]) > 0 {
+		response.Value = values[0][0]
+	}
+	if len(values) > 1 || len(values) == 1 && len(values[0]) > 1 {
+		response.Values = values
+	}
+	if text, ok := response.Value.(string); ok && slices.Contains(formulaErrors, text) {
+		return respondWithToolError(toolError{Code: errorFormula, Message: fmt.Sprintf("%s evaluated to %s", formula, text)})
+	}
+	return respondWithJSON(response)
+}
+
+// formulaResult is the value a formula evaluated to
+type formulaResult struct {
+	SpreadsheetID string  `json:"spreadsheetId"`
+	Formula       string  `json:"formula"`
+	Value         any     `json:"value"`
+	Values        [][]any `json:"values,omitempty"`
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/textproto"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
+)
+
+// maxAttachmentBytes is the largest attachment Gmail accepts on a message
+const maxAttachmentBytes = 25 << 20
+
+// parseAddresses parses recipients such as "Ann <ann@example.com>", so that nothing but addresses can
+// reach the message headers
+func parseAddresses(field string, addresses []string) ([]*mail.Address, error) {
+	parsed := make([]*mail.Address, 0, len(addresses))
+	for _, address := range addresses {
+		addr, err := mail.ParseAddress(address)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s address %q: %w", field, address, err)
+		}
+		parsed = append(parsed, addr)
+	}
+	return parsed, nil
+}
+
+// formatAddresses renders parsed addresses for a To or Cc header
+func formatAddresses(addresses []*mail.Address) string {
+	formatted := make([]string, len(addresses))
+	for i, addr := range addresses {
+		formatted[i] = addr.String()
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// buildDraftMessage renders an RFC 822 message with a plain text body and one attachment
+func buildDraftMessage(to, cc []*mail.Address, subject, body, filename, mimeType string, attachment []byte) ([]byte, error) {
+	var msg bytes.Buffer
+	parts := multipart.NewWriter(&msg)
+
+	fmt.Fprintf(&msg, "To: %s\r\n", formatAddresses(to))
+	if len(cc) > 0 {
+		fmt.Fprintf(&msg, "Cc: %s\r\n", formatAddresses(cc))
+	}
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", parts.Boundary())
+
+	text, err := parts.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=UTF-8"},
+		"Content-Transfer-Encoding": {"8bit"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	io.WriteString(text, strings.ReplaceAll(body, "\n", "\r\n"))
+
+	file, err := parts.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {mime.FormatMediaType(mimeType, map[string]string{"name": filename})},
+		"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": filename})},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	encoded := base64.StdEncoding.EncodeToString(attachment)
+	for len(encoded) > 76 {
+		io.WriteString(file, encoded[:76]+"\r\n")
+		encoded = encoded[76:]
+	}
+	io.WriteString(file, encoded+"\r\n")
+
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+	return msg.Bytes(), nil
+}
+
+func (s *SheetsMCPServer) handleDraftEmailWithExport(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID := parseArgument(args, "spreadsheet_id", "")
+	format := parseArgument(args, "format", "xlsx")
+	sheet := parseArgument(args, "sheet", "")
+	subject := parseArgument(args, "subject", "")
+	body := parseArgument(args, "body", "")
+
+	var to, cc []string
+	if raw, ok := args["to"]; ok {
+		if err := convertToType(raw, &to); err != nil {
+			return respondWithError(fmt.Sprintf("invalid to format: %v", err))
+		}
+	}
+	if raw, ok := args["cc"]; ok {
+		if err := convertToType(raw, &cc); err != nil {
+			return respondWithError(fmt.Sprintf("invalid cc format: %v", err))
+		}
+	}
+
+	if spreadsheetID == "" || len(to) == 0 {
+		return respondWithError("spreadsheet_id and to are required")
+	}
+	if format != "xlsx" && format != "pdf" {
+		return respondWithError(fmt.Sprintf("invalid format '%s': must be xlsx or pdf", format))
+	}
+	toAddresses, err := parseAddresses("to", to)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	ccAddresses, err := parseAddresses("cc", cc)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+
+	file, err := s.driveService.Files.Get(spreadsheetID).Fields("name,webViewLink").Context(ctx).Do()
+	if err != nil {
+		return s.respondWithAPIError("failed to get spreadsheet", err)
+	}
+
+	export, sheet, err := s.openExport(ctx, spreadsheetID, format, sheet)
+	if err != nil {
+		return s.respondWithAPIError("failed to export spreadsheet", err)
+	}
+	defer export.Close()
+
+	attachment, err := io.ReadAll(io.LimitReader(export, maxAttachmentBytes+1))
+	if err != nil {
+		return respondWithError(fmt.Sprintf("failed to read export: %v", err))
+	}
+	if len(attachment) > maxAttachmentBytes {
+		return respondWithError(fmt.Sprintf("the %s export is larger than Gmail's %d MB attachment limit; share the link instead", format, maxAttachmentBytes>>20))
+	}
+
+	if subject == "" {
+		subject = file.Name
+	}
+	if body != "" {
+		body += "\n\n"
+	}
+	body += file.WebViewLink + "\n"
+
+	message, err := buildDraftMessage(toAddresses, ccAddresses, subject, body, file.Name+"."+format, exportFormats[format], attachment)
+	if err != nil {
+		return respondWithError(fmt.Sprintf("failed to build message: %v", err))
+	}
+
+	draft, err := s.gmailService.Users.Drafts.Create("me", &gmail.Draft{}).
+		Media(bytes.NewReader(message), googleapi.ContentType("message/rfc822")).
+		Context(ctx).
+		Do()
+	if err != nil {
+		return s.respondWithAPIError("failed to create draft", err)
+	}
+
+	response := draftResult{
+		DraftID:     draft.Id,
+		Subject:     subject,
+		To:          to,
+		Sheet:       sheet,
+		Attachment:  file.Name + "." + format,
+		Size:        len(attachment),
+		WebViewLink: file.WebViewLink,
+		URL:         "https://mail.google.com/mail/#drafts",
+	}
+
+	return respondWithJSON(response)
+}
+
+// draftResult identifies the draft draft_email_with_export created
+type draftResult struct {
+	DraftID     string   `json:"draftId"`
+	Subject     string   `json:"subject"`
+	To          []string `json:"to"`
+	Sheet       string   `json:"sheet,omitempty"`
+	Attachment  string   `json:"attachment"`
+	Size        int      `json:"size"`
+	WebViewLink string   `json:"webViewLink"`
+	URL         string   `json:"url"`
+}
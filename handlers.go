@@ -1,15 +1,25 @@
 package main
 
 import (
+	"cmp"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"math"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/sync/errgroup"
 	"google.golang.org/api/sheets/v4"
 )
 
+// maxParallelSpreadsheets bounds how many spreadsheets the multi-spreadsheet tools fetch at once
+const maxParallelSpreadsheets = 4
+
 func getArgsFromRequest(request *mcp.CallToolRequest) (map[string]any, error) {
 	if len(request.Params.Arguments) == 0 {
 		return map[string]any{}, nil
@@ -28,40 +38,198 @@ func (s *SheetsMCPServer) handleGetSheetData(ctx context.Context, request *mcp.C
 	}
 	spreadsheetID, sheet, rangeStr := parseCommonArgs(args)
 	includeGridData := parseArgument(args, "include_grid_data", false)
+	outputFormat := parseArgument(args, "output_format", "json")
 
 	if spreadsheetID == "" || sheet == "" {
 		return respondWithError("spreadsheet_id and sheet are required")
 	}
 
+	valueRender, dateTimeRender, err := parseRenderOptions(args)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	majorDimension, err := parseMajorDimension(args)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+
+	asRecords := parseArgument(args, "as_records", false)
+	if asRecords && (majorDimension != "ROWS" || includeGridData || outputFormat != "json") {
+		return respondWithError("as_records cannot be combined with major_dimension COLUMNS, include_grid_data, or output_format csv or markdown")
+	}
+	coerce := parseArgument(args, "coerce_types", false)
+	var columnTypes map[string]string
+	if raw, ok := args["column_types"]; ok {
+		if err := convertToType(raw, &columnTypes); err != nil {
+			return respondWithError(fmt.Sprintf("invalid column_types format: %v", err))
+		}
+		if majorDimension != "ROWS" {
+			return respondWithError("column_types cannot be combined with major_dimension COLUMNS")
+		}
+	}
+	headerRow := int(parseArgument(args, "header_row", float64(1)))
+	if headerRow < 1 {
+		return respondWithError("header_row must be at least 1")
+	}
+
+	isoDates := parseArgument(args, "iso_dates", false)
+	if isoDates && (includeGridData || valueRender == "FORMULA") {
+		return respondWithError("iso_dates cannot be combined with include_grid_data or value_render_option FORMULA")
+	}
+
+	rowOffset := int(parseArgument(args, "row_offset", float64(0)))
+	rowLimit := int(parseArgument(args, "row_limit", float64(0)))
+	if rowOffset < 0 || rowLimit < 0 {
+		return respondWithError("row_offset and row_limit must not be negative")
+	}
+
 	fullRange := buildFullRange(sheet, rangeStr)
+	paged := rowOffset > 0 || rowLimit > 0
 
 	if includeGridData {
+		gridRange := fullRange
+		if window, ok := pageRange(rangeStr, rowOffset, rowLimit); paged && ok {
+			gridRange = buildFullRange(sheet, window)
+		}
 		result, err := s.sheetsService.Spreadsheets.Get(spreadsheetID).
-			Ranges(fullRange).
+			Ranges(gridRange).
 			IncludeGridData(true).
+			Context(ctx).
 			Do()
 		if err != nil {
-			return respondWithError(fmt.Sprintf("failed to get sheet data: %v", err))
+			return s.respondWithAPIError("failed to get sheet data", err)
+		}
+		if cells := gridDataCells(result); s.config.MaxResponseCells > 0 && cells > s.config.MaxResponseCells {
+			return respondWithError(fmt.Sprintf("the grid data holds %d cells, more than the %d a response may return (MAX_RESPONSE_CELLS); narrow range or pass row_limit", cells, s.config.MaxResponseCells))
 		}
 		return respondWithJSON(result)
 	}
 
-	valuesResult, err := s.sheetsService.Spreadsheets.Values.Get(spreadsheetID, fullRange).Do()
-	if err != nil {
-		return respondWithError(fmt.Sprintf("failed to get sheet values: %v", err))
+	// A page of rows is read on its own, together with the header row, rather than cut from the whole
+	// range. With major_dimension COLUMNS the values are columns, so row_offset and row_limit page those.
+	skip := rowOffset
+	if asRecords {
+		skip += headerRow
+	}
+	readLimit := rowLimit
+	if rowLimit > 0 {
+		readLimit++
 	}
+	window, windowed := pageRange(rangeStr, skip, readLimit)
+	windowed = windowed && paged && majorDimension == "ROWS"
 
-	response := map[string]any{
-		"spreadsheetId": spreadsheetID,
-		"valueRanges": []map[string]any{
-			{
-				"range":  fullRange,
-				"values": valuesResult.Values,
-			},
-		},
+	var rows, headerValues [][]any
+	readRange := fullRange
+	if windowed {
+		readRange = buildFullRange(sheet, window)
+		ranges := []string{readRange}
+		if headerRange, ok := pageRange(rangeStr, headerRow-1, 1); ok {
+			ranges = append(ranges, buildFullRange(sheet, headerRange))
+		}
+		batchResult, err := s.sheetsService.Spreadsheets.Values.BatchGet(spreadsheetID).
+			Ranges(ranges...).
+			ValueRenderOption(valueRender).
+			DateTimeRenderOption(dateTimeRender).
+			Context(ctx).
+			Do()
+		if err != nil {
+			return s.respondWithAPIError("failed to get sheet values", err)
+		}
+		if len(batchResult.ValueRanges) > 0 {
+			rows = batchResult.ValueRanges[0].Values
+		}
+		if len(batchResult.ValueRanges) > 1 {
+			headerValues = batchResult.ValueRanges[1].Values
+			recordCellsRead(ctx, headerValues)
+		}
+	} else {
+		valuesResult, err := s.sheetsService.Spreadsheets.Values.Get(spreadsheetID, fullRange).
+			ValueRenderOption(valueRender).
+			DateTimeRenderOption(dateTimeRender).
+			MajorDimension(majorDimension).
+			Context(ctx).
+			Do()
+		if err != nil {
+			return s.respondWithAPIError("failed to get sheet values", err)
+		}
+		rows = valuesResult.Values
+		if headerRow <= len(rows) {
+			headerValues = rows[headerRow-1 : headerRow]
+		}
+	}
+	recordCellsRead(ctx, rows)
+	if isoDates {
+		if err := s.isoDatesForRead(ctx, spreadsheetID, readRange, rows, majorDimension); err != nil {
+			return s.respondWithAPIError("failed to read date formats", err)
+		}
+	}
+	page := func(rows [][]any) ([][]any, pageInfo) {
+		if windowed {
+			return paginateWindow(rows, rowOffset, rowLimit, s.config.MaxResponseCells)
+		}
+		return paginate(rows, rowOffset, rowLimit, s.config.MaxResponseCells)
 	}
 
-	return respondWithJSON(response)
+	// Column types name columns by the headers in header_row, whether or not records are returned
+	var headers []any
+	if len(headerValues) > 0 {
+		headers = headerValues[0]
+	}
+	var kinds map[int]string
+	if len(columnTypes) > 0 {
+		names := make([]string, len(headers))
+		for i, header := range headers {
+			names[i] = formatCell(header)
+		}
+		if kinds, err = columnCoercions(names, columnTypes); err != nil {
+			return respondWithError(err.Error())
+		}
+	}
+
+	if asRecords {
+		if !windowed {
+			rows = rows[min(headerRow, len(rows)):]
+		}
+		keys := recordKeys(headers)
+		rows, pagination := page(rows)
+		if coerce || len(kinds) > 0 {
+			coerceRows(rows, kinds, coerce)
+		}
+		return respondWithJSON(sheetDataResult{
+			SpreadsheetID: spreadsheetID,
+			Range:         fullRange,
+			Headers:       keys,
+			Records:       rowsToRecords(keys, rows, false),
+			Pagination:    &pagination,
+		})
+	}
+
+	values, pagination := page(rows)
+	if coerce || len(kinds) > 0 {
+		coerceRows(values, kinds, coerce)
+	}
+	valueRange := valueRangeResult{
+		Range:  fullRange,
+		Values: values,
+	}
+	if majorDimension == "COLUMNS" {
+		valueRange.MajorDimension = majorDimension
+	}
+	response := sheetDataResult{
+		SpreadsheetID: spreadsheetID,
+		ValueRanges:   []valueRangeResult{valueRange},
+	}
+	paged = paged || pagination.HasMore
+	if paged {
+		response.Pagination = &pagination
+	}
+
+	result, err := respondWithValues(outputFormat, values, response)
+	// Text formats have no room for metadata, so there the page description travels in _meta
+	if paged && (outputFormat == "csv" || outputFormat == "markdown") && result != nil {
+		result.Meta = mcp.Meta{"pagination": pagination}
+	}
+	return result, err
 }
 
 func (s *SheetsMCPServer) handleGetSheetFormulas(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -70,6 +238,7 @@ func (s *SheetsMCPServer) handleGetSheetFormulas(ctx context.Context, request *m
 		return respondWithError(err.Error())
 	}
 	spreadsheetID, sheet, rangeStr := parseCommonArgs(args)
+	outputFormat := parseArgument(args, "output_format", "json")
 
 	if spreadsheetID == "" || sheet == "" {
 		return respondWithError("spreadsheet_id and sheet are required")
@@ -79,12 +248,69 @@ func (s *SheetsMCPServer) handleGetSheetFormulas(ctx context.Context, request *m
 
 	result, err := s.sheetsService.Spreadsheets.Values.Get(spreadsheetID, fullRange).
 		ValueRenderOption("FORMULA").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return s.respondWithAPIError("failed to get formulas", err)
+	}
+
+	recordCellsRead(ctx, result.Values)
+
+	values := result.Values
+	if values == nil {
+		values = [][]any{}
+	}
+	return respondWithValues(outputFormat, values, values)
+}
+
+func (s *SheetsMCPServer) handleHashRange(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID, sheet, rangeStr := parseCommonArgs(args)
+	includeFormulas := parseArgument(args, "include_formulas", false)
+
+	if spreadsheetID == "" || sheet == "" {
+		return respondWithError("spreadsheet_id and sheet are required")
+	}
+
+	renderOption := "UNFORMATTED_VALUE"
+	if includeFormulas {
+		renderOption = "FORMULA"
+	}
+
+	fullRange := buildFullRange(sheet, rangeStr)
+
+	valuesResult, err := s.sheetsService.Spreadsheets.Values.Get(spreadsheetID, fullRange).
+		ValueRenderOption(renderOption).
+		Context(ctx).
 		Do()
 	if err != nil {
-		return respondWithError(fmt.Sprintf("failed to get formulas: %v", err))
+		return s.respondWithAPIError("failed to get sheet values", err)
 	}
 
-	return respondWithJSON(result.Values)
+	// Each cell is hashed as its type tag plus text so that the number 1 and the string "1" differ
+	h := sha256.New()
+	cells := 0
+	for _, row := range valuesResult.Values {
+		for _, cell := range row {
+			fmt.Fprintf(h, "%T:%s\x1f", cell, formatCell(cell))
+			cells++
+		}
+		h.Write([]byte{'\n'})
+	}
+
+	response := hashResult{
+		SpreadsheetID: spreadsheetID,
+		Range:         valuesResult.Range,
+		Algorithm:     "sha256",
+		Hash:          hex.EncodeToString(h.Sum(nil)),
+		Rows:          len(valuesResult.Values),
+		Cells:         cells,
+	}
+
+	return respondWithJSON(response)
 }
 
 func (s *SheetsMCPServer) handleUpdateCells(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -108,20 +334,41 @@ func (s *SheetsMCPServer) handleUpdateCells(ctx context.Context, request *mcp.Ca
 		return respondWithError(fmt.Sprintf("invalid data format: %v", err))
 	}
 
+	valueInput, err := parseValueInputOption(args)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+
+	majorDimension, err := parseMajorDimension(args)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+
+	if err := s.localeParserFor(args, spreadsheetID, valueInput).convert(ctx, data); err != nil {
+		return respondWithError(err.Error())
+	}
+	if err := s.isoDateWriterFor(args, spreadsheetID, valueInput).convert(ctx, data); err != nil {
+		return respondWithError(err.Error())
+	}
+	s.sanitizeInput(args, valueInput, data)
+
 	fullRange := buildFullRange(sheet, rangeStr)
 
 	valueRange := &sheets.ValueRange{
-		Values: data,
+		MajorDimension: majorDimension,
+		Values:         data,
 	}
 
 	result, err := s.sheetsService.Spreadsheets.Values.Update(spreadsheetID, fullRange, valueRange).
-		ValueInputOption("USER_ENTERED").
+		ValueInputOption(valueInput).
+		Context(ctx).
 		Do()
 	if err != nil {
-		return respondWithError(fmt.Sprintf("failed to update cells: %v", err))
+		return s.respondWithAPIError("failed to update cells", err)
 	}
+	recordCellsWritten(ctx, result.UpdatedCells)
 
-	return respondWithJSON(result)
+	return respondWithShape(args, result)
 }
 
 func (s *SheetsMCPServer) handleBatchUpdateCells(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -146,14 +393,28 @@ func (s *SheetsMCPServer) handleBatchUpdateCells(ctx context.Context, request *m
 		return respondWithError("ranges must be an object/map")
 	}
 
+	valueInput, err := parseValueInputOption(args)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+
+	locale := s.localeParserFor(args, spreadsheetID, valueInput)
+	dates := s.isoDateWriterFor(args, spreadsheetID, valueInput)
 	var valueRanges []*sheets.ValueRange
 	for rangeStr, valuesRaw := range rangesMap {
 		values, err := convertToValues(valuesRaw)
 		if err != nil {
 			return respondWithError(fmt.Sprintf("invalid data format for range %s: %v", rangeStr, err))
 		}
+		if err := locale.convert(ctx, values); err != nil {
+			return respondWithError(err.Error())
+		}
+		if err := dates.convert(ctx, values); err != nil {
+			return respondWithError(err.Error())
+		}
+		s.sanitizeInput(args, valueInput, values)
 
-		fullRange := fmt.Sprintf("%s!%s", sheet, rangeStr)
+		fullRange := buildFullRange(sheet, rangeStr)
 		valueRanges = append(valueRanges, &sheets.ValueRange{
 			Range:  fullRange,
 			Values: values,
@@ -161,16 +422,17 @@ func (s *SheetsMCPServer) handleBatchUpdateCells(ctx context.Context, request *m
 	}
 
 	batchUpdate := &sheets.BatchUpdateValuesRequest{
-		ValueInputOption: "USER_ENTERED",
+		ValueInputOption: valueInput,
 		Data:             valueRanges,
 	}
 
-	result, err := s.sheetsService.Spreadsheets.Values.BatchUpdate(spreadsheetID, batchUpdate).Do()
+	result, err := s.sheetsService.Spreadsheets.Values.BatchUpdate(spreadsheetID, batchUpdate).Context(ctx).Do()
 	if err != nil {
-		return respondWithError(fmt.Sprintf("failed to batch update cells: %v", err))
+		return s.respondWithAPIError("failed to batch update cells", err)
 	}
+	recordCellsWritten(ctx, result.TotalUpdatedCells)
 
-	return respondWithJSON(result)
+	return respondWithShape(args, result)
 }
 
 func (s *SheetsMCPServer) handleAddRows(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -185,9 +447,9 @@ func (s *SheetsMCPServer) handleAddRows(ctx context.Context, request *mcp.CallTo
 		return respondWithError("spreadsheet_id, sheet, and count are required")
 	}
 
-	sheetID, err := s.getSheetID(spreadsheetID, sheet)
+	sheetID, err := s.getSheetID(ctx, spreadsheetID, sheet)
 	if err != nil {
-		return respondWithError(fmt.Sprintf("failed to get sheet ID: %v", err))
+		return s.respondWithAPIError("failed to get sheet ID", err)
 	}
 
 	startRow := int64(0)
@@ -209,12 +471,190 @@ func (s *SheetsMCPServer) handleAddRows(ctx context.Context, request *mcp.CallTo
 		},
 	}
 
-	result, err := s.executeBatchUpdate(spreadsheetID, requests)
+	result, err := s.executeBatchUpdate(ctx, spreadsheetID, requests)
+	if err != nil {
+		return s.respondWithAPIError("failed to add rows", err)
+	}
+
+	return respondWithShape(args, result)
+}
+
+func (s *SheetsMCPServer) handleInsertRowsWithData(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID, sheet, _ := parseCommonArgs(args)
+	startRow := int64(parseArgument(args, "start_row", float64(0)))
+	startColumn := parseArgument(args, "start_column", "A")
+	dataRaw, ok := args["data"]
+
+	if spreadsheetID == "" || sheet == "" || startRow < 1 || !ok {
+		return respondWithError("spreadsheet_id, sheet, start_row (1-based), and data are required")
+	}
+	column, err := parseA1Endpoint(startColumn)
+	if err != nil || column.col < 0 || column.row >= 0 {
+		return respondWithError(fmt.Sprintf("invalid start_column '%s': must be a column letter", startColumn))
+	}
+
+	data, err := convertToValues(dataRaw)
+	if err != nil {
+		return respondWithError(fmt.Sprintf("invalid data format: %v", err))
+	}
+	width := 0
+	for _, row := range data {
+		width = max(width, len(row))
+	}
+	if len(data) == 0 || width == 0 {
+		return respondWithError("data must contain at least one value")
+	}
+
+	valueInput, err := parseValueInputOption(args)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	// Inserting above the headers would push them down just as surely as overwriting them
+	headerRows, err := s.protectedHeaderRows(ctx, args, spreadsheetID, sheet)
+	if err != nil {
+		return s.respondWithAPIError("failed to get sheet properties", err)
+	}
+	if err := headerViolation("insert_rows_with_data", startRow-1, headerRows); err != nil {
+		return respondWithError(err.Error())
+	}
+	if err := s.localeParserFor(args, spreadsheetID, valueInput).convert(ctx, data); err != nil {
+		return respondWithError(err.Error())
+	}
+	if err := s.isoDateWriterFor(args, spreadsheetID, valueInput).convert(ctx, data); err != nil {
+		return respondWithError(err.Error())
+	}
+	s.sanitizeInput(args, valueInput, data)
+
+	sheetID, err := s.getSheetID(ctx, spreadsheetID, sheet)
 	if err != nil {
-		return respondWithError(fmt.Sprintf("failed to add rows: %v", err))
+		return s.respondWithAPIError("failed to get sheet ID", err)
+	}
+
+	// New rows take their formatting from the row above, or from the row below when inserted at the top.
+	// The rows are inserted and filled in one batch update, so a failed write inserts nothing.
+	requests := []*sheets.Request{{InsertDimension: &sheets.InsertDimensionRequest{
+		Range: &sheets.DimensionRange{
+			SheetId:    sheetID,
+			Dimension:  "ROWS",
+			StartIndex: startRow - 1,
+			EndIndex:   startRow - 1 + int64(len(data)),
+		},
+		InheritFromBefore: startRow > 1,
+	}}}
+	requests = append(requests, fillCellsRequests(sheetID, startRow-1, column.col, data, valueInput)...)
+	if _, err := s.executeBatchUpdate(ctx, spreadsheetID, requests); err != nil {
+		return s.respondWithAPIError("failed to insert rows", err)
+	}
+
+	cells := int64(0)
+	for _, row := range data {
+		cells += int64(len(row))
+	}
+	recordCellsWritten(ctx, cells)
+
+	return respondWithJSON(insertRowsResult{
+		SpreadsheetID: spreadsheetID,
+		InsertedRows:  len(data),
+		UpdatedRange: buildFullRange(sheet, fmt.Sprintf("%s%d:%s%d",
+			columnToLetter(column.col), startRow, columnToLetter(column.col+int64(width)-1), startRow+int64(len(data))-1)),
+		UpdatedCells: cells,
+	})
+}
+
+// fillCellsRequests writes values into empty cells from a row and column, as batch update requests.
+// With USER_ENTERED, text is pasted so Sheets parses it as if typed, as a values update would, and
+// the rows keep the formatting they inherited. Numbers, booleans, formulas, and text a paste would
+// split or unquote are set as typed values instead.
+func fillCellsRequests(sheetID, row, column int64, data [][]any, valueInput string) []*sheets.Request {
+	if valueInput == "RAW" {
+		rows := make([]*sheets.RowData, len(data))
+		for r, values := range data {
+			rows[r] = &sheets.RowData{}
+			for _, value := range values {
+				rows[r].Values = append(rows[r].Values, typedCellData(value, valueInput))
+			}
+		}
+		return []*sheets.Request{{UpdateCells: &sheets.UpdateCellsRequest{
+			Start:  &sheets.GridCoordinate{SheetId: sheetID, RowIndex: row, ColumnIndex: column},
+			Rows:   rows,
+			Fields: "userEnteredValue",
+		}}}
+	}
+
+	typed := func(row []any, i int) bool {
+		text, ok := row[i].(string)
+		return !ok || strings.HasPrefix(text, "=") || strings.ContainsAny(text, "\t\r\n\"")
+	}
+
+	var requests []*sheets.Request
+	var pasted strings.Builder
+	hasText := false
+	for r, values := range data {
+		if r > 0 {
+			pasted.WriteByte('\n')
+		}
+		for i := 0; i < len(values); i++ {
+			if i > 0 {
+				pasted.WriteByte('\t')
+			}
+			if !typed(values, i) {
+				pasted.WriteString(values[i].(string))
+				hasText = hasText || values[i] != ""
+				continue
+			}
+			// A run of typed values goes into one request
+			start := i
+			var cells []*sheets.CellData
+			for ; i < len(values) && typed(values, i); i++ {
+				cells = append(cells, typedCellData(values[i], valueInput))
+				if i > start {
+					pasted.WriteByte('\t')
+				}
+			}
+			i--
+			requests = append(requests, &sheets.Request{UpdateCells: &sheets.UpdateCellsRequest{
+				Start:  &sheets.GridCoordinate{SheetId: sheetID, RowIndex: row + int64(r), ColumnIndex: column + int64(start)},
+				Rows:   []*sheets.RowData{{Values: cells}},
+				Fields: "userEnteredValue",
+			}})
+		}
 	}
+	if !hasText {
+		return requests
+	}
+	// The paste leaves the typed values' cells empty, so it goes first
+	paste := &sheets.Request{PasteData: &sheets.PasteDataRequest{
+		Coordinate: &sheets.GridCoordinate{SheetId: sheetID, RowIndex: row, ColumnIndex: column},
+		Data:       pasted.String(),
+		Delimiter:  "\t",
+		Type:       "PASTE_VALUES",
+	}}
+	return append([]*sheets.Request{paste}, requests...)
+}
 
-	return respondWithJSON(result)
+// typedCellData is the cell a value becomes, with formulas and a leading apostrophe handled as
+// USER_ENTERED does
+func typedCellData(value any, valueInput string) *sheets.CellData {
+	switch v := value.(type) {
+	case nil:
+		return &sheets.CellData{}
+	case float64:
+		return &sheets.CellData{UserEnteredValue: &sheets.ExtendedValue{NumberValue: &v}}
+	case bool:
+		return &sheets.CellData{UserEnteredValue: &sheets.ExtendedValue{BoolValue: &v}}
+	}
+	text := formatCell(value)
+	if valueInput != "RAW" {
+		if strings.HasPrefix(text, "=") {
+			return &sheets.CellData{UserEnteredValue: &sheets.ExtendedValue{FormulaValue: &text}}
+		}
+		text = strings.TrimPrefix(text, "'")
+	}
+	return &sheets.CellData{UserEnteredValue: &sheets.ExtendedValue{StringValue: &text}}
 }
 
 func (s *SheetsMCPServer) handleAddColumns(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -229,9 +669,9 @@ func (s *SheetsMCPServer) handleAddColumns(ctx context.Context, request *mcp.Cal
 		return respondWithError("spreadsheet_id, sheet, and count are required")
 	}
 
-	sheetID, err := s.getSheetID(spreadsheetID, sheet)
+	sheetID, err := s.getSheetID(ctx, spreadsheetID, sheet)
 	if err != nil {
-		return respondWithError(fmt.Sprintf("failed to get sheet ID: %v", err))
+		return s.respondWithAPIError("failed to get sheet ID", err)
 	}
 
 	startColumn := int64(0)
@@ -253,12 +693,12 @@ func (s *SheetsMCPServer) handleAddColumns(ctx context.Context, request *mcp.Cal
 		},
 	}
 
-	result, err := s.executeBatchUpdate(spreadsheetID, requests)
+	result, err := s.executeBatchUpdate(ctx, spreadsheetID, requests)
 	if err != nil {
-		return respondWithError(fmt.Sprintf("failed to add columns: %v", err))
+		return s.respondWithAPIError("failed to add columns", err)
 	}
 
-	return respondWithJSON(result)
+	return respondWithShape(args, result)
 }
 
 func (s *SheetsMCPServer) handleListSheets(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -272,12 +712,12 @@ func (s *SheetsMCPServer) handleListSheets(ctx context.Context, request *mcp.Cal
 		return respondWithError("spreadsheet_id is required")
 	}
 
-	spreadsheet, err := s.sheetsService.Spreadsheets.Get(spreadsheetID).Do()
+	spreadsheet, err := s.sheetsService.Spreadsheets.Get(spreadsheetID).Context(ctx).Do()
 	if err != nil {
-		return respondWithError(fmt.Sprintf("failed to get spreadsheet: %v", err))
+		return s.respondWithAPIError("failed to get spreadsheet", err)
 	}
 
-	var sheetNames []string
+	sheetNames := []string{}
 	for _, sheet := range spreadsheet.Sheets {
 		sheetNames = append(sheetNames, sheet.Properties.Title)
 	}
@@ -307,23 +747,18 @@ func (s *SheetsMCPServer) handleCreateSheet(ctx context.Context, request *mcp.Ca
 		},
 	}
 
-	result, err := s.executeBatchUpdate(spreadsheetID, requests)
+	result, err := s.executeBatchUpdate(ctx, spreadsheetID, requests)
 	if err != nil {
-		return respondWithError(fmt.Sprintf("failed to create sheet: %v", err))
+		return s.respondWithAPIError("failed to create sheet", err)
 	}
 
 	if len(result.Replies) > 0 && result.Replies[0].AddSheet != nil {
-		props := result.Replies[0].AddSheet.Properties
-		response := map[string]any{
-			"sheetId":       props.SheetId,
-			"title":         props.Title,
-			"index":         props.Index,
-			"spreadsheetId": spreadsheetID,
-		}
+		response := summarizeSheet(result.Replies[0].AddSheet.Properties)
+		response.SpreadsheetID = spreadsheetID
 		return respondWithJSON(response)
 	}
 
-	return respondWithJSON(result)
+	return respondWithShape(args, result)
 }
 
 func (s *SheetsMCPServer) handleCopySheet(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -340,23 +775,23 @@ func (s *SheetsMCPServer) handleCopySheet(ctx context.Context, request *mcp.Call
 		return respondWithError("src_spreadsheet, src_sheet, dst_spreadsheet, and dst_sheet are required")
 	}
 
-	srcSheetID, err := s.getSheetID(srcSpreadsheet, srcSheet)
+	srcSheetID, err := s.getSheetID(ctx, srcSpreadsheet, srcSheet)
 	if err != nil {
-		return respondWithError(fmt.Sprintf("failed to get source sheet ID: %v", err))
+		return s.respondWithAPIError("failed to get source sheet ID", err)
 	}
 
 	copyRequest := &sheets.CopySheetToAnotherSpreadsheetRequest{
 		DestinationSpreadsheetId: dstSpreadsheet,
 	}
 
-	copyResult, err := s.sheetsService.Spreadsheets.Sheets.CopyTo(srcSpreadsheet, srcSheetID, copyRequest).Do()
+	copyResult, err := s.sheetsService.Spreadsheets.Sheets.CopyTo(srcSpreadsheet, srcSheetID, copyRequest).Context(ctx).Do()
 	if err != nil {
-		return respondWithError(fmt.Sprintf("failed to copy sheet: %v", err))
+		return s.respondWithAPIError("failed to copy sheet", err)
 	}
 
-	result := map[string]any{
-		"copy": copyResult,
-	}
+	s.metadataCache.invalidate(dstSpreadsheet)
+
+	result := copySheetReply{Copy: copyResult}
 
 	if copyResult.Title != dstSheet {
 		requests := []*sheets.Request{
@@ -371,18 +806,14 @@ func (s *SheetsMCPServer) handleCopySheet(ctx context.Context, request *mcp.Call
 			},
 		}
 
-		batchUpdate := &sheets.BatchUpdateSpreadsheetRequest{
-			Requests: requests,
-		}
-
-		renameResult, err := s.sheetsService.Spreadsheets.BatchUpdate(dstSpreadsheet, batchUpdate).Do()
+		renameResult, err := s.executeBatchUpdate(ctx, dstSpreadsheet, requests)
 		if err != nil {
-			return respondWithError(fmt.Sprintf("failed to rename copied sheet: %v", err))
+			return s.respondWithAPIError("failed to rename copied sheet", err)
 		}
-		result["rename"] = renameResult
+		result.Rename = renameResult
 	}
 
-	return respondWithJSON(result)
+	return respondWithShape(args, result)
 }
 
 func (s *SheetsMCPServer) handleRenameSheet(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -398,9 +829,9 @@ func (s *SheetsMCPServer) handleRenameSheet(ctx context.Context, request *mcp.Ca
 		return respondWithError("spreadsheet, sheet, and new_name are required")
 	}
 
-	sheetID, err := s.getSheetID(spreadsheet, sheet)
+	sheetID, err := s.getSheetID(ctx, spreadsheet, sheet)
 	if err != nil {
-		return respondWithError(fmt.Sprintf("failed to get sheet ID: %v", err))
+		return s.respondWithAPIError("failed to get sheet ID", err)
 	}
 
 	requests := []*sheets.Request{
@@ -415,29 +846,144 @@ func (s *SheetsMCPServer) handleRenameSheet(ctx context.Context, request *mcp.Ca
 		},
 	}
 
-	result, err := s.executeBatchUpdate(spreadsheet, requests)
+	result, err := s.executeBatchUpdate(ctx, spreadsheet, requests)
+	if err != nil {
+		return s.respondWithAPIError("failed to rename sheet", err)
+	}
+
+	return respondWithShape(args, result)
+}
+
+func (s *SheetsMCPServer) handleSetSheetProperties(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID, sheet, _ := parseCommonArgs(args)
+
+	if spreadsheetID == "" || sheet == "" {
+		return respondWithError("spreadsheet_id and sheet are required")
+	}
+
+	sheetID, err := s.getSheetID(ctx, spreadsheetID, sheet)
+	if err != nil {
+		return s.respondWithAPIError("failed to get sheet ID", err)
+	}
+
+	props := &sheets.SheetProperties{SheetId: sheetID}
+	fields := []string{}
+
+	if tabColorRaw, ok := args["tab_color"]; ok {
+		tabColor, err := parseColor(tabColorRaw)
+		if err != nil {
+			return respondWithError(fmt.Sprintf("invalid tab_color: %v", err))
+		}
+		props.TabColorStyle = &sheets.ColorStyle{RgbColor: tabColor}
+		fields = append(fields, "tabColorStyle")
+	}
+
+	if _, ok := args["index"]; ok {
+		props.Index = int64(parseArgument(args, "index", float64(0)))
+		props.ForceSendFields = append(props.ForceSendFields, "Index")
+		fields = append(fields, "index")
+	}
+
+	if _, ok := args["right_to_left"]; ok {
+		props.RightToLeft = parseArgument(args, "right_to_left", false)
+		props.ForceSendFields = append(props.ForceSendFields, "RightToLeft")
+		fields = append(fields, "rightToLeft")
+	}
+
+	if _, ok := args["hide_gridlines"]; ok {
+		props.GridProperties = &sheets.GridProperties{
+			HideGridlines:   parseArgument(args, "hide_gridlines", false),
+			ForceSendFields: []string{"HideGridlines"},
+		}
+		fields = append(fields, "gridProperties.hideGridlines")
+	}
+
+	if len(fields) == 0 {
+		return respondWithError("at least one of tab_color, index, right_to_left, or hide_gridlines must be provided")
+	}
+
+	requests := []*sheets.Request{
+		{
+			UpdateSheetProperties: &sheets.UpdateSheetPropertiesRequest{
+				Properties: props,
+				Fields:     strings.Join(fields, ","),
+			},
+		},
+	}
+
+	result, err := s.executeBatchUpdate(ctx, spreadsheetID, requests)
 	if err != nil {
-		return respondWithError(fmt.Sprintf("failed to rename sheet: %v", err))
+		return s.respondWithAPIError("failed to set sheet properties", err)
 	}
 
-	return respondWithJSON(result)
+	return respondWithShape(args, result)
 }
 
-func (s *SheetsMCPServer) getSheetID(spreadsheetID, sheetName string) (int64, error) {
-	spreadsheet, err := s.sheetsService.Spreadsheets.Get(spreadsheetID).Do()
+func (s *SheetsMCPServer) getSheetID(ctx context.Context, spreadsheetID, sheetName string) (int64, error) {
+	props, err := s.getSheetProperties(ctx, spreadsheetID)
 	if err != nil {
 		return 0, err
 	}
 
-	for _, sheet := range spreadsheet.Sheets {
-		if sheet.Properties.Title == sheetName {
-			return sheet.Properties.SheetId, nil
+	for _, p := range props {
+		if p.Title == sheetName {
+			return p.SheetId, nil
 		}
 	}
 
 	return 0, fmt.Errorf("sheet '%s' not found", sheetName)
 }
 
+// getSheetProperties returns the properties of every sheet in a spreadsheet, served from the metadata cache when fresh
+func (s *SheetsMCPServer) getSheetProperties(ctx context.Context, spreadsheetID string) ([]*sheets.SheetProperties, error) {
+	if props, ok := s.metadataCache.get(s.profile, spreadsheetID); ok {
+		return props, nil
+	}
+
+	spreadsheet, err := s.sheetsService.Spreadsheets.Get(spreadsheetID).
+		Fields("sheets(properties)").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return nil, err
+	}
+
+	props := make([]*sheets.SheetProperties, 0, len(spreadsheet.Sheets))
+	for _, sheet := range spreadsheet.Sheets {
+		props = append(props, sheet.Properties)
+	}
+
+	s.metadataCache.set(s.profile, spreadsheetID, props)
+	return props, nil
+}
+
+// ensureSheet creates the named sheet if it does not exist yet and reports whether it was created
+func (s *SheetsMCPServer) ensureSheet(ctx context.Context, spreadsheetID, sheetName string) (bool, error) {
+	if _, err := s.getSheetID(ctx, spreadsheetID, sheetName); err == nil {
+		return false, nil
+	}
+
+	requests := []*sheets.Request{
+		{
+			AddSheet: &sheets.AddSheetRequest{
+				Properties: &sheets.SheetProperties{
+					Title: sheetName,
+				},
+			},
+		},
+	}
+
+	if _, err := s.executeBatchUpdate(ctx, spreadsheetID, requests); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
 func convertToValues(data any) ([][]any, error) {
 	// If data is already a [][]any, return it directly
 	if values, ok := data.([][]any); ok {
@@ -467,39 +1013,163 @@ func convertToValues(data any) ([][]any, error) {
 	return values, nil
 }
 
-func (s *SheetsMCPServer) handleCreateSpreadsheet(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (s *SheetsMCPServer) handleCompareWithFile(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	args, err := getArgsFromRequest(request)
 	if err != nil {
 		return respondWithError(err.Error())
 	}
-	title := parseArgument(args, "title", "")
+	spreadsheetID, sheet, rangeStr := parseCommonArgs(args)
+	filePath := parseArgument(args, "file_path", "")
+	fileSheet := parseArgument(args, "file_sheet", "")
+	maxDifferences := max(1, int(parseArgument(args, "max_differences", float64(100))))
 
-	if title == "" {
-		return respondWithError("title is required")
+	if spreadsheetID == "" || sheet == "" || filePath == "" {
+		return respondWithError("spreadsheet_id, sheet, and file_path are required")
 	}
 
-	spreadsheet := &sheets.Spreadsheet{
-		Properties: &sheets.SpreadsheetProperties{
-			Title: title,
-		},
+	localPath, err := s.localPath(filePath)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	fileRows, err := readLocalTable(localPath, fileSheet)
+	if err != nil {
+		return s.respondWithAPIError("failed to read file", err)
 	}
 
-	result, err := s.sheetsService.Spreadsheets.Create(spreadsheet).Do()
+	fullRange := buildFullRange(sheet, rangeStr)
+
+	valuesResult, err := s.sheetsService.Spreadsheets.Values.Get(spreadsheetID, fullRange).
+		ValueRenderOption("UNFORMATTED_VALUE").
+		Context(ctx).
+		Do()
 	if err != nil {
-		return respondWithError(fmt.Sprintf("failed to create spreadsheet: %v", err))
+		return s.respondWithAPIError("failed to get sheet values", err)
 	}
 
-	response := map[string]any{
-		"spreadsheetId": result.SpreadsheetId,
-		"title":         result.Properties.Title,
-		"url":           result.SpreadsheetUrl,
+	// Offsets let differences be reported with the sheet's own cell addresses
+	rowOffset, colOffset := int64(0), int64(0)
+	if rangeStr != "" {
+		startCell, _, _ := strings.Cut(rangeStr, ":")
+		if start, err := parseA1Endpoint(startCell); err == nil {
+			rowOffset, colOffset = max(start.row, 0), max(start.col, 0)
+		}
 	}
 
-	return respondWithJSON(response)
-}
+	differences := []cellDifference{}
+	differenceCount := 0
+	sheetRows := len(valuesResult.Values)
+	sheetCols, fileCols := 0, 0
 
-func (s *SheetsMCPServer) handleGetMultipleSheetData(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	args, err := getArgsFromRequest(request)
+	for i := 0; i < max(len(fileRows), sheetRows); i++ {
+		var fileRow []string
+		var sheetRow []any
+		if i < len(fileRows) {
+			fileRow = fileRows[i]
+			fileCols = max(fileCols, len(fileRow))
+		}
+		if i < sheetRows {
+			sheetRow = valuesResult.Values[i]
+			sheetCols = max(sheetCols, len(sheetRow))
+		}
+
+		for j := 0; j < max(len(fileRow), len(sheetRow)); j++ {
+			fileValue, sheetValue := "", ""
+			if j < len(fileRow) {
+				fileValue = fileRow[j]
+			}
+			if j < len(sheetRow) {
+				sheetValue = formatCell(sheetRow[j])
+			}
+			if cellValuesEqual(fileValue, sheetValue) {
+				continue
+			}
+
+			differenceCount++
+			if len(differences) < maxDifferences {
+				differences = append(differences, cellDifference{
+					Cell:  fmt.Sprintf("%s%d", columnToLetter(colOffset+int64(j)), rowOffset+int64(i)+1),
+					File:  fileValue,
+					Sheet: sheetValue,
+				})
+			}
+		}
+	}
+
+	response := compareResult{
+		SpreadsheetID:   spreadsheetID,
+		Range:           fullRange,
+		File:            filePath,
+		Match:           differenceCount == 0,
+		DifferenceCount: differenceCount,
+		Differences:     differences,
+		Truncated:       differenceCount > len(differences),
+		FileSize:        gridSize{Rows: len(fileRows), Columns: fileCols},
+		SheetSize:       gridSize{Rows: sheetRows, Columns: sheetCols},
+	}
+
+	return respondWithJSON(response)
+}
+
+// cellValuesEqual compares cell text, treating numerically equal values ("1.50" vs "1.5") as the same
+func cellValuesEqual(a, b string) bool {
+	a, b = strings.TrimSpace(a), strings.TrimSpace(b)
+	if a == b {
+		return true
+	}
+
+	x, errA := strconv.ParseFloat(a, 64)
+	y, errB := strconv.ParseFloat(b, 64)
+	if errA != nil || errB != nil {
+		return strings.EqualFold(a, b) && (strings.EqualFold(a, "true") || strings.EqualFold(a, "false"))
+	}
+	return math.Abs(x-y) <= 1e-9*math.Max(1, math.Abs(x))
+}
+
+func (s *SheetsMCPServer) handleCreateSpreadsheet(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	title := parseArgument(args, "title", "")
+
+	if title == "" {
+		return respondWithError("title is required")
+	}
+
+	spreadsheet := &sheets.Spreadsheet{
+		Properties: &sheets.SpreadsheetProperties{
+			Title: title,
+		},
+	}
+
+	result, err := s.sheetsService.Spreadsheets.Create(spreadsheet).Context(ctx).Do()
+	if err != nil {
+		return s.respondWithAPIError("failed to create spreadsheet", err)
+	}
+
+	response := createSpreadsheetResult{
+		SpreadsheetID: result.SpreadsheetId,
+		Title:         title,
+		URL:           result.SpreadsheetUrl,
+	}
+
+	// New spreadsheets land in the Drive root; file them into the configured folder
+	if folderID := s.driveFolder(ctx); folderID != "" {
+		if err := s.requireDrive("filing into a folder"); err != nil {
+			response.Warning = err.Error()
+			return respondWithJSON(response)
+		}
+		if err := s.moveToFolder(ctx, result.SpreadsheetId, folderID); err != nil {
+			return respondWithError(fmt.Sprintf("created spreadsheet %s, but failed to move it to folder %s: %v", result.SpreadsheetId, folderID, err))
+		}
+		response.FolderID = folderID
+	}
+
+	return respondWithJSON(response)
+}
+
+func (s *SheetsMCPServer) handleGetMultipleSheetData(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
 	if err != nil {
 		return respondWithError(err.Error())
 	}
@@ -513,47 +1183,168 @@ func (s *SheetsMCPServer) handleGetMultipleSheetData(ctx context.Context, reques
 		return respondWithError(fmt.Sprintf("invalid queries format: %v", err))
 	}
 
-	var results []map[string]any
+	valueRender, dateTimeRender, err := parseRenderOptions(args)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
 
-	for _, query := range queries {
+	// Queries on the same spreadsheet share one batchGet, and spreadsheets are fetched concurrently
+	results := make([]sheetQueryResult, len(queries))
+	var order []string
+	bySpreadsheet := make(map[string][]int)
+	for i, query := range queries {
 		spreadsheetID := query["spreadsheet_id"]
-		sheet := query["sheet"]
-		rangeStr := query["range"]
-
-		if spreadsheetID == "" || sheet == "" || rangeStr == "" {
-			results = append(results, map[string]any{
-				"spreadsheet_id": spreadsheetID,
-				"sheet":          sheet,
-				"range":          rangeStr,
-				"error":          "Missing required keys (spreadsheet_id, sheet, range)",
-			})
+		results[i] = sheetQueryResult{
+			SpreadsheetID: spreadsheetID,
+			Sheet:         query["sheet"],
+			Range:         query["range"],
+		}
+		if spreadsheetID == "" || query["sheet"] == "" || query["range"] == "" {
+			results[i].Error = "Missing required keys (spreadsheet_id, sheet, range)"
 			continue
 		}
+		if _, ok := bySpreadsheet[spreadsheetID]; !ok {
+			order = append(order, spreadsheetID)
+		}
+		bySpreadsheet[spreadsheetID] = append(bySpreadsheet[spreadsheetID], i)
+	}
 
-		fullRange := fmt.Sprintf("%s!%s", sheet, rangeStr)
+	var group errgroup.Group
+	group.SetLimit(maxParallelSpreadsheets)
+	for _, spreadsheetID := range order {
+		indexes := bySpreadsheet[spreadsheetID]
+		group.Go(func() error {
+			ranges := make([]string, len(indexes))
+			for j, i := range indexes {
+				ranges[j] = buildFullRange(queries[i]["sheet"], queries[i]["range"])
+			}
 
-		valuesResult, err := s.sheetsService.Spreadsheets.Values.Get(spreadsheetID, fullRange).Do()
-		if err != nil {
-			results = append(results, map[string]any{
-				"spreadsheet_id": spreadsheetID,
-				"sheet":          sheet,
-				"range":          rangeStr,
-				"error":          err.Error(),
-			})
-			continue
-		}
+			batchResult, err := s.sheetsService.Spreadsheets.Values.BatchGet(spreadsheetID).
+				Ranges(ranges...).
+				ValueRenderOption(valueRender).
+				DateTimeRenderOption(dateTimeRender).
+				Context(ctx).
+				Do()
+			// One invalid range fails the whole batchGet, so the queries are then read one at a time
+			// to return the others and pin the error on the query it belongs to
+			if err != nil && len(indexes) > 1 && classifyAPIError(err).Code == errorInvalidArgument {
+				for j, i := range indexes {
+					valueRange, err := s.sheetsService.Spreadsheets.Values.Get(spreadsheetID, ranges[j]).
+						ValueRenderOption(valueRender).
+						DateTimeRenderOption(dateTimeRender).
+						Context(ctx).
+						Do()
+					if err != nil {
+						results[i].Error = err.Error()
+						continue
+					}
+					recordCellsRead(ctx, valueRange.Values)
+					results[i].Data = valueRange.Values
+				}
+				return nil
+			}
+			if err != nil {
+				for _, i := range indexes {
+					results[i].Error = err.Error()
+				}
+				return nil
+			}
 
-		results = append(results, map[string]any{
-			"spreadsheet_id": spreadsheetID,
-			"sheet":          sheet,
-			"range":          rangeStr,
-			"data":           valuesResult.Values,
+			for j, i := range indexes {
+				var values [][]any
+				if j < len(batchResult.ValueRanges) {
+					values = batchResult.ValueRanges[j].Values
+				}
+				recordCellsRead(ctx, values)
+				results[i].Data = values
+			}
+			return nil
 		})
 	}
+	group.Wait()
 
 	return respondWithJSON(results)
 }
 
+func (s *SheetsMCPServer) handleGetRanges(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID := parseArgument(args, "spreadsheet_id", "")
+	sheet := parseArgument(args, "sheet", "")
+	rangesRaw, ok := args["ranges"]
+
+	if spreadsheetID == "" || !ok {
+		return respondWithError("spreadsheet_id and ranges are required")
+	}
+
+	var ranges []string
+	if err := convertToType(rangesRaw, &ranges); err != nil {
+		return respondWithError(fmt.Sprintf("invalid ranges format: %v", err))
+	}
+	if len(ranges) == 0 {
+		return respondWithError("ranges must not be empty")
+	}
+	valueRender, dateTimeRender, err := parseRenderOptions(args)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	majorDimension, err := parseMajorDimension(args)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+
+	// Ranges may name their sheet; the rest are read from sheet, or from the first sheet without one
+	fullRanges := make([]string, len(ranges))
+	for i, rangeStr := range ranges {
+		prefix, cells, err := splitSheetPrefix(rangeStr)
+		if err != nil {
+			return respondWithError(fmt.Sprintf("invalid range '%s': %v", rangeStr, err))
+		}
+		switch {
+		case prefix != "":
+			fullRanges[i] = buildFullRange(prefix, cells)
+		case sheet != "":
+			fullRanges[i] = buildFullRange(sheet, cells)
+		default:
+			fullRanges[i] = cells
+		}
+	}
+
+	batchResult, err := s.sheetsService.Spreadsheets.Values.BatchGet(spreadsheetID).
+		Ranges(fullRanges...).
+		ValueRenderOption(valueRender).
+		DateTimeRenderOption(dateTimeRender).
+		MajorDimension(majorDimension).
+		Context(ctx).
+		Do()
+	if err != nil {
+		return s.respondWithAPIError("failed to get ranges", err)
+	}
+
+	coerce := parseArgument(args, "coerce_types", false)
+	valueRanges := make([]valueRangeResult, len(batchResult.ValueRanges))
+	for i, valueRange := range batchResult.ValueRanges {
+		recordCellsRead(ctx, valueRange.Values)
+		if coerce {
+			coerceRows(valueRange.Values, nil, true)
+		}
+		valueRanges[i] = valueRangeResult{
+			Range:  valueRange.Range,
+			Values: valueRange.Values,
+		}
+		if majorDimension == "COLUMNS" {
+			valueRanges[i].MajorDimension = majorDimension
+		}
+	}
+
+	return respondWithJSON(sheetDataResult{
+		SpreadsheetID: spreadsheetID,
+		ValueRanges:   valueRanges,
+	})
+}
+
 func (s *SheetsMCPServer) handleGetMultipleSpreadsheetSummary(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	args, err := getArgsFromRequest(request)
 	if err != nil {
@@ -571,73 +1362,101 @@ func (s *SheetsMCPServer) handleGetMultipleSpreadsheetSummary(ctx context.Contex
 
 	rowsToFetch := max(1, int(parseArgument(args, "rows_to_fetch", float64(5))))
 
-	var summaries []map[string]any
+	summaries := make([]spreadsheetSummary, len(spreadsheetIDs))
+	progress := newProgressReporter(request, float64(len(spreadsheetIDs)))
+
+	var group errgroup.Group
+	group.SetLimit(maxParallelSpreadsheets)
+	for i, spreadsheetID := range spreadsheetIDs {
+		group.Go(func() error {
+			summaries[i] = s.summarizeSpreadsheet(ctx, spreadsheetID, rowsToFetch)
+			rows := 0
+			for _, sheet := range summaries[i].Sheets {
+				rows += len(sheet.FirstRows)
+			}
+			progress.add(ctx, 1, fmt.Sprintf("summarized %s: %d sheets, %d rows", spreadsheetID, len(summaries[i].Sheets), rows))
+			return nil
+		})
+	}
+	group.Wait()
 
-	for _, spreadsheetID := range spreadsheetIDs {
-		summary := map[string]any{
-			"spreadsheet_id": spreadsheetID,
-			"title":          nil,
-			"sheets":         []map[string]any{},
-			"error":          nil,
-		}
+	return respondWithJSON(summaries)
+}
 
-		spreadsheet, err := s.sheetsService.Spreadsheets.Get(spreadsheetID).
-			Fields("properties.title,sheets(properties(title,sheetId))").
-			Do()
-		if err != nil {
-			summary["error"] = fmt.Sprintf("Error fetching spreadsheet %s: %v", spreadsheetID, err)
-			summaries = append(summaries, summary)
-			continue
-		}
+// summarizeSpreadsheet fetches the title, sheet list, and leading rows of every grid sheet in two requests:
+// one for the metadata and one batchGet covering all sheets
+func (s *SheetsMCPServer) summarizeSpreadsheet(ctx context.Context, spreadsheetID string, rowsToFetch int) spreadsheetSummary {
+	summary := spreadsheetSummary{
+		SpreadsheetID: spreadsheetID,
+		Sheets:        []sheetSummary{},
+	}
 
-		summary["title"] = spreadsheet.Properties.Title
+	spreadsheet, err := s.sheetsService.Spreadsheets.Get(spreadsheetID).
+		Fields("properties.title,sheets(properties(title,sheetId,sheetType))").
+		Context(ctx).
+		Do()
+	if err != nil {
+		summary.Error = fmt.Sprintf("Error fetching spreadsheet %s: %v", spreadsheetID, err)
+		return summary
+	}
 
-		var sheetSummaries []map[string]any
+	summary.Title = spreadsheet.Properties.Title
 
-		for _, sheet := range spreadsheet.Sheets {
-			sheetTitle := sheet.Properties.Title
-			sheetID := sheet.Properties.SheetId
+	var ranges []string
+	var fetched []int
 
-			sheetSummary := map[string]any{
-				"title":      sheetTitle,
-				"sheet_id":   sheetID,
-				"headers":    []any{},
-				"first_rows": []any{},
-				"error":      nil,
-			}
+	for _, sheet := range spreadsheet.Sheets {
+		// Chart sheets have no cells, and a values read of one fails the whole batchGet
+		if sheet.Properties.SheetType != "" && sheet.Properties.SheetType != "GRID" {
+			continue
+		}
+		sheetTitle := sheet.Properties.Title
+		summary.Sheets = append(summary.Sheets, sheetSummary{
+			Title:     sheetTitle,
+			SheetID:   sheet.Properties.SheetId,
+			Headers:   []any{},
+			FirstRows: [][]any{},
+		})
 
-			if sheetTitle == "" {
-				sheetSummary["error"] = "Sheet title not found"
-				sheetSummaries = append(sheetSummaries, sheetSummary)
-				continue
-			}
+		if sheetTitle == "" {
+			summary.Sheets[len(summary.Sheets)-1].Error = "Sheet title not found"
+			continue
+		}
+		ranges = append(ranges, buildFullRange(sheetTitle, fmt.Sprintf("A1:ZZ%d", rowsToFetch)))
+		fetched = append(fetched, len(summary.Sheets)-1)
+	}
 
-			// FIX: The original code had a bug here - it used fmt.Sprintf("%s!A1:%d", sheetTitle, rowsToFetch)
-			// which produces invalid ranges like "Sheet1!A1:5". Fixed to use proper A1 notation.
-			rangeToGet := fmt.Sprintf("%s!A1:ZZ%d", sheetTitle, rowsToFetch)
+	if len(ranges) == 0 {
+		return summary
+	}
 
-			valuesResult, err := s.sheetsService.Spreadsheets.Values.Get(spreadsheetID, rangeToGet).Do()
-			if err != nil {
-				sheetSummary["error"] = fmt.Sprintf("Error fetching data for sheet %s: %v", sheetTitle, err)
-				sheetSummaries = append(sheetSummaries, sheetSummary)
-				continue
-			}
+	batchResult, err := s.sheetsService.Spreadsheets.Values.BatchGet(spreadsheetID).
+		Ranges(ranges...).
+		Fields("valueRanges.values").
+		Context(ctx).
+		Do()
+	if err != nil {
+		for _, k := range fetched {
+			summary.Sheets[k].Error = fmt.Sprintf("Error fetching data for sheet %s: %v", summary.Sheets[k].Title, err)
+		}
+		return summary
+	}
 
-			if len(valuesResult.Values) > 0 {
-				sheetSummary["headers"] = valuesResult.Values[0]
-				if len(valuesResult.Values) > 1 {
-					sheetSummary["first_rows"] = valuesResult.Values[1:]
-				}
+	for i, k := range fetched {
+		if i >= len(batchResult.ValueRanges) {
+			break
+		}
+		values := batchResult.ValueRanges[i].Values
+		recordCellsRead(ctx, values)
+		if len(values) > 0 {
+			summary.Sheets[k].Headers = values[0]
+			if len(values) > 1 {
+				summary.Sheets[k].FirstRows = values[1:]
 			}
-
-			sheetSummaries = append(sheetSummaries, sheetSummary)
 		}
-
-		summary["sheets"] = sheetSummaries
-		summaries = append(summaries, summary)
 	}
 
-	return respondWithJSON(summaries)
+	return summary
 }
 
 func (s *SheetsMCPServer) handleGetSpreadsheetInfo(ctx context.Context, request *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
@@ -654,8 +1473,13 @@ func (s *SheetsMCPServer) handleGetSpreadsheetInfo(ctx context.Context, request
 	}
 
 	spreadsheetID := pathParts[0]
+	if tenant := s.callerTenant(ctx, request.Extra); tenant != nil {
+		if err := tenant.check("get_spreadsheet_info", map[string]any{"spreadsheet_id": spreadsheetID}); err != nil {
+			return nil, err
+		}
+	}
 
-	spreadsheet, err := s.sheetsService.Spreadsheets.Get(spreadsheetID).Do()
+	spreadsheet, err := s.sheetsService.Spreadsheets.Get(spreadsheetID).Context(ctx).Do()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get spreadsheet: %w", err)
 	}
@@ -704,11 +1528,16 @@ func (s *SheetsMCPServer) handleAppendData(ctx context.Context, request *mcp.Cal
 	if err != nil {
 		return respondWithError(err.Error())
 	}
-	spreadsheetID, sheet, _ := parseCommonArgs(args)
+	spreadsheetID, sheet, rangeStr := parseCommonArgs(args)
+	insertDataOption := strings.ToUpper(parseArgument(args, "insert_data_option", "OVERWRITE"))
+	includeValues := parseArgument(args, "include_values_in_response", false)
 
 	if spreadsheetID == "" || sheet == "" {
 		return respondWithError("spreadsheet_id and sheet are required")
 	}
+	if insertDataOption != "OVERWRITE" && insertDataOption != "INSERT_ROWS" {
+		return respondWithError(fmt.Sprintf("invalid insert_data_option '%s': must be OVERWRITE or INSERT_ROWS", insertDataOption))
+	}
 
 	dataRaw, ok := args["data"]
 	if !ok {
@@ -720,18 +1549,45 @@ func (s *SheetsMCPServer) handleAppendData(ctx context.Context, request *mcp.Cal
 		return respondWithError(fmt.Sprintf("invalid data format: %v", err))
 	}
 
+	valueInput, err := parseValueInputOption(args)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+
+	majorDimension, err := parseMajorDimension(args)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+
+	if err := s.localeParserFor(args, spreadsheetID, valueInput).convert(ctx, data); err != nil {
+		return respondWithError(err.Error())
+	}
+	if err := s.isoDateWriterFor(args, spreadsheetID, valueInput).convert(ctx, data); err != nil {
+		return respondWithError(err.Error())
+	}
+	s.sanitizeInput(args, valueInput, data)
+
 	valueRange := &sheets.ValueRange{
-		Values: data,
+		MajorDimension: majorDimension,
+		Values:         data,
 	}
 
-	result, err := s.sheetsService.Spreadsheets.Values.Append(spreadsheetID, sheet, valueRange).
-		ValueInputOption("USER_ENTERED").
+	// The API appends after the table it finds at or below the start of range, so a range pointing at
+	// the intended table keeps rows from landing under an unrelated one further down
+	result, err := s.sheetsService.Spreadsheets.Values.Append(spreadsheetID, buildFullRange(sheet, rangeStr), valueRange).
+		ValueInputOption(valueInput).
+		InsertDataOption(insertDataOption).
+		IncludeValuesInResponse(includeValues).
+		Context(ctx).
 		Do()
 	if err != nil {
-		return respondWithError(fmt.Sprintf("failed to append data: %v", err))
+		return s.respondWithAPIError("failed to append data", err)
+	}
+	if result.Updates != nil {
+		recordCellsWritten(ctx, result.Updates.UpdatedCells)
 	}
 
-	return respondWithJSON(result)
+	return respondWithShape(args, result)
 }
 
 func (s *SheetsMCPServer) handleClearRange(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -745,14 +1601,22 @@ func (s *SheetsMCPServer) handleClearRange(ctx context.Context, request *mcp.Cal
 		return respondWithError("spreadsheet_id, sheet, and range are required")
 	}
 
+	headerRows, err := s.protectedHeaderRows(ctx, args, spreadsheetID, sheet)
+	if err != nil {
+		return s.respondWithAPIError("failed to get sheet properties", err)
+	}
+	if err := headerViolation("clear_range", a1StartRow(rangeStr), headerRows); err != nil {
+		return respondWithError(err.Error())
+	}
+
 	fullRange := buildFullRange(sheet, rangeStr)
 
-	result, err := s.sheetsService.Spreadsheets.Values.Clear(spreadsheetID, fullRange, &sheets.ClearValuesRequest{}).Do()
+	result, err := s.sheetsService.Spreadsheets.Values.Clear(spreadsheetID, fullRange, &sheets.ClearValuesRequest{}).Context(ctx).Do()
 	if err != nil {
-		return respondWithError(fmt.Sprintf("failed to clear range: %v", err))
+		return s.respondWithAPIError("failed to clear range", err)
 	}
 
-	return respondWithJSON(result)
+	return respondWithShape(args, result)
 }
 
 func (s *SheetsMCPServer) handleDeleteSheet(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -766,9 +1630,9 @@ func (s *SheetsMCPServer) handleDeleteSheet(ctx context.Context, request *mcp.Ca
 		return respondWithError("spreadsheet_id and sheet are required")
 	}
 
-	sheetID, err := s.getSheetID(spreadsheetID, sheet)
+	sheetID, err := s.getSheetID(ctx, spreadsheetID, sheet)
 	if err != nil {
-		return respondWithError(fmt.Sprintf("failed to get sheet ID: %v", err))
+		return s.respondWithAPIError("failed to get sheet ID", err)
 	}
 
 	requests := []*sheets.Request{
@@ -779,147 +1643,541 @@ func (s *SheetsMCPServer) handleDeleteSheet(ctx context.Context, request *mcp.Ca
 		},
 	}
 
-	result, err := s.executeBatchUpdate(spreadsheetID, requests)
+	result, err := s.executeBatchUpdate(ctx, spreadsheetID, requests)
+	if err != nil {
+		return s.respondWithAPIError("failed to delete sheet", err)
+	}
+
+	return respondWithShape(args, result)
+}
+
+func (s *SheetsMCPServer) handleDuplicateSheet(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID, sheet, _ := parseCommonArgs(args)
+	newTitle := parseArgument(args, "new_title", "")
+
+	if spreadsheetID == "" || sheet == "" {
+		return respondWithError("spreadsheet_id and sheet are required")
+	}
+
+	sheetID, err := s.getSheetID(ctx, spreadsheetID, sheet)
+	if err != nil {
+		return s.respondWithAPIError("failed to get sheet ID", err)
+	}
+
+	requests := []*sheets.Request{
+		{
+			DuplicateSheet: &sheets.DuplicateSheetRequest{
+				SourceSheetId: sheetID,
+				NewSheetName:  newTitle,
+			},
+		},
+	}
+
+	result, err := s.executeBatchUpdate(ctx, spreadsheetID, requests)
+	if err != nil {
+		return s.respondWithAPIError("failed to duplicate sheet", err)
+	}
+
+	return respondWithShape(args, result)
+}
+
+func (s *SheetsMCPServer) handleFindReplace(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID := parseArgument(args, "spreadsheet_id", "")
+	find := parseArgument(args, "find", "")
+	replacement := parseArgument(args, "replacement", "")
+	allSheets := parseArgument(args, "all_sheets", false)
+	matchCase := parseArgument(args, "match_case", false)
+	matchEntireCell := parseArgument(args, "match_entire_cell", false)
+
+	if spreadsheetID == "" || find == "" {
+		return respondWithError("spreadsheet_id and find are required")
+	}
+
+	findReplaceRequest := &sheets.FindReplaceRequest{
+		Find:            find,
+		Replacement:     replacement,
+		MatchCase:       matchCase,
+		MatchEntireCell: matchEntireCell,
+		SearchByRegex:   false,
+		IncludeFormulas: true,
+	}
+
+	// The search starts below each sheet's protected header rows; the caller is told which rows it skipped
+	var requests []*sheets.Request
+	excluded := map[string]int64{}
+	switch configured, explicit := s.configuredHeaderRows(args); {
+	case allSheets && explicit && configured == 0:
+		findReplaceRequest.AllSheets = true
+		requests = append(requests, &sheets.Request{FindReplace: findReplaceRequest})
+	case allSheets:
+		// AllSheets cannot skip rows, so search each sheet below its own header rows instead
+		props, err := s.getSheetProperties(ctx, spreadsheetID)
+		if err != nil {
+			return s.respondWithAPIError("failed to get sheets", err)
+		}
+		for _, p := range props {
+			headerRows, err := s.protectedHeaderRows(ctx, args, spreadsheetID, p.Title)
+			if err != nil {
+				return s.respondWithAPIError("failed to get sheet properties", err)
+			}
+			sheetRequest := *findReplaceRequest
+			sheetRequest.Range = &sheets.GridRange{
+				SheetId:       p.SheetId,
+				StartRowIndex: headerRows,
+			}
+			requests = append(requests, &sheets.Request{FindReplace: &sheetRequest})
+			if headerRows > 0 {
+				excluded[p.Title] = headerRows
+			}
+		}
+	default:
+		sheet := parseArgument(args, "sheet", "")
+		if sheet == "" {
+			return respondWithError("sheet is required when all_sheets is false")
+		}
+		sheetID, err := s.getSheetID(ctx, spreadsheetID, sheet)
+		if err != nil {
+			return s.respondWithAPIError("failed to get sheet ID", err)
+		}
+		headerRows, err := s.protectedHeaderRows(ctx, args, spreadsheetID, sheet)
+		if err != nil {
+			return s.respondWithAPIError("failed to get sheet properties", err)
+		}
+		// Use Range instead of SheetId to avoid issues with sheet ID 0
+		findReplaceRequest.Range = &sheets.GridRange{
+			SheetId:       sheetID,
+			StartRowIndex: headerRows,
+		}
+		requests = append(requests, &sheets.Request{FindReplace: findReplaceRequest})
+		if headerRows > 0 {
+			excluded[sheet] = headerRows
+		}
+	}
+
+	result, err := s.executeBatchUpdate(ctx, spreadsheetID, requests)
+	if err != nil {
+		return s.respondWithAPIError("failed to find and replace", err)
+	}
+
+	return respondWithShape(args, findReplaceReply{Reply: result, ExcludedHeaderRows: excluded})
+}
+
+func (s *SheetsMCPServer) handleSortRange(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID, sheet, rangeStr := parseCommonArgs(args)
+	sortColumn := int64(parseArgument(args, "sort_column", float64(0)))
+	ascending := parseArgument(args, "ascending", true)
+	captureOrder := parseArgument(args, "capture_order", false)
+	keepOrderColumn := parseArgument(args, "keep_order_column", false)
+	includeHeaders := parseArgument(args, "include_headers", false)
+
+	if spreadsheetID == "" || sheet == "" || rangeStr == "" {
+		return respondWithError("spreadsheet_id, sheet, and range are required")
+	}
+
+	sheetID, err := s.getSheetID(ctx, spreadsheetID, sheet)
+	if err != nil {
+		return s.respondWithAPIError("failed to get sheet ID", err)
+	}
+
+	gridRange, err := parseGridRange(sheetID, rangeStr)
+	if err != nil {
+		return respondWithError(fmt.Sprintf("invalid range format: %v", err))
+	}
+
+	// Frozen rows are almost always headers, so they stay in place unless the caller opts in
+	excludedRows := int64(0)
+	if !includeHeaders {
+		excludedRows, err = s.excludeFrozenRows(ctx, spreadsheetID, sheet, gridRange)
+		if err != nil {
+			return respondWithError(err.Error())
+		}
+	}
+
+	// Frozen rows were either skipped above or explicitly included, so only configured header rows count here
+	headerRows, _ := s.configuredHeaderRows(args)
+	if err := headerViolation("sort_range", gridRange.StartRowIndex, headerRows); err != nil {
+		return respondWithError(err.Error())
+	}
+
+	if captureOrder || keepOrderColumn {
+		if !isBounded(gridRange) {
+			return respondWithError("capture_order and keep_order_column need a range with an end row and column, such as A2:D50")
+		}
+		return s.sortRangeWithOrderColumn(ctx, spreadsheetID, sheet, gridRange, sortColumn, ascending, keepOrderColumn)
+	}
+
+	requests := []*sheets.Request{
+		{
+			SortRange: &sheets.SortRangeRequest{
+				Range: gridRange,
+				SortSpecs: []*sheets.SortSpec{
+					{
+						DimensionIndex: sortColumn,
+						SortOrder:      getSortOrder(ascending),
+					},
+				},
+			},
+		},
+	}
+
+	if _, err := s.executeBatchUpdate(ctx, spreadsheetID, requests); err != nil {
+		return s.respondWithAPIError("failed to sort range", err)
+	}
+
+	response := sortResult{
+		SpreadsheetID:      spreadsheetID,
+		SortedRange:        buildFullRange(sheet, gridRangeToA1(gridRange)),
+		ExcludedHeaderRows: excludedRows,
+	}
+
+	return respondWithJSON(response)
+}
+
+// sortRangeWithOrderColumn sorts a range after tagging each row with its original row number in a
+// freshly inserted helper column, then reads the tags back to report the permutation that was applied
+func (s *SheetsMCPServer) sortRangeWithOrderColumn(ctx context.Context, spreadsheetID, sheet string, gridRange *sheets.GridRange, sortColumn int64, ascending, keepOrderColumn bool) (*mcp.CallToolResult, error) {
+	orderColumn := gridRange.EndColumnIndex
+
+	var rows []*sheets.RowData
+	for row := gridRange.StartRowIndex; row < gridRange.EndRowIndex; row++ {
+		originalRow := float64(row + 1)
+		rows = append(rows, &sheets.RowData{
+			Values: []*sheets.CellData{
+				{UserEnteredValue: &sheets.ExtendedValue{NumberValue: &originalRow}},
+			},
+		})
+	}
+
+	sortRange := *gridRange
+	sortRange.EndColumnIndex = orderColumn + 1
+
+	requests := []*sheets.Request{
+		{
+			InsertDimension: &sheets.InsertDimensionRequest{
+				Range: &sheets.DimensionRange{
+					SheetId:    gridRange.SheetId,
+					Dimension:  "COLUMNS",
+					StartIndex: orderColumn,
+					EndIndex:   orderColumn + 1,
+				},
+			},
+		},
+		{
+			UpdateCells: &sheets.UpdateCellsRequest{
+				Start: &sheets.GridCoordinate{
+					SheetId:     gridRange.SheetId,
+					RowIndex:    gridRange.StartRowIndex,
+					ColumnIndex: orderColumn,
+				},
+				Rows:   rows,
+				Fields: "userEnteredValue",
+			},
+		},
+		{
+			SortRange: &sheets.SortRangeRequest{
+				Range: &sortRange,
+				SortSpecs: []*sheets.SortSpec{
+					{
+						DimensionIndex: sortColumn,
+						SortOrder:      getSortOrder(ascending),
+					},
+				},
+			},
+		},
+	}
+
+	if _, err := s.executeBatchUpdate(ctx, spreadsheetID, requests); err != nil {
+		return s.respondWithAPIError("failed to sort range", err)
+	}
+
+	orderColumnLetter := columnToLetter(orderColumn)
+	orderRange := buildFullRange(sheet, fmt.Sprintf("%s%d:%s%d", orderColumnLetter, gridRange.StartRowIndex+1, orderColumnLetter, gridRange.EndRowIndex))
+
+	orderValues, err := s.sheetsService.Spreadsheets.Values.Get(spreadsheetID, orderRange).
+		ValueRenderOption("UNFORMATTED_VALUE").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return respondWithError(fmt.Sprintf("sorted, but failed to read back the original order from column %s: %v", orderColumnLetter, err))
+	}
+
+	permutation := []rowMove{}
+	for i, row := range orderValues.Values {
+		if len(row) == 0 {
+			continue
+		}
+		if originalRow, ok := row[0].(float64); ok {
+			permutation = append(permutation, rowMove{
+				OriginalRow: int64(originalRow),
+				NewRow:      gridRange.StartRowIndex + int64(i) + 1,
+			})
+		}
+	}
+
+	response := sortResult{
+		SpreadsheetID: spreadsheetID,
+		Permutation:   permutation,
+	}
+
+	if keepOrderColumn {
+		response.OrderColumn = orderColumnLetter
+		return respondWithJSON(response)
+	}
+
+	cleanup := []*sheets.Request{
+		{
+			DeleteDimension: &sheets.DeleteDimensionRequest{
+				Range: &sheets.DimensionRange{
+					SheetId:    gridRange.SheetId,
+					Dimension:  "COLUMNS",
+					StartIndex: orderColumn,
+					EndIndex:   orderColumn + 1,
+				},
+			},
+		},
+	}
+
+	if _, err := s.executeBatchUpdate(ctx, spreadsheetID, cleanup); err != nil {
+		return respondWithError(fmt.Sprintf("sorted, but failed to remove helper column %s: %v", orderColumnLetter, err))
+	}
+
+	return respondWithJSON(response)
+}
+
+func (s *SheetsMCPServer) handleCopyRange(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID, sheet, rangeStr := parseCommonArgs(args)
+	dstSheet := parseArgument(args, "destination_sheet", sheet)
+	dstRange := parseArgument(args, "destination_range", "")
+	cut := parseArgument(args, "cut", false)
+
+	if spreadsheetID == "" || sheet == "" || rangeStr == "" || dstRange == "" {
+		return respondWithError("spreadsheet_id, sheet, range, and destination_range are required")
+	}
+
+	pasteType, err := getPasteType(parseArgument(args, "paste_type", "all"))
 	if err != nil {
-		return respondWithError(fmt.Sprintf("failed to delete sheet: %v", err))
+		return respondWithError(err.Error())
 	}
 
-	return respondWithJSON(result)
-}
-
-func (s *SheetsMCPServer) handleDuplicateSheet(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	args, err := getArgsFromRequest(request)
+	srcSheetID, err := s.getSheetID(ctx, spreadsheetID, sheet)
 	if err != nil {
-		return respondWithError(err.Error())
+		return s.respondWithAPIError("failed to get sheet ID", err)
 	}
-	spreadsheetID, sheet, _ := parseCommonArgs(args)
-	newTitle := parseArgument(args, "new_title", "")
 
-	if spreadsheetID == "" || sheet == "" {
-		return respondWithError("spreadsheet_id and sheet are required")
+	dstSheetID := srcSheetID
+	if dstSheet != sheet {
+		dstSheetID, err = s.getSheetID(ctx, spreadsheetID, dstSheet)
+		if err != nil {
+			return s.respondWithAPIError("failed to get destination sheet ID", err)
+		}
 	}
 
-	sheetID, err := s.getSheetID(spreadsheetID, sheet)
+	srcGridRange, err := parseGridRange(srcSheetID, rangeStr)
 	if err != nil {
-		return respondWithError(fmt.Sprintf("failed to get sheet ID: %v", err))
+		return respondWithError(fmt.Sprintf("invalid range format: %v", err))
 	}
 
-	requests := []*sheets.Request{
-		{
-			DuplicateSheet: &sheets.DuplicateSheetRequest{
-				SourceSheetId: sheetID,
-				NewSheetName:  newTitle,
+	var req *sheets.Request
+	if cut {
+		// CutPaste only takes the top-left destination cell
+		dstCell, _, _ := strings.Cut(dstRange, ":")
+		dstCoordinate, err := parseGridCoordinate(dstSheetID, dstCell)
+		if err != nil {
+			return respondWithError(fmt.Sprintf("invalid destination_range format: %v", err))
+		}
+		req = &sheets.Request{
+			CutPaste: &sheets.CutPasteRequest{
+				Source:      srcGridRange,
+				Destination: dstCoordinate,
+				PasteType:   pasteType,
 			},
-		},
+		}
+	} else {
+		dstGridRange, err := parseGridRange(dstSheetID, dstRange)
+		if err != nil {
+			return respondWithError(fmt.Sprintf("invalid destination_range format: %v", err))
+		}
+		req = &sheets.Request{
+			CopyPaste: &sheets.CopyPasteRequest{
+				Source:           srcGridRange,
+				Destination:      dstGridRange,
+				PasteType:        pasteType,
+				PasteOrientation: "NORMAL",
+			},
+		}
 	}
 
-	result, err := s.executeBatchUpdate(spreadsheetID, requests)
+	result, err := s.executeBatchUpdate(ctx, spreadsheetID, []*sheets.Request{req})
 	if err != nil {
-		return respondWithError(fmt.Sprintf("failed to duplicate sheet: %v", err))
+		action := "copy"
+		if cut {
+			action = "move"
+		}
+		return respondWithError(fmt.Sprintf("failed to %s range: %v", action, err))
 	}
 
-	return respondWithJSON(result)
+	return respondWithShape(args, result)
 }
 
-func (s *SheetsMCPServer) handleFindReplace(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (s *SheetsMCPServer) handleSetDropdownFromRange(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	args, err := getArgsFromRequest(request)
 	if err != nil {
 		return respondWithError(err.Error())
 	}
-	spreadsheetID := parseArgument(args, "spreadsheet_id", "")
-	find := parseArgument(args, "find", "")
-	replacement := parseArgument(args, "replacement", "")
-	allSheets := parseArgument(args, "all_sheets", false)
-	matchCase := parseArgument(args, "match_case", false)
-	matchEntireCell := parseArgument(args, "match_entire_cell", false)
+	spreadsheetID, sheet, rangeStr := parseCommonArgs(args)
+	optionsSheet := parseArgument(args, "options_sheet", "Lists")
+	optionsRange := parseArgument(args, "options_range", "A1:A")
+	strict := parseArgument(args, "strict", true)
+	showDropdown := parseArgument(args, "show_dropdown", true)
 
-	if spreadsheetID == "" || find == "" {
-		return respondWithError("spreadsheet_id and find are required")
+	if spreadsheetID == "" || sheet == "" || rangeStr == "" {
+		return respondWithError("spreadsheet_id, sheet, and range are required")
 	}
 
-	findReplaceRequest := &sheets.FindReplaceRequest{
-		Find:            find,
-		Replacement:     replacement,
-		MatchCase:       matchCase,
-		MatchEntireCell: matchEntireCell,
-		SearchByRegex:   false,
-		IncludeFormulas: true,
+	sheetID, err := s.getSheetID(ctx, spreadsheetID, sheet)
+	if err != nil {
+		return s.respondWithAPIError("failed to get sheet ID", err)
 	}
 
-	if allSheets {
-		findReplaceRequest.AllSheets = true
-	} else {
-		sheet := parseArgument(args, "sheet", "")
-		if sheet == "" {
-			return respondWithError("sheet is required when all_sheets is false")
+	gridRange, err := parseGridRange(sheetID, rangeStr)
+	if err != nil {
+		return respondWithError(fmt.Sprintf("invalid range format: %v", err))
+	}
+
+	response := dropdownResult{
+		SpreadsheetID: spreadsheetID,
+		OptionsSheet:  optionsSheet,
+		OptionsRange:  optionsRange,
+	}
+
+	if optionsRaw, ok := args["options"]; ok {
+		var options []string
+		if err := convertToType(optionsRaw, &options); err != nil {
+			return respondWithError(fmt.Sprintf("invalid options format: %v", err))
 		}
-		sheetID, err := s.getSheetID(spreadsheetID, sheet)
+
+		created, err := s.ensureSheet(ctx, spreadsheetID, optionsSheet)
 		if err != nil {
-			return respondWithError(fmt.Sprintf("failed to get sheet ID: %v", err))
+			return s.respondWithAPIError("failed to prepare options sheet", err)
 		}
-		// Use Range instead of SheetId to avoid issues with sheet ID 0
-		findReplaceRequest.Range = &sheets.GridRange{
-			SheetId: sheetID,
+
+		fullOptionsRange := buildFullRange(optionsSheet, optionsRange)
+		if _, err := s.sheetsService.Spreadsheets.Values.Clear(spreadsheetID, fullOptionsRange, &sheets.ClearValuesRequest{}).Context(ctx).Do(); err != nil {
+			return s.respondWithAPIError("failed to clear options range", err)
+		}
+
+		written := len(options)
+		values := make([][]any, 0, len(options))
+		for _, option := range options {
+			values = append(values, []any{option})
 		}
+
+		if _, err := s.sheetsService.Spreadsheets.Values.Update(spreadsheetID, fullOptionsRange, &sheets.ValueRange{Values: values}).
+			ValueInputOption("RAW").
+			Context(ctx).
+			Do(); err != nil {
+			return s.respondWithAPIError("failed to write options", err)
+		}
+
+		response.OptionsSheetCreated = &created
+		response.OptionsWritten = &written
 	}
 
 	requests := []*sheets.Request{
 		{
-			FindReplace: findReplaceRequest,
+			SetDataValidation: &sheets.SetDataValidationRequest{
+				Range: gridRange,
+				Rule: &sheets.DataValidationRule{
+					Condition: &sheets.BooleanCondition{
+						Type: "ONE_OF_RANGE",
+						Values: []*sheets.ConditionValue{
+							{UserEnteredValue: "=" + buildFullRange(optionsSheet, optionsRange)},
+						},
+					},
+					Strict:       strict,
+					ShowCustomUi: showDropdown,
+				},
+			},
 		},
 	}
 
-	result, err := s.executeBatchUpdate(spreadsheetID, requests)
+	result, err := s.executeBatchUpdate(ctx, spreadsheetID, requests)
 	if err != nil {
-		return respondWithError(fmt.Sprintf("failed to find and replace: %v", err))
+		return s.respondWithAPIError("failed to set data validation", err)
 	}
 
-	return respondWithJSON(result)
+	response.Validation = result
+	return respondWithJSON(response)
 }
 
-func (s *SheetsMCPServer) handleSortRange(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (s *SheetsMCPServer) handleAutoFill(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	args, err := getArgsFromRequest(request)
 	if err != nil {
 		return respondWithError(err.Error())
 	}
 	spreadsheetID, sheet, rangeStr := parseCommonArgs(args)
-	sortColumn := int64(parseArgument(args, "sort_column", float64(0)))
-	ascending := parseArgument(args, "ascending", true)
+	dstRange := parseArgument(args, "destination_range", "")
+	useAlternateSeries := parseArgument(args, "use_alternate_series", false)
 
-	if spreadsheetID == "" || sheet == "" || rangeStr == "" {
-		return respondWithError("spreadsheet_id, sheet, and range are required")
+	if spreadsheetID == "" || sheet == "" || rangeStr == "" || dstRange == "" {
+		return respondWithError("spreadsheet_id, sheet, range, and destination_range are required")
 	}
 
-	sheetID, err := s.getSheetID(spreadsheetID, sheet)
+	sheetID, err := s.getSheetID(ctx, spreadsheetID, sheet)
 	if err != nil {
-		return respondWithError(fmt.Sprintf("failed to get sheet ID: %v", err))
+		return s.respondWithAPIError("failed to get sheet ID", err)
 	}
 
-	gridRange, err := parseGridRange(sheetID, rangeStr)
+	sourceRange, err := parseGridRange(sheetID, rangeStr)
 	if err != nil {
 		return respondWithError(fmt.Sprintf("invalid range format: %v", err))
 	}
 
+	fillRange, err := parseGridRange(sheetID, dstRange)
+	if err != nil {
+		return respondWithError(fmt.Sprintf("invalid destination_range format: %v", err))
+	}
+
+	if !isBounded(sourceRange) || !isBounded(fillRange) {
+		return respondWithError("range and destination_range must have an end row and column, such as A1:A3 and A4:A20")
+	}
+
+	sourceAndDestination, err := buildSourceAndDestination(sourceRange, fillRange)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+
 	requests := []*sheets.Request{
 		{
-			SortRange: &sheets.SortRangeRequest{
-				Range: gridRange,
-				SortSpecs: []*sheets.SortSpec{
-					{
-						DimensionIndex: sortColumn,
-						SortOrder:      getSortOrder(ascending),
-					},
-				},
+			AutoFill: &sheets.AutoFillRequest{
+				SourceAndDestination: sourceAndDestination,
+				UseAlternateSeries:   useAlternateSeries,
 			},
 		},
 	}
 
-	result, err := s.executeBatchUpdate(spreadsheetID, requests)
+	result, err := s.executeBatchUpdate(ctx, spreadsheetID, requests)
 	if err != nil {
-		return respondWithError(fmt.Sprintf("failed to sort range: %v", err))
+		return s.respondWithAPIError("failed to auto fill", err)
 	}
 
-	return respondWithJSON(result)
+	return respondWithShape(args, result)
 }
 
 func (s *SheetsMCPServer) handleFormatCells(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -933,9 +2191,9 @@ func (s *SheetsMCPServer) handleFormatCells(ctx context.Context, request *mcp.Ca
 		return respondWithError("spreadsheet_id, sheet, and range are required")
 	}
 
-	sheetID, err := s.getSheetID(spreadsheetID, sheet)
+	sheetID, err := s.getSheetID(ctx, spreadsheetID, sheet)
 	if err != nil {
-		return respondWithError(fmt.Sprintf("failed to get sheet ID: %v", err))
+		return s.respondWithAPIError("failed to get sheet ID", err)
 	}
 
 	gridRange, err := parseGridRange(sheetID, rangeStr)
@@ -1003,12 +2261,12 @@ func (s *SheetsMCPServer) handleFormatCells(ctx context.Context, request *mcp.Ca
 		},
 	}
 
-	result, err := s.executeBatchUpdate(spreadsheetID, requests)
+	result, err := s.executeBatchUpdate(ctx, spreadsheetID, requests)
 	if err != nil {
-		return respondWithError(fmt.Sprintf("failed to format cells: %v", err))
+		return s.respondWithAPIError("failed to format cells", err)
 	}
 
-	return respondWithJSON(result)
+	return respondWithShape(args, result)
 }
 
 func (s *SheetsMCPServer) handleMergeCells(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -1023,9 +2281,9 @@ func (s *SheetsMCPServer) handleMergeCells(ctx context.Context, request *mcp.Cal
 		return respondWithError("spreadsheet_id, sheet, and range are required")
 	}
 
-	sheetID, err := s.getSheetID(spreadsheetID, sheet)
+	sheetID, err := s.getSheetID(ctx, spreadsheetID, sheet)
 	if err != nil {
-		return respondWithError(fmt.Sprintf("failed to get sheet ID: %v", err))
+		return s.respondWithAPIError("failed to get sheet ID", err)
 	}
 
 	gridRange, err := parseGridRange(sheetID, rangeStr)
@@ -1042,12 +2300,12 @@ func (s *SheetsMCPServer) handleMergeCells(ctx context.Context, request *mcp.Cal
 		},
 	}
 
-	result, err := s.executeBatchUpdate(spreadsheetID, requests)
+	result, err := s.executeBatchUpdate(ctx, spreadsheetID, requests)
 	if err != nil {
-		return respondWithError(fmt.Sprintf("failed to merge cells: %v", err))
+		return s.respondWithAPIError("failed to merge cells", err)
 	}
 
-	return respondWithJSON(result)
+	return respondWithShape(args, result)
 }
 
 func (s *SheetsMCPServer) handleUnmergeCells(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -1061,9 +2319,9 @@ func (s *SheetsMCPServer) handleUnmergeCells(ctx context.Context, request *mcp.C
 		return respondWithError("spreadsheet_id, sheet, and range are required")
 	}
 
-	sheetID, err := s.getSheetID(spreadsheetID, sheet)
+	sheetID, err := s.getSheetID(ctx, spreadsheetID, sheet)
 	if err != nil {
-		return respondWithError(fmt.Sprintf("failed to get sheet ID: %v", err))
+		return s.respondWithAPIError("failed to get sheet ID", err)
 	}
 
 	gridRange, err := parseGridRange(sheetID, rangeStr)
@@ -1079,15 +2337,30 @@ func (s *SheetsMCPServer) handleUnmergeCells(ctx context.Context, request *mcp.C
 		},
 	}
 
-	result, err := s.executeBatchUpdate(spreadsheetID, requests)
+	result, err := s.executeBatchUpdate(ctx, spreadsheetID, requests)
 	if err != nil {
-		return respondWithError(fmt.Sprintf("failed to unmerge cells: %v", err))
+		return s.respondWithAPIError("failed to unmerge cells", err)
 	}
 
-	return respondWithJSON(result)
+	return respondWithShape(args, result)
 }
 
-func (s *SheetsMCPServer) handleHideSheet(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+// formatFieldsExceptNumberFormat lists every userEnteredFormat field except numberFormat
+var formatFieldsExceptNumberFormat = []string{
+	"userEnteredFormat.backgroundColor",
+	"userEnteredFormat.backgroundColorStyle",
+	"userEnteredFormat.borders",
+	"userEnteredFormat.padding",
+	"userEnteredFormat.horizontalAlignment",
+	"userEnteredFormat.verticalAlignment",
+	"userEnteredFormat.wrapStrategy",
+	"userEnteredFormat.textDirection",
+	"userEnteredFormat.textFormat",
+	"userEnteredFormat.hyperlinkDisplayType",
+	"userEnteredFormat.textRotation",
+}
+
+func (s *SheetsMCPServer) handleGetMerges(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	args, err := getArgsFromRequest(request)
 	if err != nil {
 		return respondWithError(err.Error())
@@ -1098,10 +2371,43 @@ func (s *SheetsMCPServer) handleHideSheet(ctx context.Context, request *mcp.Call
 		return respondWithError("spreadsheet_id and sheet are required")
 	}
 
-	return s.updateSheetVisibility(spreadsheetID, sheet, true)
+	spreadsheet, err := s.sheetsService.Spreadsheets.Get(spreadsheetID).
+		Fields("sheets(properties(sheetId,title),merges)").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return s.respondWithAPIError("failed to get spreadsheet", err)
+	}
+
+	for _, sh := range spreadsheet.Sheets {
+		if sh.Properties.Title != sheet {
+			continue
+		}
+
+		merges := []mergeResult{}
+		for _, merge := range sh.Merges {
+			merges = append(merges, mergeResult{
+				Range:            gridRangeToA1(merge),
+				StartRowIndex:    merge.StartRowIndex,
+				EndRowIndex:      merge.EndRowIndex,
+				StartColumnIndex: merge.StartColumnIndex,
+				EndColumnIndex:   merge.EndColumnIndex,
+			})
+		}
+
+		response := sheetRulesResult{
+			SpreadsheetID: spreadsheetID,
+			Sheet:         sheet,
+			SheetID:       sh.Properties.SheetId,
+			Merges:        merges,
+		}
+		return respondWithJSON(response)
+	}
+
+	return respondWithError(fmt.Sprintf("sheet '%s' not found", sheet))
 }
 
-func (s *SheetsMCPServer) handleUnhideSheet(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (s *SheetsMCPServer) handleGetConditionalFormatRules(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	args, err := getArgsFromRequest(request)
 	if err != nil {
 		return respondWithError(err.Error())
@@ -1112,59 +2418,218 @@ func (s *SheetsMCPServer) handleUnhideSheet(ctx context.Context, request *mcp.Ca
 		return respondWithError("spreadsheet_id and sheet are required")
 	}
 
-	return s.updateSheetVisibility(spreadsheetID, sheet, false)
-}
+	spreadsheet, err := s.sheetsService.Spreadsheets.Get(spreadsheetID).
+		Fields("sheets(properties(sheetId,title),conditionalFormats)").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return s.respondWithAPIError("failed to get spreadsheet", err)
+	}
 
-func parseGridRange(sheetID int64, rangeStr string) (*sheets.GridRange, error) {
-	parts := strings.Split(rangeStr, ":")
-	if len(parts) != 2 {
-		return nil, fmt.Errorf("range must be in A1:B2 format")
+	for _, sh := range spreadsheet.Sheets {
+		if sh.Properties.Title != sheet {
+			continue
+		}
+
+		rules := []sheetRule{}
+		for i, rule := range sh.ConditionalFormats {
+			ranges := []string{}
+			for _, r := range rule.Ranges {
+				ranges = append(ranges, gridRangeToA1(r))
+			}
+			rules = append(rules, sheetRule{
+				Index:        &i,
+				Ranges:       ranges,
+				BooleanRule:  rule.BooleanRule,
+				GradientRule: rule.GradientRule,
+			})
+		}
+
+		response := sheetRulesResult{
+			SpreadsheetID: spreadsheetID,
+			Sheet:         sheet,
+			SheetID:       sh.Properties.SheetId,
+			Rules:         rules,
+		}
+		return respondWithJSON(response)
 	}
 
-	startCol, startRow, err := parseA1Notation(parts[0])
+	return respondWithError(fmt.Sprintf("sheet '%s' not found", sheet))
+}
+
+func (s *SheetsMCPServer) handleGetValidationRules(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
 	if err != nil {
-		return nil, err
+		return respondWithError(err.Error())
+	}
+	spreadsheetID, sheet, rangeStr := parseCommonArgs(args)
+
+	if spreadsheetID == "" || sheet == "" {
+		return respondWithError("spreadsheet_id and sheet are required")
 	}
 
-	endCol, endRow, err := parseA1Notation(parts[1])
+	spreadsheet, err := s.sheetsService.Spreadsheets.Get(spreadsheetID).
+		Ranges(buildFullRange(sheet, rangeStr)).
+		Fields("sheets(properties(sheetId,title),data(startRow,startColumn,rowData(values(dataValidation))))").
+		Context(ctx).
+		Do()
 	if err != nil {
-		return nil, err
+		return s.respondWithAPIError("failed to get spreadsheet", err)
+	}
+	if len(spreadsheet.Sheets) == 0 {
+		return respondWithError(fmt.Sprintf("sheet '%s' not found", sheet))
 	}
 
-	return &sheets.GridRange{
-		SheetId:          sheetID,
-		StartRowIndex:    startRow,
-		EndRowIndex:      endRow + 1,
-		StartColumnIndex: startCol,
-		EndColumnIndex:   endCol + 1,
-	}, nil
-}
+	type ruleGroup struct {
+		rule  *sheets.DataValidationRule
+		cells [][2]int64
+	}
+	var groups []*ruleGroup
+	groupIndex := map[string]*ruleGroup{}
 
-func parseA1Notation(cell string) (col int64, row int64, err error) {
-	col = 0
-	row = 0
+	for _, grid := range spreadsheet.Sheets[0].Data {
+		for i, row := range grid.RowData {
+			for j, cell := range row.Values {
+				if cell.DataValidation == nil {
+					continue
+				}
+				key, err := json.Marshal(cell.DataValidation)
+				if err != nil {
+					return s.respondWithAPIError("failed to read validation rule", err)
+				}
+				group, ok := groupIndex[string(key)]
+				if !ok {
+					group = &ruleGroup{rule: cell.DataValidation}
+					groupIndex[string(key)] = group
+					groups = append(groups, group)
+				}
+				group.cells = append(group.cells, [2]int64{grid.StartColumn + int64(j), grid.StartRow + int64(i)})
+			}
+		}
+	}
 
-	i := 0
-	for i < len(cell) && cell[i] >= 'A' && cell[i] <= 'Z' {
-		col = col*26 + int64(cell[i]-'A'+1)
-		i++
+	rules := []sheetRule{}
+	for _, group := range groups {
+		rules = append(rules, sheetRule{
+			Rule:   group.rule,
+			Ranges: compressCellsToRanges(group.cells),
+			Cells:  len(group.cells),
+		})
 	}
-	col--
 
-	if i == len(cell) {
-		return 0, 0, fmt.Errorf("invalid cell notation: %s", cell)
+	response := sheetRulesResult{
+		SpreadsheetID: spreadsheetID,
+		Sheet:         sheet,
+		SheetID:       spreadsheet.Sheets[0].Properties.SheetId,
+		Rules:         rules,
 	}
 
-	for i < len(cell) {
-		if cell[i] < '0' || cell[i] > '9' {
-			return 0, 0, fmt.Errorf("invalid cell notation: %s", cell)
+	return respondWithJSON(response)
+}
+
+// compressCellsToRanges turns (column, row) cell coordinates into A1 ranges, merging vertical runs in a column
+func compressCellsToRanges(cells [][2]int64) []string {
+	sorted := slices.Clone(cells)
+	slices.SortFunc(sorted, func(a, b [2]int64) int {
+		if a[0] != b[0] {
+			return cmp.Compare(a[0], b[0])
 		}
-		row = row*10 + int64(cell[i]-'0')
+		return cmp.Compare(a[1], b[1])
+	})
+
+	ranges := []string{}
+	for i := 0; i < len(sorted); {
+		col, startRow := sorted[i][0], sorted[i][1]
+		endRow := startRow
 		i++
+		for i < len(sorted) && sorted[i][0] == col && sorted[i][1] == endRow+1 {
+			endRow++
+			i++
+		}
+
+		start := fmt.Sprintf("%s%d", columnToLetter(col), startRow+1)
+		if endRow == startRow {
+			ranges = append(ranges, start)
+		} else {
+			ranges = append(ranges, fmt.Sprintf("%s:%s%d", start, columnToLetter(col), endRow+1))
+		}
+	}
+	return ranges
+}
+
+func (s *SheetsMCPServer) handleClearFormatting(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID, sheet, rangeStr := parseCommonArgs(args)
+	preserveNumberFormat := parseArgument(args, "preserve_number_format", false)
+
+	if spreadsheetID == "" || sheet == "" || rangeStr == "" {
+		return respondWithError("spreadsheet_id, sheet, and range are required")
+	}
+
+	sheetID, err := s.getSheetID(ctx, spreadsheetID, sheet)
+	if err != nil {
+		return s.respondWithAPIError("failed to get sheet ID", err)
+	}
+
+	gridRange, err := parseGridRange(sheetID, rangeStr)
+	if err != nil {
+		return respondWithError(fmt.Sprintf("invalid range format: %v", err))
+	}
+
+	fields := "userEnteredFormat"
+	if preserveNumberFormat {
+		fields = strings.Join(formatFieldsExceptNumberFormat, ",")
+	}
+
+	requests := []*sheets.Request{
+		{
+			RepeatCell: &sheets.RepeatCellRequest{
+				Range: gridRange,
+				Cell: &sheets.CellData{
+					UserEnteredFormat: &sheets.CellFormat{},
+				},
+				Fields: fields,
+			},
+		},
+	}
+
+	result, err := s.executeBatchUpdate(ctx, spreadsheetID, requests)
+	if err != nil {
+		return s.respondWithAPIError("failed to clear formatting", err)
+	}
+
+	return respondWithShape(args, result)
+}
+
+func (s *SheetsMCPServer) handleHideSheet(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID, sheet, _ := parseCommonArgs(args)
+
+	if spreadsheetID == "" || sheet == "" {
+		return respondWithError("spreadsheet_id and sheet are required")
+	}
+
+	return s.updateSheetVisibility(ctx, args, spreadsheetID, sheet, true)
+}
+
+func (s *SheetsMCPServer) handleUnhideSheet(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID, sheet, _ := parseCommonArgs(args)
+
+	if spreadsheetID == "" || sheet == "" {
+		return respondWithError("spreadsheet_id and sheet are required")
 	}
-	row--
 
-	return col, row, nil
+	return s.updateSheetVisibility(ctx, args, spreadsheetID, sheet, false)
 }
 
 func parseColor(colorRaw any) (*sheets.Color, error) {
@@ -1190,6 +2655,42 @@ func getSortOrder(ascending bool) string {
 	return "DESCENDING"
 }
 
+// buildSourceAndDestination works out the fill direction and length of a destination range
+// that sits directly next to its source range
+func buildSourceAndDestination(source, destination *sheets.GridRange) (*sheets.SourceAndDestination, error) {
+	sameColumns := source.StartColumnIndex == destination.StartColumnIndex && source.EndColumnIndex == destination.EndColumnIndex
+	sameRows := source.StartRowIndex == destination.StartRowIndex && source.EndRowIndex == destination.EndRowIndex
+
+	switch {
+	case sameColumns && destination.StartRowIndex == source.EndRowIndex:
+		return &sheets.SourceAndDestination{Source: source, Dimension: "ROWS", FillLength: destination.EndRowIndex - destination.StartRowIndex}, nil
+	case sameColumns && destination.EndRowIndex == source.StartRowIndex:
+		return &sheets.SourceAndDestination{Source: source, Dimension: "ROWS", FillLength: -(destination.EndRowIndex - destination.StartRowIndex)}, nil
+	case sameRows && destination.StartColumnIndex == source.EndColumnIndex:
+		return &sheets.SourceAndDestination{Source: source, Dimension: "COLUMNS", FillLength: destination.EndColumnIndex - destination.StartColumnIndex}, nil
+	case sameRows && destination.EndColumnIndex == source.StartColumnIndex:
+		return &sheets.SourceAndDestination{Source: source, Dimension: "COLUMNS", FillLength: -(destination.EndColumnIndex - destination.StartColumnIndex)}, nil
+	default:
+		return nil, fmt.Errorf("destination_range must be directly above, below, left, or right of range and span the same rows or columns")
+	}
+}
+
+// getPasteType maps a friendly paste type name to the Sheets API paste type
+func getPasteType(pasteType string) (string, error) {
+	switch strings.ToLower(pasteType) {
+	case "", "all":
+		return "PASTE_NORMAL", nil
+	case "values":
+		return "PASTE_VALUES", nil
+	case "format":
+		return "PASTE_FORMAT", nil
+	case "formulas":
+		return "PASTE_FORMULA", nil
+	default:
+		return "", fmt.Errorf("invalid paste_type '%s': must be one of all, values, format, formulas", pasteType)
+	}
+}
+
 // convertToType converts a parameter to the desired type, handling string JSON input
 func convertToType(data any, target any) error {
 	// If data is a string, try to unmarshal it as JSON
@@ -1207,34 +2708,103 @@ func convertToType(data any, target any) error {
 }
 
 // executeBatchUpdate executes a batch update request on a spreadsheet
-func (s *SheetsMCPServer) executeBatchUpdate(spreadsheetID string, requests []*sheets.Request) (*sheets.BatchUpdateSpreadsheetResponse, error) {
+func (s *SheetsMCPServer) executeBatchUpdate(ctx context.Context, spreadsheetID string, requests []*sheets.Request) (*sheets.BatchUpdateSpreadsheetResponse, error) {
 	batchUpdate := &sheets.BatchUpdateSpreadsheetRequest{
 		Requests: requests,
 	}
-	return s.sheetsService.Spreadsheets.BatchUpdate(spreadsheetID, batchUpdate).Do()
+	// Batch updates can add, remove, or rename sheets, so cached metadata is no longer trustworthy
+	defer s.metadataCache.invalidate(spreadsheetID)
+	return s.sheetsService.Spreadsheets.BatchUpdate(spreadsheetID, batchUpdate).Context(ctx).Do()
 }
 
-// buildFullRange builds a full range string from sheet and optional range
+// buildFullRange builds a full range string from sheet and optional range, quoting the sheet name
+// when the API would otherwise misread it
 func buildFullRange(sheet, rangeStr string) string {
+	sheet = quoteSheetName(sheet)
 	if rangeStr != "" {
 		return fmt.Sprintf("%s!%s", sheet, rangeStr)
 	}
 	return sheet
 }
 
-// parseCommonArgs extracts common spreadsheet_id, sheet, and range arguments
+// parseCommonArgs extracts common spreadsheet_id, sheet, and range arguments. A range that names its
+// sheet ('My Sheet'!A1:C9) supplies the sheet when none is given; when it names the given sheet, the
+// prefix is dropped so the range can be combined with the sheet again.
 func parseCommonArgs(args map[string]any) (spreadsheetID, sheet, rangeStr string) {
 	spreadsheetID = parseArgument(args, "spreadsheet_id", "")
 	sheet = parseArgument(args, "sheet", "")
 	rangeStr = parseArgument(args, "range", "")
+	if prefix, cells, err := splitSheetPrefix(rangeStr); err == nil && prefix != "" {
+		switch {
+		case sheet == "":
+			sheet, rangeStr = prefix, cells
+		case sheet == prefix:
+			rangeStr = cells
+		}
+	}
 	return
 }
 
+// parseRenderOptions reads value_render_option and date_time_render_option, which decide whether reads
+// return displayed strings, raw numbers, or formulas, and how dates appear when they are not formatted
+func parseRenderOptions(args map[string]any) (valueRender, dateTimeRender string, err error) {
+	valueRender = strings.ToUpper(parseArgument(args, "value_render_option", "FORMATTED_VALUE"))
+	dateTimeRender = strings.ToUpper(parseArgument(args, "date_time_render_option", "SERIAL_NUMBER"))
+
+	if !slices.Contains([]string{"FORMATTED_VALUE", "UNFORMATTED_VALUE", "FORMULA"}, valueRender) {
+		return "", "", fmt.Errorf("invalid value_render_option '%s': must be FORMATTED_VALUE, UNFORMATTED_VALUE, or FORMULA", valueRender)
+	}
+	if !slices.Contains([]string{"SERIAL_NUMBER", "FORMATTED_STRING"}, dateTimeRender) {
+		return "", "", fmt.Errorf("invalid date_time_render_option '%s': must be SERIAL_NUMBER or FORMATTED_STRING", dateTimeRender)
+	}
+	return valueRender, dateTimeRender, nil
+}
+
+// parseValueInputOption reads value_input_option: USER_ENTERED parses values as if typed into the UI
+// (formulas, dates, numbers), while RAW stores them exactly as given
+func parseValueInputOption(args map[string]any) (string, error) {
+	option := strings.ToUpper(parseArgument(args, "value_input_option", "USER_ENTERED"))
+	if option != "USER_ENTERED" && option != "RAW" {
+		return "", fmt.Errorf("invalid value_input_option '%s': must be USER_ENTERED or RAW", option)
+	}
+	return option, nil
+}
+
+// parseMajorDimension reads major_dimension: with ROWS each inner array is a row, with COLUMNS a column
+func parseMajorDimension(args map[string]any) (string, error) {
+	dimension := strings.ToUpper(parseArgument(args, "major_dimension", "ROWS"))
+	if dimension != "ROWS" && dimension != "COLUMNS" {
+		return "", fmt.Errorf("invalid major_dimension '%s': must be ROWS or COLUMNS", dimension)
+	}
+	return dimension, nil
+}
+
+// sanitizeInput guards against formula injection when sanitize_input (default: SANITIZE_INPUT) is on:
+// text starting with =, +, - or @ gets a leading apostrophe, which Sheets takes as "store as text".
+// Numbers, including negative numbers written as text, are left alone, and RAW writes never need it.
+func (s *SheetsMCPServer) sanitizeInput(args map[string]any, valueInput string, values [][]any) {
+	if valueInput == "RAW" || !parseArgument(args, "sanitize_input", s.config.SanitizeInput) {
+		return
+	}
+	for _, row := range values {
+		for i, cell := range row {
+			text, ok := cell.(string)
+			if !ok || text == "" || !strings.ContainsRune("=+-@", rune(text[0])) {
+				continue
+			}
+			if _, err := strconv.ParseFloat(text, 64); err == nil {
+				continue
+			}
+			row[i] = "'" + text
+		}
+	}
+}
+
 // updateSheetVisibility updates the hidden property of a sheet
-func (s *SheetsMCPServer) updateSheetVisibility(spreadsheetID, sheet string, hidden bool) (*mcp.CallToolResult, error) {
-	sheetID, err := s.getSheetID(spreadsheetID, sheet)
+func (s *SheetsMCPServer) updateSheetVisibility(ctx context.Context, args map[string]any, spreadsheetID, sheet string, hidden bool) (*mcp.CallToolResult, error) {
+	sheetID, err := s.getSheetID(ctx, spreadsheetID, sheet)
 	if err != nil {
-		return respondWithError(fmt.Sprintf("failed to get sheet ID: %v", err))
+		return s.respondWithAPIError("failed to get sheet ID", err)
 	}
 
 	requests := []*sheets.Request{
@@ -1249,7 +2819,7 @@ func (s *SheetsMCPServer) updateSheetVisibility(spreadsheetID, sheet string, hid
 		},
 	}
 
-	result, err := s.executeBatchUpdate(spreadsheetID, requests)
+	result, err := s.executeBatchUpdate(ctx, spreadsheetID, requests)
 	if err != nil {
 		action := "hide"
 		if !hidden {
@@ -1258,5 +2828,18 @@ func (s *SheetsMCPServer) updateSheetVisibility(spreadsheetID, sheet string, hid
 		return respondWithError(fmt.Sprintf("failed to %s sheet: %v", action, err))
 	}
 
-	return respondWithJSON(result)
+	return respondWithShape(args, result)
+}
+
+func (s *SheetsMCPServer) handleCacheStats(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	response := cacheStatsResult{
+		Metadata: s.metadataCache.stats(),
+		Results:  s.resultCache.stats(),
+	}
+
+	return respondWithJSON(response)
+}
+
+func (s *SheetsMCPServer) handleWriteQueueStats(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return respondWithJSON(s.writeQueue.stats())
 }
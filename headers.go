@@ -0,0 +1,443 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"google.golang.org/api/sheets/v4"
+)
+
+// readHeaderRow returns the header cells of a sheet as text; headerRow is 1-based
+func (s *SheetsMCPServer) readHeaderRow(ctx context.Context, spreadsheetID, sheet string, headerRow int64) ([]string, error) {
+	rowRange := fmt.Sprintf("%d:%d", headerRow, headerRow)
+	result, err := s.sheetsService.Spreadsheets.Values.Get(spreadsheetID, buildFullRange(sheet, rowRange)).Context(ctx).Do()
+	if err != nil {
+		return nil, err
+	}
+
+	var headers []string
+	if len(result.Values) > 0 {
+		for _, cell := range result.Values[0] {
+			headers = append(headers, formatCell(cell))
+		}
+	}
+	return headers, nil
+}
+
+// findHeader returns the index of the first header matching name, ignoring case and surrounding spaces, or -1
+func findHeader(headers []string, name string) int {
+	name = strings.TrimSpace(name)
+	for i, header := range headers {
+		if strings.EqualFold(strings.TrimSpace(header), name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// planColumnMoves computes MoveDimension requests that put the named columns first, in order, and keep
+// the remaining columns after them in their current relative order. It also returns the final headers.
+func planColumnMoves(sheetID int64, headers []string, order []string) ([]*sheets.Request, []string, error) {
+	// current holds the original index of the column at each position as moves are applied
+	current := make([]int, len(headers))
+	for i := range current {
+		current[i] = i
+	}
+
+	seen := map[int]bool{}
+	var requests []*sheets.Request
+	for target, name := range order {
+		original := findHeader(headers, name)
+		if original < 0 {
+			return nil, nil, fmt.Errorf("header '%s' not found (headers: %s)", name, strings.Join(headers, ", "))
+		}
+		if seen[original] {
+			return nil, nil, fmt.Errorf("header '%s' is listed more than once", name)
+		}
+		seen[original] = true
+
+		position := 0
+		for current[position] != original {
+			position++
+		}
+		if position == target {
+			continue
+		}
+
+		// Earlier targets are already in place, so the column always moves left
+		requests = append(requests, &sheets.Request{
+			MoveDimension: &sheets.MoveDimensionRequest{
+				Source: &sheets.DimensionRange{
+					SheetId:    sheetID,
+					Dimension:  "COLUMNS",
+					StartIndex: int64(position),
+					EndIndex:   int64(position + 1),
+				},
+				DestinationIndex: int64(target),
+			},
+		})
+		copy(current[target+1:position+1], current[target:position])
+		current[target] = original
+	}
+
+	final := make([]string, len(current))
+	for i, original := range current {
+		final[i] = headers[original]
+	}
+	return requests, final, nil
+}
+
+func (s *SheetsMCPServer) handleReorderColumns(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID, sheet, _ := parseCommonArgs(args)
+	headerRow := int64(parseArgument(args, "header_row", float64(1)))
+	orderRaw, ok := args["order"]
+
+	if spreadsheetID == "" || sheet == "" || !ok {
+		return respondWithError("spreadsheet_id, sheet, and order are required")
+	}
+	if headerRow < 1 {
+		return respondWithError("header_row must be 1 or greater")
+	}
+
+	var order []string
+	if err := convertToType(orderRaw, &order); err != nil {
+		return respondWithError(fmt.Sprintf("invalid order format: %v", err))
+	}
+
+	sheetID, err := s.getSheetID(ctx, spreadsheetID, sheet)
+	if err != nil {
+		return s.respondWithAPIError("failed to get sheet ID", err)
+	}
+
+	headers, err := s.readHeaderRow(ctx, spreadsheetID, sheet, headerRow)
+	if err != nil {
+		return s.respondWithAPIError("failed to read headers", err)
+	}
+
+	requests, final, err := planColumnMoves(sheetID, headers, order)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+
+	if len(requests) > 0 {
+		if _, err := s.executeBatchUpdate(ctx, spreadsheetID, requests); err != nil {
+			return s.respondWithAPIError("failed to reorder columns", err)
+		}
+	}
+
+	response := reorderColumnsResult{
+		SpreadsheetID: spreadsheetID,
+		Sheet:         sheet,
+		Moves:         len(requests),
+		Headers:       final,
+	}
+
+	return respondWithJSON(response)
+}
+
+// headerWords splits a header into words at spaces, punctuation, and camelCase boundaries
+func headerWords(header string) []string {
+	var words []string
+	var word []rune
+	runes := []rune(strings.TrimSpace(header))
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(word) > 0 {
+				words = append(words, string(word))
+				word = nil
+			}
+			continue
+		}
+		if unicode.IsUpper(r) && len(word) > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				words = append(words, string(word))
+				word = nil
+			}
+		}
+		word = append(word, r)
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	return words
+}
+
+// applyHeaderCase rewrites a header in snake_case or Title Case; any other style leaves it unchanged
+func applyHeaderCase(header, style string) string {
+	words := headerWords(header)
+	switch style {
+	case "snake_case":
+		for i, word := range words {
+			words[i] = strings.ToLower(word)
+		}
+		return strings.Join(words, "_")
+	case "title_case":
+		for i, word := range words {
+			runes := []rune(strings.ToLower(word))
+			runes[0] = unicode.ToUpper(runes[0])
+			words[i] = string(runes)
+		}
+		return strings.Join(words, " ")
+	}
+	return header
+}
+
+func (s *SheetsMCPServer) handleNormalizeHeaders(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID, sheet, _ := parseCommonArgs(args)
+	headerRow := int64(parseArgument(args, "header_row", float64(1)))
+	style := parseArgument(args, "case", "")
+	dedupe := parseArgument(args, "dedupe", true)
+	dryRun := s.config.DryRun || parseArgument(args, "dry_run", false)
+
+	if spreadsheetID == "" || sheet == "" {
+		return respondWithError("spreadsheet_id and sheet are required")
+	}
+	if headerRow < 1 {
+		return respondWithError("header_row must be 1 or greater")
+	}
+	if style != "" && style != "snake_case" && style != "title_case" {
+		return respondWithError(fmt.Sprintf("invalid case '%s': use snake_case or title_case", style))
+	}
+
+	var rename map[string]string
+	if raw, ok := args["rename"]; ok {
+		if err := convertToType(raw, &rename); err != nil {
+			return respondWithError(fmt.Sprintf("invalid rename format: %v", err))
+		}
+	}
+
+	original, err := s.readHeaderRow(ctx, spreadsheetID, sheet, headerRow)
+	if err != nil {
+		return s.respondWithAPIError("failed to read headers", err)
+	}
+
+	trimmed := make([]string, len(original))
+	for i, header := range original {
+		trimmed[i] = strings.TrimSpace(header)
+	}
+	// Every rename is looked up among the original headers, so swaps and chains such as a->b, b->c
+	// rename the columns they name instead of depending on the order they are applied in
+	headers := slices.Clone(trimmed)
+	renamedFrom := map[int]string{}
+	for from, to := range rename {
+		i := findHeader(trimmed, from)
+		if i < 0 {
+			return respondWithError(fmt.Sprintf("header '%s' not found (headers: %s)", from, strings.Join(original, ", ")))
+		}
+		if other, ok := renamedFrom[i]; ok {
+			return respondWithError(fmt.Sprintf("rename names column %s twice, as '%s' and '%s'", columnToLetter(int64(i)), other, from))
+		}
+		renamedFrom[i] = from
+		headers[i] = to
+	}
+	for i, header := range headers {
+		headers[i] = applyHeaderCase(header, style)
+	}
+
+	// Later duplicates get a numeric suffix in the header style, e.g. amount_2 or Amount 2
+	if dedupe {
+		separator := " "
+		if style == "snake_case" {
+			separator = "_"
+		}
+		used := map[string]bool{}
+		for i, header := range headers {
+			if header == "" {
+				continue
+			}
+			candidate := header
+			for n := 2; used[strings.ToLower(candidate)]; n++ {
+				candidate = fmt.Sprintf("%s%s%d", header, separator, n)
+			}
+			used[strings.ToLower(candidate)] = true
+			headers[i] = candidate
+		}
+	}
+
+	changed := 0
+	schema := make([]headerColumn, len(headers))
+	row := make([]any, len(headers))
+	for i, header := range headers {
+		schema[i] = headerColumn{
+			Column: columnToLetter(int64(i)),
+			Header: header,
+		}
+		if header != original[i] {
+			schema[i].Original = &original[i]
+			changed++
+		}
+		row[i] = header
+	}
+
+	if changed > 0 && !dryRun {
+		rowRange := fmt.Sprintf("A%d", headerRow)
+		_, err := s.sheetsService.Spreadsheets.Values.Update(spreadsheetID, buildFullRange(sheet, rowRange), &sheets.ValueRange{Values: [][]any{row}}).
+			ValueInputOption("RAW").
+			Context(ctx).
+			Do()
+		if err != nil {
+			return s.respondWithAPIError("failed to write headers", err)
+		}
+	}
+
+	response := headersResult{
+		SpreadsheetID: spreadsheetID,
+		Sheet:         sheet,
+		DryRun:        &dryRun,
+		Changed:       &changed,
+		Headers:       schema,
+	}
+
+	return respondWithJSON(response)
+}
+
+// resolveColumn finds a column by header name, ignoring case, or by column letters such as C or AB
+func resolveColumn(headers []string, column string) (int, error) {
+	if i := findHeader(headers, column); i >= 0 {
+		return i, nil
+	}
+	letters := strings.ToUpper(strings.TrimSpace(column))
+	if letters != "" && len(letters) <= 3 && strings.Trim(letters, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") == "" {
+		index := 0
+		for _, letter := range letters {
+			index = index*26 + int(letter-'A'+1)
+		}
+		return index - 1, nil
+	}
+	return -1, fmt.Errorf("column '%s' is neither a header nor a column letter (headers: %s)", column, strings.Join(headers, ", "))
+}
+
+func (s *SheetsMCPServer) handleGetHeaders(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID, sheet, _ := parseCommonArgs(args)
+	headerRow := int64(parseArgument(args, "header_row", float64(1)))
+
+	if spreadsheetID == "" || sheet == "" {
+		return respondWithError("spreadsheet_id and sheet are required")
+	}
+	if headerRow < 1 {
+		return respondWithError("header_row must be 1 or greater")
+	}
+
+	headers, err := s.readHeaderRow(ctx, spreadsheetID, sheet, headerRow)
+	if err != nil {
+		return s.respondWithAPIError("failed to read header row", err)
+	}
+
+	schema := make([]headerColumn, len(headers))
+	for i, header := range headers {
+		schema[i] = headerColumn{
+			Column: columnToLetter(int64(i)),
+			Index:  &i,
+			Header: header,
+		}
+	}
+
+	return respondWithJSON(headersResult{
+		SpreadsheetID: spreadsheetID,
+		Sheet:         sheet,
+		HeaderRow:     headerRow,
+		Headers:       schema,
+	})
+}
+
+func (s *SheetsMCPServer) handleFindColumn(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID, sheet, _ := parseCommonArgs(args)
+	name := parseArgument(args, "header", "")
+	headerRow := int64(parseArgument(args, "header_row", float64(1)))
+
+	if spreadsheetID == "" || sheet == "" || strings.TrimSpace(name) == "" {
+		return respondWithError("spreadsheet_id, sheet, and header are required")
+	}
+	if headerRow < 1 {
+		return respondWithError("header_row must be 1 or greater")
+	}
+
+	headers, err := s.readHeaderRow(ctx, spreadsheetID, sheet, headerRow)
+	if err != nil {
+		return s.respondWithAPIError("failed to read header row", err)
+	}
+
+	index := findHeader(headers, name)
+	if index < 0 {
+		// Headers containing the name are likely what was meant, e.g. "Amount (USD)" for "amount"
+		var candidates []string
+		lowered := strings.ToLower(strings.TrimSpace(name))
+		for _, header := range headers {
+			if header != "" && strings.Contains(strings.ToLower(header), lowered) {
+				candidates = append(candidates, header)
+			}
+		}
+		if len(candidates) > 0 {
+			return respondWithError(fmt.Sprintf("no header named '%s' in row %d; similar headers: %s", name, headerRow, strings.Join(candidates, ", ")))
+		}
+		return respondWithError(fmt.Sprintf("no header named '%s' in row %d (headers: %s)", name, headerRow, strings.Join(headers, ", ")))
+	}
+
+	letter := columnToLetter(int64(index))
+	return respondWithJSON(columnMatch{
+		SpreadsheetID: spreadsheetID,
+		Sheet:         sheet,
+		Header:        headers[index],
+		Column:        letter,
+		Index:         index,
+		Range:         buildFullRange(sheet, fmt.Sprintf("%s%d:%s", letter, headerRow+1, letter)),
+	})
+}
+
+// reorderColumnsResult reports the moves reorder_columns made and the header order they left
+type reorderColumnsResult struct {
+	SpreadsheetID string   `json:"spreadsheetId"`
+	Sheet         string   `json:"sheet"`
+	Moves         int      `json:"moves"`
+	Headers       []string `json:"headers"`
+}
+
+// headerColumn is one column of a header row, with the header it had before normalize_headers renamed it
+type headerColumn struct {
+	Column   string  `json:"column"`
+	Index    *int    `json:"index,omitempty"`
+	Header   string  `json:"header"`
+	Original *string `json:"original,omitempty"`
+}
+
+// headersResult is the header row get_headers read or normalize_headers wrote
+type headersResult struct {
+	SpreadsheetID string         `json:"spreadsheetId"`
+	Sheet         string         `json:"sheet"`
+	HeaderRow     int64          `json:"headerRow,omitempty"`
+	DryRun        *bool          `json:"dryRun,omitempty"`
+	Changed       *int           `json:"changed,omitempty"`
+	Headers       []headerColumn `json:"headers"`
+}
+
+// columnMatch is the column find_column found and the range of its values
+type columnMatch struct {
+	SpreadsheetID string `json:"spreadsheetId"`
+	Sheet         string `json:"sheet"`
+	Header        string `json:"header"`
+	Column        string `json:"column"`
+	Index         int    `json:"index"`
+	Range         string `json:"range"`
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// tokenInfoURL describes an access token: who it belongs to, its scopes, and when it expires
+const tokenInfoURL = "https://oauth2.googleapis.com/tokeninfo"
+
+// healthResult is what health_check returns
+type healthResult struct {
+	// Status is ok, degraded when some access is missing, or unhealthy when no token can be had
+	Status          string        `json:"status"`
+	Profile         string        `json:"profile,omitempty"`
+	Principal       string        `json:"principal,omitempty"`
+	AuthMethod      string        `json:"authMethod"`
+	GrantedScopes   []string      `json:"grantedScopes,omitempty"`
+	RequestedScopes []string      `json:"requestedScopes"`
+	MissingScopes   []string      `json:"missingScopes,omitempty"`
+	TokenExpiry     string        `json:"tokenExpiry,omitempty"`
+	ExpiresIn       string        `json:"expiresIn,omitempty"`
+	TokenError      string        `json:"tokenError,omitempty"`
+	DriveFolderID   string        `json:"driveFolderId,omitempty"`
+	SharedDriveID   string        `json:"sharedDriveId,omitempty"`
+	Session         *tenantConfig `json:"session,omitempty"`
+	Drive           driveHealth   `json:"drive"`
+	Problems        []string      `json:"problems,omitempty"`
+}
+
+// driveHealth is the outcome of a Drive about call made with the server's credentials
+type driveHealth struct {
+	Available bool       `json:"available"`
+	User      *driveUser `json:"user,omitempty"`
+	LatencyMs int64      `json:"latencyMs,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// tokenInfo is the part of the tokeninfo response health_check reports
+type tokenInfo struct {
+	Email string `json:"email"`
+	Scope string `json:"scope"`
+}
+
+// handleHealthCheck reports who the server is authenticated as and what it can reach, so that a
+// spreadsheet the agent cannot see can be explained without access to the server host
+func (s *SheetsMCPServer) handleHealthCheck(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	response := healthResult{
+		Status:          "ok",
+		Profile:         s.profile,
+		Principal:       s.serviceAccountEmail,
+		AuthMethod:      s.authMethod,
+		RequestedScopes: s.scopes,
+		DriveFolderID:   s.driveFolder(ctx),
+		SharedDriveID:   s.config.SharedDriveID,
+		Session:         tenantFromContext(ctx),
+	}
+	problem := func(text string) {
+		response.Status = "degraded"
+		response.Problems = append(response.Problems, text)
+	}
+
+	token, err := s.tokenSource.Token()
+	if err != nil {
+		response.Status = "unhealthy"
+		response.TokenError = err.Error()
+		message := "no access token could be obtained"
+		if hint := s.apiErrorHint(err); hint != "" {
+			message += ": " + hint
+		}
+		response.Problems = append(response.Problems, message)
+		return respondWithJSON(response)
+	}
+	if !token.Expiry.IsZero() {
+		response.TokenExpiry = token.Expiry.UTC().Format(time.RFC3339)
+		response.ExpiresIn = time.Until(token.Expiry).Round(time.Second).String()
+	}
+
+	info, err := fetchTokenInfo(ctx, token.AccessToken)
+	if err != nil {
+		problem(fmt.Sprintf("failed to look up the granted scopes: %v", err))
+	} else {
+		if info.Email != "" {
+			response.Principal = info.Email
+		}
+		response.GrantedScopes = strings.Fields(info.Scope)
+		for _, scope := range s.scopes {
+			if !slices.Contains(response.GrantedScopes, scope) {
+				response.MissingScopes = append(response.MissingScopes, scope)
+			}
+		}
+		if len(response.MissingScopes) > 0 {
+			problem("the token lacks requested scopes; for OAuth, call reauthenticate to sign in again and grant them")
+		}
+	}
+
+	if s.driveService == nil {
+		response.Drive.Error = s.driveUnavailable
+		problem("Google Drive is unavailable: " + s.driveUnavailable)
+		return respondWithJSON(response)
+	}
+	start := time.Now()
+	about, err := s.driveService.About.Get().Fields("user(displayName,emailAddress)").Context(ctx).Do()
+	response.Drive.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		response.Drive.Error = err.Error()
+		message := "the Drive about call failed"
+		if hint := s.apiErrorHint(err); hint != "" {
+			message += ": " + hint
+		}
+		problem(message)
+		return respondWithJSON(response)
+	}
+	response.Drive.Available = true
+	if about.User != nil {
+		response.Drive.User = &driveUser{DisplayName: about.User.DisplayName, EmailAddress: about.User.EmailAddress}
+		if response.Principal == "" {
+			response.Principal = about.User.EmailAddress
+		}
+	}
+
+	return respondWithJSON(response)
+}
+
+// fetchTokenInfo asks Google about an access token. The token is posted rather than put in the URL,
+// and the request bypasses the API client so the token is not logged.
+func fetchTokenInfo(ctx context.Context, accessToken string) (*tokenInfo, error) {
+	form := url.Values{"access_token": {accessToken}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenInfoURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("tokeninfo returned %s", resp.Status)
+	}
+
+	var info tokenInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, fmt.Errorf("failed to decode tokeninfo: %w", err)
+	}
+	return &info, nil
+}
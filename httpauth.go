@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/auth"
+)
+
+const (
+	// oidcCacheTTL is how long a verified OIDC token is trusted before it is verified again
+	oidcCacheTTL = 5 * time.Minute
+	// tenantClaim is the token claim naming the TENANTS_FILE tenant a client is bound to
+	tenantClaim = "sheets_mcp_tenant"
+)
+
+// httpAuthenticator verifies the bearer tokens of HTTP clients and rate limits each client
+type httpAuthenticator struct {
+	config     HTTPAuthConfig
+	tenants    map[string]*tenantConfig
+	httpClient *http.Client
+
+	mu           sync.Mutex
+	jwksURL      string
+	keys         map[string]crypto.PublicKey
+	keysFetched  time.Time
+	verified     map[string]*auth.TokenInfo
+	windowStart  time.Time
+	windowCounts map[string]int64
+}
+
+func newHTTPAuthenticator(config HTTPAuthConfig, tenants map[string]*tenantConfig) *httpAuthenticator {
+	return &httpAuthenticator{
+		config:       config,
+		tenants:      tenants,
+		httpClient:   &http.Client{Timeout: 10 * time.Second},
+		verified:     make(map[string]*auth.TokenInfo),
+		windowCounts: make(map[string]int64),
+	}
+}
+
+// middleware requires a valid bearer token on every request and applies the per-client rate limit
+func (a *httpAuthenticator) middleware(next http.Handler) http.Handler {
+	limited := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info := auth.TokenInfoFromContext(r.Context())
+		if retryAfter, ok := a.allow(info.Extra["client"].(string)); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+			http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+	return auth.RequireBearerToken(a.verify, nil)(limited)
+}
+
+// verify implements auth.TokenVerifier for static tokens and OIDC access tokens.
+// Extra["client"] identifies the client for rate limiting; Extra["tenant"] is the tenant it is bound to.
+func (a *httpAuthenticator) verify(ctx context.Context, token string, r *http.Request) (*auth.TokenInfo, error) {
+	for i, static := range a.config.Tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(static)) == 1 {
+			client := fmt.Sprintf("token-%d", i+1)
+			info := &auth.TokenInfo{
+				Expiration: time.Now().Add(time.Hour),
+				Extra:      map[string]any{"client": client},
+			}
+			if tenant := clientTenant(a.tenants, client); tenant != nil {
+				info.Extra["tenant"] = tenant
+			} else if len(a.tenants) > 0 {
+				return nil, fmt.Errorf("%w: %s is not bound to a tenant", auth.ErrInvalidToken, client)
+			}
+			return info, nil
+		}
+	}
+
+	if a.config.OIDCIssuer == "" {
+		return nil, auth.ErrInvalidToken
+	}
+	return a.verifyOIDC(ctx, token)
+}
+
+// verifyOIDC accepts a JWT, such as an ID token, that the issuer signed for HTTP_AUTH_OIDC_CLIENT_ID
+// and that has not expired. Results are cached briefly so each request does not verify it again.
+func (a *httpAuthenticator) verifyOIDC(ctx context.Context, token string) (*auth.TokenInfo, error) {
+	sum := sha256.Sum256([]byte(token))
+	key := hex.EncodeToString(sum[:])
+
+	a.mu.Lock()
+	if info, ok := a.verified[key]; ok && time.Now().Before(info.Expiration) {
+		a.mu.Unlock()
+		return info, nil
+	}
+	a.mu.Unlock()
+
+	claims, err := verifyJWT(token, func(kid string) (crypto.PublicKey, error) { return a.signingKey(ctx, kid) })
+	if err != nil {
+		return nil, err
+	}
+	expiry, err := checkOIDCClaims(claims, a.config.OIDCIssuer, a.config.OIDCClientID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, auth.ErrInvalidToken
+	}
+	if !a.oidcAllowed(subject, claims) {
+		return nil, fmt.Errorf("%w: %s is not in HTTP_AUTH_OIDC_ALLOWED", auth.ErrInvalidToken, subject)
+	}
+
+	// A token is not trusted past its own expiry, however recently it was verified
+	expiration := time.Now().Add(oidcCacheTTL)
+	if expiry.Before(expiration) {
+		expiration = expiry
+	}
+	info := &auth.TokenInfo{
+		Expiration: expiration,
+		Extra:      map[string]any{"client": "oidc:" + subject, "claims": claims},
+	}
+	// The issuer can name the tenant in a claim; otherwise the subject may be bound to one in TENANTS_FILE
+	tenant := clientTenant(a.tenants, "oidc:"+subject)
+	if raw, ok := claims[tenantClaim]; ok {
+		name, _ := raw.(string)
+		if tenant = a.tenants[name]; tenant == nil {
+			return nil, fmt.Errorf("%w: the %s claim names no tenant in TENANTS_FILE", auth.ErrInvalidToken, tenantClaim)
+		}
+	}
+	if tenant != nil {
+		info.Extra["tenant"] = tenant
+	} else if len(a.tenants) > 0 {
+		return nil, fmt.Errorf("%w: oidc:%s is not bound to a tenant", auth.ErrInvalidToken, subject)
+	}
+
+	a.mu.Lock()
+	a.verified[key] = info
+	for k, cached := range a.verified {
+		if time.Now().After(cached.Expiration) {
+			delete(a.verified, k)
+		}
+	}
+	a.mu.Unlock()
+
+	return info, nil
+}
+
+// oidcAllowed reports whether HTTP_AUTH_OIDC_ALLOWED lets a user in by subject, or by a verified email
+// address or its domain
+func (a *httpAuthenticator) oidcAllowed(subject string, claims map[string]any) bool {
+	email, _ := claims["email"].(string)
+	if verified, ok := claims["email_verified"].(bool); ok && !verified {
+		email = ""
+	}
+	_, domain, _ := strings.Cut(email, "@")
+	for _, allowed := range a.config.OIDCAllowed {
+		switch {
+		case allowed == subject:
+			return true
+		case email == "":
+		case strings.HasPrefix(allowed, "@") && strings.EqualFold(allowed[1:], domain):
+			return true
+		case strings.EqualFold(allowed, email):
+			return true
+		}
+	}
+	return false
+}
+
+// allow counts a request against the client's budget for the current minute, returning how long
+// until the budget resets when it is spent
+func (a *httpAuthenticator) allow(client string) (time.Duration, bool) {
+	if a.config.RateLimitPerMinute <= 0 {
+		return 0, true
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(a.windowStart) >= time.Minute {
+		a.windowStart = now
+		clear(a.windowCounts)
+	}
+	if a.windowCounts[client] >= a.config.RateLimitPerMinute {
+		return a.windowStart.Add(time.Minute).Sub(now), false
+	}
+	a.windowCounts[client]++
+	return 0, true
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Endpoints of the HTTP transports; SSE clients post their messages back to the SSE path
+const (
+	mcpHTTPPath = "/mcp"
+	mcpSSEPath  = "/sse"
+)
+
+// runHTTP serves the MCP server over streamable HTTP or SSE until ctx is cancelled. Every client gets
+// its own session, so session statistics and tenant settings stay per client. An SSE session ends when
+// its connection closes, which cancels the calls still running for it.
+func (s *SheetsMCPServer) runHTTP(ctx context.Context) error {
+	getServer := func(*http.Request) *mcp.Server { return s.mcpServer }
+
+	var handler http.Handler
+	path := mcpHTTPPath
+	if s.config.Transport == "sse" {
+		handler = mcp.NewSSEHandler(getServer, nil)
+		path = mcpSSEPath
+	} else {
+		handler = mcp.NewStreamableHTTPHandler(getServer, nil)
+	}
+
+	if s.config.HTTPAuth.enabled() {
+		handler = newHTTPAuthenticator(s.config.HTTPAuth, s.config.Tenants).middleware(handler)
+	} else if !isLoopbackAddr(s.config.HTTPAddr) {
+		slog.Warn("serving without authentication; set HTTP_AUTH_TOKENS or HTTP_AUTH_OIDC_ISSUER", "addr", s.config.HTTPAddr)
+	}
+
+	// Hosts are checked before authentication, so a rebound page learns nothing from the server's replies
+	handler = s.withAllowedHosts(handler)
+	remoteHosts := slices.ContainsFunc(s.config.AllowedHosts, func(host string) bool {
+		return !isLoopbackAddr(net.JoinHostPort(host, "0"))
+	})
+	if !remoteHosts && !isLoopbackAddr(s.config.HTTPAddr) {
+		slog.Warn("only localhost Host headers are accepted; set MCP_ALLOWED_HOSTS to the names clients connect to", "addr", s.config.HTTPAddr)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(path, handler)
+
+	server := &http.Server{
+		Addr:              s.config.HTTPAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		slog.Info("serving MCP", "transport", s.config.Transport, "url", "http://"+s.config.HTTPAddr+path)
+		errs <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	}
+}
+
+// withAllowedHosts rejects requests whose Host header is not in AllowedHosts, and browser requests
+// whose Origin is neither in AllowedOrigins nor on an allowed host. Otherwise a web page whose domain
+// is rebound to 127.0.0.1 could call the tools of a server listening on localhost.
+func (s *SheetsMCPServer) withAllowedHosts(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !slices.Contains(s.config.AllowedHosts, requestHost(r.Host)) {
+			http.Error(w, "host not allowed", http.StatusForbidden)
+			return
+		}
+		// Clients other than browsers send no Origin
+		if origin := r.Header.Get("Origin"); origin != "" && !s.originAllowed(origin) {
+			http.Error(w, "origin not allowed", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// originAllowed reports whether a browser origin may call the server
+func (s *SheetsMCPServer) originAllowed(origin string) bool {
+	origin = strings.ToLower(strings.TrimSuffix(origin, "/"))
+	if slices.Contains(s.config.AllowedOrigins, origin) {
+		return true
+	}
+	parsed, err := url.Parse(origin)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return false
+	}
+	return slices.Contains(s.config.AllowedHosts, requestHost(parsed.Host))
+}
+
+// requestHost returns the host name of a Host header or origin, without its port and IPv6 brackets
+func requestHost(hostport string) string {
+	host, _, err := net.SplitHostPort(hostport)
+	if err != nil {
+		host = hostport
+	}
+	return strings.ToLower(strings.Trim(host, "[]"))
+}
+
+// isLoopbackAddr reports whether a listen address only accepts connections from this machine
+func isLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// commaDecimalLanguages write numbers as 1.234,56 (or 1 234,56) instead of 1,234.56
+var commaDecimalLanguages = map[string]bool{
+	"bg": true, "ca": true, "cs": true, "da": true, "de": true, "el": true, "es": true,
+	"et": true, "fi": true, "fr": true, "hr": true, "hu": true, "id": true, "it": true,
+	"lt": true, "lv": true, "nb": true, "nl": true, "no": true, "pl": true, "pt": true,
+	"ro": true, "ru": true, "sk": true, "sl": true, "sr": true, "sv": true, "tr": true,
+	"uk": true, "vi": true,
+}
+
+// numberFormat describes how a locale writes numbers
+type numberFormat struct {
+	decimal rune
+	groups  string
+}
+
+// spaceGroupLanguages group thousands with a (no-break) space, e.g. 1 234,56
+var spaceGroupLanguages = map[string]bool{
+	"bg": true, "cs": true, "et": true, "fi": true, "fr": true, "hu": true, "lt": true,
+	"lv": true, "nb": true, "no": true, "pl": true, "ru": true, "sk": true, "sv": true,
+	"uk": true,
+}
+
+// getNumberFormat returns the decimal and grouping separators for a locale such as de_DE or en-IN
+func getNumberFormat(locale string) numberFormat {
+	normalized := strings.ReplaceAll(locale, "-", "_")
+	lang, region, _ := strings.Cut(normalized, "_")
+	lang = strings.ToLower(lang)
+
+	// Swiss and Liechtenstein locales group with apostrophes and keep the decimal point
+	if strings.EqualFold(region, "CH") || strings.EqualFold(region, "LI") {
+		return numberFormat{decimal: '.', groups: "'’"}
+	}
+
+	if spaceGroupLanguages[lang] {
+		return numberFormat{decimal: ',', groups: " \u00a0\u202f"}
+	}
+	if commaDecimalLanguages[lang] {
+		return numberFormat{decimal: ',', groups: "."}
+	}
+
+	return numberFormat{decimal: '.', groups: ","}
+}
+
+// parseLocaleNumber converts a string written the way the locale writes numbers ("1.234,56",
+// "₹1,00,000", "-12,5 %", "(1,200)") into a float64. Anything else is reported as not parsed and
+// stays text: digits grouped other than in threes (Indian lakh grouping aside), leading zeros as in
+// IDs and zip codes, and spaces that are not the locale's grouping separator, as in phone numbers.
+func parseLocaleNumber(value string, format numberFormat) (float64, bool) {
+	text := strings.TrimSpace(value)
+
+	negative := false
+	if strings.HasPrefix(text, "(") && strings.HasSuffix(text, ")") {
+		negative = true
+		text = strings.TrimSpace(text[1 : len(text)-1])
+	}
+
+	percent := false
+	if strings.HasSuffix(text, "%") {
+		percent = true
+		text = strings.TrimSpace(strings.TrimSuffix(text, "%"))
+	}
+
+	// A sign and a currency symbol may lead, in either order, and a currency symbol may trail
+	signed := false
+	for range 2 {
+		if r, size := utf8.DecodeRuneInString(text); r == '-' || r == '−' || r == '+' {
+			if signed {
+				return 0, false
+			}
+			signed = true
+			negative = negative != (r != '+')
+			text = strings.TrimSpace(text[size:])
+		} else if unicode.Is(unicode.Sc, r) {
+			text = strings.TrimSpace(text[size:])
+		}
+	}
+	if r, size := utf8.DecodeLastRuneInString(text); unicode.Is(unicode.Sc, r) {
+		text = strings.TrimSpace(text[:len(text)-size])
+	}
+
+	integer, fraction, hasFraction := strings.Cut(text, string(format.decimal))
+	if hasFraction && (fraction == "" || !allDigits(fraction)) {
+		return 0, false
+	}
+	digits, ok := ungroupDigits(integer, format.groups)
+	if !ok || (digits == "" && !hasFraction) {
+		return 0, false
+	}
+	if len(digits) > 1 && digits[0] == '0' {
+		return 0, false
+	}
+
+	number := digits
+	if number == "" {
+		number = "0"
+	}
+	if hasFraction {
+		number += "." + fraction
+	}
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil || math.IsInf(n, 0) {
+		return 0, false
+	}
+	if negative {
+		n = -n
+	}
+	if percent {
+		n /= 100
+	}
+	return n, true
+}
+
+// ungroupDigits removes the grouping separators from the integer part of a number. Groups after the
+// first hold three digits, or two before the last group as in Indian lakh grouping.
+func ungroupDigits(integer, groups string) (string, bool) {
+	parts := strings.FieldsFunc(integer, func(r rune) bool { return strings.ContainsRune(groups, r) })
+	if len(parts) == 0 {
+		return "", integer == ""
+	}
+	var b strings.Builder
+	for i, part := range parts {
+		if !allDigits(part) {
+			return "", false
+		}
+		switch {
+		case len(parts) == 1:
+		case i == 0 && len(part) > 3:
+			return "", false
+		case i == len(parts)-1 && len(part) != 3:
+			return "", false
+		case i > 0 && i < len(parts)-1 && len(part) != 2 && len(part) != 3:
+			return "", false
+		}
+		b.WriteString(part)
+	}
+	if len(parts) > 1 && !separatedOnce(integer, groups) {
+		return "", false
+	}
+	return b.String(), true
+}
+
+// separatedOnce reports whether every separator in a grouped integer sits alone between two digits
+func separatedOnce(integer, groups string) bool {
+	previousDigit := false
+	for _, r := range integer {
+		isDigit := r >= '0' && r <= '9'
+		if !isDigit && !previousDigit {
+			return false
+		}
+		previousDigit = isDigit
+	}
+	return previousDigit
+}
+
+func allDigits(text string) bool {
+	for _, r := range text {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return text != ""
+}
+
+// localizeValues replaces locale-formatted numeric strings in values with numbers and reports how many cells changed
+func localizeValues(values [][]any, locale string) int {
+	format := getNumberFormat(locale)
+	converted := 0
+	for _, row := range values {
+		for i, cell := range row {
+			str, ok := cell.(string)
+			// Sheets already reads percentages and amounts in the spreadsheet's locale and keeps their format
+			if !ok || strings.HasPrefix(str, "=") || hasUnitSymbol(str) {
+				continue
+			}
+			if n, ok := parseLocaleNumber(str, format); ok {
+				row[i] = n
+				converted++
+			}
+		}
+	}
+	return converted
+}
+
+// hasUnitSymbol reports whether text carries a percent sign or a currency symbol
+func hasUnitSymbol(text string) bool {
+	return strings.ContainsFunc(text, func(r rune) bool { return r == '%' || unicode.Is(unicode.Sc, r) })
+}
+
+// localeParser parses locale-formatted numbers on a write. With locale "auto" it uses the
+// spreadsheet's own locale, looked up once and only when some value contains a digit.
+type localeParser struct {
+	server        *SheetsMCPServer
+	spreadsheetID string
+	locale        string
+	looked        bool
+}
+
+// localeParserFor returns the parser for a write, or nil when numbers are not to be parsed. Parsing
+// is opt-in: a write parses only with a locale argument, or with LOCALE_NUMBERS unless it is RAW,
+// which stores text as given.
+func (s *SheetsMCPServer) localeParserFor(args map[string]any, spreadsheetID, valueInput string) *localeParser {
+	locale := parseArgument(args, "locale", "")
+	switch {
+	case locale == "none":
+		return nil
+	case locale == "auto":
+		locale = ""
+	case locale == "":
+		if !s.config.LocaleNumbers || valueInput == "RAW" {
+			return nil
+		}
+	}
+	return &localeParser{server: s, spreadsheetID: spreadsheetID, locale: locale, looked: locale != ""}
+}
+
+func (p *localeParser) convert(ctx context.Context, values [][]any) error {
+	if p == nil || !hasDigitText(values) {
+		return nil
+	}
+	if !p.looked {
+		spreadsheet, err := p.server.sheetsService.Spreadsheets.Get(p.spreadsheetID).
+			Fields("properties.locale").
+			Context(ctx).
+			Do()
+		if err != nil {
+			return fmt.Errorf("failed to get spreadsheet locale: %w", err)
+		}
+		p.locale = spreadsheet.Properties.Locale
+		p.looked = true
+	}
+	localizeValues(values, p.locale)
+	return nil
+}
+
+// hasDigitText reports whether any text value could be a number
+func hasDigitText(values [][]any) bool {
+	for _, row := range values {
+		for _, cell := range row {
+			if text, ok := cell.(string); ok && strings.ContainsAny(text, "0123456789") {
+				return true
+			}
+		}
+	}
+	return false
+}
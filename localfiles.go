@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// localPath returns the file a file_path argument names. Over stdio the client runs on the server's
+// machine, so paths are taken as given unless FILE_ROOT is set. Over HTTP the caller is remote, so
+// paths are refused unless FILE_ROOT is set, and then resolved against it. Either way, a path under
+// FILE_ROOT must not lead out of it, through ".." or a symbolic link.
+func (s *SheetsMCPServer) localPath(path string) (string, error) {
+	root := s.config.FileRoot
+	if root == "" {
+		if s.config.Transport != "stdio" {
+			return "", fmt.Errorf("file_path is disabled over %s; set FILE_ROOT to allow files under a directory", s.config.Transport)
+		}
+		return path, nil
+	}
+
+	root, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", fmt.Errorf("FILE_ROOT is not readable: %w", err)
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("cannot open %s: %w", path, err)
+	}
+	rel, err := filepath.Rel(root, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("file_path %s is outside FILE_ROOT", path)
+	}
+	return resolved, nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// setupLogging installs the default slog logger from LOG_LEVEL (debug, info, warn, error), LOG_FORMAT
+// (text or json) and LOG_FILE. Logs go to stderr unless LOG_FILE is set; stdout is reserved for the
+// stdio MCP transport and the call subcommand's output.
+func setupLogging() error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(getEnvOrDefault("LOG_LEVEL", "info"))); err != nil {
+		return fmt.Errorf("invalid LOG_LEVEL: %w", err)
+	}
+
+	var out io.Writer = os.Stderr
+	if path := os.Getenv("LOG_FILE"); path != "" {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return fmt.Errorf("failed to open LOG_FILE: %w", err)
+		}
+		out = f
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch format := getEnvOrDefault("LOG_FORMAT", "text"); format {
+	case "text":
+		handler = slog.NewTextHandler(out, opts)
+	case "json":
+		handler = slog.NewJSONHandler(out, opts)
+	default:
+		return fmt.Errorf("invalid LOG_FORMAT '%s': must be text or json", format)
+	}
+
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
+
+// apiLogger is an http.RoundTripper that logs every Google API call at debug level
+type apiLogger struct {
+	next http.RoundTripper
+}
+
+// withAPILogging returns a copy of client whose requests are logged at debug level
+func withAPILogging(client *http.Client) *http.Client {
+	next := client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	logged := *client
+	logged.Transport = &apiLogger{next: next}
+	return &logged
+}
+
+func (l *apiLogger) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !slog.Default().Enabled(req.Context(), slog.LevelDebug) {
+		return l.next.RoundTrip(req)
+	}
+
+	start := time.Now()
+	resp, err := l.next.RoundTrip(req)
+	attrs := []any{
+		"method", req.Method,
+		"host", req.URL.Host,
+		"path", req.URL.Path,
+		"latency", time.Since(start),
+	}
+	if id := fileIDFromAPIPath(req.URL.Path); id != "" {
+		attrs = append(attrs, "spreadsheet_id", id)
+	}
+	if err != nil {
+		slog.DebugContext(req.Context(), "api call failed", append(attrs, "error", err)...)
+		return nil, err
+	}
+	slog.DebugContext(req.Context(), "api call", append(attrs, "status", resp.StatusCode)...)
+	return resp, nil
+}
+
+// fileIDFromAPIPath extracts the spreadsheet or file ID from a Sheets or Drive API path, such as
+// /v4/spreadsheets/ID/values/A1:append or /drive/v3/files/ID/permissions
+func fileIDFromAPIPath(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	for i, part := range parts[:max(len(parts)-1, 0)] {
+		if part == "spreadsheets" || part == "files" {
+			id, _, _ := strings.Cut(parts[i+1], ":")
+			return id
+		}
+	}
+	return ""
+}
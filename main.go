@@ -3,20 +3,34 @@ package main
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
 )
 
 func main() {
-	ctx := context.Background()
+	if err := setupLogging(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to set up logging: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Cancelled on interrupt so the HTTP transport can shut down gracefully
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if len(os.Args) > 1 && os.Args[1] == "call" {
+		os.Exit(runCall(ctx, os.Args[2:]))
+	}
 
 	srv, err := NewSheetsMCPServer(ctx)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to create sheets MCP server: %v\n", err)
+		slog.Error("failed to create sheets MCP server", "error", err)
 		os.Exit(1)
 	}
 
 	if err := srv.Run(ctx); err != nil {
-		fmt.Fprintf(os.Stderr, "Server error: %v\n", err)
+		slog.Error("server error", "error", err)
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// oauthFlowTimeout is how long the browser sign-in may take before the server gives up
+const oauthFlowTimeout = 5 * time.Minute
+
+// oauthCallback is what the browser brought back to the loopback listener
+type oauthCallback struct {
+	code string
+	err  error
+}
+
+// webSignIn is a browser sign-in in progress. It finishes when the redirect arrives and the code is
+// exchanged, or after oauthFlowTimeout.
+type webSignIn struct {
+	authURL string
+	expires time.Time
+	done    chan struct{}
+	token   *oauth2.Token
+	err     error
+}
+
+// wait returns the token once the sign-in finishes
+func (w *webSignIn) wait(ctx context.Context) (*oauth2.Token, error) {
+	select {
+	case <-w.done:
+		return w.token, w.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// finished reports whether the sign-in has succeeded, failed or timed out
+func (w *webSignIn) finished() bool {
+	select {
+	case <-w.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// getTokenFromWeb signs in through the browser and waits for the sign-in to finish
+func (ac *AuthConfig) getTokenFromWeb(ctx context.Context, config *oauth2.Config) (*oauth2.Token, error) {
+	signIn, err := ac.startWebSignIn(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	// This is an instruction for whoever is at the machine rather than a log line, so it always goes to the terminal
+	account := "Google"
+	if ac.Profile != "" {
+		account = fmt.Sprintf("the Google account of profile %s", ac.Profile)
+	}
+	fmt.Fprintf(os.Stderr, "Sign in to %s in your browser. If it does not open, go to:\n%v\n", account, signIn.authURL)
+	if err := openBrowser(signIn.authURL); err != nil {
+		slog.Debug("failed to open the browser", "error", err)
+	}
+	return signIn.wait(ctx)
+}
+
+// startWebSignIn starts a browser sign-in. A listener on a loopback port receives the authorization
+// code from Google's redirect, so nothing has to be pasted into a terminal, which an MCP client
+// launching the server does not have. PKCE keeps an intercepted code from being redeemed by anyone
+// else, and offline access with forced consent makes Google return a refresh token.
+func (ac *AuthConfig) startWebSignIn(ctx context.Context, config *oauth2.Config) (*webSignIn, error) {
+	listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", ac.OAuthCallbackPort))
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for the OAuth redirect: %w", err)
+	}
+	redirectConfig := *config
+	redirectConfig.RedirectURL = fmt.Sprintf("http://%s/", listener.Addr())
+
+	stateBytes := make([]byte, 16)
+	if _, err := rand.Read(stateBytes); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to generate OAuth state: %w", err)
+	}
+	state := hex.EncodeToString(stateBytes)
+	verifier := oauth2.GenerateVerifier()
+
+	callbacks := make(chan oauthCallback, 1)
+	server := &http.Server{
+		ReadHeaderTimeout: 10 * time.Second,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/" {
+				http.NotFound(w, r)
+				return
+			}
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			query := r.URL.Query()
+			if query.Get("state") != state {
+				http.Error(w, "Sign-in failed: the state does not match. Start again from the server.", http.StatusBadRequest)
+				return
+			}
+			var callback oauthCallback
+			switch {
+			case query.Get("error") != "":
+				callback.err = fmt.Errorf("authorization was denied: %s", query.Get("error"))
+				fmt.Fprintln(w, "Sign-in failed: "+query.Get("error")+". You can close this window.")
+			case query.Get("code") == "":
+				callback.err = errors.New("the redirect carried no authorization code")
+				http.Error(w, "Sign-in failed: no authorization code was returned.", http.StatusBadRequest)
+			default:
+				callback.code = query.Get("code")
+				fmt.Fprintln(w, "Signed in to Google Sheets MCP. You can close this window.")
+			}
+			select {
+			case callbacks <- callback:
+			default:
+			}
+		}),
+	}
+	go server.Serve(listener)
+
+	signIn := &webSignIn{
+		authURL: redirectConfig.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.ApprovalForce, oauth2.S256ChallengeOption(verifier)),
+		expires: time.Now().Add(oauthFlowTimeout),
+		done:    make(chan struct{}),
+	}
+	go func() {
+		defer close(signIn.done)
+		defer server.Close()
+
+		ctx, cancel := context.WithDeadline(ctx, signIn.expires)
+		defer cancel()
+		var callback oauthCallback
+		select {
+		case callback = <-callbacks:
+		case <-ctx.Done():
+			signIn.err = fmt.Errorf("no sign-in completed within %s", oauthFlowTimeout)
+			return
+		}
+		if callback.err != nil {
+			signIn.err = callback.err
+			return
+		}
+
+		signIn.token, signIn.err = redirectConfig.Exchange(ctx, callback.code, oauth2.VerifierOption(verifier))
+		if signIn.err != nil {
+			signIn.err = fmt.Errorf("failed to exchange authorization code: %w", signIn.err)
+		}
+	}()
+	return signIn, nil
+}
+
+// openBrowser opens a URL in the user's default browser
+func openBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	// Reap the launcher in the background; it exits as soon as the browser has the URL
+	go cmd.Wait()
+	return nil
+}
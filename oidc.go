@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	_ "crypto/sha512" // registers SHA-384 and SHA-512 for RS384, RS512, ES384 and ES512
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/auth"
+)
+
+const (
+	// jwksRefreshInterval limits how often an unknown key ID makes the issuer's keys be fetched again
+	jwksRefreshInterval = time.Minute
+	// jwtClockSkew tolerates clocks that disagree slightly with the issuer's
+	jwtClockSkew = time.Minute
+)
+
+// jwtAlgorithms are the signature algorithms accepted for OIDC tokens, with the hash each signs
+var jwtAlgorithms = map[string]crypto.Hash{
+	"RS256": crypto.SHA256, "RS384": crypto.SHA384, "RS512": crypto.SHA512,
+	"ES256": crypto.SHA256, "ES384": crypto.SHA384, "ES512": crypto.SHA512,
+}
+
+// verifyJWT checks a JWT's signature with the key its header names and returns its claims. The
+// claims are not checked here.
+func verifyJWT(token string, keyFor func(kid string) (crypto.PublicKey, error)) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: not a JWT", auth.ErrInvalidToken)
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return nil, err
+	}
+	hash, ok := jwtAlgorithms[header.Alg]
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported signature algorithm %q", auth.ErrInvalidToken, header.Alg)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature", auth.ErrInvalidToken)
+	}
+	key, err := keyFor(header.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := hash.New()
+	digest.Write([]byte(parts[0] + "." + parts[1]))
+	sum := digest.Sum(nil)
+	valid := false
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		valid = strings.HasPrefix(header.Alg, "RS") && rsa.VerifyPKCS1v15(key, hash, sum, signature) == nil
+	case *ecdsa.PublicKey:
+		// ES signatures are r and s side by side, each as long as the curve's order
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if strings.HasPrefix(header.Alg, "ES") && len(signature) == 2*size {
+			r := new(big.Int).SetBytes(signature[:size])
+			s := new(big.Int).SetBytes(signature[size:])
+			valid = ecdsa.Verify(key, sum, r, s)
+		}
+	}
+	if !valid {
+		return nil, fmt.Errorf("%w: invalid signature", auth.ErrInvalidToken)
+	}
+
+	var claims map[string]any
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func decodeJWTPart(part string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return fmt.Errorf("%w: malformed JWT", auth.ErrInvalidToken)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: malformed JWT", auth.ErrInvalidToken)
+	}
+	return nil
+}
+
+// checkOIDCClaims checks that a token comes from the issuer, was issued for the client ID, and is
+// current, returning when it expires
+func checkOIDCClaims(claims map[string]any, issuer, clientID string, now time.Time) (time.Time, error) {
+	if iss, _ := claims["iss"].(string); strings.TrimSuffix(iss, "/") != issuer {
+		return time.Time{}, fmt.Errorf("%w: issued by %q", auth.ErrInvalidToken, iss)
+	}
+
+	var audiences []string
+	switch aud := claims["aud"].(type) {
+	case string:
+		audiences = []string{aud}
+	case []any:
+		for _, a := range aud {
+			if s, ok := a.(string); ok {
+				audiences = append(audiences, s)
+			}
+		}
+	}
+	// A token for several audiences names the party it was issued to in azp
+	azp, _ := claims["azp"].(string)
+	if !slices.Contains(audiences, clientID) || (len(audiences) > 1 && azp != "" && azp != clientID) {
+		return time.Time{}, fmt.Errorf("%w: not issued for HTTP_AUTH_OIDC_CLIENT_ID", auth.ErrInvalidToken)
+	}
+
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return time.Time{}, fmt.Errorf("%w: no expiry", auth.ErrInvalidToken)
+	}
+	expiry := time.Unix(int64(exp), 0)
+	if now.After(expiry.Add(jwtClockSkew)) {
+		return time.Time{}, fmt.Errorf("%w: expired", auth.ErrInvalidToken)
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(jwtClockSkew).Before(time.Unix(int64(nbf), 0)) {
+		return time.Time{}, fmt.Errorf("%w: not valid yet", auth.ErrInvalidToken)
+	}
+	return expiry, nil
+}
+
+// signingKey returns the issuer's key with the given ID. The keys are fetched on first use and again
+// when a token names a key they lack, as issuers rotate keys, but at most once per
+// jwksRefreshInterval. Fetches happen without holding a.mu, so rate limiting is never held up.
+func (a *httpAuthenticator) signingKey(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	a.mu.Lock()
+	key, ok := a.keys[kid]
+	stale := time.Since(a.keysFetched) >= jwksRefreshInterval
+	a.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+	if !stale {
+		return nil, fmt.Errorf("%w: unknown signing key %q", auth.ErrInvalidToken, kid)
+	}
+
+	keys, err := a.fetchSigningKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	a.mu.Lock()
+	a.keys = keys
+	a.keysFetched = time.Now()
+	a.mu.Unlock()
+
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w: unknown signing key %q", auth.ErrInvalidToken, kid)
+}
+
+// fetchSigningKeys reads the issuer's JWKS document, found through its OpenID configuration, skipping
+// keys that are not for signatures or of a type it cannot use
+func (a *httpAuthenticator) fetchSigningKeys(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	a.mu.Lock()
+	jwksURL := a.jwksURL
+	a.mu.Unlock()
+	if jwksURL == "" {
+		var discovery struct {
+			JWKSURI string `json:"jwks_uri"`
+		}
+		if err := a.getJSON(ctx, a.config.OIDCIssuer+"/.well-known/openid-configuration", &discovery); err != nil {
+			return nil, fmt.Errorf("failed to fetch the OIDC configuration: %w", err)
+		}
+		if discovery.JWKSURI == "" {
+			return nil, fmt.Errorf("the OIDC issuer %s publishes no signing keys", a.config.OIDCIssuer)
+		}
+		jwksURL = discovery.JWKSURI
+		a.mu.Lock()
+		a.jwksURL = jwksURL
+		a.mu.Unlock()
+	}
+
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := a.getJSON(ctx, jwksURL, &jwks); err != nil {
+		return nil, fmt.Errorf("failed to fetch the OIDC signing keys: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey)
+	for _, k := range jwks.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		switch k.Kty {
+		case "RSA":
+			n, errN := base64.RawURLEncoding.DecodeString(k.N)
+			e, errE := base64.RawURLEncoding.DecodeString(k.E)
+			exponent := new(big.Int).SetBytes(e)
+			if errN != nil || errE != nil || !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+				continue
+			}
+			keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}
+		case "EC":
+			curves := map[string]elliptic.Curve{"P-256": elliptic.P256(), "P-384": elliptic.P384(), "P-521": elliptic.P521()}
+			curve, ok := curves[k.Crv]
+			x, errX := base64.RawURLEncoding.DecodeString(k.X)
+			y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+			if !ok || errX != nil || errY != nil {
+				continue
+			}
+			key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+			// ECDH rejects points that are not on the curve
+			if _, err := key.ECDH(); err != nil {
+				continue
+			}
+			keys[k.Kid] = key
+		}
+	}
+	return keys, nil
+}
+
+func (a *httpAuthenticator) getJSON(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,410 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// structuredOutputVersion is the first protocol version in which tools have output schemas and
+// return structured content
+const structuredOutputVersion = "2025-06-18"
+
+// toolOutputs names the result type of every tool. addTool announces the schema derived from it as
+// the tool's output schema.
+var toolOutputs = map[string]reflect.Type{
+	"get_sheet_data":                   reflect.TypeFor[sheetDataResult](),
+	"find_rows":                        reflect.TypeFor[findRowsResult](),
+	"query_sheet":                      reflect.TypeFor[queryResult](),
+	"aggregate_range":                  reflect.TypeFor[aggregateResult](),
+	"evaluate_formula":                 reflect.TypeFor[formulaResult](),
+	"get_used_range":                   reflect.TypeFor[usedRangeResult](),
+	"search_values":                    reflect.TypeFor[valueSearchResult](),
+	"get_sheet_formulas":               reflect.TypeFor[listResult[[]any]](),
+	"hash_range":                       reflect.TypeFor[hashResult](),
+	"update_cells":                     reflect.TypeFor[valuesUpdateResult](),
+	"batch_update_cells":               reflect.TypeFor[batchValuesUpdateResult](),
+	"add_rows":                         reflect.TypeFor[batchUpdateResult](),
+	"insert_rows_with_data":            reflect.TypeFor[insertRowsResult](),
+	"add_columns":                      reflect.TypeFor[batchUpdateResult](),
+	"reorder_columns":                  reflect.TypeFor[reorderColumnsResult](),
+	"get_headers":                      reflect.TypeFor[headersResult](),
+	"find_column":                      reflect.TypeFor[columnMatch](),
+	"normalize_headers":                reflect.TypeFor[headersResult](),
+	"list_sheets":                      reflect.TypeFor[listResult[string]](),
+	"create_sheet":                     reflect.TypeFor[sheetResult](),
+	"copy_sheet":                       reflect.TypeFor[copySheetResult](),
+	"rename_sheet":                     reflect.TypeFor[batchUpdateResult](),
+	"set_sheet_properties":             reflect.TypeFor[batchUpdateResult](),
+	"compare_with_file":                reflect.TypeFor[compareResult](),
+	"generate_change_digest":           reflect.TypeFor[digestResult](),
+	"list_revisions":                   reflect.TypeFor[revisionsResult](),
+	"get_revision":                     reflect.TypeFor[revisionResult](),
+	"create_spreadsheet":               reflect.TypeFor[createSpreadsheetResult](),
+	"delete_spreadsheet":               reflect.TypeFor[driveFileResult](),
+	"restore_spreadsheet":              reflect.TypeFor[driveFileResult](),
+	"star_spreadsheet":                 reflect.TypeFor[driveFileResult](),
+	"unstar_spreadsheet":               reflect.TypeFor[driveFileResult](),
+	"rename_spreadsheet":               reflect.TypeFor[driveFileResult](),
+	"move_spreadsheet":                 reflect.TypeFor[driveFileResult](),
+	"copy_spreadsheet":                 reflect.TypeFor[driveFileResult](),
+	"get_spreadsheet_metadata":         reflect.TypeFor[spreadsheetMetadata](),
+	"import_xlsx":                      reflect.TypeFor[driveFileResult](),
+	"list_spreadsheets":                reflect.TypeFor[spreadsheetListResult](),
+	"search_spreadsheets":              reflect.TypeFor[spreadsheetListResult](),
+	"export_spreadsheet":               reflect.TypeFor[exportResult](),
+	"list_shared_drives":               reflect.TypeFor[sharedDrivesResult](),
+	"create_snapshot":                  reflect.TypeFor[snapshotResult](),
+	"list_snapshots":                   reflect.TypeFor[snapshotsResult](),
+	"prune_snapshots":                  reflect.TypeFor[pruneSnapshotsResult](),
+	"share_spreadsheet":                reflect.TypeFor[shareResult](),
+	"list_permissions":                 reflect.TypeFor[permissionsResult](),
+	"remove_permission":                reflect.TypeFor[permissionEntry](),
+	"set_link_sharing":                 reflect.TypeFor[linkSharingResult](),
+	"revoke_all_external_access":       reflect.TypeFor[revokeResult](),
+	"list_group_members":               reflect.TypeFor[groupMembersResult](),
+	"get_multiple_sheet_data":          reflect.TypeFor[listResult[sheetQueryResult]](),
+	"get_ranges":                       reflect.TypeFor[sheetDataResult](),
+	"get_multiple_spreadsheet_summary": reflect.TypeFor[listResult[spreadsheetSummary]](),
+	"append_data":                      reflect.TypeFor[appendResult](),
+	"write_records":                    reflect.TypeFor[recordWriteResult](),
+	"append_records":                   reflect.TypeFor[recordWriteResult](),
+	"update_by_header":                 reflect.TypeFor[updateByHeaderResult](),
+	"consolidate_sheets":               reflect.TypeFor[consolidateResult](),
+	"clear_range":                      reflect.TypeFor[clearResult](),
+	"delete_sheet":                     reflect.TypeFor[batchUpdateResult](),
+	"duplicate_sheet":                  reflect.TypeFor[batchUpdateResult](),
+	"find_replace":                     reflect.TypeFor[findReplaceResult](),
+	"sort_range":                       reflect.TypeFor[sortResult](),
+	"copy_range":                       reflect.TypeFor[batchUpdateResult](),
+	"copy_range_across_spreadsheets":   reflect.TypeFor[copyAcrossResult](),
+	"set_dropdown_from_range":          reflect.TypeFor[dropdownResult](),
+	"auto_fill":                        reflect.TypeFor[batchUpdateResult](),
+	"add_data_source":                  reflect.TypeFor[dataSourceResult](),
+	"refresh_data_source":              reflect.TypeFor[refreshDataSourceResult](),
+	"delete_data_source":               reflect.TypeFor[dataSourceResult](),
+	"list_data_sources":                reflect.TypeFor[listResult[dataSourceResult]](),
+	"format_cells":                     reflect.TypeFor[batchUpdateResult](),
+	"merge_cells":                      reflect.TypeFor[batchUpdateResult](),
+	"unmerge_cells":                    reflect.TypeFor[batchUpdateResult](),
+	"get_merges":                       reflect.TypeFor[sheetRulesResult](),
+	"get_conditional_format_rules":     reflect.TypeFor[sheetRulesResult](),
+	"get_validation_rules":             reflect.TypeFor[sheetRulesResult](),
+	"clear_formatting":                 reflect.TypeFor[batchUpdateResult](),
+	"hide_sheet":                       reflect.TypeFor[batchUpdateResult](),
+	"unhide_sheet":                     reflect.TypeFor[batchUpdateResult](),
+	"get_audit_log":                    reflect.TypeFor[auditLogResult](),
+	"create_range_link":                reflect.TypeFor[signedLinkResult](),
+	"create_doc_summary":               reflect.TypeFor[docSummaryResult](),
+	"draft_email_with_export":          reflect.TypeFor[draftResult](),
+	"plan_operations":                  reflect.TypeFor[planResult](),
+	"cache_stats":                      reflect.TypeFor[cacheStatsResult](),
+	"session_stats":                    reflect.TypeFor[SessionStatsReport](),
+	"health_check":                     reflect.TypeFor[healthResult](),
+	"reauthenticate":                   reflect.TypeFor[reauthResult](),
+	"write_queue_stats":                reflect.TypeFor[WriteQueueStats](),
+}
+
+// listResult is the structured content of tools whose JSON text is an array, since structured
+// content has to be an object
+type listResult[T any] struct {
+	Items []T `json:"items"`
+}
+
+// structuredContent returns the structured form of an encoded JSON result: objects as they are and
+// arrays under items
+func structuredContent(encoded []byte) any {
+	if len(encoded) == 0 {
+		return nil
+	}
+	raw := json.RawMessage(append([]byte(nil), encoded...))
+	switch encoded[0] {
+	case '{':
+		return raw
+	case '[':
+		return map[string]any{"items": raw}
+	}
+	return nil
+}
+
+// withResultForm keeps a JSON result from being sent twice. Clients that know output schemas get the
+// structured content with a short text summary, and older clients only the JSON text. Results whose
+// text is another form, such as CSV, are sent as they are.
+func withResultForm(handler mcp.ToolHandler) mcp.ToolHandler {
+	return func(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		result, err := handler(ctx, request)
+		if err != nil || result == nil || !duplicatesText(result) {
+			return result, err
+		}
+		// The result may be shared with the result cache, so it is copied rather than changed
+		sent := *result
+		if !supportsStructuredOutput(request.Session) {
+			sent.StructuredContent = nil
+			return &sent, nil
+		}
+		sent.Content = []mcp.Content{&mcp.TextContent{Text: resultSummary(result)}}
+		return &sent, nil
+	}
+}
+
+// supportsStructuredOutput reports whether a session's client asked for a protocol version with
+// structured content; sessions that were never initialized are assumed to
+func supportsStructuredOutput(session *mcp.ServerSession) bool {
+	if session == nil || session.InitializeParams() == nil {
+		return true
+	}
+	return session.InitializeParams().ProtocolVersion >= structuredOutputVersion
+}
+
+// duplicatesText reports whether a result's only text is the JSON of its structured content
+func duplicatesText(result *mcp.CallToolResult) bool {
+	if len(result.Content) != 1 {
+		return false
+	}
+	text, ok := result.Content[0].(*mcp.TextContent)
+	if !ok {
+		return false
+	}
+	switch structured := result.StructuredContent.(type) {
+	case json.RawMessage:
+		return text.Text == string(structured)
+	case map[string]any:
+		items, ok := structured["items"].(json.RawMessage)
+		return ok && len(structured) == 1 && text.Text == string(items)
+	}
+	return false
+}
+
+// resultSummary describes a result in a line of text: the message of a failure, or the fields the
+// structured content holds
+func resultSummary(result *mcp.CallToolResult) string {
+	if result.IsError {
+		return errorMessage(result)
+	}
+	var fields map[string]json.RawMessage
+	switch structured := result.StructuredContent.(type) {
+	case json.RawMessage:
+		if err := json.Unmarshal(structured, &fields); err != nil {
+			return "The result is in the structured content"
+		}
+	case map[string]any:
+		var items []json.RawMessage
+		if raw, ok := structured["items"].(json.RawMessage); ok && json.Unmarshal(raw, &items) == nil {
+			return fmt.Sprintf("The result is in the structured content: %d items", len(items))
+		}
+	}
+	if len(fields) == 0 {
+		return "The result is in the structured content"
+	}
+	return "The result is in the structured content: " + strings.Join(slices.Sorted(maps.Keys(fields)), ", ")
+}
+
+// outputSchema derives the output schema of a tool from its result type, or returns nil for a tool
+// without one. Every property is optional and others may appear, because failed calls reply with
+// an error object instead, dry runs with the requests they would have sent, and verbose calls with
+// the raw Google API reply.
+func outputSchema(name string) *jsonschema.Schema {
+	t, ok := toolOutputs[name]
+	if !ok {
+		return nil
+	}
+	schema, err := jsonschema.ForType(t, &jsonschema.ForOptions{})
+	if err != nil {
+		panic(fmt.Sprintf("output schema of %s: %v", name, err))
+	}
+	loosenSchema(schema, true)
+	if schema.Properties == nil {
+		schema.Properties = map[string]*jsonschema.Schema{}
+	}
+	errorSchema, err := jsonschema.ForType(reflect.TypeFor[toolError](), &jsonschema.ForOptions{})
+	if err != nil {
+		panic(fmt.Sprintf("error schema: %v", err))
+	}
+	errorSchema.Description = "Why the call failed; no other result fields are set"
+	schema.Properties["error"] = errorSchema
+	return schema
+}
+
+// loosenSchema drops required properties and the ban on unknown ones throughout schema, and lets
+// nested arrays and objects be null, as nil slices and maps encode
+func loosenSchema(schema *jsonschema.Schema, top bool) {
+	if schema == nil {
+		return
+	}
+	schema.Required = nil
+	if schema.Type == "object" && schema.Properties != nil {
+		schema.AdditionalProperties = nil
+	}
+	if !top && (schema.Type == "array" || schema.Type == "object") {
+		schema.Types = []string{schema.Type, "null"}
+		schema.Type = ""
+	}
+	for _, property := range schema.Properties {
+		loosenSchema(property, false)
+	}
+	loosenSchema(schema.Items, false)
+	loosenSchema(schema.AdditionalProperties, false)
+}
+
+// valueRangeResult is the values of one range
+type valueRangeResult struct {
+	Range          string  `json:"range"`
+	MajorDimension string  `json:"majorDimension,omitempty"`
+	Values         [][]any `json:"values"`
+}
+
+// sheetDataResult is what get_sheet_data returns: the values of the range, or with as_records the
+// header keys and one record per row
+type sheetDataResult struct {
+	SpreadsheetID string             `json:"spreadsheetId"`
+	Range         string             `json:"range,omitempty"`
+	Headers       []string           `json:"headers,omitzero"`
+	Records       []map[string]any   `json:"records,omitzero"`
+	ValueRanges   []valueRangeResult `json:"valueRanges,omitzero"`
+	Pagination    *pageInfo          `json:"pagination,omitempty"`
+}
+
+// hashResult is the fingerprint of a range from hash_range
+type hashResult struct {
+	SpreadsheetID string `json:"spreadsheetId"`
+	Range         string `json:"range"`
+	Algorithm     string `json:"algorithm"`
+	Hash          string `json:"hash"`
+	Rows          int    `json:"rows"`
+	Cells         int    `json:"cells"`
+}
+
+// insertRowsResult reports the rows insert_rows_with_data inserted and filled
+type insertRowsResult struct {
+	SpreadsheetID string `json:"spreadsheetId"`
+	InsertedRows  int    `json:"insertedRows"`
+	UpdatedRange  string `json:"updatedRange"`
+	UpdatedCells  int64  `json:"updatedCells"`
+}
+
+// gridSize is the extent of a grid of values
+type gridSize struct {
+	Rows    int `json:"rows"`
+	Columns int `json:"columns"`
+}
+
+// cellDifference is a cell whose value differs between a file and a sheet
+type cellDifference struct {
+	Cell  string `json:"cell"`
+	File  string `json:"file"`
+	Sheet string `json:"sheet"`
+}
+
+// compareResult is what compare_with_file found
+type compareResult struct {
+	SpreadsheetID   string           `json:"spreadsheetId"`
+	Range           string           `json:"range"`
+	File            string           `json:"file"`
+	Match           bool             `json:"match"`
+	DifferenceCount int              `json:"differenceCount"`
+	Differences     []cellDifference `json:"differences"`
+	Truncated       bool             `json:"truncated"`
+	FileSize        gridSize         `json:"fileSize"`
+	SheetSize       gridSize         `json:"sheetSize"`
+}
+
+// createSpreadsheetResult identifies a new spreadsheet
+type createSpreadsheetResult struct {
+	SpreadsheetID string `json:"spreadsheetId"`
+	Title         string `json:"title"`
+	URL           string `json:"url"`
+	FolderID      string `json:"folderId,omitempty"`
+	Warning       string `json:"warning,omitempty"`
+}
+
+// sheetQueryResult is the outcome of one query of get_multiple_sheet_data
+type sheetQueryResult struct {
+	SpreadsheetID string  `json:"spreadsheet_id"`
+	Sheet         string  `json:"sheet"`
+	Range         string  `json:"range"`
+	Data          [][]any `json:"data,omitempty"`
+	Error         string  `json:"error,omitempty"`
+}
+
+// spreadsheetSummary is the title and sheets of one spreadsheet from get_multiple_spreadsheet_summary
+type spreadsheetSummary struct {
+	SpreadsheetID string         `json:"spreadsheet_id"`
+	Title         string         `json:"title,omitempty"`
+	Sheets        []sheetSummary `json:"sheets"`
+	Error         string         `json:"error,omitempty"`
+}
+
+// sheetSummary is the headers and first rows of one sheet
+type sheetSummary struct {
+	Title     string  `json:"title"`
+	SheetID   int64   `json:"sheet_id"`
+	Headers   []any   `json:"headers"`
+	FirstRows [][]any `json:"first_rows"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// rowMove is where sort_range moved one row
+type rowMove struct {
+	OriginalRow int64 `json:"originalRow"`
+	NewRow      int64 `json:"newRow"`
+}
+
+// sortResult reports a sorted range, and with capture_order the permutation the sort applied
+type sortResult struct {
+	SpreadsheetID      string    `json:"spreadsheetId"`
+	SortedRange        string    `json:"sortedRange,omitempty"`
+	ExcludedHeaderRows int64     `json:"excludedHeaderRows,omitempty"`
+	Permutation        []rowMove `json:"permutation,omitzero"`
+	OrderColumn        string    `json:"orderColumn,omitempty"`
+}
+
+// dropdownResult reports the dropdown set_dropdown_from_range created and the options it wrote
+type dropdownResult struct {
+	SpreadsheetID       string `json:"spreadsheetId"`
+	OptionsSheet        string `json:"optionsSheet"`
+	OptionsRange        string `json:"optionsRange"`
+	OptionsSheetCreated *bool  `json:"optionsSheetCreated,omitempty"`
+	OptionsWritten      *int   `json:"optionsWritten,omitempty"`
+	Validation          any    `json:"validation"`
+}
+
+// mergeResult is one merged range
+type mergeResult struct {
+	Range            string `json:"range"`
+	StartRowIndex    int64  `json:"startRowIndex"`
+	EndRowIndex      int64  `json:"endRowIndex"`
+	StartColumnIndex int64  `json:"startColumnIndex"`
+	EndColumnIndex   int64  `json:"endColumnIndex"`
+}
+
+// sheetRule is a conditional format rule, or a data validation rule with the cells it applies to
+type sheetRule struct {
+	Index        *int     `json:"index,omitempty"`
+	Ranges       []string `json:"ranges"`
+	BooleanRule  any      `json:"booleanRule,omitempty"`
+	GradientRule any      `json:"gradientRule,omitempty"`
+	Rule         any      `json:"rule,omitempty"`
+	Cells        int      `json:"cells,omitempty"`
+}
+
+// sheetRulesResult lists the merges, conditional format rules or validation rules of a sheet
+type sheetRulesResult struct {
+	SpreadsheetID string        `json:"spreadsheetId"`
+	Sheet         string        `json:"sheet"`
+	SheetID       int64         `json:"sheetId"`
+	Merges        []mergeResult `json:"merges,omitzero"`
+	Rules         []sheetRule   `json:"rules,omitzero"`
+}
+
+// cacheStatsResult is the state of the metadata and result caches
+type cacheStatsResult struct {
+	Metadata CacheStats `json:"metadata"`
+	Results  CacheStats `json:"results"`
+}
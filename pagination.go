@@ -0,0 +1,111 @@
+package main
+
+import "google.golang.org/api/sheets/v4"
+
+// pageInfo describes a page of rows: where it starts, how many rows it holds, and where the next one starts
+type pageInfo struct {
+	RowOffset    int `json:"rowOffset"`
+	ReturnedRows int `json:"returnedRows"`
+	// TotalRows is left out when only the page was read and it ends before the range does
+	TotalRows  *int  `json:"totalRows,omitempty"`
+	HasMore    bool  `json:"hasMore"`
+	NextOffset int   `json:"nextOffset,omitempty"`
+	Truncated  bool  `json:"truncated,omitempty"`
+	MaxCells   int64 `json:"maxCells,omitempty"`
+}
+
+// paginate returns the rows from offset on, stopping after limit rows (0 means no limit) or before the
+// page would hold more than maxCells cells (0 means no cap); at least one row is returned when any are
+// left, so a page always makes progress. The second result describes the page for the response.
+func paginate(rows [][]any, offset, limit int, maxCells int64) ([][]any, pageInfo) {
+	total := len(rows)
+	start := min(offset, total)
+	end := total
+	if limit > 0 {
+		end = min(start+limit, total)
+	}
+
+	truncated := false
+	if maxCells > 0 {
+		var cells int64
+		for i := start; i < end; i++ {
+			cells += int64(len(rows[i]))
+			if cells > maxCells && i > start {
+				end = i
+				truncated = true
+				break
+			}
+		}
+	}
+
+	info := pageInfo{
+		RowOffset:    start,
+		ReturnedRows: end - start,
+		TotalRows:    &total,
+		HasMore:      end < total,
+	}
+	if end < total {
+		info.NextOffset = end
+	}
+	if truncated {
+		info.Truncated = true
+		info.MaxCells = maxCells
+	}
+	return rows[start:end], info
+}
+
+// paginateWindow pages rows that were read from offset on rather than from the start of the range.
+// With a limit the read takes one row more than the page, which tells whether more rows follow; the
+// total is only known when the read reached the end of the range.
+func paginateWindow(rows [][]any, offset, limit int, maxCells int64) ([][]any, pageInfo) {
+	page, info := paginate(rows, 0, limit, maxCells)
+	info.RowOffset = offset
+	if info.HasMore {
+		info.NextOffset += offset
+	}
+	if limit > 0 && len(rows) > limit || len(rows) == 0 && offset > 0 {
+		info.TotalRows = nil
+	} else {
+		*info.TotalRows += offset
+	}
+	return page, info
+}
+
+// pageRange narrows an A1 range (without its sheet) to the rows of a page: the rows after the first
+// skip, and at most limit of them (0 for the rest of the range). ok is false for ranges it cannot
+// narrow, such as named ranges or a page past a bounded range's end, which are then read whole.
+func pageRange(rangeStr string, skip, limit int) (string, bool) {
+	gridRange := &sheets.GridRange{}
+	if rangeStr != "" {
+		var err error
+		if gridRange, err = parseGridRange(0, rangeStr); err != nil {
+			return "", false
+		}
+	}
+	end := gridRange.EndRowIndex
+	gridRange.StartRowIndex += int64(skip)
+	if limit > 0 && (end == 0 || gridRange.StartRowIndex+int64(limit) < end) {
+		gridRange.EndRowIndex = gridRange.StartRowIndex + int64(limit)
+	}
+	if end > 0 && gridRange.StartRowIndex >= end || gridRange.StartRowIndex >= maxA1Row {
+		return "", false
+	}
+	// A1 has no way to write "every column from row N on"
+	if gridRange.EndRowIndex == 0 && gridRange.EndColumnIndex == 0 {
+		return "", false
+	}
+	return gridRangeToA1(gridRange), true
+}
+
+// gridDataCells counts the cells of the grid data in a spreadsheet read with includeGridData
+func gridDataCells(spreadsheet *sheets.Spreadsheet) int64 {
+	var cells int64
+	for _, sheet := range spreadsheet.Sheets {
+		for _, data := range sheet.Data {
+			for _, row := range data.RowData {
+				cells += int64(len(row.Values))
+			}
+		}
+	}
+	return cells
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// operationCost is what one tool call costs in Google API requests
+type operationCost struct {
+	reads  int
+	writes int
+	drive  int
+	// sheetLookup marks tools that resolve sheet names to IDs first, which costs a read unless cached
+	sheetLookup bool
+	// merge names the batch endpoint that consecutive calls on the same spreadsheet can share
+	merge string
+}
+
+const (
+	mergeBatchUpdate  = "spreadsheets.batchUpdate"
+	mergeValuesUpdate = "values.batchUpdate"
+	mergeValuesGet    = "values.batchGet"
+	mergeValuesClear  = "values.batchClear"
+)
+
+var structuralWrite = operationCost{writes: 1, sheetLookup: true, merge: mergeBatchUpdate}
+
+var operationCosts = map[string]operationCost{
+	"get_sheet_data":                 {reads: 1, merge: mergeValuesGet},
+	"get_sheet_formulas":             {reads: 1},
+	"find_rows":                      {reads: 1},
+	"query_sheet":                    {reads: 1},
+	"get_ranges":                     {reads: 1},
+	"aggregate_range":                {reads: 1},
+	"evaluate_formula":               {reads: 1, writes: 2},
+	"get_used_range":                 {reads: 1, sheetLookup: true},
+	"hash_range":                     {reads: 1},
+	"list_sheets":                    {reads: 1},
+	"compare_with_file":              {reads: 1},
+	"get_merges":                     {reads: 1},
+	"get_conditional_format_rules":   {reads: 1},
+	"get_validation_rules":           {reads: 1},
+	"list_data_sources":              {reads: 1},
+	"generate_change_digest":         {reads: 2, drive: 2},
+	"update_cells":                   {writes: 1, merge: mergeValuesUpdate},
+	"batch_update_cells":             {writes: 1, merge: mergeValuesUpdate},
+	"append_data":                    {writes: 1},
+	"write_records":                  {reads: 1, writes: 1},
+	"append_records":                 {reads: 1, writes: 1},
+	"update_by_header":               {reads: 2, writes: 1},
+	"consolidate_sheets":             {reads: 3, writes: 2},
+	"copy_range_across_spreadsheets": {reads: 1, writes: 1},
+	"clear_range":                    {writes: 1, merge: mergeValuesClear},
+	"create_sheet":                   {writes: 1, merge: mergeBatchUpdate},
+	"copy_sheet":                     {writes: 2, sheetLookup: true},
+	"add_data_source":                {writes: 1, merge: mergeBatchUpdate},
+	"refresh_data_source":            {writes: 1, merge: mergeBatchUpdate},
+	"delete_data_source":             {writes: 1, merge: mergeBatchUpdate},
+	"create_spreadsheet":             {writes: 1},
+	"delete_spreadsheet":             {drive: 1},
+	"restore_spreadsheet":            {drive: 1},
+	"rename_spreadsheet":             {drive: 1},
+	"star_spreadsheet":               {drive: 1},
+	"unstar_spreadsheet":             {drive: 1},
+	"move_spreadsheet":               {drive: 2},
+	"copy_spreadsheet":               {drive: 1},
+	"import_xlsx":                    {drive: 1},
+	"get_spreadsheet_metadata":       {drive: 1},
+	"list_shared_drives":             {drive: 1},
+	"search_spreadsheets":            {drive: 1},
+	"list_spreadsheets":              {drive: 1},
+	"list_permissions":               {drive: 1},
+	"remove_permission":              {drive: 2},
+	"list_revisions":                 {drive: 1},
+	"get_revision":                   {drive: 2},
+	"create_doc_summary":             {reads: 2, drive: 2},
+	"export_spreadsheet":             {drive: 1},
+	"draft_email_with_export":        {drive: 3},
+	"create_snapshot":                {drive: 2},
+	"list_snapshots":                 {drive: 1},
+	"prune_snapshots":                {drive: 2},
+	"add_rows":                       structuralWrite,
+	"insert_rows_with_data":          {writes: 1, sheetLookup: true},
+	"add_columns":                    structuralWrite,
+	"reorder_columns":                {reads: 1, writes: 1, sheetLookup: true},
+	"normalize_headers":              {reads: 1, writes: 1},
+	"get_headers":                    {reads: 1},
+	"find_column":                    {reads: 1},
+	"rename_sheet":                   structuralWrite,
+	"set_sheet_properties":           structuralWrite,
+	"delete_sheet":                   structuralWrite,
+	"duplicate_sheet":                structuralWrite,
+	"find_replace":                   structuralWrite,
+	"sort_range":                     structuralWrite,
+	"copy_range":                     structuralWrite,
+	"set_dropdown_from_range":        structuralWrite,
+	"auto_fill":                      structuralWrite,
+	"format_cells":                   structuralWrite,
+	"merge_cells":                    structuralWrite,
+	"unmerge_cells":                  structuralWrite,
+	"clear_formatting":               structuralWrite,
+	"hide_sheet":                     structuralWrite,
+	"unhide_sheet":                   structuralWrite,
+	"list_group_members":             {},
+	"revoke_all_external_access":     {drive: 2},
+	"set_link_sharing":               {drive: 2},
+	"create_range_link":              {},
+	"cache_stats":                    {},
+	"write_queue_stats":              {},
+	"session_stats":                  {},
+	"health_check":                   {drive: 1},
+	"reauthenticate":                 {},
+	"get_audit_log":                  {reads: 1},
+	"plan_operations":                {},
+}
+
+// estimateCost returns the API cost of one tool call, accounting for arguments that change it
+func estimateCost(tool string, args map[string]any) (operationCost, bool) {
+	switch tool {
+	case "get_multiple_sheet_data":
+		// Queries are batched into one request per spreadsheet
+		queries, _ := args["queries"].([]any)
+		spreadsheets := make(map[any]bool)
+		for _, query := range queries {
+			if q, ok := query.(map[string]any); ok {
+				spreadsheets[q["spreadsheet_id"]] = true
+			}
+		}
+		return operationCost{reads: len(spreadsheets)}, true
+	case "search_values":
+		if parseArgument(args, "spreadsheet_id", "") != "" {
+			return operationCost{reads: 2}, true
+		}
+		return operationCost{reads: 2 * int(parseArgument(args, "max_spreadsheets", float64(20))), drive: 1}, true
+	case "get_multiple_spreadsheet_summary":
+		ids, _ := args["spreadsheet_ids"].([]any)
+		return operationCost{reads: 2 * len(ids)}, true
+	case "share_spreadsheet":
+		recipients, _ := args["recipients"].([]any)
+		return operationCost{drive: len(recipients)}, true
+	}
+
+	cost, ok := operationCosts[tool]
+	if !ok {
+		return operationCost{writes: 1}, false
+	}
+	// Grid data and sort permutations need extra requests and cannot share a batch
+	if tool == "get_sheet_data" && parseArgument(args, "include_grid_data", false) {
+		cost.merge = ""
+	}
+	if tool == "sort_range" && (parseArgument(args, "capture_order", false) || parseArgument(args, "keep_order_column", false)) {
+		cost = operationCost{reads: 1, writes: 2, sheetLookup: true}
+	}
+	return cost, true
+}
+
+type plannedOperation struct {
+	Tool      string         `json:"tool"`
+	Arguments map[string]any `json:"arguments"`
+}
+
+// planStep is one API round trip of the plan, possibly serving several operations
+type planStep struct {
+	SpreadsheetID string `json:"spreadsheetId,omitempty"`
+	Endpoint      string `json:"endpoint,omitempty"`
+	Operations    []int  `json:"operations"`
+	Reads         int    `json:"reads"`
+	Writes        int    `json:"writes"`
+	Drive         int    `json:"drive"`
+}
+
+type callCounts struct {
+	Reads  int `json:"reads"`
+	Writes int `json:"writes"`
+	Drive  int `json:"drive"`
+}
+
+// quotaBudget is the per-minute request budget a plan is checked against
+type quotaBudget struct {
+	ReadsPerMinute  int64 `json:"readsPerMinute"`
+	WritesPerMinute int64 `json:"writesPerMinute"`
+}
+
+// planResult is the plan for a list of operations and the calls it saves
+type planResult struct {
+	Operations    int         `json:"operations"`
+	Steps         []planStep  `json:"steps"`
+	OriginalCalls callCounts  `json:"originalCalls"`
+	PlannedCalls  callCounts  `json:"plannedCalls"`
+	SavedCalls    int         `json:"savedCalls"`
+	Budget        quotaBudget `json:"budget"`
+	Warnings      []string    `json:"warnings"`
+}
+
+func (s *SheetsMCPServer) handlePlanOperations(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	operationsRaw, ok := args["operations"]
+	if !ok {
+		return respondWithError("operations is required")
+	}
+
+	var operations []plannedOperation
+	if err := convertToType(operationsRaw, &operations); err != nil {
+		return respondWithError(fmt.Sprintf("invalid operations format: %v", err))
+	}
+
+	warnings := []string{}
+	costs := make([]operationCost, len(operations))
+	var original callCounts
+
+	// Operations on different spreadsheets commute, so they are grouped per spreadsheet in order
+	// of first appearance; within a spreadsheet the original order is always kept
+	var order []string
+	groups := map[string][]int{}
+	for i, op := range operations {
+		cost, known := estimateCost(op.Tool, op.Arguments)
+		if !known {
+			warnings = append(warnings, fmt.Sprintf("operation %d: unknown tool '%s', estimated as one write", i, op.Tool))
+		}
+		costs[i] = cost
+		original.Reads += cost.reads
+		original.Writes += cost.writes
+		original.Drive += cost.drive
+
+		target := mutationTarget(op.Arguments)
+		if _, seen := groups[target]; !seen {
+			order = append(order, target)
+		}
+		groups[target] = append(groups[target], i)
+	}
+
+	var steps []planStep
+	var planned callCounts
+	for _, spreadsheetID := range order {
+		needsLookup := false
+		var current *planStep
+		for _, i := range groups[spreadsheetID] {
+			cost := costs[i]
+			needsLookup = needsLookup || cost.sheetLookup
+
+			if current != nil && cost.merge != "" && current.Endpoint == cost.merge {
+				current.Operations = append(current.Operations, i)
+				continue
+			}
+
+			steps = append(steps, planStep{
+				SpreadsheetID: spreadsheetID,
+				Endpoint:      cost.merge,
+				Operations:    []int{i},
+				Reads:         cost.reads,
+				Writes:        cost.writes,
+				Drive:         cost.drive,
+			})
+			current = &steps[len(steps)-1]
+			planned.Reads += cost.reads
+			planned.Writes += cost.writes
+			planned.Drive += cost.drive
+		}
+
+		// Sheet names are resolved once per spreadsheet and then served from the metadata cache
+		if needsLookup && spreadsheetID != "" && !s.metadataCache.contains(s.profile, spreadsheetID) {
+			original.Reads++
+			planned.Reads++
+		}
+	}
+
+	for _, budget := range []struct {
+		kind   string
+		calls  int
+		perMin int64
+	}{
+		{"read", planned.Reads, s.config.ReadQuotaPerMinute},
+		{"write", planned.Writes, s.config.WriteQuotaPerMinute},
+	} {
+		if budget.perMin > 0 && int64(budget.calls) > budget.perMin {
+			minutes := (int64(budget.calls) + budget.perMin - 1) / budget.perMin
+			warnings = append(warnings, fmt.Sprintf("the plan needs %d %s requests but the budget is %d per minute; expect it to take at least %d minutes or split it",
+				budget.calls, budget.kind, budget.perMin, minutes))
+		}
+	}
+
+	response := planResult{
+		Operations:    len(operations),
+		Steps:         steps,
+		OriginalCalls: original,
+		PlannedCalls:  planned,
+		SavedCalls:    (original.Reads + original.Writes + original.Drive) - (planned.Reads + planned.Writes + planned.Drive),
+		Budget: quotaBudget{
+			ReadsPerMinute:  s.config.ReadQuotaPerMinute,
+			WritesPerMinute: s.config.WriteQuotaPerMinute,
+		},
+		Warnings: warnings,
+	}
+
+	return respondWithJSON(response)
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"gopkg.in/yaml.v3"
+)
+
+// profilesConfig is the PROFILES_FILE document: named Google accounts one server can act as
+type profilesConfig struct {
+	// Default is the profile used when a call names none; it may be left out when there is one profile
+	Default  string                        `yaml:"default"`
+	Profiles map[string]profileCredentials `yaml:"profiles"`
+}
+
+// profileCredentials are the credentials of one profile. They mean the same as the environment
+// variables of the same names, which they replace for that profile.
+type profileCredentials struct {
+	CredentialsConfig  string `yaml:"credentials_config"`
+	ServiceAccountPath string `yaml:"service_account_path"`
+	CredentialsPath    string `yaml:"credentials_path"`
+	// TokenPath defaults to token-<profile>.json so that OAuth profiles do not share a token
+	TokenPath string `yaml:"token_path"`
+}
+
+// loadProfiles reads and checks PROFILES_FILE, or returns nil when it is not set
+func loadProfiles(path string) (*profilesConfig, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PROFILES_FILE: %w", err)
+	}
+
+	var profiles profilesConfig
+	if err := yaml.Unmarshal(data, &profiles); err != nil {
+		return nil, fmt.Errorf("invalid PROFILES_FILE %s: %w", path, err)
+	}
+	if len(profiles.Profiles) == 0 {
+		return nil, fmt.Errorf("invalid PROFILES_FILE %s: no profiles are defined", path)
+	}
+	for _, name := range slices.Sorted(maps.Keys(profiles.Profiles)) {
+		credentials := profiles.Profiles[name]
+		if credentials.CredentialsConfig == "" && credentials.ServiceAccountPath == "" && credentials.CredentialsPath == "" {
+			return nil, fmt.Errorf("invalid PROFILES_FILE %s: profile %s needs credentials_config, service_account_path, or credentials_path", path, name)
+		}
+	}
+	if profiles.Default == "" {
+		if len(profiles.Profiles) > 1 {
+			return nil, fmt.Errorf("invalid PROFILES_FILE %s: default is required when there is more than one profile", path)
+		}
+		for name := range profiles.Profiles {
+			profiles.Default = name
+		}
+	}
+	if _, ok := profiles.Profiles[profiles.Default]; !ok {
+		return nil, fmt.Errorf("invalid PROFILES_FILE %s: the default profile %s is not defined", path, profiles.Default)
+	}
+	return &profiles, nil
+}
+
+// forProfile returns a copy of the auth settings that authenticates with a profile's credentials
+// instead of those from the environment
+func (ac *AuthConfig) forProfile(name string, credentials profileCredentials) *AuthConfig {
+	profile := *ac
+	profile.Profile = name
+	profile.CredentialsConfig = credentials.CredentialsConfig
+	profile.ServiceAccountPath = credentials.ServiceAccountPath
+	profile.CredentialsPath = credentials.CredentialsPath
+	profile.TokenPath = credentials.TokenPath
+	if profile.TokenPath == "" {
+		profile.TokenPath = fmt.Sprintf("token-%s.json", name)
+	}
+	return &profile
+}
+
+// addProfiles creates a server for every profile other than the default one, which s already is. The
+// profile servers have their own API clients and share everything else with s, including the metadata
+// and result caches, whose entries are kept apart by profile.
+func (s *SheetsMCPServer) addProfiles(ctx context.Context, authConfig *AuthConfig, profiles *profilesConfig) error {
+	s.profiles = map[string]*SheetsMCPServer{s.profile: s}
+	for _, name := range slices.Sorted(maps.Keys(profiles.Profiles)) {
+		if name == s.profile {
+			continue
+		}
+		profileAuth := authConfig.forProfile(name, profiles.Profiles[name])
+		services, err := profileAuth.CreateServices(ctx, s.config)
+		if err != nil {
+			return fmt.Errorf("failed to create services for profile %s: %w", name, err)
+		}
+
+		p := *s
+		p.profile = name
+		p.sheetsService = services.Sheets
+		p.driveService = services.Drive
+		p.activityService = services.Activity
+		p.directoryService = services.Directory
+		p.docsService = services.Docs
+		p.gmailService = services.Gmail
+		p.httpClient = services.HTTPClient
+		p.driveUnavailable = ""
+		p.serviceAccountEmail = services.ServiceAccountEmail
+		p.tokenSource = services.TokenSource
+		p.authMethod = services.AuthMethod
+		p.spreadsheetList = newSpreadsheetListCache()
+		p.profileTools = nil
+		p.checkDriveAccess(ctx)
+		s.profiles[name] = &p
+	}
+	return nil
+}
+
+// profileTool is a tool a profile server has wrapped and is waiting to be registered behind the router
+type profileTool struct {
+	tool    *mcp.Tool
+	handler mcp.ToolHandler
+}
+
+// registerProfileTools registers every tool any profile offers, with a profile argument choosing the
+// account that runs the call
+func (s *SheetsMCPServer) registerProfileTools() {
+	names := slices.Sorted(maps.Keys(s.profiles))
+	// The default profile comes first so that the tool list keeps its usual order
+	profileOrder := []string{s.profile}
+	for _, name := range names {
+		if name != s.profile {
+			s.profiles[name].registerTools()
+			profileOrder = append(profileOrder, name)
+		}
+	}
+
+	tools := map[string]*mcp.Tool{}
+	handlers := map[string]map[string]mcp.ToolHandler{}
+	var order []string
+	for _, name := range profileOrder {
+		for _, registered := range s.profiles[name].profileTools {
+			toolName := registered.tool.Name
+			if handlers[toolName] == nil {
+				tools[toolName] = registered.tool
+				handlers[toolName] = map[string]mcp.ToolHandler{}
+				order = append(order, toolName)
+			}
+			handlers[toolName][name] = registered.handler
+		}
+	}
+
+	for _, toolName := range order {
+		tool := tools[toolName]
+		if schema, ok := tool.InputSchema.(map[string]any); ok {
+			if props, ok := schema["properties"].(map[string]any); ok {
+				props["profile"] = map[string]any{"type": "string", "description": fmt.Sprintf("The account profile to act as (default: %s)", s.profile), "enum": names}
+			}
+		}
+		s.mcpServer.AddTool(tool, s.withProfile(toolName, handlers[toolName]))
+	}
+}
+
+// withProfile hands a call to the profile server it names, or to the profile its session is bound
+// to, or to the default profile
+func (s *SheetsMCPServer) withProfile(name string, handlers map[string]mcp.ToolHandler) mcp.ToolHandler {
+	return func(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArgsFromRequest(request)
+		if err != nil {
+			return respondWithError(err.Error())
+		}
+		requested, ok := args["profile"].(string)
+		if _, present := args["profile"]; present && !ok {
+			return respondWithToolError(toolError{Code: errorInvalidArgument, Message: "profile must be a string"})
+		}
+
+		if tenant := s.callerTenant(ctx, request.Extra); tenant != nil && tenant.Profile != "" {
+			if requested != "" && requested != tenant.Profile {
+				return respondWithToolError(toolError{Code: errorPermission, Message: fmt.Sprintf("this session is bound to profile %s", tenant.Profile)})
+			}
+			requested = tenant.Profile
+		}
+
+		p, err := s.profileServer(requested)
+		if err != nil {
+			return respondWithToolError(toolError{Code: errorInvalidArgument, Message: err.Error()})
+		}
+		handler, ok := handlers[p.profile]
+		if !ok {
+			return respondWithToolError(toolError{Code: errorInvalidRequest, Message: fmt.Sprintf("%s is not available in profile %s", name, p.profile)})
+		}
+		return handler(ctx, request)
+	}
+}
+
+// profileServer returns the server acting as the named profile, or the default one for an empty name
+func (s *SheetsMCPServer) profileServer(name string) (*SheetsMCPServer, error) {
+	if name == "" || name == s.profile {
+		return s, nil
+	}
+	if p, ok := s.profiles[name]; ok {
+		return p, nil
+	}
+	if s.profiles == nil {
+		return nil, fmt.Errorf("unknown profile %s: PROFILES_FILE is not set", name)
+	}
+	return nil, fmt.Errorf("unknown profile %s: must be one of %s", name, joinWords(slices.Sorted(maps.Keys(s.profiles)), "or"))
+}
+
+// sessionProfile returns the server acting as the profile a session reading a resource is bound to
+func (s *SheetsMCPServer) sessionProfile(ctx context.Context, extra *mcp.RequestExtra) (*SheetsMCPServer, error) {
+	tenant := s.callerTenant(ctx, extra)
+	if tenant == nil {
+		return s, nil
+	}
+	return s.profileServer(tenant.Profile)
+}
+
+// withSessionProfile reads a resource as the profile the session is bound to
+func (s *SheetsMCPServer) withSessionProfile(handler func(*SheetsMCPServer, context.Context, *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error)) mcp.ResourceHandler {
+	return func(ctx context.Context, request *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+		p, err := s.sessionProfile(ctx, request.Extra)
+		if err != nil {
+			return nil, err
+		}
+		return handler(p, ctx, request)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// progressInterval is the least time between two progress notifications of one call; the last step
+// is always sent
+const progressInterval = 250 * time.Millisecond
+
+// progressReporter sends MCP progress notifications for a tool call whose client asked for them with a
+// progress token. A nil reporter, returned when the client did not ask, reports nothing.
+type progressReporter struct {
+	session *mcp.ServerSession
+	token   any
+	total   float64
+
+	mu       sync.Mutex
+	progress float64
+	lastSent time.Time
+}
+
+// newProgressReporter returns a reporter for the call, with total as the amount of work when it is
+// known ahead and 0 when it is not
+func newProgressReporter(request *mcp.CallToolRequest, total float64) *progressReporter {
+	if request == nil || request.Session == nil || request.Params == nil {
+		return nil
+	}
+	token := request.Params.GetProgressToken()
+	if token == nil {
+		return nil
+	}
+	return &progressReporter{session: request.Session, token: token, total: total}
+}
+
+// add records that n more units of work are done and tells the client, with message describing what
+// was just done. It is safe to call from several goroutines.
+func (p *progressReporter) add(ctx context.Context, n float64, message string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.progress += n
+	p.notifyLocked(ctx, message)
+}
+
+// set records how much of the work is done, for work whose progress is reported as a running total
+func (p *progressReporter) set(ctx context.Context, progress float64, message string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.progress = max(p.progress, progress)
+	p.notifyLocked(ctx, message)
+}
+
+// notifyLocked sends the current progress unless one was sent within progressInterval, and unlocks
+// the reporter before sending
+func (p *progressReporter) notifyLocked(ctx context.Context, message string) {
+	progress := p.progress
+	done := p.total > 0 && progress >= p.total
+	if !done && time.Since(p.lastSent) < progressInterval {
+		p.mu.Unlock()
+		return
+	}
+	p.lastSent = time.Now()
+	p.mu.Unlock()
+
+	err := p.session.NotifyProgress(ctx, &mcp.ProgressNotificationParams{
+		ProgressToken: p.token,
+		Progress:      progress,
+		Total:         p.total,
+		Message:       message,
+	})
+	if err != nil {
+		slog.Debug("failed to send progress notification", "error", err)
+	}
+}
+
+// progressReader reports the bytes read through it, for downloads whose length may not be known
+type progressReader struct {
+	ctx      context.Context
+	reader   io.Reader
+	progress *progressReporter
+	message  func(read int64) string
+	read     int64
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.read += int64(n)
+		r.progress.set(r.ctx, float64(r.read), r.message(r.read))
+	}
+	return n, err
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// workflowPrompt is a prompt that walks the model through a sequence of tool calls
+type workflowPrompt struct {
+	prompt *mcp.Prompt
+	// tools are the tools the workflow cannot do without; the prompt is only offered when all of
+	// them are registered
+	tools []string
+	// render writes the instructions for the given arguments
+	render func(s *SheetsMCPServer, args map[string]string) string
+}
+
+var workflowPrompts = []workflowPrompt{
+	{
+		prompt: &mcp.Prompt{
+			Name:        "summarize_spreadsheet",
+			Title:       "Summarize a spreadsheet",
+			Description: "Survey every sheet of a spreadsheet and summarize what it holds",
+			Arguments: []*mcp.PromptArgument{
+				{Name: "spreadsheet_id", Description: "The ID or URL of the spreadsheet", Required: true},
+				{Name: "focus", Description: "What the summary should pay most attention to"},
+			},
+		},
+		tools: []string{"list_sheets", "get_used_range", "get_headers", "get_sheet_data", "aggregate_range"},
+		render: func(s *SheetsMCPServer, args map[string]string) string {
+			var b strings.Builder
+			fmt.Fprintf(&b, "Summarize the Google Spreadsheet %s.\n\n", args["spreadsheet_id"])
+			b.WriteString("1. Call list_sheets to find its sheets.\n")
+			b.WriteString("2. For each sheet, call get_used_range to see how much data it holds and get_headers to learn its columns. Skip sheets that are empty.\n")
+			b.WriteString("3. Read a sample of each sheet with get_sheet_data, using row_limit of about 20 and as_records, rather than reading whole sheets.\n")
+			b.WriteString("4. For numeric columns, call aggregate_range with sum, avg, min and max, and group_by a category column when there is an obvious one.\n")
+			b.WriteString("5. Write the summary: what each sheet is for, its size, its key columns, notable figures, and any data quality problems you noticed such as blank headers or mixed types.\n")
+			if focus := args["focus"]; focus != "" {
+				fmt.Fprintf(&b, "\nPay most attention to: %s\n", focus)
+			}
+			b.WriteString("\nDo not modify the spreadsheet.")
+			return b.String()
+		},
+	},
+	{
+		prompt: &mcp.Prompt{
+			Name:        "clean_and_dedupe_sheet",
+			Title:       "Clean and dedupe a sheet",
+			Description: "Tidy the headers and values of a sheet and remove duplicate rows, after confirming the plan",
+			Arguments: []*mcp.PromptArgument{
+				{Name: "spreadsheet_id", Description: "The ID or URL of the spreadsheet", Required: true},
+				{Name: "sheet", Description: "The name of the sheet to clean", Required: true},
+				{Name: "key_columns", Description: "Comma-separated headers that identify a row; rows agreeing on all of them are duplicates (default: every column)"},
+			},
+		},
+		tools: []string{"get_headers", "normalize_headers", "get_used_range", "get_sheet_data", "update_cells", "clear_range"},
+		render: func(s *SheetsMCPServer, args map[string]string) string {
+			var b strings.Builder
+			fmt.Fprintf(&b, "Clean up the sheet %q of the Google Spreadsheet %s and remove duplicate rows.\n\n", args["sheet"], args["spreadsheet_id"])
+			step := 1
+			if s.toolAvailable("create_snapshot") {
+				fmt.Fprintf(&b, "%d. Call create_snapshot first so the original can be restored.\n", step)
+				step++
+			}
+			fmt.Fprintf(&b, "%d. Call get_headers. If headers are blank, repeated or inconsistently named, call normalize_headers with dry_run true and include its proposed renames in your plan.\n", step)
+			fmt.Fprintf(&b, "%d. Call get_used_range, then read the data rows with get_sheet_data using value_render_option UNFORMATTED_VALUE so numbers and dates are not reformatted.\n", step+1)
+			fmt.Fprintf(&b, "%d. Work out the cleaned rows: trim surrounding whitespace, make the spelling of repeated values consistent, and drop rows that are entirely blank. ", step+2)
+			if keys := args["key_columns"]; keys != "" {
+				fmt.Fprintf(&b, "Treat rows that agree on %s as duplicates and keep the first of each.\n", keys)
+			} else {
+				b.WriteString("Treat rows that agree on every column as duplicates and keep the first of each.\n")
+			}
+			fmt.Fprintf(&b, "%d. Before changing anything, show the plan: header renames, how many values change, and how many rows are removed with a few examples. Wait for the user to approve it.\n", step+3)
+			fmt.Fprintf(&b, "%d. Once approved, call normalize_headers if needed, write the cleaned rows back from the first data row with update_cells, and clear_range the rows left over below them.\n", step+4)
+			fmt.Fprintf(&b, "%d. Report what changed.\n", step+5)
+			b.WriteString("\nSheet names with spaces or punctuation are quoted in A1 ranges, e.g. 'Q1 Sales'!A2:F100.")
+			return b.String()
+		},
+	},
+	{
+		prompt: &mcp.Prompt{
+			Name:        "build_report_from_data",
+			Title:       "Build a report from data",
+			Description: "Aggregate the data in a sheet and write the results to a report sheet",
+			Arguments: []*mcp.PromptArgument{
+				{Name: "spreadsheet_id", Description: "The ID or URL of the spreadsheet", Required: true},
+				{Name: "sheet", Description: "The name of the sheet holding the data", Required: true},
+				{Name: "goal", Description: "What the report should show, e.g. monthly revenue by region"},
+				{Name: "report_sheet", Description: "The name of the sheet to write the report to (default: Report)"},
+			},
+		},
+		tools: []string{"get_headers", "query_sheet", "aggregate_range", "list_sheets", "create_sheet", "update_cells", "format_cells"},
+		render: func(s *SheetsMCPServer, args map[string]string) string {
+			reportSheet := args["report_sheet"]
+			if reportSheet == "" {
+				reportSheet = "Report"
+			}
+			var b strings.Builder
+			fmt.Fprintf(&b, "Build a report from the data in the sheet %q of the Google Spreadsheet %s", args["sheet"], args["spreadsheet_id"])
+			if goal := args["goal"]; goal != "" {
+				fmt.Fprintf(&b, " showing %s", goal)
+			}
+			fmt.Fprintf(&b, ", and write it to the sheet %q.\n\n", reportSheet)
+			b.WriteString("1. Call get_headers to learn the columns, and decide which columns to group by and which to total. If the goal is unclear, propose a layout and ask before continuing.\n")
+			b.WriteString("2. Compute the figures with aggregate_range, using group_by for breakdowns, and query_sheet when only some rows count. Let the server do the arithmetic rather than reading every row.\n")
+			fmt.Fprintf(&b, "3. Call list_sheets. If %q does not exist, create it with create_sheet; if it does, ask before overwriting it.\n", reportSheet)
+			fmt.Fprintf(&b, "4. Write the report to %q with update_cells: a title, the date range or filters it covers, then a table with one header row.\n", reportSheet)
+			b.WriteString("5. Make the header row bold with format_cells.\n")
+			b.WriteString("6. Summarize the main findings in your reply.")
+			return b.String()
+		},
+	},
+}
+
+// registerPrompts offers the workflow prompts whose tools are all registered
+func (s *SheetsMCPServer) registerPrompts() {
+	for _, workflow := range workflowPrompts {
+		available := true
+		for _, tool := range workflow.tools {
+			if !s.toolAvailable(tool) {
+				available = false
+				break
+			}
+		}
+		if !available {
+			continue
+		}
+		s.mcpServer.AddPrompt(workflow.prompt, s.promptHandler(workflow))
+	}
+}
+
+func (s *SheetsMCPServer) promptHandler(workflow workflowPrompt) mcp.PromptHandler {
+	return func(ctx context.Context, request *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		args := make(map[string]string, len(request.Params.Arguments))
+		for key, value := range request.Params.Arguments {
+			args[key] = strings.TrimSpace(value)
+		}
+
+		var missing []string
+		for _, argument := range workflow.prompt.Arguments {
+			if argument.Required && args[argument.Name] == "" {
+				missing = append(missing, argument.Name)
+			}
+		}
+		switch len(missing) {
+		case 0:
+		case 1:
+			return nil, fmt.Errorf("%s is required", missing[0])
+		default:
+			return nil, fmt.Errorf("%s are required", joinWords(missing, "and"))
+		}
+
+		if id, _, _, ok := parseSpreadsheetURL(args["spreadsheet_id"]); ok {
+			args["spreadsheet_id"] = id
+		}
+
+		return &mcp.GetPromptResult{
+			Description: workflow.prompt.Description,
+			Messages: []*mcp.PromptMessage{
+				{Role: "user", Content: &mcp.TextContent{Text: workflow.render(s, args)}},
+			},
+		}, nil
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// configuredHeaderRows returns how many leading rows must not be modified by a destructive call,
+// taking the per-call protect_headers argument over the server-wide PROTECT_HEADER_ROWS setting
+func (s *SheetsMCPServer) configuredHeaderRows(args map[string]any) (int64, bool) {
+	if v, ok := args["protect_headers"].(float64); ok {
+		return max(0, int64(v)), true
+	}
+	return s.config.ProtectHeaderRows, false
+}
+
+// protectedHeaderRows is configuredHeaderRows for one sheet: unless the call sets protect_headers, the
+// sheet's frozen rows are protected too, since that is how most sheets mark their headers
+func (s *SheetsMCPServer) protectedHeaderRows(ctx context.Context, args map[string]any, spreadsheetID, sheet string) (int64, error) {
+	rows, explicit := s.configuredHeaderRows(args)
+	if explicit {
+		return rows, nil
+	}
+	frozen, err := s.frozenRowCount(ctx, spreadsheetID, sheet)
+	if err != nil {
+		return 0, err
+	}
+	return max(rows, frozen), nil
+}
+
+// a1StartRow returns the 0-based first row of an A1 range such as "B3:D10"; whole-column ranges like "A:C" start at row 0
+func a1StartRow(rangeStr string) int64 {
+	start, _, _ := strings.Cut(rangeStr, ":")
+	start = strings.ReplaceAll(start, "$", "")
+
+	i := 0
+	for i < len(start) && (start[i] < '0' || start[i] > '9') {
+		i++
+	}
+
+	var row int64
+	for ; i < len(start) && start[i] >= '0' && start[i] <= '9'; i++ {
+		row = row*10 + int64(start[i]-'0')
+	}
+	if row == 0 {
+		return 0
+	}
+	return row - 1
+}
+
+// headerViolation describes why an operation starting at startRow would touch protected header rows
+func headerViolation(operation string, startRow, headerRows int64) error {
+	if headerRows == 0 || startRow >= headerRows {
+		return nil
+	}
+	return fmt.Errorf("%s would modify protected header row(s) 1-%d; start the range at row %d or pass protect_headers: 0", operation, headerRows, headerRows+1)
+}
+
+// frozenRowCount returns how many rows are frozen at the top of a sheet, which is how most sheets mark their headers
+func (s *SheetsMCPServer) frozenRowCount(ctx context.Context, spreadsheetID, sheet string) (int64, error) {
+	props, err := s.getSheetProperties(ctx, spreadsheetID)
+	if err != nil {
+		return 0, err
+	}
+	for _, p := range props {
+		if p.Title == sheet {
+			if p.GridProperties == nil {
+				return 0, nil
+			}
+			return p.GridProperties.FrozenRowCount, nil
+		}
+	}
+	return 0, fmt.Errorf("sheet '%s' not found", sheet)
+}
+
+// excludeFrozenRows moves the start of gridRange past the sheet's frozen header rows and reports how many rows it skipped
+func (s *SheetsMCPServer) excludeFrozenRows(ctx context.Context, spreadsheetID, sheet string, gridRange *sheets.GridRange) (int64, error) {
+	frozen, err := s.frozenRowCount(ctx, spreadsheetID, sheet)
+	if err != nil {
+		return 0, err
+	}
+	if gridRange.StartRowIndex >= frozen {
+		return 0, nil
+	}
+	if gridRange.EndRowIndex != 0 && gridRange.EndRowIndex <= frozen {
+		return 0, fmt.Errorf("range only covers frozen header rows 1-%d; pass include_headers: true to use them", frozen)
+	}
+
+	skipped := frozen - gridRange.StartRowIndex
+	gridRange.StartRowIndex = frozen
+	return skipped, nil
+}
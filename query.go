@@ -0,0 +1,886 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// query_sheet runs a small SQL-like language over the values of one sheet, for example
+//
+//	SELECT Region, sum(Amount) AS Total WHERE Status = 'paid' AND Amount > 100
+//	GROUP BY Region ORDER BY Total DESC LIMIT 10
+//
+// Columns are header names, in backquotes when they are not single words, or column letters. Every
+// clause is optional and they must come in the order above; a query without SELECT returns every column.
+
+type queryTokenKind int
+
+const (
+	tokenEnd queryTokenKind = iota
+	tokenWord
+	tokenColumn
+	tokenString
+	tokenNumber
+	tokenSymbol
+)
+
+type queryToken struct {
+	kind queryTokenKind
+	text string
+	num  float64
+}
+
+// is reports whether the token is the given symbol, or the given keyword ignoring case
+func (t queryToken) is(text string) bool {
+	return (t.kind == tokenSymbol || t.kind == tokenWord) && strings.EqualFold(t.text, text)
+}
+
+func (t queryToken) String() string {
+	if t.kind == tokenEnd {
+		return "end of query"
+	}
+	return "'" + t.text + "'"
+}
+
+var querySymbols = []string{"<=", ">=", "!=", "<>", "=", "<", ">", ",", "(", ")", "*", "-"}
+
+// lexQuery splits a query into tokens. Strings are in single or double quotes and column names in
+// backquotes; a doubled quote inside stands for itself.
+func lexQuery(input string) ([]queryToken, error) {
+	var tokens []queryToken
+	runes := []rune(input)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '\'' || r == '"' || r == '`':
+			var text strings.Builder
+			j := i + 1
+			for ; j < len(runes); j++ {
+				if runes[j] == r {
+					if j+1 < len(runes) && runes[j+1] == r {
+						text.WriteRune(r)
+						j++
+						continue
+					}
+					break
+				}
+				text.WriteRune(runes[j])
+			}
+			if j == len(runes) {
+				return nil, fmt.Errorf("unterminated %c quote", r)
+			}
+			kind := tokenString
+			if r == '`' {
+				kind = tokenColumn
+			}
+			tokens = append(tokens, queryToken{kind: kind, text: text.String()})
+			i = j + 1
+		case unicode.IsDigit(r) || r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1]):
+			j := i
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.') {
+				j++
+			}
+			text := string(runes[i:j])
+			n, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number '%s'", text)
+			}
+			tokens = append(tokens, queryToken{kind: tokenNumber, text: text, num: n})
+			i = j
+		case unicode.IsLetter(r) || r == '_':
+			j := i
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_') {
+				j++
+			}
+			tokens = append(tokens, queryToken{kind: tokenWord, text: string(runes[i:j])})
+			i = j
+		default:
+			rest := string(runes[i:])
+			found := false
+			for _, symbol := range querySymbols {
+				if strings.HasPrefix(rest, symbol) {
+					tokens = append(tokens, queryToken{kind: tokenSymbol, text: symbol})
+					i += len([]rune(symbol))
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("unexpected character '%c'", r)
+			}
+		}
+	}
+	return append(tokens, queryToken{kind: tokenEnd}), nil
+}
+
+// queryItem is a selected column or an aggregate over one; column is -1 for count(*)
+type queryItem struct {
+	aggregate string
+	column    int
+	label     string
+}
+
+type queryOrder struct {
+	item queryItem
+	desc bool
+}
+
+// queryCondition is a compiled WHERE clause and queryOperand a value it compares
+type queryCondition func(row []any) bool
+type queryOperand func(row []any) any
+
+type sheetQuery struct {
+	all     bool
+	items   []queryItem
+	where   queryCondition
+	groupBy []int
+	orderBy []queryOrder
+	limit   int
+	offset  int
+}
+
+var queryAggregates = []string{"count", "sum", "avg", "min", "max"}
+
+type queryParser struct {
+	tokens    []queryToken
+	pos       int
+	headers   []string
+	matchCase bool
+	aliases   map[string]queryItem
+}
+
+// parseQuery compiles a query against the headers of the sheet it will run over
+func parseQuery(input string, headers []string, matchCase bool) (*sheetQuery, error) {
+	tokens, err := lexQuery(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &queryParser{tokens: tokens, headers: headers, matchCase: matchCase, aliases: make(map[string]queryItem)}
+	q := &sheetQuery{all: true, limit: -1}
+
+	if p.keyword("SELECT") {
+		if p.peek().is("*") {
+			p.next()
+		} else {
+			q.all = false
+			for {
+				item, err := p.parseItem()
+				if err != nil {
+					return nil, err
+				}
+				if p.keyword("AS") {
+					alias := p.next()
+					if alias.kind != tokenWord && alias.kind != tokenColumn && alias.kind != tokenString {
+						return nil, fmt.Errorf("expected a name after AS, got %s", alias)
+					}
+					item.label = alias.text
+					p.aliases[strings.ToLower(alias.text)] = item
+				}
+				q.items = append(q.items, item)
+				if !p.peek().is(",") {
+					break
+				}
+				p.next()
+			}
+		}
+	}
+
+	if p.keyword("WHERE") {
+		if q.where, err = p.parseOr(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.keyword("GROUP") {
+		if !p.keyword("BY") {
+			return nil, fmt.Errorf("expected BY after GROUP, got %s", p.peek())
+		}
+		for {
+			column, _, err := p.parseColumn(p.next())
+			if err != nil {
+				return nil, err
+			}
+			q.groupBy = append(q.groupBy, column)
+			if !p.peek().is(",") {
+				break
+			}
+			p.next()
+		}
+	}
+
+	if p.keyword("ORDER") {
+		if !p.keyword("BY") {
+			return nil, fmt.Errorf("expected BY after ORDER, got %s", p.peek())
+		}
+		for {
+			var term queryOrder
+			if alias, ok := p.aliases[strings.ToLower(p.peek().text)]; ok && p.peek().kind != tokenString {
+				p.next()
+				term.item = alias
+			} else if term.item, err = p.parseItem(); err != nil {
+				return nil, err
+			}
+			if p.keyword("DESC") {
+				term.desc = true
+			} else {
+				p.keyword("ASC")
+			}
+			q.orderBy = append(q.orderBy, term)
+			if !p.peek().is(",") {
+				break
+			}
+			p.next()
+		}
+	}
+
+	if p.keyword("LIMIT") {
+		if q.limit, err = p.parseCount("LIMIT"); err != nil {
+			return nil, err
+		}
+	}
+	if p.keyword("OFFSET") {
+		if q.offset, err = p.parseCount("OFFSET"); err != nil {
+			return nil, err
+		}
+	}
+
+	if tok := p.peek(); tok.kind != tokenEnd {
+		return nil, fmt.Errorf("unexpected %s", tok)
+	}
+	return q, q.validate()
+}
+
+// validate checks that a grouped query only selects grouped columns and aggregates
+func (q *sheetQuery) validate() error {
+	if !q.grouped() {
+		for _, term := range q.orderBy {
+			if term.item.aggregate != "" {
+				return fmt.Errorf("cannot ORDER BY %s without GROUP BY or an aggregate in SELECT", term.item.label)
+			}
+		}
+		return nil
+	}
+	if q.all {
+		return fmt.Errorf("SELECT * cannot be combined with GROUP BY or aggregates")
+	}
+	for _, item := range q.items {
+		if item.aggregate == "" && !slices.Contains(q.groupBy, item.column) {
+			return fmt.Errorf("%s must be in GROUP BY or inside an aggregate", item.label)
+		}
+	}
+	for _, term := range q.orderBy {
+		if q.outputIndex(term.item) < 0 {
+			return fmt.Errorf("ORDER BY %s must be one of the selected columns when grouping", term.item.label)
+		}
+	}
+	return nil
+}
+
+func (q *sheetQuery) grouped() bool {
+	if len(q.groupBy) > 0 {
+		return true
+	}
+	return slices.ContainsFunc(q.items, func(item queryItem) bool { return item.aggregate != "" })
+}
+
+// outputIndex finds the selected column an ORDER BY term refers to
+func (q *sheetQuery) outputIndex(item queryItem) int {
+	return slices.IndexFunc(q.items, func(selected queryItem) bool {
+		return selected.aggregate == item.aggregate && selected.column == item.column
+	})
+}
+
+func (p *queryParser) peek() queryToken {
+	return p.tokens[p.pos]
+}
+
+func (p *queryParser) next() queryToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEnd {
+		p.pos++
+	}
+	return tok
+}
+
+// keyword consumes the next token if it is the given keyword
+func (p *queryParser) keyword(word string) bool {
+	if tok := p.peek(); tok.kind == tokenWord && strings.EqualFold(tok.text, word) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *queryParser) expect(symbol string) error {
+	if tok := p.next(); !tok.is(symbol) {
+		return fmt.Errorf("expected '%s', got %s", symbol, tok)
+	}
+	return nil
+}
+
+func (p *queryParser) parseCount(clause string) (int, error) {
+	tok := p.next()
+	if tok.kind != tokenNumber || tok.num != float64(int(tok.num)) {
+		return 0, fmt.Errorf("%s needs a whole number, got %s", clause, tok)
+	}
+	return int(tok.num), nil
+}
+
+// parseColumn resolves a column name or letter to its index and a label for the result
+func (p *queryParser) parseColumn(tok queryToken) (int, string, error) {
+	if tok.kind != tokenWord && tok.kind != tokenColumn {
+		return -1, "", fmt.Errorf("expected a column, got %s", tok)
+	}
+	column, err := resolveColumn(p.headers, tok.text)
+	if err != nil {
+		return -1, "", err
+	}
+	if column < len(p.headers) && p.headers[column] != "" {
+		return column, p.headers[column], nil
+	}
+	return column, columnToLetter(int64(column)), nil
+}
+
+// parseItem parses a column or an aggregate call such as sum(Amount) or count(*)
+func (p *queryParser) parseItem() (queryItem, error) {
+	tok := p.next()
+	if name := strings.ToLower(tok.text); tok.kind == tokenWord && slices.Contains(queryAggregates, name) && p.peek().is("(") {
+		p.next()
+		item := queryItem{aggregate: name, column: -1, label: name + "(*)"}
+		if name == "count" && p.peek().is("*") {
+			p.next()
+		} else {
+			column, label, err := p.parseColumn(p.next())
+			if err != nil {
+				return queryItem{}, err
+			}
+			item.column = column
+			item.label = name + "(" + label + ")"
+		}
+		return item, p.expect(")")
+	}
+	column, label, err := p.parseColumn(tok)
+	return queryItem{column: column, label: label}, err
+}
+
+func (p *queryParser) parseOr() (queryCondition, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(row []any) bool { return l(row) || right(row) }
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseAnd() (queryCondition, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("AND") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(row []any) bool { return l(row) && right(row) }
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseNot() (queryCondition, error) {
+	if p.keyword("NOT") {
+		cond, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return func(row []any) bool { return !cond(row) }, nil
+	}
+	if p.peek().is("(") {
+		p.next()
+		cond, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return cond, p.expect(")")
+	}
+	return p.parseComparison()
+}
+
+// parseComparison parses one test of a value: a comparison, IS [NOT] NULL, [NOT] IN (...), or one of
+// the text tests CONTAINS, STARTS WITH, ENDS WITH, LIKE and MATCHES
+func (p *queryParser) parseComparison() (queryCondition, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.keyword("IS") {
+		negate := p.keyword("NOT")
+		if !p.keyword("NULL") {
+			return nil, fmt.Errorf("expected NULL after IS, got %s", p.peek())
+		}
+		return func(row []any) bool { return (formatCell(left(row)) == "") != negate }, nil
+	}
+
+	negate := p.keyword("NOT")
+	var cond queryCondition
+	switch {
+	case p.keyword("IN"):
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		var options []queryOperand
+		for {
+			option, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			options = append(options, option)
+			if !p.peek().is(",") {
+				break
+			}
+			p.next()
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		cond = func(row []any) bool {
+			value := left(row)
+			return slices.ContainsFunc(options, func(option queryOperand) bool {
+				return compareCells(value, option(row), p.matchCase) == 0
+			})
+		}
+	case p.keyword("CONTAINS"):
+		cond, err = p.textTest(left, strings.Contains)
+	case p.keyword("STARTS"):
+		if !p.keyword("WITH") {
+			return nil, fmt.Errorf("expected WITH after STARTS, got %s", p.peek())
+		}
+		cond, err = p.textTest(left, strings.HasPrefix)
+	case p.keyword("ENDS"):
+		if !p.keyword("WITH") {
+			return nil, fmt.Errorf("expected WITH after ENDS, got %s", p.peek())
+		}
+		cond, err = p.textTest(left, strings.HasSuffix)
+	case p.keyword("LIKE"), p.keyword("MATCHES"):
+		like := p.tokens[p.pos-1].is("LIKE")
+		tok := p.next()
+		if tok.kind != tokenString {
+			return nil, fmt.Errorf("expected a quoted pattern, got %s", tok)
+		}
+		pattern := tok.text
+		if like {
+			pattern = likePattern(pattern)
+		} else {
+			pattern = "^(?:" + pattern + ")$"
+		}
+		if !p.matchCase {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %s: %v", tok, err)
+		}
+		cond = func(row []any) bool { return re.MatchString(formatCell(left(row))) }
+	default:
+		if negate {
+			return nil, fmt.Errorf("expected IN, CONTAINS, STARTS WITH, ENDS WITH, LIKE or MATCHES after NOT, got %s", p.peek())
+		}
+		return p.parseRelation(left)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if negate {
+		inner := cond
+		cond = func(row []any) bool { return !inner(row) }
+	}
+	return cond, nil
+}
+
+// parseRelation parses the operator and right side of a comparison. Only = and != hold for empty
+// cells; ordering comparisons against an empty cell are false, as in SQL.
+func (p *queryParser) parseRelation(left queryOperand) (queryCondition, error) {
+	op := p.next()
+	if op.kind != tokenSymbol || !slices.Contains([]string{"=", "!=", "<>", "<", "<=", ">", ">="}, op.text) {
+		return nil, fmt.Errorf("expected a comparison operator, got %s", op)
+	}
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	return func(row []any) bool {
+		a, b := left(row), right(row)
+		c := compareCells(a, b, p.matchCase)
+		switch op.text {
+		case "=":
+			return c == 0
+		case "!=", "<>":
+			return c != 0
+		}
+		if formatCell(a) == "" || formatCell(b) == "" {
+			return false
+		}
+		switch op.text {
+		case "<":
+			return c < 0
+		case "<=":
+			return c <= 0
+		case ">":
+			return c > 0
+		default:
+			return c >= 0
+		}
+	}, nil
+}
+
+// textTest builds a CONTAINS, STARTS WITH or ENDS WITH test, ignoring case unless matchCase is set
+func (p *queryParser) textTest(left queryOperand, test func(s, substr string) bool) (queryCondition, error) {
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	return func(row []any) bool {
+		s, substr := formatCell(left(row)), formatCell(right(row))
+		if !p.matchCase {
+			s, substr = strings.ToLower(s), strings.ToLower(substr)
+		}
+		return test(s, substr)
+	}, nil
+}
+
+// parseOperand parses a column, a quoted string, a number, or TRUE/FALSE
+func (p *queryParser) parseOperand() (queryOperand, error) {
+	tok := p.next()
+	switch {
+	case tok.kind == tokenString:
+		return func([]any) any { return tok.text }, nil
+	case tok.kind == tokenNumber:
+		return func([]any) any { return tok.num }, nil
+	case tok.is("-") && p.peek().kind == tokenNumber:
+		n := -p.next().num
+		return func([]any) any { return n }, nil
+	case tok.kind == tokenWord && findHeader(p.headers, tok.text) < 0 && (tok.is("TRUE") || tok.is("FALSE")):
+		value := tok.is("TRUE")
+		return func([]any) any { return value }, nil
+	}
+	column, _, err := p.parseColumn(tok)
+	if err != nil {
+		return nil, err
+	}
+	return func(row []any) any { return cellAt(row, column) }, nil
+}
+
+// likePattern turns a SQL LIKE pattern, where % matches any text and _ one character, into a regex
+func likePattern(like string) string {
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	for _, r := range like {
+		switch r {
+		case '%':
+			pattern.WriteString(".*")
+		case '_':
+			pattern.WriteString(".")
+		default:
+			pattern.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	pattern.WriteString("$")
+	return pattern.String()
+}
+
+func cellAt(row []any, column int) any {
+	if column < len(row) {
+		return row[column]
+	}
+	return nil
+}
+
+// cellNumber returns the numeric value of a cell holding a number or numeric text
+func cellNumber(cell any) (float64, bool) {
+	n, ok := coerceCell(cell).(float64)
+	return n, ok
+}
+
+// compareCells orders two cells: numerically when both are numbers, otherwise as text, ignoring case
+// unless matchCase is set
+func compareCells(a, b any, matchCase bool) int {
+	x, xNumber := cellNumber(a)
+	y, yNumber := cellNumber(b)
+	if xNumber && yNumber {
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	}
+	s, t := formatCell(a), formatCell(b)
+	if !matchCase {
+		s, t = strings.ToLower(s), strings.ToLower(t)
+	}
+	return strings.Compare(s, t)
+}
+
+// aggregateCells computes an aggregate over one column of a group of rows. Empty cells are ignored;
+// sum and avg also ignore cells that are not numbers, and avg, min and max are null with no values.
+func aggregateCells(item queryItem, rows [][]any, matchCase bool) any {
+	if item.column < 0 {
+		return len(rows)
+	}
+	var values []any
+	for _, row := range rows {
+		if cell := cellAt(row, item.column); formatCell(cell) != "" {
+			values = append(values, cell)
+		}
+	}
+
+	switch item.aggregate {
+	case "count":
+		return len(values)
+	case "sum", "avg":
+		sum, count := 0.0, 0
+		for _, value := range values {
+			if n, ok := cellNumber(value); ok {
+				sum += n
+				count++
+			}
+		}
+		if item.aggregate == "sum" {
+			return sum
+		}
+		if count == 0 {
+			return nil
+		}
+		return sum / float64(count)
+	default:
+		if len(values) == 0 {
+			return nil
+		}
+		best := values[0]
+		for _, value := range values[1:] {
+			c := compareCells(value, best, matchCase)
+			if item.aggregate == "min" && c < 0 || item.aggregate == "max" && c > 0 {
+				best = value
+			}
+		}
+		return best
+	}
+}
+
+// sortRows sorts rows stably by a list of (column, descending) keys; empty cells always sort last
+func sortRows(rows [][]any, columns []int, desc []bool, matchCase bool) {
+	slices.SortStableFunc(rows, func(a, b []any) int {
+		for i, column := range columns {
+			x, y := cellAt(a, column), cellAt(b, column)
+			xEmpty, yEmpty := formatCell(x) == "", formatCell(y) == ""
+			switch {
+			case xEmpty && yEmpty:
+				continue
+			case xEmpty:
+				return 1
+			case yEmpty:
+				return -1
+			}
+			if c := compareCells(x, y, matchCase); c != 0 {
+				if desc[i] {
+					return -c
+				}
+				return c
+			}
+		}
+		return 0
+	})
+}
+
+// run executes the query over the data rows, returning the result's column labels and rows
+func (q *sheetQuery) run(headers []string, rows [][]any, matchCase bool) ([]string, [][]any) {
+	var matched [][]any
+	for _, row := range rows {
+		if isEmptyRow(row) || q.where != nil && !q.where(row) {
+			continue
+		}
+		matched = append(matched, row)
+	}
+
+	if q.all {
+		width := len(headers)
+		for _, row := range matched {
+			width = max(width, len(row))
+		}
+		for i := range width {
+			label := columnToLetter(int64(i))
+			if i < len(headers) && headers[i] != "" {
+				label = headers[i]
+			}
+			q.items = append(q.items, queryItem{column: i, label: label})
+		}
+	}
+	columns := make([]string, len(q.items))
+	for i, item := range q.items {
+		columns[i] = item.label
+	}
+
+	var result [][]any
+	orderColumns := make([]int, len(q.orderBy))
+	orderDesc := make([]bool, len(q.orderBy))
+	for i, term := range q.orderBy {
+		orderDesc[i] = term.desc
+	}
+
+	if q.grouped() {
+		var keys []string
+		groups := make(map[string][][]any)
+		for _, row := range matched {
+			parts := make([]string, len(q.groupBy))
+			for i, column := range q.groupBy {
+				parts[i] = formatCell(cellAt(row, column))
+			}
+			key := strings.Join(parts, "\x00")
+			if !matchCase {
+				key = strings.ToLower(key)
+			}
+			if _, seen := groups[key]; !seen {
+				keys = append(keys, key)
+			}
+			groups[key] = append(groups[key], row)
+		}
+		// Aggregates without GROUP BY summarize all rows, even when none matched
+		if len(q.groupBy) == 0 && len(keys) == 0 {
+			keys = append(keys, "")
+		}
+
+		for _, key := range keys {
+			group := groups[key]
+			out := make([]any, len(q.items))
+			for i, item := range q.items {
+				if item.aggregate != "" {
+					out[i] = aggregateCells(item, group, matchCase)
+				} else {
+					out[i] = cellAt(group[0], item.column)
+				}
+			}
+			result = append(result, out)
+		}
+		for i, term := range q.orderBy {
+			orderColumns[i] = q.outputIndex(term.item)
+		}
+		sortRows(result, orderColumns, orderDesc, matchCase)
+	} else {
+		for i, term := range q.orderBy {
+			orderColumns[i] = term.item.column
+		}
+		sortRows(matched, orderColumns, orderDesc, matchCase)
+		for _, row := range matched {
+			out := make([]any, len(q.items))
+			for i, item := range q.items {
+				out[i] = cellAt(row, item.column)
+			}
+			result = append(result, out)
+		}
+	}
+
+	result = result[min(q.offset, len(result)):]
+	if q.limit >= 0 && q.limit < len(result) {
+		result = result[:q.limit]
+	}
+	return columns, result
+}
+
+func (s *SheetsMCPServer) handleQuerySheet(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID, sheet, rangeStr := parseCommonArgs(args)
+	query := parseArgument(args, "query", "")
+	headerRow := int(parseArgument(args, "header_row", float64(1)))
+	matchCase := parseArgument(args, "match_case", false)
+	outputFormat := parseArgument(args, "output_format", "json")
+
+	if spreadsheetID == "" || sheet == "" || query == "" {
+		return respondWithError("spreadsheet_id, sheet, and query are required")
+	}
+	if headerRow < 0 {
+		return respondWithError("header_row must be 0 (no headers) or a 1-based row number")
+	}
+
+	fullRange := buildFullRange(sheet, rangeStr)
+	// Unformatted numbers compare and add up as numbers, while dates stay readable text
+	valuesResult, err := s.sheetsService.Spreadsheets.Values.Get(spreadsheetID, fullRange).
+		ValueRenderOption("UNFORMATTED_VALUE").
+		DateTimeRenderOption("FORMATTED_STRING").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return s.respondWithAPIError("failed to get sheet values", err)
+	}
+	recordCellsRead(ctx, valuesResult.Values)
+
+	var headers []string
+	rows := valuesResult.Values
+	if headerRow > 0 {
+		if headerRow <= len(rows) {
+			for _, cell := range rows[headerRow-1] {
+				headers = append(headers, formatCell(cell))
+			}
+		}
+		rows = rows[min(headerRow, len(rows)):]
+	}
+
+	parsed, err := parseQuery(query, headers, matchCase)
+	if err != nil {
+		return respondWithError(fmt.Sprintf("invalid query: %v", err))
+	}
+	columns, result := parsed.run(headers, rows, matchCase)
+	result, page := paginate(result, 0, 0, s.config.MaxResponseCells)
+
+	response := queryResult{
+		SpreadsheetID: spreadsheetID,
+		Range:         fullRange,
+		Columns:       columns,
+		Rows:          result,
+		RowCount:      len(result),
+	}
+	if page.HasMore {
+		response.Truncated = true
+		response.TotalRows = *page.TotalRows
+	}
+
+	table := make([][]any, 0, len(result)+1)
+	header := make([]any, len(columns))
+	for i, column := range columns {
+		header[i] = column
+	}
+	table = append(table, header)
+	return respondWithValues(outputFormat, append(table, result...), response)
+}
+
+// queryResult is the table query_sheet produced
+type queryResult struct {
+	SpreadsheetID string   `json:"spreadsheetId"`
+	Range         string   `json:"range"`
+	Columns       []string `json:"columns"`
+	Rows          [][]any  `json:"rows"`
+	RowCount      int      `json:"rowCount"`
+	Truncated     bool     `json:"truncated,omitempty"`
+	TotalRows     int      `json:"totalRows,omitempty"`
+}
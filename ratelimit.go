@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenBucket allows bursts of up to capacity requests and refills at capacity per minute
+type tokenBucket struct {
+	mu       sync.Mutex
+	capacity float64
+	tokens   float64
+	perSec   float64
+	last     time.Time
+}
+
+func newTokenBucket(perMinute int64) *tokenBucket {
+	return &tokenBucket{
+		capacity: float64(perMinute),
+		tokens:   float64(perMinute),
+		perSec:   float64(perMinute) / 60,
+		last:     time.Now(),
+	}
+}
+
+// wait blocks until a token is available or ctx is done
+func (b *tokenBucket) wait(ctx context.Context) error {
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens = min(b.capacity, b.tokens+now.Sub(b.last).Seconds()*b.perSec)
+	b.last = now
+	// Taking the token up front reserves it, so concurrent waiters queue behind each other
+	b.tokens--
+	delay := time.Duration(-b.tokens / b.perSec * float64(time.Second))
+	b.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		b.mu.Lock()
+		b.tokens++
+		b.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// sheetsRateLimiter is an http.RoundTripper that holds Sheets API requests back once the per-minute
+// read or write budget is spent, so a burst of calls from one tool cannot exhaust the project quota
+// and starve everything else. Requests to other APIs pass straight through.
+type sheetsRateLimiter struct {
+	next   http.RoundTripper
+	reads  *tokenBucket
+	writes *tokenBucket
+}
+
+// withSheetsRateLimit returns a copy of client whose Sheets API requests are limited to the given
+// reads and writes per minute; a budget of 0 leaves that kind of request unlimited
+func withSheetsRateLimit(client *http.Client, readsPerMinute, writesPerMinute int64) *http.Client {
+	if readsPerMinute <= 0 && writesPerMinute <= 0 {
+		return client
+	}
+	next := client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	limiter := &sheetsRateLimiter{next: next}
+	if readsPerMinute > 0 {
+		limiter.reads = newTokenBucket(readsPerMinute)
+	}
+	if writesPerMinute > 0 {
+		limiter.writes = newTokenBucket(writesPerMinute)
+	}
+	limited := *client
+	limited.Transport = limiter
+	return &limited
+}
+
+func (l *sheetsRateLimiter) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host != "sheets.googleapis.com" {
+		return l.next.RoundTrip(req)
+	}
+
+	kind, bucket := "write", l.writes
+	if isSheetsRead(req) {
+		kind, bucket = "read", l.reads
+	}
+	if bucket != nil {
+		start := time.Now()
+		if err := bucket.wait(req.Context()); err != nil {
+			return nil, err
+		}
+		if waited := time.Since(start); waited > time.Millisecond {
+			slog.DebugContext(req.Context(), "sheets rate limit delayed request", "kind", kind, "waited", waited)
+		}
+	}
+	return l.next.RoundTrip(req)
+}
+
+// isSheetsRead reports whether the Sheets API counts a request against the read quota
+func isSheetsRead(req *http.Request) bool {
+	return req.Method == http.MethodGet ||
+		strings.HasSuffix(req.URL.Path, ":getByDataFilter") ||
+		strings.HasSuffix(req.URL.Path, ":batchGetByDataFilter")
+}
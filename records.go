@@ -0,0 +1,659 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"google.golang.org/api/sheets/v4"
+)
+
+// groupedNumber matches numbers with comma thousands separators, such as 1,234,567.89
+var groupedNumber = regexp.MustCompile(`^-?[1-9]\d{0,2}(,\d{3})+(\.\d+)?$`)
+
+// plainNumber matches decimal numbers as Sheets displays them. A leading zero marks text such as a
+// ZIP code or an ID, and spellings ParseFloat also accepts (NaN, Inf, hex, exponents) stay text.
+var plainNumber = regexp.MustCompile(`^-?(0|[1-9]\d*)(\.\d+)?$`)
+
+// recordKeys turns a header row into unique record keys. Blank headers are named after their column
+// letter and repeated headers get a numeric suffix (Amount, Amount_2), so no value is lost.
+func recordKeys(headers []any) []string {
+	keys := make([]string, len(headers))
+	seen := make(map[string]int)
+	for i, header := range headers {
+		key := strings.TrimSpace(formatCell(header))
+		if key == "" {
+			key = columnToLetter(int64(i))
+		}
+		seen[key]++
+		if n := seen[key]; n > 1 {
+			key = fmt.Sprintf("%s_%d", key, n)
+		}
+		keys[i] = key
+	}
+	return keys
+}
+
+// rowsToRecords converts rows into objects keyed by keys. Missing trailing cells become null, and
+// cells beyond the last header are dropped. Entirely empty rows are skipped.
+func rowsToRecords(keys []string, rows [][]any, coerce bool) []map[string]any {
+	records := make([]map[string]any, 0, len(rows))
+	for _, row := range rows {
+		if isEmptyRow(row) {
+			continue
+		}
+		records = append(records, rowToRecord(keys, row, coerce))
+	}
+	return records
+}
+
+// rowToRecord converts one row into an object keyed by keys
+func rowToRecord(keys []string, row []any, coerce bool) map[string]any {
+	record := make(map[string]any, len(keys))
+	for i, key := range keys {
+		var value any
+		if i < len(row) {
+			value = row[i]
+		}
+		if coerce {
+			value = coerceCell(value)
+		}
+		record[key] = value
+	}
+	return record
+}
+
+// isEmptyRow reports whether every cell of a row is blank
+func isEmptyRow(row []any) bool {
+	for _, cell := range row {
+		if formatCell(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// coerceCell converts a formatted cell into the JSON type it looks like: plain decimal numbers (with
+// optional comma thousands separators), booleans, or null for an empty cell. Anything else stays text.
+func coerceCell(value any) any {
+	text, ok := value.(string)
+	if !ok {
+		return value
+	}
+	trimmed := strings.TrimSpace(text)
+	switch {
+	case trimmed == "":
+		return nil
+	case strings.EqualFold(trimmed, "true"):
+		return true
+	case strings.EqualFold(trimmed, "false"):
+		return false
+	}
+	if groupedNumber.MatchString(trimmed) {
+		trimmed = strings.ReplaceAll(trimmed, ",", "")
+	} else if !plainNumber.MatchString(trimmed) {
+		return text
+	}
+	if n, err := strconv.ParseFloat(trimmed, 64); err == nil && !math.IsInf(n, 0) && !math.IsNaN(n) {
+		return n
+	}
+	return text
+}
+
+// coerceKinds are the types column_types can ask for; auto behaves like coerce_types
+var coerceKinds = []string{"auto", "number", "boolean", "string"}
+
+// coerceAs converts a cell to the given kind. A cell that cannot be read as that kind keeps its value
+// rather than being lost, and blank cells become null whatever the kind.
+func coerceAs(value any, kind string) any {
+	coerced := coerceCell(value)
+	switch kind {
+	case "string":
+		if coerced == nil {
+			return nil
+		}
+		return formatCell(value)
+	case "number":
+		if text, ok := coerced.(string); ok {
+			// Displayed values such as $1,234.56 or 12% are numbers too
+			if n, ok := parseLocaleNumber(text, numberFormat{decimal: '.', groups: ","}); ok {
+				return n
+			}
+		}
+		if _, ok := coerced.(bool); ok {
+			return value
+		}
+	case "boolean":
+		if _, ok := coerced.(float64); ok {
+			return value
+		}
+	}
+	return coerced
+}
+
+// columnCoercions resolves column_types, which name columns by header (ignoring case) or letter,
+// into the kind of each column index
+func columnCoercions(headers []string, columnTypes map[string]string) (map[int]string, error) {
+	kinds := make(map[int]string, len(columnTypes))
+	for name, kind := range columnTypes {
+		kind = strings.ToLower(kind)
+		if !slices.Contains(coerceKinds, kind) {
+			return nil, fmt.Errorf("invalid type '%s' for column '%s': must be one of %s", kind, name, strings.Join(coerceKinds, ", "))
+		}
+		column, err := resolveColumn(headers, name)
+		if err != nil {
+			return nil, err
+		}
+		kinds[column] = kind
+	}
+	return kinds, nil
+}
+
+// coerceRows converts the cells of rows in place: columns in kinds get their kind, and every other
+// column is coerced automatically when all is set and left alone otherwise
+func coerceRows(rows [][]any, kinds map[int]string, all bool) {
+	for _, row := range rows {
+		for i, cell := range row {
+			kind, ok := kinds[i]
+			if !ok {
+				if !all {
+					continue
+				}
+				kind = "auto"
+			}
+			row[i] = coerceAs(cell, kind)
+		}
+	}
+}
+
+// recordColumns maps the keys of records to header columns. Keys without a matching header (compared
+// ignoring case) become new columns after the last header when createMissing is set, and are an
+// error otherwise. It returns the column index of every key and the headers that were added.
+func recordColumns(headers []string, records []map[string]any, createMissing bool) (map[string]int, []string, error) {
+	columns := make(map[string]int)
+	var added, unknown []string
+	for _, record := range records {
+		keys := make([]string, 0, len(record))
+		for key := range record {
+			keys = append(keys, key)
+		}
+		// Object keys arrive unordered, so new columns are added in alphabetical order per record
+		slices.Sort(keys)
+
+		for _, key := range keys {
+			if _, ok := columns[key]; ok {
+				continue
+			}
+			if i := findHeader(headers, key); i >= 0 {
+				columns[key] = i
+				continue
+			}
+			if i := findHeader(added, key); i >= 0 {
+				columns[key] = len(headers) + i
+				continue
+			}
+			if !createMissing {
+				unknown = append(unknown, key)
+				columns[key] = -1
+				continue
+			}
+			columns[key] = len(headers) + len(added)
+			added = append(added, key)
+		}
+	}
+
+	if len(unknown) > 0 {
+		return nil, nil, fmt.Errorf("no column for %s (headers: %s); set create_missing_columns to add them",
+			strings.Join(unknown, ", "), strings.Join(headers, ", "))
+	}
+	return columns, added, nil
+}
+
+// recordRows lays records out as rows of width cells. Cells for keys a record lacks are nil, which the
+// Sheets API skips, so those cells keep their current content. Nested objects and arrays are written
+// as JSON text.
+func recordRows(records []map[string]any, columns map[string]int, width int) [][]any {
+	rows := make([][]any, len(records))
+	for r, record := range records {
+		row := make([]any, width)
+		for key, value := range record {
+			switch value.(type) {
+			case map[string]any, []any:
+				encoded, _ := json.Marshal(value)
+				value = string(encoded)
+			}
+			row[columns[key]] = value
+		}
+		rows[r] = row
+	}
+	return rows
+}
+
+// prepareRecordWrite parses the arguments shared by write_records and append_records, reads the
+// header row, and returns the rows to write with the header cells to add (nil when none are added)
+func (s *SheetsMCPServer) prepareRecordWrite(ctx context.Context, args map[string]any) (rows [][]any, newHeaders *sheets.ValueRange, width int, err error) {
+	spreadsheetID, sheet, _ := parseCommonArgs(args)
+	headerRow := int64(parseArgument(args, "header_row", float64(1)))
+	createMissing := parseArgument(args, "create_missing_columns", false)
+
+	if spreadsheetID == "" || sheet == "" {
+		return nil, nil, 0, fmt.Errorf("spreadsheet_id and sheet are required")
+	}
+	if headerRow < 1 {
+		return nil, nil, 0, fmt.Errorf("header_row must be at least 1")
+	}
+
+	var records []map[string]any
+	if err := convertToType(args["records"], &records); err != nil || len(records) == 0 {
+		return nil, nil, 0, fmt.Errorf("records must be a non-empty array of objects")
+	}
+
+	valueInput, err := parseValueInputOption(args)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	headers, err := s.readHeaderRow(ctx, spreadsheetID, sheet, headerRow)
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("failed to read header row: %w", err)
+	}
+
+	columns, added, err := recordColumns(headers, records, createMissing)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	width = len(headers) + len(added)
+	rows = recordRows(records, columns, width)
+	s.sanitizeInput(args, valueInput, rows)
+
+	if len(added) > 0 {
+		cells := make([]any, len(added))
+		for i, header := range added {
+			cells[i] = header
+		}
+		newHeaders = &sheets.ValueRange{
+			Range: buildFullRange(sheet, fmt.Sprintf("%s%d:%s%d",
+				columnToLetter(int64(len(headers))), headerRow, columnToLetter(int64(width-1)), headerRow)),
+			Values: [][]any{cells},
+		}
+	}
+	return rows, newHeaders, width, nil
+}
+
+func (s *SheetsMCPServer) handleWriteRecords(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID, sheet, _ := parseCommonArgs(args)
+
+	rows, newHeaders, width, err := s.prepareRecordWrite(ctx, args)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+
+	headerRow := int64(parseArgument(args, "header_row", float64(1)))
+	startRow := int64(parseArgument(args, "start_row", float64(headerRow+1)))
+	if startRow <= headerRow {
+		return respondWithError("start_row must be below header_row")
+	}
+
+	valueInput, _ := parseValueInputOption(args)
+	dataRange := buildFullRange(sheet, fmt.Sprintf("A%d:%s%d", startRow, columnToLetter(int64(width-1)), startRow+int64(len(rows))-1))
+	data := []*sheets.ValueRange{{Range: dataRange, Values: rows}}
+	if newHeaders != nil {
+		data = append(data, newHeaders)
+	}
+
+	result, err := s.sheetsService.Spreadsheets.Values.BatchUpdate(spreadsheetID, &sheets.BatchUpdateValuesRequest{
+		ValueInputOption: valueInput,
+		Data:             data,
+	}).Context(ctx).Do()
+	if err != nil {
+		return s.respondWithAPIError("failed to write records", err)
+	}
+	recordCellsWritten(ctx, result.TotalUpdatedCells)
+
+	return respondWithJSON(recordWriteResponse(spreadsheetID, dataRange, len(rows), newHeaders))
+}
+
+func (s *SheetsMCPServer) handleAppendRecords(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID, sheet, _ := parseCommonArgs(args)
+
+	rows, newHeaders, width, err := s.prepareRecordWrite(ctx, args)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	valueInput, _ := parseValueInputOption(args)
+
+	// New headers go in first so that the appended rows land in a table that already has them
+	if newHeaders != nil {
+		if _, err := s.sheetsService.Spreadsheets.Values.Update(spreadsheetID, newHeaders.Range, newHeaders).
+			ValueInputOption("RAW").
+			Context(ctx).
+			Do(); err != nil {
+			return s.respondWithAPIError("failed to add header columns", err)
+		}
+	}
+
+	headerRow := int64(parseArgument(args, "header_row", float64(1)))
+	tableRange := buildFullRange(sheet, fmt.Sprintf("A%d:%s", headerRow, columnToLetter(int64(width-1))))
+	result, err := s.sheetsService.Spreadsheets.Values.Append(spreadsheetID, tableRange, &sheets.ValueRange{Values: rows}).
+		ValueInputOption(valueInput).
+		InsertDataOption("INSERT_ROWS").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return s.respondWithAPIError("failed to append records", err)
+	}
+
+	updatedRange := ""
+	if result.Updates != nil {
+		recordCellsWritten(ctx, result.Updates.UpdatedCells)
+		updatedRange = result.Updates.UpdatedRange
+	}
+	return respondWithJSON(recordWriteResponse(spreadsheetID, updatedRange, len(rows), newHeaders))
+}
+
+// recordWriteResult reports the records write_records or append_records wrote and the header columns
+// they added
+type recordWriteResult struct {
+	SpreadsheetID  string `json:"spreadsheetId"`
+	UpdatedRange   string `json:"updatedRange"`
+	Rows           int    `json:"rows"`
+	CreatedColumns []any  `json:"createdColumns,omitempty"`
+}
+
+func recordWriteResponse(spreadsheetID, updatedRange string, rows int, newHeaders *sheets.ValueRange) recordWriteResult {
+	response := recordWriteResult{
+		SpreadsheetID: spreadsheetID,
+		UpdatedRange:  updatedRange,
+		Rows:          rows,
+	}
+	if newHeaders != nil {
+		response.CreatedColumns = newHeaders.Values[0]
+	}
+	return response
+}
+
+// headerUpdate is one entry of update_by_header: the key identifying the row and the new values by header
+type headerUpdate struct {
+	Key    any            `json:"key"`
+	Values map[string]any `json:"values"`
+}
+
+func (s *SheetsMCPServer) handleUpdateByHeader(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID, sheet, _ := parseCommonArgs(args)
+	keyColumn := parseArgument(args, "key_column", "")
+	headerRow := int64(parseArgument(args, "header_row", float64(1)))
+	matchCase := parseArgument(args, "match_case", false)
+	allMatches := parseArgument(args, "all_matches", false)
+
+	if spreadsheetID == "" || sheet == "" || keyColumn == "" {
+		return respondWithError("spreadsheet_id, sheet, key_column, and updates are required")
+	}
+	if headerRow < 1 {
+		return respondWithError("header_row must be at least 1")
+	}
+	var updates []headerUpdate
+	if err := convertToType(args["updates"], &updates); err != nil || len(updates) == 0 {
+		return respondWithError("updates must be a non-empty array of {key, values} objects")
+	}
+	valueInput, err := parseValueInputOption(args)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+
+	headers, err := s.readHeaderRow(ctx, spreadsheetID, sheet, headerRow)
+	if err != nil {
+		return s.respondWithAPIError("failed to read header row", err)
+	}
+	keyIndex, err := resolveColumn(headers, keyColumn)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	records := make([]map[string]any, len(updates))
+	for i, update := range updates {
+		if len(update.Values) == 0 {
+			return respondWithError(fmt.Sprintf("update for key '%s' has no values", formatCell(update.Key)))
+		}
+		records[i] = update.Values
+	}
+	columns, _, err := recordColumns(headers, records, false)
+	if err != nil {
+		return respondWithError(strings.TrimSuffix(err.Error(), "; set create_missing_columns to add them"))
+	}
+
+	// Only the key column is read; unformatted values let numeric keys match however they are displayed
+	keyLetter := columnToLetter(int64(keyIndex))
+	keyCells, err := s.sheetsService.Spreadsheets.Values.Get(spreadsheetID,
+		buildFullRange(sheet, fmt.Sprintf("%s%d:%s", keyLetter, headerRow+1, keyLetter))).
+		ValueRenderOption("UNFORMATTED_VALUE").
+		MajorDimension("COLUMNS").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return s.respondWithAPIError("failed to read key column", err)
+	}
+	var keys []any
+	if len(keyCells.Values) > 0 {
+		keys = keyCells.Values[0]
+	}
+	recordCellsRead(ctx, keyCells.Values)
+
+	rowsByKey := make(map[string][]int64)
+	for i, cell := range keys {
+		key := formatCell(cell)
+		if !matchCase {
+			key = strings.ToLower(key)
+		}
+		if key != "" {
+			rowsByKey[key] = append(rowsByKey[key], headerRow+1+int64(i))
+		}
+	}
+
+	width := len(headers)
+	laidOut := recordRows(records, columns, width)
+	s.sanitizeInput(args, valueInput, laidOut)
+
+	var data []*sheets.ValueRange
+	updated := []keyedRows{}
+	notFound := []any{}
+	ambiguous := []keyedRows{}
+	for i, update := range updates {
+		key := formatCell(update.Key)
+		if !matchCase {
+			key = strings.ToLower(key)
+		}
+		rows := rowsByKey[key]
+		switch {
+		case len(rows) == 0:
+			notFound = append(notFound, update.Key)
+			continue
+		case len(rows) > 1 && !allMatches:
+			ambiguous = append(ambiguous, keyedRows{Key: update.Key, Rows: rows})
+			continue
+		}
+
+		// Each row is written as one span from its first to its last changed column; the nil cells
+		// between them are skipped by the API and keep their content
+		first, last := width, -1
+		for column, cell := range laidOut[i] {
+			if cell != nil {
+				first, last = min(first, column), max(last, column)
+			}
+		}
+		for _, row := range rows {
+			data = append(data, &sheets.ValueRange{
+				Range:  buildFullRange(sheet, fmt.Sprintf("%s%d:%s%d", columnToLetter(int64(first)), row, columnToLetter(int64(last)), row)),
+				Values: [][]any{laidOut[i][first : last+1]},
+			})
+		}
+		updated = append(updated, keyedRows{Key: update.Key, Rows: rows})
+	}
+
+	response := updateByHeaderResult{
+		SpreadsheetID: spreadsheetID,
+		Sheet:         sheet,
+		Updated:       updated,
+		NotFound:      notFound,
+		Ambiguous:     ambiguous,
+	}
+	if len(ambiguous) > 0 {
+		response.Hint = "these keys match several rows; set all_matches to update every matching row"
+	}
+	if len(data) == 0 {
+		return respondWithJSON(response)
+	}
+
+	result, err := s.sheetsService.Spreadsheets.Values.BatchUpdate(spreadsheetID, &sheets.BatchUpdateValuesRequest{
+		ValueInputOption: valueInput,
+		Data:             data,
+	}).Context(ctx).Do()
+	if err != nil {
+		return s.respondWithAPIError("failed to update cells", err)
+	}
+	recordCellsWritten(ctx, result.TotalUpdatedCells)
+	response.UpdatedCells = result.TotalUpdatedCells
+
+	return respondWithJSON(response)
+}
+
+func (s *SheetsMCPServer) handleFindRows(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID, sheet, _ := parseCommonArgs(args)
+	column := parseArgument(args, "column", "")
+	value := parseArgument(args, "value", "")
+	pattern := parseArgument(args, "regex", "")
+	matchCase := parseArgument(args, "match_case", false)
+	headerRow := int(parseArgument(args, "header_row", float64(1)))
+	maxResults := int(parseArgument(args, "max_results", float64(100)))
+
+	if spreadsheetID == "" || sheet == "" || column == "" {
+		return respondWithError("spreadsheet_id, sheet, and column are required")
+	}
+	if (value == "") == (pattern == "") {
+		return respondWithError("exactly one of value or regex is required")
+	}
+	if headerRow < 0 {
+		return respondWithError("header_row must be 0 (no headers) or a 1-based row number")
+	}
+	if maxResults < 1 {
+		return respondWithError("max_results must be at least 1")
+	}
+
+	match := func(cell string) bool {
+		if matchCase {
+			return cell == value
+		}
+		return strings.EqualFold(cell, value)
+	}
+	if pattern != "" {
+		if !matchCase {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return respondWithError(fmt.Sprintf("invalid regex: %v", err))
+		}
+		match = re.MatchString
+	}
+
+	valuesResult, err := s.sheetsService.Spreadsheets.Values.Get(spreadsheetID, buildFullRange(sheet, "")).Context(ctx).Do()
+	if err != nil {
+		return s.respondWithAPIError("failed to get sheet values", err)
+	}
+	recordCellsRead(ctx, valuesResult.Values)
+
+	var headers []string
+	var keys []string
+	if headerRow > 0 && headerRow <= len(valuesResult.Values) {
+		keys = recordKeys(valuesResult.Values[headerRow-1])
+		for _, cell := range valuesResult.Values[headerRow-1] {
+			headers = append(headers, formatCell(cell))
+		}
+	}
+	columnIndex, err := resolveColumn(headers, column)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+
+	matches := []rowMatch{}
+	truncated := false
+	for i := headerRow; i < len(valuesResult.Values); i++ {
+		row := valuesResult.Values[i]
+		if columnIndex >= len(row) || !match(formatCell(row[columnIndex])) {
+			continue
+		}
+		if len(matches) == maxResults {
+			truncated = true
+			break
+		}
+		entry := rowMatch{Row: i + 1}
+		if keys != nil {
+			entry.Record = rowToRecord(keys, row, false)
+		} else {
+			entry.Values = row
+		}
+		matches = append(matches, entry)
+	}
+
+	return respondWithJSON(findRowsResult{
+		SpreadsheetID: spreadsheetID,
+		Sheet:         sheet,
+		Column:        columnToLetter(int64(columnIndex)),
+		Matches:       matches,
+		Truncated:     truncated,
+	})
+}
+
+// keyedRows is the rows update_by_header found for one key
+type keyedRows struct {
+	Key  any     `json:"key"`
+	Rows []int64 `json:"rows"`
+}
+
+// updateByHeaderResult reports the keys update_by_header updated, could not find, or found more than once
+type updateByHeaderResult struct {
+	SpreadsheetID string      `json:"spreadsheetId"`
+	Sheet         string      `json:"sheet"`
+	Updated       []keyedRows `json:"updated"`
+	UpdatedCells  int64       `json:"updatedCells"`
+	NotFound      []any       `json:"notFound,omitempty"`
+	Ambiguous     []keyedRows `json:"ambiguous,omitempty"`
+	Hint          string      `json:"hint,omitempty"`
+}
+
+// rowMatch is a row find_rows matched, as a record when the sheet has headers
+type rowMatch struct {
+	Row    int            `json:"row"`
+	Record map[string]any `json:"record,omitempty"`
+	Values []any          `json:"values,omitempty"`
+}
+
+// findRowsResult lists the rows find_rows matched
+type findRowsResult struct {
+	SpreadsheetID string     `json:"spreadsheetId"`
+	Sheet         string     `json:"sheet"`
+	Column        string     `json:"column"`
+	Matches       []rowMatch `json:"matches"`
+	Truncated     bool       `json:"truncated"`
+}
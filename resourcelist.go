@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	// spreadsheetListTTL is how long a folder listing is reused before resources/list asks Drive again
+	spreadsheetListTTL = time.Minute
+	// maxListedSpreadsheets caps the spreadsheets listed as resources, which come from one Drive page
+	maxListedSpreadsheets = 1000
+)
+
+// spreadsheetListCache keeps the spreadsheets of recently listed folders as resources, so that clients
+// polling resources/list do not cost a Drive call each time
+type spreadsheetListCache struct {
+	mu      sync.Mutex
+	entries map[string]spreadsheetListEntry
+}
+
+type spreadsheetListEntry struct {
+	spreadsheets []listedSpreadsheet
+	fetchedAt    time.Time
+}
+
+// listedSpreadsheet is a spreadsheet listed as a resource, kept with its ID for tenant filtering
+type listedSpreadsheet struct {
+	id       string
+	resource *mcp.Resource
+}
+
+func newSpreadsheetListCache() *spreadsheetListCache {
+	return &spreadsheetListCache{entries: make(map[string]spreadsheetListEntry)}
+}
+
+func (c *spreadsheetListCache) get(folderID string) ([]listedSpreadsheet, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[folderID]
+	if !ok || time.Since(entry.fetchedAt) > spreadsheetListTTL {
+		delete(c.entries, folderID)
+		return nil, false
+	}
+	return entry.spreadsheets, true
+}
+
+func (c *spreadsheetListCache) set(folderID string, spreadsheets []listedSpreadsheet) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[folderID] = spreadsheetListEntry{spreadsheets: spreadsheets, fetchedAt: time.Now()}
+}
+
+// withSpreadsheetResources adds the spreadsheets of the session's folder to resources/list, each as
+// its spreadsheet://{id}/info resource. They follow the server's own resources on the last page, and
+// a Drive failure leaves them out rather than failing the listing. Exports made by other sessions are
+// left out of every page.
+func (s *SheetsMCPServer) withSpreadsheetResources(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		result, err := next(ctx, method, req)
+		if err != nil || method != "resources/list" {
+			return result, err
+		}
+		listResult, ok := result.(*mcp.ListResourcesResult)
+		if !ok {
+			return result, nil
+		}
+		request, ok := req.(*mcp.ListResourcesRequest)
+		if !ok {
+			return result, nil
+		}
+		// Exports are only listed to the session that made them
+		visible := make([]*mcp.Resource, 0, len(listResult.Resources))
+		for _, resource := range listResult.Resources {
+			if !s.exports.hidden(resource.URI, request.Session) {
+				visible = append(visible, resource)
+			}
+		}
+		listResult.Resources = visible
+		if listResult.NextCursor != "" {
+			return listResult, nil
+		}
+
+		tenant := s.callerTenant(ctx, request.Extra)
+		// The spreadsheets are those the session's profile can see
+		p := s
+		if tenant != nil {
+			if p, err = s.profileServer(tenant.Profile); err != nil {
+				return nil, err
+			}
+		}
+		if p.driveService == nil || p.driveUnavailable != "" {
+			return result, nil
+		}
+		folderID := s.config.DriveFolderID
+		if folderID == "" {
+			folderID = s.config.SharedDriveID
+		}
+		if tenant != nil && tenant.FolderID != "" {
+			folderID = tenant.FolderID
+		}
+
+		spreadsheets, err := p.listedSpreadsheets(ctx, folderID)
+		if err != nil {
+			slog.Warn("failed to list spreadsheets as resources", "folder", folderID, "error", err)
+			return result, nil
+		}
+		for _, spreadsheet := range spreadsheets {
+			if !tenant.allows(spreadsheet.id) {
+				continue
+			}
+			listResult.Resources = append(listResult.Resources, spreadsheet.resource)
+		}
+		return listResult, nil
+	}
+}
+
+// listedSpreadsheets lists the spreadsheets in a folder, or every spreadsheet the credentials can
+// see when no folder is configured, from the cache when the listing is recent
+func (s *SheetsMCPServer) listedSpreadsheets(ctx context.Context, folderID string) ([]listedSpreadsheet, error) {
+	if spreadsheets, ok := s.spreadsheetList.get(folderID); ok {
+		return spreadsheets, nil
+	}
+
+	query := fmt.Sprintf("mimeType = '%s' and trashed = false", spreadsheetMimeType)
+	if folderID != "" {
+		query += fmt.Sprintf(" and '%s' in parents", escapeDriveQuery(folderID))
+	}
+
+	result, err := s.driveService.Files.List().
+		Q(query).
+		PageSize(maxListedSpreadsheets).
+		OrderBy("name").
+		SupportsAllDrives(true).
+		IncludeItemsFromAllDrives(true).
+		Fields("nextPageToken,files(id,name,modifiedTime)").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return nil, err
+	}
+	if result.NextPageToken != "" {
+		slog.Debug("spreadsheet resources truncated", "folder", folderID, "limit", maxListedSpreadsheets)
+	}
+
+	spreadsheets := make([]listedSpreadsheet, 0, len(result.Files))
+	for _, file := range result.Files {
+		spreadsheets = append(spreadsheets, listedSpreadsheet{id: file.Id, resource: &mcp.Resource{
+			URI:         fmt.Sprintf("spreadsheet://%s/info", file.Id),
+			Name:        file.Name,
+			Description: "Basic information about the spreadsheet and its sheets",
+			MIMEType:    "application/json",
+			Annotations: &mcp.Annotations{LastModified: file.ModifiedTime},
+		}})
+	}
+
+	s.spreadsheetList.set(folderID, spreadsheets)
+	return spreadsheets, nil
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// resultCache remembers the results of read-only tool calls for a short time, so re-reading the
+// same range within one reasoning chain does not repeat identical API calls
+type resultCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]resultCacheEntry
+	hits    int64
+	misses  int64
+}
+
+type resultCacheEntry struct {
+	result *mcp.CallToolResult
+	// spreadsheetIDs are every spreadsheet the call read, so a write to any of them drops the entry
+	spreadsheetIDs []string
+	storedAt       time.Time
+}
+
+// maxResultCacheEntries bounds the result cache; once full, expired entries are swept and then the
+// oldest ones evicted
+const maxResultCacheEntries = 1000
+
+// uncacheableTools are read-only tools whose results change on every call
+var uncacheableTools = map[string]bool{
+	"create_range_link": true,
+	"plan_operations":   true,
+	"cache_stats":       true,
+	"session_stats":     true,
+	"write_queue_stats": true,
+	"health_check":      true,
+}
+
+func newResultCache(ttl time.Duration) *resultCache {
+	return &resultCache{
+		ttl:     ttl,
+		entries: make(map[string]resultCacheEntry),
+	}
+}
+
+func (c *resultCache) enabled() bool {
+	return c.ttl > 0
+}
+
+// resultCacheKey identifies a call by who makes it, the tool name and the arguments. Results depend on
+// what the profile's account can see and what the tenant may see, so neither shares entries with
+// another. json.Marshal sorts map keys, so the same arguments always produce the same key.
+func resultCacheKey(profile, tenant, tool string, args map[string]any) (string, bool) {
+	key := maps.Clone(args)
+	delete(key, "force_refresh")
+	delete(key, "profile")
+	data, err := json.Marshal(key)
+	if err != nil {
+		return "", false
+	}
+	return profile + "\x00" + tenant + "\x00" + tool + "\x00" + string(data), true
+}
+
+func (c *resultCache) get(key string) (resultCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || time.Since(entry.storedAt) > c.ttl {
+		delete(c.entries, key)
+		c.misses++
+		return resultCacheEntry{}, false
+	}
+
+	c.hits++
+	return entry, true
+}
+
+func (c *resultCache) set(key string, spreadsheetIDs []string, result *mcp.CallToolResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= maxResultCacheEntries {
+		c.makeRoom()
+	}
+	c.entries[key] = resultCacheEntry{
+		result:         result,
+		spreadsheetIDs: spreadsheetIDs,
+		storedAt:       time.Now(),
+	}
+}
+
+// makeRoom drops expired entries, and the oldest entry when none had expired; c.mu must be held
+func (c *resultCache) makeRoom() {
+	oldestKey := ""
+	var oldest time.Time
+	for key, entry := range c.entries {
+		if time.Since(entry.storedAt) > c.ttl {
+			delete(c.entries, key)
+			continue
+		}
+		if oldestKey == "" || entry.storedAt.Before(oldest) {
+			oldestKey, oldest = key, entry.storedAt
+		}
+	}
+	if len(c.entries) >= maxResultCacheEntries {
+		delete(c.entries, oldestKey)
+	}
+}
+
+// invalidate drops every cached result that read from any of the spreadsheets
+func (c *resultCache) invalidate(spreadsheetIDs []string) {
+	if len(spreadsheetIDs) == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, entry := range c.entries {
+		for _, id := range entry.spreadsheetIDs {
+			if slices.Contains(spreadsheetIDs, id) {
+				delete(c.entries, key)
+				break
+			}
+		}
+	}
+}
+
+func (c *resultCache) stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := CacheStats{
+		Hits:    c.hits,
+		Misses:  c.misses,
+		Size:    len(c.entries),
+		TTL:     c.ttl.String(),
+		Enabled: c.enabled(),
+	}
+	if total := c.hits + c.misses; total > 0 {
+		stats.HitRatePct = c.hits * 100 / total
+	}
+	return stats
+}
+
+// withCachedResult serves repeated calls of a read-only tool from the result cache and reports
+// in the result's _meta whether it was served from cache and how old it is
+func (s *SheetsMCPServer) withCachedResult(name string, handler mcp.ToolHandler) mcp.ToolHandler {
+	return func(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArgsFromRequest(request)
+		if err != nil {
+			return handler(ctx, request)
+		}
+		tenant := ""
+		if t := tenantFromContext(ctx); t != nil {
+			tenant = t.Name
+		}
+		// Profiles share the cache, so that writes through one invalidate reads through another
+		key, ok := resultCacheKey(s.profile, tenant, name, args)
+		if !ok {
+			return handler(ctx, request)
+		}
+
+		if !parseArgument(args, "force_refresh", false) {
+			if entry, ok := s.resultCache.get(key); ok {
+				return withCacheMeta(entry.result, true, time.Since(entry.storedAt)), nil
+			}
+		}
+
+		result, err := handler(ctx, request)
+		if err != nil || result == nil || isErrorResult(result) {
+			return result, err
+		}
+
+		s.resultCache.set(key, spreadsheetIDArguments(args), result)
+		return withCacheMeta(result, false, 0), nil
+	}
+}
+
+// withInvalidation drops cached results for the spreadsheets a mutating tool wrote to
+func (s *SheetsMCPServer) withInvalidation(handler mcp.ToolHandler) mcp.ToolHandler {
+	return func(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		result, err := handler(ctx, request)
+		if args, argsErr := getArgsFromRequest(request); argsErr == nil {
+			s.resultCache.invalidate(spreadsheetIDArguments(args))
+		}
+		return result, err
+	}
+}
+
+// withCacheMeta returns a copy of result annotated with cache metadata, leaving the cached original untouched
+func withCacheMeta(result *mcp.CallToolResult, hit bool, age time.Duration) *mcp.CallToolResult {
+	annotated := *result
+	annotated.Meta = maps.Clone(result.Meta)
+	if annotated.Meta == nil {
+		annotated.Meta = mcp.Meta{}
+	}
+	annotated.Meta["cache"] = map[string]any{
+		"hit":   hit,
+		"ageMs": age.Milliseconds(),
+	}
+	return &annotated
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"io"
+	"maps"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// RetryPolicy decides how Google API requests that hit quota or transient server errors are retried
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt; 0 disables retrying
+	MaxRetries int64
+	// BaseDelay is the first backoff, doubled on every retry up to MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// retryableStatus lists the responses worth retrying: quota exhaustion and transient backend errors
+var retryableStatus = map[int]bool{
+	http.StatusTooManyRequests:     true,
+	http.StatusInternalServerError: true,
+	http.StatusServiceUnavailable:  true,
+}
+
+// retryTransport is an http.RoundTripper that retries retryable responses with exponential backoff
+// and full jitter, waiting at least as long as the server's Retry-After asks
+type retryTransport struct {
+	next   http.RoundTripper
+	policy RetryPolicy
+}
+
+// withRetries returns a copy of client whose requests are retried according to policy
+func withRetries(client *http.Client, policy RetryPolicy) *http.Client {
+	if policy.MaxRetries <= 0 {
+		return client
+	}
+	next := client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	retrying := *client
+	retrying.Transport = &retryTransport{next: next, policy: policy}
+	return &retrying
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Streamed uploads cannot be replayed, so only requests without a body or with a rewindable one are retried
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	repeatable := safeToRepeat(req)
+
+	for attempt := int64(0); ; attempt++ {
+		resp, err := t.next.RoundTrip(req)
+		if err != nil || !retryableStatus[resp.StatusCode] || !replayable || attempt >= t.policy.MaxRetries {
+			return resp, err
+		}
+		// A server error may come after the request took effect, so only quota refusals are retried
+		// for requests that would add something twice
+		if resp.StatusCode != http.StatusTooManyRequests && !repeatable {
+			return resp, err
+		}
+
+		delay := t.backoff(attempt, resp.Header.Get("Retry-After"))
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+		if counter, ok := req.Context().Value(retryCounterKey{}).(*atomic.Int64); ok {
+			counter.Add(1)
+		}
+	}
+}
+
+// safeToRepeat reports whether sending a request twice has the same effect as sending it once: the
+// idempotent HTTP methods, reads sent as POST, and value writes that overwrite or clear cells. Appends,
+// spreadsheet batchUpdates, copies and permission grants are not.
+func safeToRepeat(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	path := req.URL.Path
+	return isAPIRead(req) ||
+		strings.HasSuffix(path, "/values:batchUpdate") ||
+		strings.HasSuffix(path, "/values:batchClear") ||
+		strings.Contains(path, "/values/") && strings.HasSuffix(path, ":clear")
+}
+
+// backoff returns how long to wait before retry number attempt+1
+func (t *retryTransport) backoff(attempt int64, retryAfter string) time.Duration {
+	ceiling := t.policy.BaseDelay << attempt
+	if ceiling <= 0 || ceiling > t.policy.MaxDelay {
+		ceiling = t.policy.MaxDelay
+	}
+	delay := rand.N(ceiling + 1)
+
+	if seconds, err := strconv.Atoi(retryAfter); err == nil {
+		delay = max(delay, time.Duration(seconds)*time.Second)
+	} else if at, err := http.ParseTime(retryAfter); err == nil {
+		delay = max(delay, time.Until(at))
+	}
+	return delay
+}
+
+type retryCounterKey struct{}
+
+// withRetryCount counts the API retries a tool call needed and reports them in the result's
+// _meta as "retries", so agents can see when they are running into quota
+func (s *SheetsMCPServer) withRetryCount(handler mcp.ToolHandler) mcp.ToolHandler {
+	return func(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		counter := &atomic.Int64{}
+		result, err := handler(context.WithValue(ctx, retryCounterKey{}, counter), request)
+		if retries := counter.Load(); retries > 0 && result != nil {
+			annotated := *result
+			annotated.Meta = maps.Clone(result.Meta)
+			if annotated.Meta == nil {
+				annotated.Meta = mcp.Meta{}
+			}
+			annotated.Meta["retries"] = retries
+			result = &annotated
+		}
+		return result, err
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"google.golang.org/api/drive/v3"
+)
+
+const revisionFields = "id,modifiedTime,lastModifyingUser(displayName,emailAddress),keepForever,size"
+
+// revisionResult is a revision without its export links, and with export the sheet as it was then
+type revisionResult struct {
+	SpreadsheetID     string     `json:"spreadsheetId,omitempty"`
+	RevisionID        string     `json:"revisionId"`
+	ModifiedTime      string     `json:"modifiedTime"`
+	KeepForever       bool       `json:"keepForever"`
+	LastModifyingUser *driveUser `json:"lastModifyingUser,omitempty"`
+	Sheet             *string    `json:"sheet,omitempty"`
+	Values            [][]string `json:"values,omitzero"`
+	Truncated         bool       `json:"truncated,omitempty"`
+}
+
+// revisionsResult is a page of revisions
+type revisionsResult struct {
+	SpreadsheetID string           `json:"spreadsheetId"`
+	Revisions     []revisionResult `json:"revisions"`
+	NextPageToken string           `json:"nextPageToken"`
+}
+
+// revisionSummary describes a revision without its export links
+func revisionSummary(rev *drive.Revision) revisionResult {
+	summary := revisionResult{
+		RevisionID:   rev.Id,
+		ModifiedTime: rev.ModifiedTime,
+		KeepForever:  rev.KeepForever,
+	}
+	if rev.LastModifyingUser != nil {
+		summary.LastModifyingUser = &driveUser{
+			DisplayName:  rev.LastModifyingUser.DisplayName,
+			EmailAddress: rev.LastModifyingUser.EmailAddress,
+		}
+	}
+	return summary
+}
+
+func (s *SheetsMCPServer) handleListRevisions(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID := parseArgument(args, "spreadsheet_id", "")
+	pageSize := int64(parseArgument(args, "page_size", float64(100)))
+	pageToken := parseArgument(args, "page_token", "")
+
+	if spreadsheetID == "" {
+		return respondWithError("spreadsheet_id is required")
+	}
+	if pageSize < 1 || pageSize > 1000 {
+		return respondWithError("page_size must be between 1 and 1000")
+	}
+
+	call := s.driveService.Revisions.List(spreadsheetID).
+		PageSize(pageSize).
+		Fields("nextPageToken,revisions(" + revisionFields + ")")
+	if pageToken != "" {
+		call = call.PageToken(pageToken)
+	}
+
+	result, err := call.Context(ctx).Do()
+	if err != nil {
+		return s.respondWithAPIError("failed to list revisions", err)
+	}
+
+	revisions := make([]revisionResult, 0, len(result.Revisions))
+	for _, rev := range result.Revisions {
+		revisions = append(revisions, revisionSummary(rev))
+	}
+
+	response := revisionsResult{
+		SpreadsheetID: spreadsheetID,
+		Revisions:     revisions,
+		NextPageToken: result.NextPageToken,
+	}
+
+	return respondWithJSON(response)
+}
+
+func (s *SheetsMCPServer) handleGetRevision(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID := parseArgument(args, "spreadsheet_id", "")
+	revisionID := parseArgument(args, "revision_id", "")
+	export := parseArgument(args, "export", false)
+	sheet := parseArgument(args, "sheet", "")
+	maxRows := int(parseArgument(args, "max_rows", float64(1000)))
+
+	if spreadsheetID == "" || revisionID == "" {
+		return respondWithError("spreadsheet_id and revision_id are required")
+	}
+
+	rev, err := s.driveService.Revisions.Get(spreadsheetID, revisionID).
+		Fields(revisionFields + ",exportLinks").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return s.respondWithAPIError("failed to get revision", err)
+	}
+
+	response := revisionSummary(rev)
+	response.SpreadsheetID = spreadsheetID
+
+	if !export {
+		return respondWithJSON(response)
+	}
+
+	// Revisions of native spreadsheets can only be read back through their xlsx export
+	link := rev.ExportLinks[xlsxMimeType]
+	if link == "" {
+		return respondWithError(fmt.Sprintf("revision %s cannot be exported", revisionID))
+	}
+
+	workbook, err := s.downloadWorkbook(link)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+
+	values, err := readXLSXWorksheet(workbook, sheet)
+	if errors.Is(err, errWorksheetNotFound) {
+		return respondWithError(fmt.Sprintf("sheet '%s' did not exist in revision %s", sheet, revisionID))
+	} else if err != nil {
+		return respondWithError(fmt.Sprintf("failed to read revision export: %v", err))
+	}
+
+	if maxRows > 0 && len(values) > maxRows {
+		response.Truncated = true
+		values = values[:maxRows]
+	}
+	response.Sheet = &sheet
+	response.Values = values
+
+	return respondWithJSON(response)
+}
@@ -1,29 +1,122 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/oauth2"
+	admin "google.golang.org/api/admin/directory/v1"
+	"google.golang.org/api/docs/v1"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/driveactivity/v2"
+	"google.golang.org/api/gmail/v1"
 	"google.golang.org/api/sheets/v4"
 )
 
 type SheetsMCPServer struct {
-	mcpServer     *mcp.Server
-	sheetsService *sheets.Service
+	mcpServer           *mcp.Server
+	sheetsService       *sheets.Service
+	driveService        *drive.Service
+	activityService     *driveactivity.Service
+	directoryService    *admin.Service
+	docsService         *docs.Service
+	gmailService        *gmail.Service
+	httpClient          *http.Client
+	driveUnavailable    string
+	serviceAccountEmail string
+	tokenSource         oauth2.TokenSource
+	authMethod          string
+	scopeMode           string
+	scopes              []string
+	config              *ServerConfig
+	dataSources         bool
+	metadataCache       *sheetMetadataCache
+	resultCache         *resultCache
+	writeQueue          *writeQueue
+	sessionStats        *sessionStatsRegistry
+	exports             *exportStore
+	spreadsheetList     *spreadsheetListCache
+	audit               *auditLog
+	// toolNames holds every tool the server knows, registered or not
+	toolNames map[string]bool
+	// profile names the account the server acts as when PROFILES_FILE is set; profiles holds the
+	// servers of every profile, keyed by name, and is nil without PROFILES_FILE
+	profile  string
+	profiles map[string]*SheetsMCPServer
+	// profileTools are the wrapped tools waiting for registerProfileTools
+	profileTools []profileTool
 }
 
 func NewSheetsMCPServer(ctx context.Context) (*SheetsMCPServer, error) {
 	authConfig := LoadAuthConfig()
 
-	sheetsService, _, err := authConfig.CreateServices(ctx)
+	config, err := LoadServerConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load server config: %w", err)
+	}
+
+	profiles, err := loadProfiles(authConfig.ProfilesFile)
+	if err != nil {
+		return nil, err
+	}
+	defaultAuth := authConfig
+	if profiles != nil {
+		defaultAuth = authConfig.forProfile(profiles.Default, profiles.Profiles[profiles.Default])
+	}
+
+	services, err := defaultAuth.CreateServices(ctx, config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create services: %w", err)
 	}
 
+	audit, err := openAuditLog(config.AuditLogFile)
+	if err != nil {
+		return nil, err
+	}
+
 	s := &SheetsMCPServer{
-		sheetsService: sheetsService,
+		sheetsService:       services.Sheets,
+		driveService:        services.Drive,
+		activityService:     services.Activity,
+		directoryService:    services.Directory,
+		docsService:         services.Docs,
+		gmailService:        services.Gmail,
+		httpClient:          services.HTTPClient,
+		serviceAccountEmail: services.ServiceAccountEmail,
+		tokenSource:         services.TokenSource,
+		authMethod:          services.AuthMethod,
+		scopeMode:           authConfig.ScopeMode,
+		scopes:              services.Scopes,
+		dataSources:         authConfig.BigQueryDataSources,
+		config:              config,
+		metadataCache:       newSheetMetadataCache(config.MetadataCacheTTL),
+		resultCache:         newResultCache(config.ResultCacheTTL),
+		writeQueue:          newWriteQueue(),
+		sessionStats:        newSessionStatsRegistry(),
+		exports:             newExportStore(),
+		spreadsheetList:     newSpreadsheetListCache(),
+		audit:               audit,
+		toolNames:           make(map[string]bool),
+	}
+	if profiles != nil {
+		s.profile = profiles.Default
+	}
+
+	// Over stdio the client is the parent process, so there is no connection to keep alive
+	serverOptions := &mcp.ServerOptions{}
+	if config.Transport != "stdio" {
+		serverOptions.KeepAlive = config.KeepAlive
 	}
 
 	mcpServer := mcp.NewServer(
@@ -31,38 +124,179 @@ func NewSheetsMCPServer(ctx context.Context) (*SheetsMCPServer, error) {
 			Name:    "Google Spreadsheet",
 			Version: "1.0.0",
 		},
-		nil,
+		serverOptions,
 	)
 
 	s.mcpServer = mcpServer
+	mcpServer.AddReceivingMiddleware(s.withSpreadsheetResources)
+	s.checkDriveAccess(ctx)
+	if profiles != nil {
+		if err := s.addProfiles(ctx, authConfig, profiles); err != nil {
+			return nil, err
+		}
+	}
 	s.registerTools()
+	if s.profiles != nil {
+		s.registerProfileTools()
+	}
+	s.warnUnknownTools()
+	if err := s.checkToolTables(); err != nil {
+		return nil, err
+	}
 	s.registerResources()
+	s.registerPrompts()
 
 	return s, nil
 }
 
 func (s *SheetsMCPServer) Run(ctx context.Context) error {
+	if s.config.Transport != "stdio" {
+		return s.runHTTP(ctx)
+	}
 	return s.mcpServer.Run(ctx, &mcp.StdioTransport{})
 }
 
 func (s *SheetsMCPServer) registerTools() {
 	// Sheet data operations
-	s.mcpServer.AddTool(&mcp.Tool{
+	s.addTool(&mcp.Tool{
 		Name:        "get_sheet_data",
 		Description: "Get data from a specific sheet in a Google Spreadsheet",
 		InputSchema: mustSchema(map[string]any{
 			"type": "object",
 			"properties": map[string]any{
-				"spreadsheet_id":    map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
-				"sheet":             map[string]any{"type": "string", "description": "The name of the sheet"},
-				"range":             map[string]any{"type": "string", "description": "Optional cell range in A1 notation"},
-				"include_grid_data": map[string]any{"type": "boolean", "description": "If True, includes cell formatting and metadata"},
+				"spreadsheet_id":          map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+				"sheet":                   map[string]any{"type": "string", "description": "The name of the sheet"},
+				"range":                   map[string]any{"type": "string", "description": "Optional cell range in A1 notation"},
+				"include_grid_data":       map[string]any{"type": "boolean", "description": "If True, includes cell formatting and metadata; refused when it exceeds the server's cell cap"},
+				"output_format":           map[string]any{"type": "string", "description": "Response format for values: json, csv, or markdown (a table whose header is the first row; default: json)", "enum": []string{"json", "csv", "markdown"}},
+				"value_render_option":     map[string]any{"type": "string", "description": "FORMATTED_VALUE (as displayed, e.g. \"$1,234.56\"), UNFORMATTED_VALUE (raw numbers), or FORMULA (default: FORMATTED_VALUE)", "enum": []string{"FORMATTED_VALUE", "UNFORMATTED_VALUE", "FORMULA"}},
+				"date_time_render_option": map[string]any{"type": "string", "description": "How unformatted dates are returned: SERIAL_NUMBER or FORMATTED_STRING (default: SERIAL_NUMBER)", "enum": []string{"SERIAL_NUMBER", "FORMATTED_STRING"}},
+				"iso_dates":               map[string]any{"type": "boolean", "description": "Return cells formatted as dates, times or date-times as ISO-8601 text (2024-03-01, 09:30:00, 2024-03-01T09:30:00+01:00 in the spreadsheet time zone) whatever their display format (default: false)"},
+				"major_dimension":         map[string]any{"type": "string", "description": "ROWS if each inner array is a row, COLUMNS if it is a column (default: ROWS)", "enum": []string{"ROWS", "COLUMNS"}},
+				"as_records":              map[string]any{"type": "boolean", "description": "Return an array of objects keyed by the header row instead of a 2D array (default: false)"},
+				"header_row":              map[string]any{"type": "number", "description": "The row within the range that holds the headers, 1-based; with as_records rows above it are skipped (default: 1)"},
+				"coerce_types":            map[string]any{"type": "boolean", "description": "Turn numeric text into numbers, TRUE/FALSE into booleans, and empty cells into null, so every row has consistent JSON types (default: false)"},
+				"column_types":            map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "string", "enum": coerceKinds}, "description": "Per-column types by header or letter, overriding coerce_types, e.g. {\"Zip\": \"string\", \"Total\": \"number\"}; number also reads displayed values such as $1,234.56 or 12%"},
+				"row_offset":              map[string]any{"type": "number", "description": "Skip this many rows (data rows with as_records, columns with major_dimension COLUMNS) before returning any; use nextOffset from a previous page (default: 0)"},
+				"row_limit":               map[string]any{"type": "number", "description": "Return at most this many rows (columns with major_dimension COLUMNS; default: all, up to the server's cell cap)"},
 			},
 			"required": []string{"spreadsheet_id", "sheet"},
 		}),
 	}, s.handleGetSheetData)
 
-	s.mcpServer.AddTool(&mcp.Tool{
+	s.addTool(&mcp.Tool{
+		Name:        "find_rows",
+		Description: "Find the rows of a sheet whose value in one column equals a value or matches a regex, returning them with their 1-based row numbers; nothing is modified",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+				"sheet":          map[string]any{"type": "string", "description": "The name of the sheet"},
+				"column":         map[string]any{"type": "string", "description": "Header name (ignoring case) or column letter to search"},
+				"value":          map[string]any{"type": "string", "description": "Match cells equal to this text"},
+				"regex":          map[string]any{"type": "string", "description": "Match cells containing this regular expression (RE2 syntax) instead"},
+				"match_case":     map[string]any{"type": "boolean", "description": "Compare case-sensitively (default: false)"},
+				"header_row":     map[string]any{"type": "number", "description": "Row holding the headers, 1-based; 0 if the sheet has none, in which case rows are returned as arrays (default: 1)"},
+				"max_results":    map[string]any{"type": "number", "description": "Maximum number of rows to return (default: 100)"},
+			},
+			"required": []string{"spreadsheet_id", "sheet", "column"},
+		}),
+	}, s.handleFindRows)
+
+	s.addTool(&mcp.Tool{
+		Name:        "query_sheet",
+		Description: "Run a SQL-like query over a sheet and return only the resulting table: SELECT columns or aggregates (count, sum, avg, min, max), WHERE filters, GROUP BY, ORDER BY, LIMIT and OFFSET, e.g. SELECT Region, sum(Amount) AS Total WHERE Status = 'paid' GROUP BY Region ORDER BY Total DESC LIMIT 5",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+				"sheet":          map[string]any{"type": "string", "description": "The name of the sheet"},
+				"query":          map[string]any{"type": "string", "description": "The query. Columns are header names (in `backquotes` if they contain spaces) or column letters; text values go in single quotes. WHERE supports = != < <= > >=, AND, OR, NOT, IS [NOT] NULL, IN (...), CONTAINS, STARTS WITH, ENDS WITH, LIKE ('%' and '_' wildcards) and MATCHES (regex)"},
+				"range":          map[string]any{"type": "string", "description": "Range to query in A1 notation, headers included (default: the whole sheet)"},
+				"header_row":     map[string]any{"type": "number", "description": "Row holding the headers, 1-based within the range; 0 if there are none, in which case columns are named by letter (default: 1)"},
+				"match_case":     map[string]any{"type": "boolean", "description": "Compare, sort and match text case-sensitively (default: false)"},
+				"output_format":  map[string]any{"type": "string", "description": "Output format: json, csv, or markdown (default: json)", "enum": []string{"json", "csv", "markdown"}},
+			},
+			"required": []string{"spreadsheet_id", "sheet", "query"},
+		}),
+	}, s.handleQuerySheet)
+
+	s.addTool(&mcp.Tool{
+		Name:        "aggregate_range",
+		Description: "Compute SUM, AVG, MIN, MAX, COUNT (numbers) and COUNTA (non-empty cells) for columns of a sheet, optionally per group of rows sharing the same values in group_by columns, without reading the data or writing formulas into the sheet",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+				"sheet":          map[string]any{"type": "string", "description": "The name of the sheet"},
+				"range":          map[string]any{"type": "string", "description": "Range to aggregate in A1 notation, headers included (default: the whole sheet)"},
+				"columns": map[string]any{
+					"type":        "array",
+					"description": "Header names or column letters to aggregate (default: every column not in group_by)",
+					"items":       map[string]any{"type": "string"},
+				},
+				"functions": map[string]any{
+					"type":        "array",
+					"description": "Aggregates to compute (default: all)",
+					"items":       map[string]any{"type": "string", "enum": aggregateFunctions},
+				},
+				"group_by": map[string]any{
+					"type":        "array",
+					"description": "Header names or column letters whose values group the rows; each group gets its own aggregates",
+					"items":       map[string]any{"type": "string"},
+				},
+				"header_row": map[string]any{"type": "number", "description": "Row holding the headers, 1-based within the range; 0 if there are none (default: 1)"},
+			},
+			"required": []string{"spreadsheet_id", "sheet"},
+		}),
+	}, s.handleAggregateRange)
+
+	s.addTool(&mcp.Tool{
+		Name:        "evaluate_formula",
+		Description: "Let Sheets compute a formula, such as =SUMIFS(Sales!C:C, Sales!A:A, \"East\"), and return its value without touching the user's sheets: the formula is evaluated in a hidden scratch sheet that is deleted afterwards. Refer to cells with sheet names, since unqualified references point into the empty scratch sheet",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_id":          map[string]any{"type": "string", "description": "The ID of the spreadsheet to evaluate the formula in"},
+				"formula":                 map[string]any{"type": "string", "description": "The formula, with or without the leading ="},
+				"value_render_option":     map[string]any{"type": "string", "description": "FORMATTED_VALUE returns the value as displayed, UNFORMATTED_VALUE the raw number (default: FORMATTED_VALUE)", "enum": []string{"FORMATTED_VALUE", "UNFORMATTED_VALUE"}},
+				"date_time_render_option": map[string]any{"type": "string", "description": "With UNFORMATTED_VALUE, SERIAL_NUMBER returns dates as serial numbers and FORMATTED_STRING as text (default: SERIAL_NUMBER)", "enum": []string{"SERIAL_NUMBER", "FORMATTED_STRING"}},
+			},
+			"required": []string{"spreadsheet_id", "formula"},
+		}),
+	}, s.handleEvaluateFormula)
+
+	s.addTool(&mcp.Tool{
+		Name:        "get_used_range",
+		Description: "Find where the data in a sheet actually is: the used range in A1 notation, last row and column, whether the first row looks like headers, and the number of non-empty cells. Use it before reading to avoid fetching huge empty ranges",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+				"sheet":          map[string]any{"type": "string", "description": "The name of the sheet"},
+			},
+			"required": []string{"spreadsheet_id", "sheet"},
+		}),
+	}, s.handleGetUsedRange)
+
+	s.addTool(&mcp.Tool{
+		Name:        "search_values",
+		Description: "Search every sheet of a spreadsheet, or of the spreadsheets in a folder, for cells containing some text or matching a regex, returning where each hit is",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"query":            map[string]any{"type": "string", "description": "Find cells containing this text"},
+				"regex":            map[string]any{"type": "string", "description": "Find cells matching this regular expression (RE2 syntax) instead"},
+				"match_case":       map[string]any{"type": "boolean", "description": "Compare case-sensitively (default: false)"},
+				"spreadsheet_id":   map[string]any{"type": "string", "description": "Search only this spreadsheet"},
+				"folder_id":        map[string]any{"type": "string", "description": "Without spreadsheet_id, search the spreadsheets in this folder (default: DRIVE_FOLDER_ID or SHARED_DRIVE_ID, otherwise the most recently modified spreadsheets)"},
+				"max_results":      map[string]any{"type": "number", "description": "Maximum number of hits to return (default: 100)"},
+				"max_spreadsheets": map[string]any{"type": "number", "description": "Without spreadsheet_id, the most spreadsheets to search, newest first, 1-100 (default: 20)"},
+			},
+		}),
+	}, s.handleSearchValues)
+
+	s.addTool(&mcp.Tool{
 		Name:        "get_sheet_formulas",
 		Description: "Get formulas from a specific sheet in a Google Spreadsheet",
 		InputSchema: mustSchema(map[string]any{
@@ -71,12 +305,28 @@ func (s *SheetsMCPServer) registerTools() {
 				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
 				"sheet":          map[string]any{"type": "string", "description": "The name of the sheet"},
 				"range":          map[string]any{"type": "string", "description": "Optional cell range in A1 notation"},
+				"output_format":  map[string]any{"type": "string", "description": "Response format for formulas: json, csv, or markdown (default: json)", "enum": []string{"json", "csv", "markdown"}},
 			},
 			"required": []string{"spreadsheet_id", "sheet"},
 		}),
 	}, s.handleGetSheetFormulas)
 
-	s.mcpServer.AddTool(&mcp.Tool{
+	s.addTool(&mcp.Tool{
+		Name:        "hash_range",
+		Description: "Return a deterministic SHA-256 fingerprint of a range's values to cheaply check whether anything changed",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_id":   map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+				"sheet":            map[string]any{"type": "string", "description": "The name of the sheet"},
+				"range":            map[string]any{"type": "string", "description": "Optional cell range in A1 notation (default: whole sheet)"},
+				"include_formulas": map[string]any{"type": "boolean", "description": "Hash formulas instead of their computed values (default: false)"},
+			},
+			"required": []string{"spreadsheet_id", "sheet"},
+		}),
+	}, s.handleHashRange)
+
+	s.addTool(&mcp.Tool{
 		Name:        "update_cells",
 		Description: "Update cells in a Google Spreadsheet",
 		InputSchema: mustSchema(map[string]any{
@@ -93,27 +343,36 @@ func (s *SheetsMCPServer) registerTools() {
 						"items": map[string]any{},
 					},
 				},
+				"locale":             map[string]any{"type": "string", "description": "Parse numeric strings written in this locale (e.g. de_DE, en_IN) into numbers, or \"none\" to write them as given (\"auto\" uses the spreadsheet locale; default: none, or auto with LOCALE_NUMBERS and USER_ENTERED)"},
+				"iso_dates":          map[string]any{"type": "boolean", "description": "Turn ISO-8601 strings such as 2024-03-01 or 2024-03-01T09:30:00Z into dates; offsets are converted to the spreadsheet time zone (default: false)"},
+				"value_input_option": map[string]any{"type": "string", "description": "USER_ENTERED parses values as if typed (formulas, dates, numbers); RAW stores text exactly as given, so \"=x\" or \"3/4\" are not interpreted (default: USER_ENTERED)", "enum": []string{"USER_ENTERED", "RAW"}},
+				"sanitize_input":     map[string]any{"type": "boolean", "description": "Store text starting with =, +, - or @ as plain text so untrusted data cannot inject formulas (default: SANITIZE_INPUT, normally false)"},
+				"major_dimension":    map[string]any{"type": "string", "description": "ROWS if each inner array is a row, COLUMNS if it is a column (default: ROWS)", "enum": []string{"ROWS", "COLUMNS"}},
 			},
 			"required": []string{"spreadsheet_id", "sheet", "range", "data"},
 		}),
 	}, s.handleUpdateCells)
 
-	s.mcpServer.AddTool(&mcp.Tool{
+	s.addTool(&mcp.Tool{
 		Name:        "batch_update_cells",
 		Description: "Batch update multiple ranges in a Google Spreadsheet",
 		InputSchema: mustSchema(map[string]any{
 			"type": "object",
 			"properties": map[string]any{
-				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
-				"sheet":          map[string]any{"type": "string", "description": "The name of the sheet"},
-				"ranges":         map[string]any{"type": "object", "description": "Dictionary mapping range strings to 2D arrays of values"},
+				"spreadsheet_id":     map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+				"sheet":              map[string]any{"type": "string", "description": "The name of the sheet"},
+				"ranges":             map[string]any{"type": "object", "description": "Dictionary mapping range strings to 2D arrays of values"},
+				"locale":             map[string]any{"type": "string", "description": "Parse numeric strings written in this locale (e.g. de_DE, en_IN) into numbers, or \"none\" to write them as given (\"auto\" uses the spreadsheet locale; default: none, or auto with LOCALE_NUMBERS and USER_ENTERED)"},
+				"iso_dates":          map[string]any{"type": "boolean", "description": "Turn ISO-8601 strings such as 2024-03-01 or 2024-03-01T09:30:00Z into dates; offsets are converted to the spreadsheet time zone (default: false)"},
+				"value_input_option": map[string]any{"type": "string", "description": "USER_ENTERED parses values as if typed (formulas, dates, numbers); RAW stores text exactly as given, so \"=x\" or \"3/4\" are not interpreted (default: USER_ENTERED)", "enum": []string{"USER_ENTERED", "RAW"}},
+				"sanitize_input":     map[string]any{"type": "boolean", "description": "Store text starting with =, +, - or @ as plain text so untrusted data cannot inject formulas (default: SANITIZE_INPUT, normally false)"},
 			},
 			"required": []string{"spreadsheet_id", "sheet", "ranges"},
 		}),
 	}, s.handleBatchUpdateCells)
 
 	// Row and column operations
-	s.mcpServer.AddTool(&mcp.Tool{
+	s.addTool(&mcp.Tool{
 		Name:        "add_rows",
 		Description: "Add rows to a sheet in a Google Spreadsheet",
 		InputSchema: mustSchema(map[string]any{
@@ -128,7 +387,35 @@ func (s *SheetsMCPServer) registerTools() {
 		}),
 	}, s.handleAddRows)
 
-	s.mcpServer.AddTool(&mcp.Tool{
+	s.addTool(&mcp.Tool{
+		Name:        "insert_rows_with_data",
+		Description: "Insert new rows at a row number and fill them with data in one step; the rows below move down and the new rows take the formatting of the row above",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+				"sheet":          map[string]any{"type": "string", "description": "The name of the sheet"},
+				"start_row":      map[string]any{"type": "number", "description": "1-based row number the first new row gets; the row currently there moves down"},
+				"data": map[string]any{
+					"type":        "array",
+					"description": "2D array of values, one inner array per new row",
+					"items": map[string]any{
+						"type":  "array",
+						"items": map[string]any{},
+					},
+				},
+				"start_column":       map[string]any{"type": "string", "description": "Column letter the values start in (default: A)"},
+				"locale":             map[string]any{"type": "string", "description": "Parse numeric strings written in this locale (e.g. de_DE, en_IN) into numbers, or \"none\" to write them as given (\"auto\" uses the spreadsheet locale; default: none, or auto with LOCALE_NUMBERS and USER_ENTERED)"},
+				"iso_dates":          map[string]any{"type": "boolean", "description": "Turn ISO-8601 strings such as 2024-03-01 or 2024-03-01T09:30:00Z into dates; offsets are converted to the spreadsheet time zone (default: false)"},
+				"value_input_option": map[string]any{"type": "string", "description": "USER_ENTERED parses values as if typed (formulas, dates, numbers); RAW stores text exactly as given, so \"=x\" or \"3/4\" are not interpreted (default: USER_ENTERED)", "enum": []string{"USER_ENTERED", "RAW"}},
+				"sanitize_input":     map[string]any{"type": "boolean", "description": "Store text starting with =, +, - or @ as plain text so untrusted data cannot inject formulas (default: SANITIZE_INPUT, normally false)"},
+				"protect_headers":    map[string]any{"type": "number", "description": "Number of header rows that must not be modified (default: the PROTECT_HEADER_ROWS setting or the sheet's frozen rows, whichever is more; 0 disables)"},
+			},
+			"required": []string{"spreadsheet_id", "sheet", "start_row", "data"},
+		}),
+	}, s.handleInsertRowsWithData)
+
+	s.addTool(&mcp.Tool{
 		Name:        "add_columns",
 		Description: "Add columns to a sheet in a Google Spreadsheet",
 		InputSchema: mustSchema(map[string]any{
@@ -143,8 +430,82 @@ func (s *SheetsMCPServer) registerTools() {
 		}),
 	}, s.handleAddColumns)
 
+	s.addTool(&mcp.Tool{
+		Name:        "reorder_columns",
+		Description: "Rearrange the columns of a sheet by header name: the listed headers come first in the given order, and all other columns follow in their current order",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+				"sheet":          map[string]any{"type": "string", "description": "The name of the sheet"},
+				"order": map[string]any{
+					"type":        "array",
+					"description": "Header names in the desired order (matched case-insensitively)",
+					"items":       map[string]any{"type": "string"},
+				},
+				"header_row": map[string]any{"type": "number", "description": "1-based row holding the headers (default: 1)"},
+			},
+			"required": []string{"spreadsheet_id", "sheet", "order"},
+		}),
+	}, s.handleReorderColumns)
+
+	s.addTool(&mcp.Tool{
+		Name:        "get_headers",
+		Description: "Get the header row of a sheet with each header's column letter and 0-based index, without reading any data",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+				"sheet":          map[string]any{"type": "string", "description": "The name of the sheet"},
+				"header_row":     map[string]any{"type": "number", "description": "Row holding the headers, 1-based (default: 1)"},
+			},
+			"required": []string{"spreadsheet_id", "sheet"},
+		}),
+	}, s.handleGetHeaders)
+
+	s.addTool(&mcp.Tool{
+		Name:        "find_column",
+		Description: "Find the column letter of a header, ignoring case, along with the A1 range of the data below it",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+				"sheet":          map[string]any{"type": "string", "description": "The name of the sheet"},
+				"header":         map[string]any{"type": "string", "description": "The header name to look for"},
+				"header_row":     map[string]any{"type": "number", "description": "Row holding the headers, 1-based (default: 1)"},
+			},
+			"required": []string{"spreadsheet_id", "sheet", "header"},
+		}),
+	}, s.handleFindColumn)
+
+	s.addTool(&mcp.Tool{
+		Name:        "normalize_headers",
+		Description: "Clean up the header row of a sheet: rename headers, convert them to snake_case or Title Case, and suffix duplicates; returns the final header schema",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+				"sheet":          map[string]any{"type": "string", "description": "The name of the sheet"},
+				"rename": map[string]any{
+					"type":                 "object",
+					"description":          "Map of current header name to new name, applied before the case conversion",
+					"additionalProperties": map[string]any{"type": "string"},
+				},
+				"case": map[string]any{
+					"type":        "string",
+					"description": "Case to convert every header to (default: keep)",
+					"enum":        []string{"snake_case", "title_case"},
+				},
+				"dedupe":     map[string]any{"type": "boolean", "description": "Add numeric suffixes to duplicate headers (default: true)"},
+				"header_row": map[string]any{"type": "number", "description": "1-based row holding the headers (default: 1)"},
+				"dry_run":    map[string]any{"type": "boolean", "description": "Only report the resulting headers (default: false, always on with DRY_RUN)"},
+			},
+			"required": []string{"spreadsheet_id", "sheet"},
+		}),
+	}, s.handleNormalizeHeaders)
+
 	// Sheet management
-	s.mcpServer.AddTool(&mcp.Tool{
+	s.addTool(&mcp.Tool{
 		Name:        "list_sheets",
 		Description: "List all sheets in a Google Spreadsheet",
 		InputSchema: mustSchema(map[string]any{
@@ -156,7 +517,7 @@ func (s *SheetsMCPServer) registerTools() {
 		}),
 	}, s.handleListSheets)
 
-	s.mcpServer.AddTool(&mcp.Tool{
+	s.addTool(&mcp.Tool{
 		Name:        "create_sheet",
 		Description: "Create a new sheet tab in an existing Google Spreadsheet",
 		InputSchema: mustSchema(map[string]any{
@@ -169,7 +530,7 @@ func (s *SheetsMCPServer) registerTools() {
 		}),
 	}, s.handleCreateSheet)
 
-	s.mcpServer.AddTool(&mcp.Tool{
+	s.addTool(&mcp.Tool{
 		Name:        "copy_sheet",
 		Description: "Copy a sheet from one spreadsheet to another",
 		InputSchema: mustSchema(map[string]any{
@@ -184,7 +545,7 @@ func (s *SheetsMCPServer) registerTools() {
 		}),
 	}, s.handleCopySheet)
 
-	s.mcpServer.AddTool(&mcp.Tool{
+	s.addTool(&mcp.Tool{
 		Name:        "rename_sheet",
 		Description: "Rename a sheet in a Google Spreadsheet",
 		InputSchema: mustSchema(map[string]any{
@@ -198,205 +559,969 @@ func (s *SheetsMCPServer) registerTools() {
 		}),
 	}, s.handleRenameSheet)
 
-	// Spreadsheet operations
-	s.mcpServer.AddTool(&mcp.Tool{
-		Name:        "create_spreadsheet",
-		Description: "Create a new Google Spreadsheet",
+	s.addTool(&mcp.Tool{
+		Name:        "set_sheet_properties",
+		Description: "Set tab color, position, right-to-left direction, and gridline visibility of a sheet in one call",
 		InputSchema: mustSchema(map[string]any{
 			"type": "object",
 			"properties": map[string]any{
-				"title": map[string]any{"type": "string", "description": "The title of the new spreadsheet"},
+				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+				"sheet":          map[string]any{"type": "string", "description": "The name of the sheet"},
+				"tab_color":      map[string]any{"type": "object", "description": "Tab color {red, green, blue, alpha} (0.0-1.0)"},
+				"index":          map[string]any{"type": "number", "description": "0-based position of the sheet among the tabs"},
+				"right_to_left":  map[string]any{"type": "boolean", "description": "Lay the sheet out right-to-left"},
+				"hide_gridlines": map[string]any{"type": "boolean", "description": "Hide gridlines in the sheet UI"},
 			},
-			"required": []string{"title"},
+			"required": []string{"spreadsheet_id", "sheet"},
 		}),
-	}, s.handleCreateSpreadsheet)
+	}, s.handleSetSheetProperties)
 
-	// Multiple queries
-	s.mcpServer.AddTool(&mcp.Tool{
-		Name:        "get_multiple_sheet_data",
-		Description: "Get data from multiple specific ranges in Google Spreadsheets",
+	s.addTool(&mcp.Tool{
+		Name:        "compare_with_file",
+		Description: "Compare a sheet against a local .xlsx or .csv file and report the cells that differ",
 		InputSchema: mustSchema(map[string]any{
 			"type": "object",
 			"properties": map[string]any{
-				"queries": map[string]any{
-					"type":        "array",
-					"description": "List of query objects with spreadsheet_id, sheet, and range",
-					"items": map[string]any{
-						"type":                 "object",
-						"additionalProperties": true,
-					},
-				},
+				"spreadsheet_id":  map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+				"sheet":           map[string]any{"type": "string", "description": "The name of the sheet"},
+				"range":           map[string]any{"type": "string", "description": "Optional cell range in A1 notation to compare (default: whole sheet)"},
+				"file_path":       map[string]any{"type": "string", "description": "Path to a local .xlsx or .csv file; relative to FILE_ROOT when set"},
+				"file_sheet":      map[string]any{"type": "string", "description": "Worksheet name inside the .xlsx file (default: first worksheet)"},
+				"max_differences": map[string]any{"type": "number", "description": "Maximum number of differing cells to list (default: 100)"},
 			},
-			"required": []string{"queries"},
+			"required": []string{"spreadsheet_id", "sheet", "file_path"},
 		}),
-	}, s.handleGetMultipleSheetData)
+	}, s.handleCompareWithFile)
 
-	s.mcpServer.AddTool(&mcp.Tool{
-		Name:        "get_multiple_spreadsheet_summary",
-		Description: "Get a summary of multiple Google Spreadsheets",
+	// Change tracking
+	digestDescription := "Summarize who changed a spreadsheet and which values changed over a time window, combining revision history and value diffs"
+	if s.activityService != nil {
+		digestDescription = "Summarize who changed a spreadsheet and which values changed over a time window, combining revision history, Drive activity, and value diffs"
+	}
+	s.addTool(&mcp.Tool{
+		Name:        "generate_change_digest",
+		Description: digestDescription,
 		InputSchema: mustSchema(map[string]any{
 			"type": "object",
 			"properties": map[string]any{
-				"spreadsheet_ids": map[string]any{
-					"type":        "array",
-					"description": "List of spreadsheet IDs",
-					"items": map[string]any{
-						"type": "string",
-					},
-				},
-				"rows_to_fetch": map[string]any{"type": "number", "description": "Number of rows to fetch for summary (default: 5)"},
+				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+				"since":          map[string]any{"type": "string", "description": "Start of the window: RFC 3339 time, YYYY-MM-DD, or a duration ago such as 24h (default: 168h)"},
+				"until":          map[string]any{"type": "string", "description": "End of the window in the same formats (default: now)"},
+				"output":         map[string]any{"type": "string", "description": "markdown to return the digest, or sheet to write it to digest_sheet (default: markdown)"},
+				"digest_sheet":   map[string]any{"type": "string", "description": "Sheet to write the digest to when output is sheet (default: Digest)"},
+				"max_changes":    map[string]any{"type": "number", "description": "Maximum number of changed cells listed per sheet (default: 50)"},
 			},
-			"required": []string{"spreadsheet_ids"},
+			"required": []string{"spreadsheet_id"},
 		}),
-	}, s.handleGetMultipleSpreadsheetSummary)
+	}, s.handleGenerateChangeDigest)
 
-	// Advanced data operations
-	s.mcpServer.AddTool(&mcp.Tool{
-		Name:        "append_data",
-		Description: "Append data to the end of a sheet without specifying exact range",
+	s.addTool(&mcp.Tool{
+		Name:        "list_revisions",
+		Description: "List the Drive revision history of a spreadsheet with modification times and editors",
 		InputSchema: mustSchema(map[string]any{
 			"type": "object",
 			"properties": map[string]any{
 				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
-				"sheet":          map[string]any{"type": "string", "description": "The name of the sheet"},
-				"data": map[string]any{
-					"type":        "array",
-					"description": "2D array of values to append",
-					"items": map[string]any{
-						"type":  "array",
-						"items": map[string]any{},
-					},
-				},
+				"page_size":      map[string]any{"type": "number", "description": "Maximum number of revisions per page, 1-1000 (default: 100)"},
+				"page_token":     map[string]any{"type": "string", "description": "nextPageToken from a previous call to fetch the next page"},
 			},
-			"required": []string{"spreadsheet_id", "sheet", "data"},
+			"required": []string{"spreadsheet_id"},
 		}),
-	}, s.handleAppendData)
+	}, s.handleListRevisions)
 
-	s.mcpServer.AddTool(&mcp.Tool{
-		Name:        "clear_range",
-		Description: "Clear content from a specific range in a sheet",
+	s.addTool(&mcp.Tool{
+		Name:        "get_revision",
+		Description: "Get one revision of a spreadsheet, optionally exporting the values a sheet had at that revision",
 		InputSchema: mustSchema(map[string]any{
 			"type": "object",
 			"properties": map[string]any{
 				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
-				"sheet":          map[string]any{"type": "string", "description": "The name of the sheet"},
-				"range":          map[string]any{"type": "string", "description": "Cell range in A1 notation to clear"},
+				"revision_id":    map[string]any{"type": "string", "description": "The revision ID from list_revisions"},
+				"export":         map[string]any{"type": "boolean", "description": "Include the values of a sheet as of this revision (default: false)"},
+				"sheet":          map[string]any{"type": "string", "description": "Sheet to export (default: first sheet)"},
+				"max_rows":       map[string]any{"type": "number", "description": "Maximum number of exported rows (default: 1000, 0 for no limit)"},
 			},
-			"required": []string{"spreadsheet_id", "sheet", "range"},
+			"required": []string{"spreadsheet_id", "revision_id"},
 		}),
-	}, s.handleClearRange)
+	}, s.handleGetRevision)
 
-	s.mcpServer.AddTool(&mcp.Tool{
-		Name:        "delete_sheet",
-		Description: "Delete a sheet tab from a spreadsheet",
+	// Spreadsheet operations
+	s.addTool(&mcp.Tool{
+		Name:        "create_spreadsheet",
+		Description: "Create a new Google Spreadsheet (in DRIVE_FOLDER_ID when configured)",
 		InputSchema: mustSchema(map[string]any{
 			"type": "object",
 			"properties": map[string]any{
-				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
-				"sheet":          map[string]any{"type": "string", "description": "The name of the sheet to delete"},
+				"title": map[string]any{"type": "string", "description": "The title of the new spreadsheet"},
 			},
-			"required": []string{"spreadsheet_id", "sheet"},
+			"required": []string{"title"},
 		}),
-	}, s.handleDeleteSheet)
+	}, s.handleCreateSpreadsheet)
 
-	s.mcpServer.AddTool(&mcp.Tool{
-		Name:        "duplicate_sheet",
-		Description: "Duplicate a sheet within the same spreadsheet",
+	s.addTool(&mcp.Tool{
+		Name:        "delete_spreadsheet",
+		Description: "Move a spreadsheet to the Drive trash, or delete it permanently",
 		InputSchema: mustSchema(map[string]any{
 			"type": "object",
 			"properties": map[string]any{
 				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
-				"sheet":          map[string]any{"type": "string", "description": "The name of the sheet to duplicate"},
-				"new_title":      map[string]any{"type": "string", "description": "Title for the duplicated sheet"},
+				"permanent":      map[string]any{"type": "boolean", "description": "Delete permanently instead of moving to trash; this cannot be undone (default: false)"},
 			},
-			"required": []string{"spreadsheet_id", "sheet"},
+			"required": []string{"spreadsheet_id"},
 		}),
-	}, s.handleDuplicateSheet)
+	}, s.handleDeleteSpreadsheet)
 
-	s.mcpServer.AddTool(&mcp.Tool{
-		Name:        "find_replace",
-		Description: "Find and replace text in a sheet or entire spreadsheet",
+	s.addTool(&mcp.Tool{
+		Name:        "restore_spreadsheet",
+		Description: "Restore a spreadsheet from the Drive trash",
 		InputSchema: mustSchema(map[string]any{
 			"type": "object",
 			"properties": map[string]any{
-				"spreadsheet_id":    map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
-				"find":              map[string]any{"type": "string", "description": "The text to find"},
-				"replacement":       map[string]any{"type": "string", "description": "The replacement text"},
-				"sheet":             map[string]any{"type": "string", "description": "Sheet name (required if all_sheets is false)"},
-				"all_sheets":        map[string]any{"type": "boolean", "description": "Search all sheets (default: false)"},
-				"match_case":        map[string]any{"type": "boolean", "description": "Match case (default: false)"},
-				"match_entire_cell": map[string]any{"type": "boolean", "description": "Match entire cell (default: false)"},
+				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
 			},
-			"required": []string{"spreadsheet_id", "find"},
+			"required": []string{"spreadsheet_id"},
 		}),
-	}, s.handleFindReplace)
+	}, s.handleRestoreSpreadsheet)
 
-	s.mcpServer.AddTool(&mcp.Tool{
-		Name:        "sort_range",
-		Description: "Sort a range of data in a sheet",
+	s.addTool(&mcp.Tool{
+		Name:        "star_spreadsheet",
+		Description: "Star a spreadsheet in Google Drive, adding it to the working set that search_spreadsheets can filter on",
 		InputSchema: mustSchema(map[string]any{
 			"type": "object",
 			"properties": map[string]any{
 				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
-				"sheet":          map[string]any{"type": "string", "description": "The name of the sheet"},
-				"range":          map[string]any{"type": "string", "description": "Cell range in A1:B2 notation to sort"},
-				"sort_column":    map[string]any{"type": "number", "description": "0-based column index to sort by (default: 0)"},
-				"ascending":      map[string]any{"type": "boolean", "description": "Sort in ascending order (default: true)"},
 			},
-			"required": []string{"spreadsheet_id", "sheet", "range"},
+			"required": []string{"spreadsheet_id"},
 		}),
-	}, s.handleSortRange)
+	}, s.handleStarSpreadsheet)
 
-	// Formatting operations
-	s.mcpServer.AddTool(&mcp.Tool{
-		Name:        "format_cells",
-		Description: "Apply formatting to cells (colors, fonts, text styles)",
+	s.addTool(&mcp.Tool{
+		Name:        "unstar_spreadsheet",
+		Description: "Remove the star from a spreadsheet in Google Drive",
 		InputSchema: mustSchema(map[string]any{
 			"type": "object",
 			"properties": map[string]any{
-				"spreadsheet_id":   map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
-				"sheet":            map[string]any{"type": "string", "description": "The name of the sheet"},
-				"range":            map[string]any{"type": "string", "description": "Cell range in A1:B2 notation"},
-				"background_color": map[string]any{"type": "object", "description": "Background color {red, green, blue, alpha} (0.0-1.0)"},
-				"text_color":       map[string]any{"type": "object", "description": "Text color {red, green, blue, alpha} (0.0-1.0)"},
-				"bold":             map[string]any{"type": "boolean", "description": "Make text bold"},
-				"italic":           map[string]any{"type": "boolean", "description": "Make text italic"},
-				"font_size":        map[string]any{"type": "number", "description": "Font size in points"},
+				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
 			},
-			"required": []string{"spreadsheet_id", "sheet", "range"},
+			"required": []string{"spreadsheet_id"},
 		}),
-	}, s.handleFormatCells)
+	}, s.handleUnstarSpreadsheet)
 
-	s.mcpServer.AddTool(&mcp.Tool{
-		Name:        "merge_cells",
-		Description: "Merge cells in a range",
+	s.addTool(&mcp.Tool{
+		Name:        "rename_spreadsheet",
+		Description: "Rename a spreadsheet file in Google Drive",
 		InputSchema: mustSchema(map[string]any{
 			"type": "object",
 			"properties": map[string]any{
 				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
-				"sheet":          map[string]any{"type": "string", "description": "The name of the sheet"},
-				"range":          map[string]any{"type": "string", "description": "Cell range in A1:B2 notation to merge"},
-				"merge_type":     map[string]any{"type": "string", "description": "Merge type: MERGE_ALL, MERGE_COLUMNS, MERGE_ROWS (default: MERGE_ALL)"},
+				"title":          map[string]any{"type": "string", "description": "The new title of the spreadsheet"},
 			},
-			"required": []string{"spreadsheet_id", "sheet", "range"},
+			"required": []string{"spreadsheet_id", "title"},
 		}),
-	}, s.handleMergeCells)
+	}, s.handleRenameSpreadsheet)
 
-	s.mcpServer.AddTool(&mcp.Tool{
-		Name:        "unmerge_cells",
-		Description: "Unmerge cells in a range",
+	s.addTool(&mcp.Tool{
+		Name:        "move_spreadsheet",
+		Description: "Move a spreadsheet into a Google Drive folder",
 		InputSchema: mustSchema(map[string]any{
 			"type": "object",
 			"properties": map[string]any{
 				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
-				"sheet":          map[string]any{"type": "string", "description": "The name of the sheet"},
-				"range":          map[string]any{"type": "string", "description": "Cell range in A1:B2 notation to unmerge"},
+				"folder_id":      map[string]any{"type": "string", "description": "The ID of the destination folder"},
 			},
-			"required": []string{"spreadsheet_id", "sheet", "range"},
+			"required": []string{"spreadsheet_id", "folder_id"},
+		}),
+	}, s.handleMoveSpreadsheet)
+
+	s.addTool(&mcp.Tool{
+		Name:        "copy_spreadsheet",
+		Description: "Copy a whole spreadsheet, e.g. to instantiate a template, optionally clearing the values of some sheets in the copy",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet to copy"},
+				"title":          map[string]any{"type": "string", "description": "The title of the copy"},
+				"folder_id":      map[string]any{"type": "string", "description": "Folder to create the copy in (default: DRIVE_FOLDER_ID, otherwise the folder of the original)"},
+				"clear_sheets": map[string]any{
+					"type":        "array",
+					"description": "Names of sheets whose values are cleared in the copy, keeping formatting",
+					"items":       map[string]any{"type": "string"},
+				},
+			},
+			"required": []string{"spreadsheet_id", "title"},
+		}),
+	}, s.handleCopySpreadsheet)
+
+	s.addTool(&mcp.Tool{
+		Name:        "get_spreadsheet_metadata",
+		Description: "Get the Drive metadata of a spreadsheet: owners, created and modified times, who modified it last, size, link, parent folders, and whether it is trashed",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+			},
+			"required": []string{"spreadsheet_id"},
+		}),
+	}, s.handleGetSpreadsheetMetadata)
+
+	s.addTool(&mcp.Tool{
+		Name:        "import_xlsx",
+		Description: "Upload a local Excel (.xlsx) file to Drive, converting it to a Google Sheets spreadsheet, and return the new spreadsheet ID",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"file_path": map[string]any{"type": "string", "description": "Path of the .xlsx file on the machine running the server; relative to FILE_ROOT when set"},
+				"title":     map[string]any{"type": "string", "description": "Title of the new spreadsheet (default: file name without extension)"},
+				"folder_id": map[string]any{"type": "string", "description": "Folder to create the spreadsheet in (default: DRIVE_FOLDER_ID)"},
+			},
+			"required": []string{"file_path"},
+		}),
+	}, s.handleImportXLSX)
+
+	s.addTool(&mcp.Tool{
+		Name:        "list_spreadsheets",
+		Description: "List the spreadsheets in the folder this server works in (the configured Drive folder or shared drive), or in another folder",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"folder_id":  map[string]any{"type": "string", "description": "Folder to list (default: DRIVE_FOLDER_ID or SHARED_DRIVE_ID, otherwise every spreadsheet the credentials can see)"},
+				"starred":    map[string]any{"type": "boolean", "description": "Only starred spreadsheets (default: false)"},
+				"page_size":  map[string]any{"type": "number", "description": "Maximum number of results per page, 1-1000 (default: 100)"},
+				"page_token": map[string]any{"type": "string", "description": "nextPageToken from a previous call to fetch the next page"},
+			},
+		}),
+	}, s.handleListSpreadsheets)
+
+	s.addTool(&mcp.Tool{
+		Name:        "search_spreadsheets",
+		Description: "Search Google Drive for spreadsheets by name, modification time, owner, and folder",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"name_contains":   map[string]any{"type": "string", "description": "Only spreadsheets whose name contains this text"},
+				"modified_after":  map[string]any{"type": "string", "description": "Only spreadsheets modified after this time: RFC 3339, YYYY-MM-DD, or a duration ago such as 72h"},
+				"owner":           map[string]any{"type": "string", "description": "Only spreadsheets owned by this email address"},
+				"folder_id":       map[string]any{"type": "string", "description": "Only spreadsheets directly inside this folder"},
+				"starred":         map[string]any{"type": "boolean", "description": "Only starred spreadsheets (default: false)"},
+				"shared_drive_id": map[string]any{"type": "string", "description": "Only spreadsheets in this shared drive (default: SHARED_DRIVE_ID, otherwise all drives)"},
+				"page_size":       map[string]any{"type": "number", "description": "Maximum number of results per page, 1-1000 (default: 50)"},
+				"page_token":      map[string]any{"type": "string", "description": "nextPageToken from a previous call to fetch the next page"},
+			},
+		}),
+	}, s.handleSearchSpreadsheets)
+
+	s.addTool(&mcp.Tool{
+		Name:        "export_spreadsheet",
+		Description: "Export a spreadsheet as xlsx, ods, or pdf, or one sheet as csv or tsv. Small exports are returned inline (csv/tsv as text, others base64); larger ones are exposed as a resource",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+				"format": map[string]any{
+					"type":        "string",
+					"description": "Export format (default: xlsx)",
+					"enum":        []string{"xlsx", "ods", "pdf", "csv", "tsv"},
+				},
+				"sheet": map[string]any{"type": "string", "description": "Sheet to export for csv and tsv (default: first sheet); for pdf, limits the export to this sheet"},
+			},
+			"required": []string{"spreadsheet_id"},
+		}),
+	}, s.handleExportSpreadsheet)
+
+	s.addTool(&mcp.Tool{
+		Name:        "list_shared_drives",
+		Description: "List the shared drives the credentials can access, to pick a shared_drive_id for search_spreadsheets",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"name_contains": map[string]any{"type": "string", "description": "Only shared drives whose name contains this text"},
+				"page_size":     map[string]any{"type": "number", "description": "Maximum number of results per page, 1-100 (default: 50)"},
+				"page_token":    map[string]any{"type": "string", "description": "nextPageToken from a previous call to fetch the next page"},
+			},
+		}),
+	}, s.handleListSharedDrives)
+
+	// Snapshots
+	s.addTool(&mcp.Tool{
+		Name:        "create_snapshot",
+		Description: "Back up a spreadsheet as a snapshot: a dated Drive copy that list_snapshots and prune_snapshots manage",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+				"folder_id":      map[string]any{"type": "string", "description": "Folder to create the snapshot in (default: DRIVE_FOLDER_ID, otherwise the folder of the original)"},
+			},
+			"required": []string{"spreadsheet_id"},
+		}),
+	}, s.handleCreateSnapshot)
+
+	s.addTool(&mcp.Tool{
+		Name:        "list_snapshots",
+		Description: "List the snapshots of a spreadsheet, newest first, showing which ones the retention policy keeps and which are prunable",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+			},
+			"required": []string{"spreadsheet_id"},
+		}),
+	}, s.handleListSnapshots)
+
+	s.addTool(&mcp.Tool{
+		Name:        "prune_snapshots",
+		Description: "Move the snapshots of a spreadsheet that fall outside the retention policy to the trash",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+				"dry_run":        map[string]any{"type": "boolean", "description": "Only report what would be pruned (default: false, always on with DRY_RUN)"},
+			},
+			"required": []string{"spreadsheet_id"},
+		}),
+	}, s.handlePruneSnapshots)
+
+	// Sharing
+	s.addTool(&mcp.Tool{
+		Name:        "share_spreadsheet",
+		Description: "Share a spreadsheet with users or Google Groups",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+				"recipients": map[string]any{
+					"type":        "array",
+					"description": "List of recipients with email_address, role (reader, commenter, writer; default: writer unless configured otherwise), and type (user or group; default: user)",
+					"items": map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"email_address": map[string]any{"type": "string"},
+							"role":          map[string]any{"type": "string"},
+							"type":          map[string]any{"type": "string"},
+						},
+						"required": []string{"email_address"},
+					},
+				},
+				"send_notification": map[string]any{"type": "boolean", "description": "Whether to send a notification email (default: true)"},
+			},
+			"required": []string{"spreadsheet_id", "recipients"},
+		}),
+	}, s.handleShareSpreadsheet)
+
+	s.addTool(&mcp.Tool{
+		Name:        "list_permissions",
+		Description: "List who has access to a spreadsheet: users, groups, domains, and link sharing, with their roles",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+			},
+			"required": []string{"spreadsheet_id"},
+		}),
+	}, s.handleListPermissions)
+
+	s.addTool(&mcp.Tool{
+		Name:        "remove_permission",
+		Description: "Remove one permission from a spreadsheet, by permission ID (from list_permissions) or by email address",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+				"permission_id":  map[string]any{"type": "string", "description": "The permission to remove"},
+				"email_address":  map[string]any{"type": "string", "description": "Remove the permission of this user or group instead"},
+			},
+			"required": []string{"spreadsheet_id"},
+		}),
+	}, s.handleRemovePermission)
+
+	s.addTool(&mcp.Tool{
+		Name:        "set_link_sharing",
+		Description: "Share a spreadsheet with anyone who has the link or with everyone in a domain, as reader or commenter, and return its link",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+				"type": map[string]any{
+					"type":        "string",
+					"description": "anyone (anyone with the link) or domain (everyone in the domain)",
+					"enum":        []string{"anyone", "domain"},
+				},
+				"role": map[string]any{
+					"type":        "string",
+					"description": "Access granted (default: reader)",
+					"enum":        []string{"reader", "commenter"},
+				},
+				"domain":               map[string]any{"type": "string", "description": "Domain to share with for type domain (default: the first of SHARING_INTERNAL_DOMAINS)"},
+				"allow_file_discovery": map[string]any{"type": "boolean", "description": "Let the spreadsheet be found through search instead of only through the link (default: false)"},
+			},
+			"required": []string{"spreadsheet_id", "type"},
+		}),
+	}, s.handleSetLinkSharing)
+
+	s.addTool(&mcp.Tool{
+		Name:        "revoke_all_external_access",
+		Description: "Incident response: remove anyone-with-link access and every user, group, or domain permission outside the allowed domains from a spreadsheet or folder (and the files in it), reporting what was removed",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"file_id": map[string]any{"type": "string", "description": "The ID of a spreadsheet or folder"},
+				"allowed_domains": map[string]any{
+					"type":        "array",
+					"description": "Domains whose users keep access (default: SHARING_INTERNAL_DOMAINS)",
+					"items":       map[string]any{"type": "string"},
+				},
+				"include_contents": map[string]any{"type": "boolean", "description": "For a folder, also clean up the files directly inside it (default: true)"},
+				"dry_run":          map[string]any{"type": "boolean", "description": "Only report what would be removed (default: false, always on with DRY_RUN)"},
+			},
+			"required": []string{"file_id"},
+		}),
+	}, s.handleRevokeAllExternalAccess)
+
+	// Group lookups need the optional Admin SDK directory scope
+	if s.directoryService != nil {
+		s.addTool(&mcp.Tool{
+			Name:        "list_group_members",
+			Description: "List the members of a Google Group",
+			InputSchema: mustSchema(map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"group_email":     map[string]any{"type": "string", "description": "The email address of the group"},
+					"include_derived": map[string]any{"type": "boolean", "description": "Include members of nested groups (default: false)"},
+				},
+				"required": []string{"group_email"},
+			}),
+		}, s.handleListGroupMembers)
+	}
+
+	// Multiple queries
+	s.addTool(&mcp.Tool{
+		Name:        "get_multiple_sheet_data",
+		Description: "Get data from multiple specific ranges in Google Spreadsheets",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"queries": map[string]any{
+					"type":        "array",
+					"description": "List of query objects with spreadsheet_id, sheet, and range",
+					"items": map[string]any{
+						"type":                 "object",
+						"additionalProperties": true,
+					},
+				},
+				"value_render_option":     map[string]any{"type": "string", "description": "FORMATTED_VALUE (as displayed, e.g. \"$1,234.56\"), UNFORMATTED_VALUE (raw numbers), or FORMULA (default: FORMATTED_VALUE)", "enum": []string{"FORMATTED_VALUE", "UNFORMATTED_VALUE", "FORMULA"}},
+				"date_time_render_option": map[string]any{"type": "string", "description": "How unformatted dates are returned: SERIAL_NUMBER or FORMATTED_STRING (default: SERIAL_NUMBER)", "enum": []string{"SERIAL_NUMBER", "FORMATTED_STRING"}},
+			},
+			"required": []string{"queries"},
+		}),
+	}, s.handleGetMultipleSheetData)
+
+	s.addTool(&mcp.Tool{
+		Name:        "get_ranges",
+		Description: "Read many ranges of one spreadsheet in a single API request, e.g. [\"Summary!B2\", \"'Q1 2024'!A1:D20\", \"Data!A:C\"]",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+				"ranges": map[string]any{
+					"type":        "array",
+					"description": "Ranges in A1 notation, each optionally prefixed with its sheet name",
+					"items":       map[string]any{"type": "string"},
+				},
+				"sheet":                   map[string]any{"type": "string", "description": "Sheet for ranges that do not name one (default: the first sheet)"},
+				"value_render_option":     map[string]any{"type": "string", "description": "FORMATTED_VALUE (as displayed, e.g. \"$1,234.56\"), UNFORMATTED_VALUE (raw numbers), or FORMULA (default: FORMATTED_VALUE)", "enum": []string{"FORMATTED_VALUE", "UNFORMATTED_VALUE", "FORMULA"}},
+				"date_time_render_option": map[string]any{"type": "string", "description": "How unformatted dates are returned: SERIAL_NUMBER or FORMATTED_STRING (default: SERIAL_NUMBER)", "enum": []string{"SERIAL_NUMBER", "FORMATTED_STRING"}},
+				"major_dimension":         map[string]any{"type": "string", "description": "ROWS if each inner array is a row, COLUMNS if it is a column (default: ROWS)", "enum": []string{"ROWS", "COLUMNS"}},
+				"coerce_types":            map[string]any{"type": "boolean", "description": "Turn numeric text into numbers, TRUE/FALSE into booleans, and empty cells into null (default: false)"},
+			},
+			"required": []string{"spreadsheet_id", "ranges"},
+		}),
+	}, s.handleGetRanges)
+
+	s.addTool(&mcp.Tool{
+		Name:        "get_multiple_spreadsheet_summary",
+		Description: "Get a summary of multiple Google Spreadsheets",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_ids": map[string]any{
+					"type":        "array",
+					"description": "List of spreadsheet IDs",
+					"items": map[string]any{
+						"type": "string",
+					},
+				},
+				"rows_to_fetch": map[string]any{"type": "number", "description": "Number of rows to fetch for summary (default: 5)"},
+			},
+			"required": []string{"spreadsheet_ids"},
+		}),
+	}, s.handleGetMultipleSpreadsheetSummary)
+
+	// Advanced data operations
+	s.addTool(&mcp.Tool{
+		Name:        "append_data",
+		Description: "Append data to the end of a sheet without specifying exact range",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+				"sheet":          map[string]any{"type": "string", "description": "The name of the sheet"},
+				"data": map[string]any{
+					"type":        "array",
+					"description": "2D array of values to append",
+					"items": map[string]any{
+						"type":  "array",
+						"items": map[string]any{},
+					},
+				},
+				"locale":                     map[string]any{"type": "string", "description": "Parse numeric strings written in this locale (e.g. de_DE, en_IN) into numbers, or \"none\" to write them as given (\"auto\" uses the spreadsheet locale; default: none, or auto with LOCALE_NUMBERS and USER_ENTERED)"},
+				"iso_dates":                  map[string]any{"type": "boolean", "description": "Turn ISO-8601 strings such as 2024-03-01 or 2024-03-01T09:30:00Z into dates; offsets are converted to the spreadsheet time zone (default: false)"},
+				"value_input_option":         map[string]any{"type": "string", "description": "USER_ENTERED parses values as if typed (formulas, dates, numbers); RAW stores text exactly as given, so \"=x\" or \"3/4\" are not interpreted (default: USER_ENTERED)", "enum": []string{"USER_ENTERED", "RAW"}},
+				"sanitize_input":             map[string]any{"type": "boolean", "description": "Store text starting with =, +, - or @ as plain text so untrusted data cannot inject formulas (default: SANITIZE_INPUT, normally false)"},
+				"major_dimension":            map[string]any{"type": "string", "description": "ROWS if each inner array is a row, COLUMNS if it is a column (default: ROWS)", "enum": []string{"ROWS", "COLUMNS"}},
+				"range":                      map[string]any{"type": "string", "description": "Where to look for the table to append to, in A1 notation (e.g. A1:D1 or F1); rows go after the last row of the table found there (default: the first table on the sheet)"},
+				"insert_data_option":         map[string]any{"type": "string", "description": "OVERWRITE writes into the empty rows after the table; INSERT_ROWS inserts new rows for the data, shifting anything below it down (default: OVERWRITE)", "enum": []string{"OVERWRITE", "INSERT_ROWS"}},
+				"include_values_in_response": map[string]any{"type": "boolean", "description": "Return the appended values as stored, after parsing (default: false)"},
+			},
+			"required": []string{"spreadsheet_id", "sheet", "data"},
+		}),
+	}, s.handleAppendData)
+
+	s.addTool(&mcp.Tool{
+		Name:        "write_records",
+		Description: "Write an array of objects as rows, mapping each key to the column with that header, starting below the header row or at start_row",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+				"sheet":          map[string]any{"type": "string", "description": "The name of the sheet"},
+				"records": map[string]any{
+					"type":        "array",
+					"description": "Objects whose keys are column headers, e.g. {\"Name\": \"Ada\", \"Amount\": 12}; keys match headers ignoring case, and missing keys leave their cells untouched",
+					"items":       map[string]any{"type": "object"},
+				},
+				"header_row":             map[string]any{"type": "number", "description": "Row holding the headers, 1-based (default: 1)"},
+				"create_missing_columns": map[string]any{"type": "boolean", "description": "Add keys that match no header as new columns after the last header instead of failing (default: false)"},
+				"value_input_option":     map[string]any{"type": "string", "description": "USER_ENTERED parses values as if typed (formulas, dates, numbers); RAW stores text exactly as given (default: USER_ENTERED)", "enum": []string{"USER_ENTERED", "RAW"}},
+				"sanitize_input":         map[string]any{"type": "boolean", "description": "Store text starting with =, +, - or @ as plain text so untrusted data cannot inject formulas (default: SANITIZE_INPUT, normally false)"},
+				"start_row":              map[string]any{"type": "number", "description": "First row to write, 1-based (default: the row below header_row)"},
+			},
+			"required": []string{"spreadsheet_id", "sheet", "records"},
+		}),
+	}, s.handleWriteRecords)
+
+	s.addTool(&mcp.Tool{
+		Name:        "append_records",
+		Description: "Append an array of objects as new rows after the table, mapping each key to the column with that header",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+				"sheet":          map[string]any{"type": "string", "description": "The name of the sheet"},
+				"records": map[string]any{
+					"type":        "array",
+					"description": "Objects whose keys are column headers, e.g. {\"Name\": \"Ada\", \"Amount\": 12}; keys match headers ignoring case, and missing keys leave their cells untouched",
+					"items":       map[string]any{"type": "object"},
+				},
+				"header_row":             map[string]any{"type": "number", "description": "Row holding the headers, 1-based (default: 1)"},
+				"create_missing_columns": map[string]any{"type": "boolean", "description": "Add keys that match no header as new columns after the last header instead of failing (default: false)"},
+				"value_input_option":     map[string]any{"type": "string", "description": "USER_ENTERED parses values as if typed (formulas, dates, numbers); RAW stores text exactly as given (default: USER_ENTERED)", "enum": []string{"USER_ENTERED", "RAW"}},
+				"sanitize_input":         map[string]any{"type": "boolean", "description": "Store text starting with =, +, - or @ as plain text so untrusted data cannot inject formulas (default: SANITIZE_INPUT, normally false)"},
+			},
+			"required": []string{"spreadsheet_id", "sheet", "records"},
+		}),
+	}, s.handleAppendRecords)
+
+	s.addTool(&mcp.Tool{
+		Name:        "update_by_header",
+		Description: "Update cells addressed by row key and column header instead of A1 coordinates, e.g. set Status to \"shipped\" in the row whose Order ID is ORD-17; all changes go out in one batch update and keep working when columns are inserted or moved",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+				"sheet":          map[string]any{"type": "string", "description": "The name of the sheet"},
+				"key_column":     map[string]any{"type": "string", "description": "Header name (ignoring case) or column letter holding the values that identify rows"},
+				"updates": map[string]any{
+					"type":        "array",
+					"description": "Changes as {\"key\": \"ORD-17\", \"values\": {\"Status\": \"shipped\", \"Amount\": 12}}; headers match ignoring case, and headers left out keep their cells",
+					"items": map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"key":    map[string]any{"description": "The key column value of the row to update"},
+							"values": map[string]any{"type": "object", "description": "New cell values by header"},
+						},
+						"required": []string{"key", "values"},
+					},
+				},
+				"header_row":         map[string]any{"type": "number", "description": "Row holding the headers, 1-based (default: 1)"},
+				"match_case":         map[string]any{"type": "boolean", "description": "Match keys case-sensitively (default: false)"},
+				"all_matches":        map[string]any{"type": "boolean", "description": "Update every row whose key matches instead of reporting keys found in several rows as ambiguous (default: false)"},
+				"value_input_option": map[string]any{"type": "string", "description": "USER_ENTERED parses values as if typed (formulas, dates, numbers); RAW stores text exactly as given (default: USER_ENTERED)", "enum": []string{"USER_ENTERED", "RAW"}},
+				"sanitize_input":     map[string]any{"type": "boolean", "description": "Store text starting with =, +, - or @ as plain text so untrusted data cannot inject formulas (default: SANITIZE_INPUT, normally false)"},
+			},
+			"required": []string{"spreadsheet_id", "sheet", "key_column", "updates"},
+		}),
+	}, s.handleUpdateByHeader)
+
+	s.addTool(&mcp.Tool{
+		Name:        "consolidate_sheets",
+		Description: "Append the rows of several source sheets or ranges, from this or other spreadsheets, to one destination sheet, matching columns by header; optionally labels each row with its source and skips rows whose key is already present",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the destination spreadsheet"},
+				"sheet":          map[string]any{"type": "string", "description": "The destination sheet; its header row is extended with any source headers it lacks"},
+				"sources": map[string]any{
+					"type":        "array",
+					"description": "Sheets or ranges to copy, in order; each range starts with its header row",
+					"items": map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"spreadsheet_id": map[string]any{"type": "string", "description": "Source spreadsheet (default: the destination spreadsheet)"},
+							"sheet":          map[string]any{"type": "string", "description": "Source sheet"},
+							"range":          map[string]any{"type": "string", "description": "Range in A1 notation (default: the whole sheet)"},
+							"label":          map[string]any{"type": "string", "description": "Value written to source_column for these rows (default: the sheet name)"},
+						},
+						"required": []string{"sheet"},
+					},
+				},
+				"header_row":         map[string]any{"type": "number", "description": "Row holding the headers in each source range and in the destination sheet, 1-based (default: 1)"},
+				"source_column":      map[string]any{"type": "string", "description": "Header of a column that records where each row came from (default: none)"},
+				"dedupe_key":         map[string]any{"type": "string", "description": "Header whose value identifies a row; rows whose key is already in the destination or an earlier source are skipped (default: none)"},
+				"match_case":         map[string]any{"type": "boolean", "description": "Compare dedupe keys case-sensitively (default: false)"},
+				"value_input_option": map[string]any{"type": "string", "description": "USER_ENTERED parses values as if typed (formulas, dates, numbers); RAW stores text exactly as given (default: USER_ENTERED)", "enum": []string{"USER_ENTERED", "RAW"}},
+				"sanitize_input":     map[string]any{"type": "boolean", "description": "Store text starting with =, +, - or @ as plain text so untrusted data cannot inject formulas (default: SANITIZE_INPUT, normally false)"},
+			},
+			"required": []string{"spreadsheet_id", "sheet", "sources"},
+		}),
+	}, s.handleConsolidateSheets)
+
+	s.addTool(&mcp.Tool{
+		Name:        "clear_range",
+		Description: "Clear content from a specific range in a sheet",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_id":  map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+				"sheet":           map[string]any{"type": "string", "description": "The name of the sheet"},
+				"range":           map[string]any{"type": "string", "description": "Cell range in A1 notation to clear"},
+				"protect_headers": map[string]any{"type": "number", "description": "Number of header rows that must not be modified (default: the PROTECT_HEADER_ROWS setting or the sheet's frozen rows, whichever is more; 0 disables)"},
+			},
+			"required": []string{"spreadsheet_id", "sheet", "range"},
+		}),
+	}, s.handleClearRange)
+
+	s.addTool(&mcp.Tool{
+		Name:        "delete_sheet",
+		Description: "Delete a sheet tab from a spreadsheet",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+				"sheet":          map[string]any{"type": "string", "description": "The name of the sheet to delete"},
+			},
+			"required": []string{"spreadsheet_id", "sheet"},
+		}),
+	}, s.handleDeleteSheet)
+
+	s.addTool(&mcp.Tool{
+		Name:        "duplicate_sheet",
+		Description: "Duplicate a sheet within the same spreadsheet",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+				"sheet":          map[string]any{"type": "string", "description": "The name of the sheet to duplicate"},
+				"new_title":      map[string]any{"type": "string", "description": "Title for the duplicated sheet"},
+			},
+			"required": []string{"spreadsheet_id", "sheet"},
+		}),
+	}, s.handleDuplicateSheet)
+
+	s.addTool(&mcp.Tool{
+		Name:        "find_replace",
+		Description: "Find and replace text in a sheet or entire spreadsheet; protected header rows are skipped and reported in excludedHeaderRows",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_id":    map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+				"find":              map[string]any{"type": "string", "description": "The text to find"},
+				"replacement":       map[string]any{"type": "string", "description": "The replacement text"},
+				"sheet":             map[string]any{"type": "string", "description": "Sheet name (required if all_sheets is false)"},
+				"all_sheets":        map[string]any{"type": "boolean", "description": "Search all sheets (default: false)"},
+				"match_case":        map[string]any{"type": "boolean", "description": "Match case (default: false)"},
+				"match_entire_cell": map[string]any{"type": "boolean", "description": "Match entire cell (default: false)"},
+				"protect_headers":   map[string]any{"type": "number", "description": "Number of header rows that must not be modified (default: the PROTECT_HEADER_ROWS setting or the sheet's frozen rows, whichever is more; 0 disables)"},
+			},
+			"required": []string{"spreadsheet_id", "find"},
+		}),
+	}, s.handleFindReplace)
+
+	s.addTool(&mcp.Tool{
+		Name:        "sort_range",
+		Description: "Sort a range of data in a sheet",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_id":    map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+				"sheet":             map[string]any{"type": "string", "description": "The name of the sheet"},
+				"range":             map[string]any{"type": "string", "description": "Cell range in A1:B2 notation to sort"},
+				"sort_column":       map[string]any{"type": "number", "description": "0-based column index to sort by (default: 0)"},
+				"ascending":         map[string]any{"type": "boolean", "description": "Sort in ascending order (default: true)"},
+				"capture_order":     map[string]any{"type": "boolean", "description": "Return the applied row permutation (original row -> new row) so the sort can be reverted (default: false)"},
+				"keep_order_column": map[string]any{"type": "boolean", "description": "Leave a helper column with the original row numbers next to the range; sorting by it restores the original order (default: false)"},
+				"protect_headers":   map[string]any{"type": "number", "description": "Number of header rows that must not be modified (default: PROTECT_HEADER_ROWS setting, 0 disables)"},
+				"include_headers":   map[string]any{"type": "boolean", "description": "Also sort the sheet's frozen rows when the range covers them (default: false, frozen rows are left in place)"},
+			},
+			"required": []string{"spreadsheet_id", "sheet", "range"},
+		}),
+	}, s.handleSortRange)
+
+	s.addTool(&mcp.Tool{
+		Name:        "copy_range",
+		Description: "Copy or move a range within a spreadsheet, keeping formats and formulas",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_id":    map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+				"sheet":             map[string]any{"type": "string", "description": "The name of the source sheet"},
+				"range":             map[string]any{"type": "string", "description": "Source cell range in A1:B2 notation"},
+				"destination_sheet": map[string]any{"type": "string", "description": "The name of the destination sheet (default: source sheet)"},
+				"destination_range": map[string]any{"type": "string", "description": "Destination top-left cell (e.g. D1) or range in A1:B2 notation"},
+				"paste_type":        map[string]any{"type": "string", "description": "What to paste: all, values, format, formulas (default: all)"},
+				"cut":               map[string]any{"type": "boolean", "description": "Move the range instead of copying it (default: false)"},
+			},
+			"required": []string{"spreadsheet_id", "sheet", "range", "destination_range"},
+		}),
+	}, s.handleCopyRange)
+
+	s.addTool(&mcp.Tool{
+		Name:        "copy_range_across_spreadsheets",
+		Description: "Copy values from a range of one spreadsheet to another (or to another sheet of the same one), optionally picking and reordering columns, renaming headers, and keeping only rows that match a filter",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_id":             map[string]any{"type": "string", "description": "The ID of the source spreadsheet"},
+				"sheet":                      map[string]any{"type": "string", "description": "The name of the source sheet"},
+				"range":                      map[string]any{"type": "string", "description": "Source range in A1 notation, starting with the header row (default: the whole sheet)"},
+				"destination_spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the destination spreadsheet (default: the source spreadsheet)"},
+				"destination_sheet":          map[string]any{"type": "string", "description": "The name of the destination sheet"},
+				"destination_range":          map[string]any{"type": "string", "description": "Top-left destination cell (default: A1); ignored when appending"},
+				"append":                     map[string]any{"type": "boolean", "description": "Append the rows after the destination's existing table instead of writing at destination_range (default: false)"},
+				"header_row":                 map[string]any{"type": "number", "description": "Row of the range holding the headers, 1-based; 0 when it has none (default: 1)"},
+				"include_headers":            map[string]any{"type": "boolean", "description": "Write the (renamed) header row above the data (default: true, or false when appending)"},
+				"columns":                    map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Header names (ignoring case) or column letters to copy, in output order (default: all columns)"},
+				"rename":                     map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "string"}, "description": "New header names by source header, e.g. {\"Cust Name\": \"Customer\"}"},
+				"where":                      map[string]any{"type": "string", "description": "Keep only rows matching a query_sheet WHERE condition, e.g. Region = 'EU' AND Amount > 100"},
+				"match_case":                 map[string]any{"type": "boolean", "description": "Compare text in the filter case-sensitively (default: false)"},
+				"value_input_option":         map[string]any{"type": "string", "description": "USER_ENTERED parses values as if typed (formulas, dates, numbers); RAW stores text exactly as given (default: USER_ENTERED)", "enum": []string{"USER_ENTERED", "RAW"}},
+				"sanitize_input":             map[string]any{"type": "boolean", "description": "Store text starting with =, +, - or @ as plain text so untrusted data cannot inject formulas (default: SANITIZE_INPUT, normally false)"},
+			},
+			"required": []string{"spreadsheet_id", "sheet", "destination_sheet"},
+		}),
+	}, s.handleCopyRangeAcrossSpreadsheets)
+
+	s.addTool(&mcp.Tool{
+		Name:        "set_dropdown_from_range",
+		Description: "Add a dropdown to cells whose options come from a range (e.g. a \"Lists\" sheet), optionally creating or updating the options first",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+				"sheet":          map[string]any{"type": "string", "description": "The name of the sheet containing the cells to validate"},
+				"range":          map[string]any{"type": "string", "description": "Cell range in A1:B2 notation that gets the dropdown"},
+				"options_sheet":  map[string]any{"type": "string", "description": "Sheet holding the options (default: Lists)"},
+				"options_range":  map[string]any{"type": "string", "description": "Range holding the options in A1 notation (default: A1:A)"},
+				"options": map[string]any{
+					"type":        "array",
+					"description": "Option values to write into the options range before applying the dropdown (creates the options sheet if missing)",
+					"items":       map[string]any{"type": "string"},
+				},
+				"strict":        map[string]any{"type": "boolean", "description": "Reject values that are not in the list (default: true)"},
+				"show_dropdown": map[string]any{"type": "boolean", "description": "Show the dropdown arrow in the cells (default: true)"},
+			},
+			"required": []string{"spreadsheet_id", "sheet", "range"},
+		}),
+	}, s.handleSetDropdownFromRange)
+
+	s.addTool(&mcp.Tool{
+		Name:        "auto_fill",
+		Description: "Fill a range from its source cells like dragging the fill handle (extends formulas and series)",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_id":       map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+				"sheet":                map[string]any{"type": "string", "description": "The name of the sheet"},
+				"range":                map[string]any{"type": "string", "description": "Source cells in A1:B2 notation holding the formula or series start"},
+				"destination_range":    map[string]any{"type": "string", "description": "Range in A1:B2 notation to fill, adjacent to the source (e.g. A3:A100)"},
+				"use_alternate_series": map[string]any{"type": "boolean", "description": "Generate data with the alternate series (default: false)"},
+			},
+			"required": []string{"spreadsheet_id", "sheet", "range", "destination_range"},
+		}),
+	}, s.handleAutoFill)
+
+	// Connected Sheets data sources need the optional BigQuery scope
+	if s.dataSources {
+		s.addTool(&mcp.Tool{
+			Name:        "add_data_source",
+			Description: "Connect a BigQuery table or query to a spreadsheet as a new data source sheet",
+			InputSchema: mustSchema(map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"spreadsheet_id":   map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+					"project_id":       map[string]any{"type": "string", "description": "The BigQuery project that is billed for queries"},
+					"dataset_id":       map[string]any{"type": "string", "description": "The BigQuery dataset of the table"},
+					"table_id":         map[string]any{"type": "string", "description": "The BigQuery table to connect"},
+					"table_project_id": map[string]any{"type": "string", "description": "The project that owns the table (default: project_id)"},
+					"query":            map[string]any{"type": "string", "description": "A BigQuery SQL query to connect instead of a table"},
+				},
+				"required": []string{"spreadsheet_id", "project_id"},
+			}),
+		}, s.handleAddDataSource)
+
+		s.addTool(&mcp.Tool{
+			Name:        "refresh_data_source",
+			Description: "Refresh one data source, or every data source in the spreadsheet",
+			InputSchema: mustSchema(map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+					"data_source_id": map[string]any{"type": "string", "description": "The data source to refresh (default: all data sources)"},
+					"force":          map[string]any{"type": "boolean", "description": "Refresh even if a refresh is already running or the data is current (default: false)"},
+				},
+				"required": []string{"spreadsheet_id"},
+			}),
+		}, s.handleRefreshDataSource)
+
+		s.addTool(&mcp.Tool{
+			Name:        "delete_data_source",
+			Description: "Delete a data source and its associated data source sheets",
+			InputSchema: mustSchema(map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+					"data_source_id": map[string]any{"type": "string", "description": "The data source to delete"},
+				},
+				"required": []string{"spreadsheet_id", "data_source_id"},
+			}),
+		}, s.handleDeleteDataSource)
+
+		s.addTool(&mcp.Tool{
+			Name:        "list_data_sources",
+			Description: "List the BigQuery data sources connected to a spreadsheet",
+			InputSchema: mustSchema(map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+				},
+				"required": []string{"spreadsheet_id"},
+			}),
+		}, s.handleListDataSources)
+	}
+
+	// Formatting operations
+	s.addTool(&mcp.Tool{
+		Name:        "format_cells",
+		Description: "Apply formatting to cells (colors, fonts, text styles)",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_id":   map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+				"sheet":            map[string]any{"type": "string", "description": "The name of the sheet"},
+				"range":            map[string]any{"type": "string", "description": "Cell range in A1:B2 notation"},
+				"background_color": map[string]any{"type": "object", "description": "Background color {red, green, blue, alpha} (0.0-1.0)"},
+				"text_color":       map[string]any{"type": "object", "description": "Text color {red, green, blue, alpha} (0.0-1.0)"},
+				"bold":             map[string]any{"type": "boolean", "description": "Make text bold"},
+				"italic":           map[string]any{"type": "boolean", "description": "Make text italic"},
+				"font_size":        map[string]any{"type": "number", "description": "Font size in points"},
+			},
+			"required": []string{"spreadsheet_id", "sheet", "range"},
+		}),
+	}, s.handleFormatCells)
+
+	s.addTool(&mcp.Tool{
+		Name:        "merge_cells",
+		Description: "Merge cells in a range",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+				"sheet":          map[string]any{"type": "string", "description": "The name of the sheet"},
+				"range":          map[string]any{"type": "string", "description": "Cell range in A1:B2 notation to merge"},
+				"merge_type":     map[string]any{"type": "string", "description": "Merge type: MERGE_ALL, MERGE_COLUMNS, MERGE_ROWS (default: MERGE_ALL)"},
+			},
+			"required": []string{"spreadsheet_id", "sheet", "range"},
+		}),
+	}, s.handleMergeCells)
+
+	s.addTool(&mcp.Tool{
+		Name:        "unmerge_cells",
+		Description: "Unmerge cells in a range",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+				"sheet":          map[string]any{"type": "string", "description": "The name of the sheet"},
+				"range":          map[string]any{"type": "string", "description": "Cell range in A1:B2 notation to unmerge"},
+			},
+			"required": []string{"spreadsheet_id", "sheet", "range"},
 		}),
 	}, s.handleUnmergeCells)
 
-	s.mcpServer.AddTool(&mcp.Tool{
+	s.addTool(&mcp.Tool{
+		Name:        "get_merges",
+		Description: "List all merged cell ranges in a sheet",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+				"sheet":          map[string]any{"type": "string", "description": "The name of the sheet"},
+			},
+			"required": []string{"spreadsheet_id", "sheet"},
+		}),
+	}, s.handleGetMerges)
+
+	s.addTool(&mcp.Tool{
+		Name:        "get_conditional_format_rules",
+		Description: "List the conditional formatting rules of a sheet",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+				"sheet":          map[string]any{"type": "string", "description": "The name of the sheet"},
+			},
+			"required": []string{"spreadsheet_id", "sheet"},
+		}),
+	}, s.handleGetConditionalFormatRules)
+
+	s.addTool(&mcp.Tool{
+		Name:        "get_validation_rules",
+		Description: "List the data validation rules in a sheet, grouped by rule with the ranges they apply to",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+				"sheet":          map[string]any{"type": "string", "description": "The name of the sheet"},
+				"range":          map[string]any{"type": "string", "description": "Optional cell range in A1 notation to inspect (default: whole sheet)"},
+			},
+			"required": []string{"spreadsheet_id", "sheet"},
+		}),
+	}, s.handleGetValidationRules)
+
+	s.addTool(&mcp.Tool{
+		Name:        "clear_formatting",
+		Description: "Reset cell formatting in a range (colors, fonts, borders, alignment) without touching values",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"spreadsheet_id":         map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+				"sheet":                  map[string]any{"type": "string", "description": "The name of the sheet"},
+				"range":                  map[string]any{"type": "string", "description": "Cell range in A1:B2 notation"},
+				"preserve_number_format": map[string]any{"type": "boolean", "description": "Keep number/date formats while clearing everything else (default: false)"},
+			},
+			"required": []string{"spreadsheet_id", "sheet", "range"},
+		}),
+	}, s.handleClearFormatting)
+
+	s.addTool(&mcp.Tool{
 		Name:        "hide_sheet",
 		Description: "Hide a sheet in a spreadsheet",
 		InputSchema: mustSchema(map[string]any{
@@ -409,7 +1534,7 @@ func (s *SheetsMCPServer) registerTools() {
 		}),
 	}, s.handleHideSheet)
 
-	s.mcpServer.AddTool(&mcp.Tool{
+	s.addTool(&mcp.Tool{
 		Name:        "unhide_sheet",
 		Description: "Unhide a sheet in a spreadsheet",
 		InputSchema: mustSchema(map[string]any{
@@ -421,6 +1546,323 @@ func (s *SheetsMCPServer) registerTools() {
 			"required": []string{"spreadsheet_id", "sheet"},
 		}),
 	}, s.handleUnhideSheet)
+
+	// The audit log can only be queried where it is written
+	if s.auditEnabled() {
+		s.addTool(&mcp.Tool{
+			Name:        "get_audit_log",
+			Description: "List recorded mutating tool calls, newest first: tool, spreadsheet, sheet, range, cells written, time, result, and arguments",
+			InputSchema: mustSchema(map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"spreadsheet_id": map[string]any{"type": "string", "description": "Only calls that changed this spreadsheet; required when the log is kept in each spreadsheet's _audit sheet"},
+					"tool":           map[string]any{"type": "string", "description": "Only calls of this tool"},
+					"since":          map[string]any{"type": "string", "description": "Only calls after this RFC 3339 time (2024-03-01T00:00:00Z) or within this duration (24h)"},
+					"limit":          map[string]any{"type": "number", "description": "Most entries to return (default: 100)"},
+				},
+			}),
+		}, s.handleGetAuditLog)
+	}
+
+	// Signed range links are only offered when a signing key is configured
+	if len(s.config.ResourceSigningKey) > 0 {
+		s.addTool(&mcp.Tool{
+			Name:        "create_range_link",
+			Description: "Create a signed, expiring resource URI that grants read-only access to one sheet range",
+			InputSchema: mustSchema(map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+					"sheet":          map[string]any{"type": "string", "description": "The name of the sheet"},
+					"range":          map[string]any{"type": "string", "description": "Optional cell range in A1 notation (default: whole sheet)"},
+					"expires_in":     map[string]any{"type": "string", "description": "How long the link stays valid, e.g. 15m or 2h (default: 1h, max: 168h)"},
+				},
+				"required": []string{"spreadsheet_id", "sheet"},
+			}),
+		}, s.handleCreateRangeLink)
+	}
+
+	// Reporting needs the optional Docs scope
+	if s.docsService != nil {
+		s.addTool(&mcp.Tool{
+			Name:        "create_doc_summary",
+			Description: "Write a summary of a spreadsheet (your summary text plus a preview table per sheet) into a new Google Doc and return its link",
+			InputSchema: mustSchema(map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+					"title":          map[string]any{"type": "string", "description": "Title of the document (default: spreadsheet title + \" summary\")"},
+					"summary_text":   map[string]any{"type": "string", "description": "Summary text to open the document with"},
+					"sheets": map[string]any{
+						"type":        "array",
+						"description": "Sheets to include (default: all grid sheets)",
+						"items":       map[string]any{"type": "string"},
+					},
+					"preview_rows": map[string]any{"type": "number", "description": "Rows of each sheet shown as a table, 0 for none (default: 10)"},
+					"folder_id":    map[string]any{"type": "string", "description": "Folder to create the document in (default: DRIVE_FOLDER_ID)"},
+				},
+				"required": []string{"spreadsheet_id"},
+			}),
+		}, s.handleCreateDocSummary)
+	}
+
+	// Gmail drafts need the optional compose scope
+	if s.gmailService != nil {
+		s.addTool(&mcp.Tool{
+			Name:        "draft_email_with_export",
+			Description: "Create a Gmail draft with a spreadsheet attached as xlsx or pdf and its link in the body, ready for a person to review and send",
+			InputSchema: mustSchema(map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"spreadsheet_id": map[string]any{"type": "string", "description": "The ID of the spreadsheet"},
+					"to": map[string]any{
+						"type":        "array",
+						"description": "Recipient email addresses",
+						"items":       map[string]any{"type": "string"},
+					},
+					"cc": map[string]any{
+						"type":        "array",
+						"description": "Cc email addresses",
+						"items":       map[string]any{"type": "string"},
+					},
+					"subject": map[string]any{"type": "string", "description": "Subject line (default: spreadsheet name)"},
+					"body":    map[string]any{"type": "string", "description": "Message text; the spreadsheet link is appended"},
+					"format": map[string]any{
+						"type":        "string",
+						"description": "Attachment format (default: xlsx)",
+						"enum":        []string{"xlsx", "pdf"},
+					},
+					"sheet": map[string]any{"type": "string", "description": "For pdf, attach only this sheet"},
+				},
+				"required": []string{"spreadsheet_id", "to"},
+			}),
+		}, s.handleDraftEmailWithExport)
+	}
+
+	// Planning
+	s.addTool(&mcp.Tool{
+		Name:        "plan_operations",
+		Description: "Estimate the Google API requests a list of pending tool calls will cost, merge calls that can share a batch request, and warn when the plan exceeds the configured quota budget. Nothing is executed.",
+		InputSchema: mustSchema(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"operations": map[string]any{
+					"type":        "array",
+					"description": "Pending tool calls in the order they would run",
+					"items": map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"tool":      map[string]any{"type": "string", "description": "The tool name"},
+							"arguments": map[string]any{"type": "object", "description": "The tool arguments"},
+						},
+						"required": []string{"tool"},
+					},
+				},
+			},
+			"required": []string{"operations"},
+		}),
+	}, s.handlePlanOperations)
+
+	// Server administration
+	s.addTool(&mcp.Tool{
+		Name:        "cache_stats",
+		Description: "Report sheet metadata and result cache statistics (hits, misses, size, TTL)",
+		InputSchema: mustSchema(map[string]any{
+			"type":       "object",
+			"properties": map[string]any{},
+		}),
+	}, s.handleCacheStats)
+
+	s.addTool(&mcp.Tool{
+		Name:        "session_stats",
+		Description: "Report tool calls, Google API requests, cells read and written, errors, and elapsed time for the current session",
+		InputSchema: mustSchema(map[string]any{
+			"type":       "object",
+			"properties": map[string]any{},
+		}),
+	}, s.handleSessionStats)
+
+	s.addTool(&mcp.Tool{
+		Name:        "health_check",
+		Description: "Report who the server is authenticated as (whoami), the OAuth scopes granted, when the access token expires, the configured folder or shared drive, and whether a Drive call succeeds. Use it to find out why a spreadsheet cannot be seen",
+		InputSchema: mustSchema(map[string]any{
+			"type":       "object",
+			"properties": map[string]any{},
+		}),
+	}, s.handleHealthCheck)
+
+	// Only a browser sign-in can be repeated from a client; other credentials are fixed at startup
+	if s.authMethod == "oauth" {
+		s.addTool(&mcp.Tool{
+			Name:        "reauthenticate",
+			Description: "Start a new Google sign-in when the OAuth token can no longer be refreshed and calls fail as unauthenticated. Opens a browser on the machine running the server and returns the sign-in link at once; calls use the new token as soon as the user completes the sign-in, without restarting the server",
+			InputSchema: mustSchema(map[string]any{
+				"type":       "object",
+				"properties": map[string]any{},
+			}),
+		}, s.handleReauthenticate)
+	}
+
+	s.addTool(&mcp.Tool{
+		Name:        "write_queue_stats",
+		Description: "Report how many mutating operations are queued per spreadsheet",
+		InputSchema: mustSchema(map[string]any{
+			"type":       "object",
+			"properties": map[string]any{},
+		}),
+	}, s.handleWriteQueueStats)
+}
+
+// readOnlyTools lists the tools that never modify a spreadsheet; every other tool goes through the write queue
+var readOnlyTools = map[string]bool{
+	"get_audit_log":                    true,
+	"get_sheet_data":                   true,
+	"find_rows":                        true,
+	"get_headers":                      true,
+	"find_column":                      true,
+	"query_sheet":                      true,
+	"aggregate_range":                  true,
+	"get_used_range":                   true,
+	"search_values":                    true,
+	"get_sheet_formulas":               true,
+	"hash_range":                       true,
+	"get_merges":                       true,
+	"get_conditional_format_rules":     true,
+	"get_validation_rules":             true,
+	"create_range_link":                true,
+	"list_sheets":                      true,
+	"get_multiple_sheet_data":          true,
+	"get_ranges":                       true,
+	"get_multiple_spreadsheet_summary": true,
+	"compare_with_file":                true,
+	"list_group_members":               true,
+	"search_spreadsheets":              true,
+	"list_spreadsheets":                true,
+	"list_permissions":                 true,
+	"get_spreadsheet_metadata":         true,
+	"list_shared_drives":               true,
+	"list_revisions":                   true,
+	"get_revision":                     true,
+	"list_snapshots":                   true,
+	"list_data_sources":                true,
+	"plan_operations":                  true,
+	"cache_stats":                      true,
+	"write_queue_stats":                true,
+	"session_stats":                    true,
+	"health_check":                     true,
+}
+
+// toolAvailable reports whether a tool is registered: it is enabled, the scope mode allows it, and
+// Drive is available when it needs Drive
+func (s *SheetsMCPServer) toolAvailable(name string) bool {
+	if !s.config.toolEnabled(name) || !s.scopeAllows(name) {
+		return false
+	}
+	return !driveTools[name] || s.driveService != nil
+}
+
+// addTool registers a tool with its annotations and output schema. Disabled tools are skipped, and so
+// are Drive tools when Drive is unavailable. Tools that work on a spreadsheet get a force_refresh
+// argument. Mutating tools go through the per-spreadsheet write queue, and read-only results are cached
+// when enabled. Every call gets the caller's tenant settings and the call timeout, reports API
+// retries, accepts spreadsheet URLs as IDs and is counted per session.
+// With profiles, the tool is kept for registerProfileTools instead.
+func (s *SheetsMCPServer) addTool(tool *mcp.Tool, handler mcp.ToolHandler) {
+	s.toolNames[tool.Name] = true
+	if !s.toolAvailable(tool.Name) {
+		return
+	}
+	if tool.Annotations == nil {
+		tool.Annotations = toolAnnotations(tool.Name)
+	}
+	if schema := outputSchema(tool.Name); tool.OutputSchema == nil && schema != nil {
+		tool.OutputSchema = schema
+	}
+	var props map[string]any
+	if schema, ok := tool.InputSchema.(map[string]any); ok {
+		props, _ = schema["properties"].(map[string]any)
+	}
+	_, ownDryRun := props["dry_run"]
+	switch {
+	case writesSpreadsheets(tool.Name):
+		handler = s.withDryRun(ownDryRun, s.withConfirmation(tool.Name, s.withAudit(tool.Name, s.withWriteQueue(s.withInvalidation(handler)))))
+	case readOnlyTools[tool.Name] && s.resultCache.enabled() && !uncacheableTools[tool.Name]:
+		handler = s.withCachedResult(tool.Name, handler)
+	}
+	if props != nil {
+		if writesSpreadsheets(tool.Name) && !ownDryRun {
+			props["dry_run"] = map[string]any{"type": "boolean", "description": "Validate the call and return the API requests it would send without sending them (default: false, always on with DRY_RUN)"}
+		}
+		if shapedTools[tool.Name] {
+			props["verbose"] = map[string]any{"type": "boolean", "description": "Return the raw Google API reply instead of a concise summary (default: false)"}
+		}
+		if _, ok := props["spreadsheet_id"]; ok {
+			props["force_refresh"] = map[string]any{"type": "boolean", "description": "Bypass the sheet metadata cache for this call (default: false)"}
+			handler = s.withCacheControl(handler)
+		}
+	}
+	handler = s.withValidation(tool, handler)
+	handler = s.withTimeout(tool.Name, handler)
+	handler = s.withRetryCount(handler)
+	handler = s.withTenant(tool.Name, handler)
+	handler = s.withSpreadsheetURLs(takesSheet(tool), handler)
+	handler = s.withSessionStats(tool.Name, handler)
+	handler = withResultForm(handler)
+	if s.profiles != nil {
+		s.profileTools = append(s.profileTools, profileTool{tool: tool, handler: handler})
+		return
+	}
+	s.mcpServer.AddTool(tool, handler)
+}
+
+// warnUnknownTools points out tool names in ENABLED_TOOLS and DISABLED_TOOLS that match no tool, which
+// are most likely typos. Tools behind optional features count as known only when the feature is on.
+func (s *SheetsMCPServer) warnUnknownTools() {
+	for _, name := range slices.Concat(s.config.EnabledTools, s.config.DisabledTools) {
+		if !s.toolNames[name] {
+			slog.Warn("unknown tool in ENABLED_TOOLS or DISABLED_TOOLS", "tool", name)
+		}
+	}
+}
+
+// checkToolTables makes sure every tool is described in the per-tool tables: in exactly one of
+// readOnlyTools and mutatingToolHints, in toolOutputs, and in the plan_operations costs. A tool left
+// out of one would otherwise get the wrong wrappers or annotations without any sign of it.
+func (s *SheetsMCPServer) checkToolTables() error {
+	var problems []string
+	for _, name := range slices.Sorted(maps.Keys(s.toolNames)) {
+		if _, hinted := mutatingToolHints[name]; readOnlyTools[name] == hinted {
+			problems = append(problems, fmt.Sprintf("%s must be in exactly one of readOnlyTools and mutatingToolHints", name))
+		}
+		if _, ok := toolOutputs[name]; !ok {
+			problems = append(problems, fmt.Sprintf("%s has no entry in toolOutputs", name))
+		}
+		if _, ok := estimateCost(name, map[string]any{}); !ok {
+			problems = append(problems, fmt.Sprintf("%s has no entry in operationCosts", name))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("tool tables are incomplete: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// takesSheet reports whether a tool has a sheet argument
+func takesSheet(tool *mcp.Tool) bool {
+	schema, _ := tool.InputSchema.(map[string]any)
+	props, _ := schema["properties"].(map[string]any)
+	_, ok := props["sheet"]
+	return ok
+}
+
+// withCacheControl drops cached sheet metadata for the spreadsheet when the caller asks for a refresh
+func (s *SheetsMCPServer) withCacheControl(handler mcp.ToolHandler) mcp.ToolHandler {
+	return func(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if args, err := getArgsFromRequest(request); err == nil && parseArgument(args, "force_refresh", false) {
+			s.metadataCache.invalidate(parseArgument(args, "spreadsheet_id", ""))
+		}
+		return handler(ctx, request)
+	}
 }
 
 func (s *SheetsMCPServer) registerResources() {
@@ -429,36 +1871,199 @@ func (s *SheetsMCPServer) registerResources() {
 		Name:        "Spreadsheet Info",
 		Description: "Get basic information about a Google Spreadsheet",
 		MIMEType:    "application/json",
-	}, s.handleGetSpreadsheetInfo)
+	}, s.withSessionProfile((*SheetsMCPServer).handleGetSpreadsheetInfo))
+
+	if len(s.config.ResourceSigningKey) > 0 {
+		s.mcpServer.AddResourceTemplate(&mcp.ResourceTemplate{
+			URITemplate: "spreadsheet://{spreadsheet_id}/signed{?sheet,range,exp,sig}",
+			Name:        "Signed Sheet Range",
+			Description: "Read the values of a range granted by a link from create_range_link",
+			MIMEType:    "application/json",
+		}, s.withSessionProfile((*SheetsMCPServer).handleReadSignedRange))
+	}
+
+	s.mcpServer.AddResourceTemplate(&mcp.ResourceTemplate{
+		URITemplate: "spreadsheet://{spreadsheet_id}/{sheet}/values{?range,format}",
+		Name:        "Sheet Values",
+		Description: fmt.Sprintf("The current values of a sheet, or of range within it, as JSON or with format=csv as CSV; reads stop after %d cells (MAX_RESPONSE_CELLS)", s.resourceCellLimit()),
+		MIMEType:    "application/json",
+	}, s.withSessionProfile((*SheetsMCPServer).handleReadSheetValues))
 }
 
 func mustSchema(schema map[string]any) map[string]any {
 	return schema
 }
 
+// parseArgument reads an argument, or returns defaultValue when it is missing. withValidation has
+// already rejected arguments of the wrong type, so a mismatch here means the schema does not declare
+// the argument, and it is logged instead of silently ignored.
 func parseArgument[T any](args map[string]any, key string, defaultValue T) T {
 	if val, ok := args[key]; ok {
 		if typed, ok := val.(T); ok {
 			return typed
 		}
+		if val != nil {
+			slog.Debug("argument has an unexpected type", "argument", key, "type", fmt.Sprintf("%T", val), "want", fmt.Sprintf("%T", defaultValue))
+		}
 	}
 	return defaultValue
 }
 
+// maxPooledBufferSize keeps one-off huge responses from pinning memory in the buffer pool
+const maxPooledBufferSize = 4 << 20
+
+var responseBufferPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+func getResponseBuffer() *bytes.Buffer {
+	buf := responseBufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+func putResponseBuffer(buf *bytes.Buffer) {
+	if buf.Cap() <= maxPooledBufferSize {
+		responseBufferPool.Put(buf)
+	}
+}
+
+// respondWithJSON returns result both as JSON text and as structured content; withResultForm sends
+// only the form the client reads
 func respondWithJSON(result any) (*mcp.CallToolResult, error) {
-	data, err := json.Marshal(result)
-	if err != nil {
+	buf := getResponseBuffer()
+	defer putResponseBuffer(buf)
+
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(result); err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf("failed to marshal result: %v", err)}},
 			IsError: true,
 		}, nil
 	}
+	// Encode terminates the document with a newline that json.Marshal never produced
+	buf.Truncate(buf.Len() - 1)
+
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{&mcp.TextContent{Text: buf.String()}},
+		StructuredContent: structuredContent(buf.Bytes()),
+	}, nil
+}
+
+// respondWithCSV renders a value matrix as CSV text, which is far more compact than JSON for large reads
+func respondWithCSV(values [][]any) (*mcp.CallToolResult, error) {
+	text, err := encodeCSV(values)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: text}},
+	}, nil
+}
+
+// encodeCSV renders a value matrix as CSV text
+func encodeCSV(values [][]any) (string, error) {
+	buf := getResponseBuffer()
+	defer putResponseBuffer(buf)
+
+	w := csv.NewWriter(buf)
+	record := []string{}
+	for _, row := range values {
+		record = record[:0]
+		for _, cell := range row {
+			record = append(record, formatCell(cell))
+		}
+		if err := w.Write(record); err != nil {
+			return "", fmt.Errorf("failed to write CSV: %w", err)
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// respondWithMarkdown renders values as a Markdown table whose header is the first row. Pipes are
+// escaped and line breaks become <br> so every row stays on one line.
+func respondWithMarkdown(values [][]any) (*mcp.CallToolResult, error) {
+	width := 0
+	for _, row := range values {
+		width = max(width, len(row))
+	}
+	if width == 0 {
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: ""}}}, nil
+	}
+
+	buf := getResponseBuffer()
+	defer putResponseBuffer(buf)
+
+	cellEscaper := strings.NewReplacer("|", "\\|", "\r\n", "<br>", "\n", "<br>")
+	writeRow := func(row []any) {
+		buf.WriteString("|")
+		for i := range width {
+			buf.WriteString(" ")
+			if i < len(row) {
+				buf.WriteString(cellEscaper.Replace(formatCell(row[i])))
+			}
+			buf.WriteString(" |")
+		}
+		buf.WriteString("\n")
+	}
+	writeRow(values[0])
+	buf.WriteString("|" + strings.Repeat(" --- |", width) + "\n")
+	for _, row := range values[1:] {
+		writeRow(row)
+	}
+
 	return &mcp.CallToolResult{
-		Content: []mcp.Content{&mcp.TextContent{Text: string(data)}},
+		Content: []mcp.Content{&mcp.TextContent{Text: buf.String()}},
 	}, nil
 }
 
+// respondWithValues renders a value matrix in the requested output format. CSV and markdown text
+// still carries jsonResult as its structured content.
+func respondWithValues(outputFormat string, values [][]any, jsonResult any) (*mcp.CallToolResult, error) {
+	var result *mcp.CallToolResult
+	var err error
+	switch outputFormat {
+	case "", "json":
+		return respondWithJSON(jsonResult)
+	case "csv":
+		result, err = respondWithCSV(values)
+	case "markdown":
+		result, err = respondWithMarkdown(values)
+	default:
+		return respondWithError(fmt.Sprintf("invalid output_format '%s': must be json, csv, or markdown", outputFormat))
+	}
+	if err != nil || isErrorResult(result) {
+		return result, err
+	}
+	encoded, err := json.Marshal(jsonResult)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+	result.StructuredContent = structuredContent(encoded)
+	return result, nil
+}
+
+// formatCell renders a single cell value as plain text
+func formatCell(cell any) string {
+	switch v := cell.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// respondWithError reports a call that failed for a reason other than a Google API error, most often
+// an invalid argument
 func respondWithError(errMsg string) (*mcp.CallToolResult, error) {
-	errorResult := map[string]string{"error": errMsg}
-	return respondWithJSON(errorResult)
+	return respondWithToolError(toolError{Code: errorInvalidRequest, Message: errMsg})
 }
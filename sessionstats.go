@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// sessionStats accumulates what one MCP session has done, for self-monitoring and spotting runaway loops
+type sessionStats struct {
+	mu           sync.Mutex
+	started      time.Time
+	toolCalls    map[string]int
+	errors       int
+	apiCalls     int64
+	cellsRead    int64
+	cellsWritten int64
+}
+
+// SessionStatsReport is the snapshot returned by session_stats
+type SessionStatsReport struct {
+	Elapsed        string         `json:"elapsed"`
+	ToolCalls      map[string]int `json:"toolCalls"`
+	TotalToolCalls int            `json:"totalToolCalls"`
+	Errors         int            `json:"errors"`
+	APICalls       int64          `json:"apiCalls"`
+	CellsRead      int64          `json:"cellsRead"`
+	CellsWritten   int64          `json:"cellsWritten"`
+}
+
+// sessionStatsRegistry keeps the statistics of every session the server has seen
+type sessionStatsRegistry struct {
+	mu       sync.Mutex
+	sessions map[*mcp.ServerSession]*sessionStats
+}
+
+type sessionStatsKey struct{}
+
+func newSessionStatsRegistry() *sessionStatsRegistry {
+	return &sessionStatsRegistry{
+		sessions: make(map[*mcp.ServerSession]*sessionStats),
+	}
+}
+
+func (r *sessionStatsRegistry) forSession(session *mcp.ServerSession) *sessionStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats, ok := r.sessions[session]
+	if !ok {
+		stats = &sessionStats{started: time.Now(), toolCalls: make(map[string]int)}
+		r.sessions[session] = stats
+		// Forget the session once it ends so long-running HTTP servers do not accumulate stats
+		if session != nil {
+			go func() {
+				session.Wait()
+				r.mu.Lock()
+				delete(r.sessions, session)
+				r.mu.Unlock()
+			}()
+		}
+	}
+	return stats
+}
+
+func (st *sessionStats) report() SessionStatsReport {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	report := SessionStatsReport{
+		Elapsed:      time.Since(st.started).Round(time.Second).String(),
+		ToolCalls:    make(map[string]int, len(st.toolCalls)),
+		Errors:       st.errors,
+		APICalls:     st.apiCalls,
+		CellsRead:    st.cellsRead,
+		CellsWritten: st.cellsWritten,
+	}
+	for tool, n := range st.toolCalls {
+		report.ToolCalls[tool] = n
+		report.TotalToolCalls += n
+	}
+	return report
+}
+
+type apiCallCounterKey struct{}
+
+// apiCallCounter is an http.RoundTripper that counts the Google API requests a tool call sends,
+// retries included, for session_stats
+type apiCallCounter struct {
+	next http.RoundTripper
+}
+
+// withAPICallCount returns a copy of client whose requests are counted against the calling tool
+func withAPICallCount(client *http.Client) *http.Client {
+	next := client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	counted := *client
+	counted.Transport = &apiCallCounter{next: next}
+	return &counted
+}
+
+func (c *apiCallCounter) RoundTrip(req *http.Request) (*http.Response, error) {
+	if counter, ok := req.Context().Value(apiCallCounterKey{}).(*atomic.Int64); ok {
+		counter.Add(1)
+	}
+	return c.next.RoundTrip(req)
+}
+
+// withSessionStats counts every call of a tool against the calling session
+func (s *SheetsMCPServer) withSessionStats(name string, handler mcp.ToolHandler) mcp.ToolHandler {
+	return func(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		stats := s.sessionStats.forSession(request.Session)
+
+		counter := &atomic.Int64{}
+		ctx = context.WithValue(ctx, apiCallCounterKey{}, counter)
+		result, err := handler(context.WithValue(ctx, sessionStatsKey{}, stats), request)
+
+		stats.mu.Lock()
+		stats.toolCalls[name]++
+		stats.apiCalls += counter.Load()
+		if err != nil || isErrorResult(result) {
+			stats.errors++
+		}
+		stats.mu.Unlock()
+
+		return result, err
+	}
+}
+
+// isErrorResult reports whether a tool result describes a failure
+func isErrorResult(result *mcp.CallToolResult) bool {
+	return result != nil && result.IsError
+}
+
+// recordCellsRead adds the cells of a value grid to the calling session's statistics
+func recordCellsRead(ctx context.Context, values [][]any) {
+	stats, ok := ctx.Value(sessionStatsKey{}).(*sessionStats)
+	if !ok {
+		return
+	}
+	var cells int64
+	for _, row := range values {
+		cells += int64(len(row))
+	}
+
+	stats.mu.Lock()
+	stats.cellsRead += cells
+	stats.mu.Unlock()
+}
+
+// recordCellsWritten adds updated cells reported by the API to the calling session's statistics and
+// to the audit entry of the call
+func recordCellsWritten(ctx context.Context, cells int64) {
+	if audited, ok := ctx.Value(auditCellsKey{}).(*atomic.Int64); ok {
+		audited.Add(cells)
+	}
+	stats, ok := ctx.Value(sessionStatsKey{}).(*sessionStats)
+	if !ok {
+		return
+	}
+
+	stats.mu.Lock()
+	stats.cellsWritten += cells
+	stats.mu.Unlock()
+}
+
+func (s *SheetsMCPServer) handleSessionStats(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return respondWithJSON(s.sessionStats.forSession(request.Session).report())
+}
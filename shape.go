@@ -0,0 +1,201 @@
+package main
+
+import (
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"google.golang.org/api/sheets/v4"
+)
+
+// shapedTools return raw Google API replies when called with verbose: true and a concise summary otherwise
+var shapedTools = map[string]bool{
+	"update_cells":         true,
+	"batch_update_cells":   true,
+	"add_rows":             true,
+	"add_columns":          true,
+	"create_sheet":         true,
+	"copy_sheet":           true,
+	"rename_sheet":         true,
+	"set_sheet_properties": true,
+	"append_data":          true,
+	"clear_range":          true,
+	"delete_sheet":         true,
+	"duplicate_sheet":      true,
+	"find_replace":         true,
+	"copy_range":           true,
+	"auto_fill":            true,
+	"format_cells":         true,
+	"merge_cells":          true,
+	"unmerge_cells":        true,
+	"clear_formatting":     true,
+	"hide_sheet":           true,
+	"unhide_sheet":         true,
+}
+
+// respondWithShape responds with a concise summary of a raw API reply, or the reply itself when verbose is requested
+func respondWithShape(args map[string]any, raw any) (*mcp.CallToolResult, error) {
+	if parseArgument(args, "verbose", false) {
+		return respondWithJSON(raw)
+	}
+	return respondWithJSON(summarizeReply(raw))
+}
+
+// valuesUpdateResult summarizes a write of one range
+type valuesUpdateResult struct {
+	UpdatedRange   string `json:"updatedRange"`
+	UpdatedRows    int64  `json:"updatedRows"`
+	UpdatedColumns int64  `json:"updatedColumns"`
+	UpdatedCells   int64  `json:"updatedCells"`
+}
+
+// batchValuesUpdateResult summarizes a write of several ranges
+type batchValuesUpdateResult struct {
+	UpdatedRanges  []string `json:"updatedRanges"`
+	UpdatedRows    int64    `json:"updatedRows"`
+	UpdatedColumns int64    `json:"updatedColumns"`
+	UpdatedCells   int64    `json:"updatedCells"`
+}
+
+// appendResult summarizes an append below a table
+type appendResult struct {
+	TableRange    string       `json:"tableRange"`
+	UpdatedRange  string       `json:"updatedRange,omitempty"`
+	UpdatedRows   int64        `json:"updatedRows,omitempty"`
+	UpdatedCells  int64        `json:"updatedCells,omitempty"`
+	UpdatedBounds *rangeBounds `json:"updatedBounds,omitempty"`
+	UpdatedValues [][]any      `json:"updatedValues,omitempty"`
+}
+
+// clearResult summarizes a cleared range
+type clearResult struct {
+	ClearedRange string `json:"clearedRange"`
+}
+
+// sheetResult identifies a sheet that was created or changed
+type sheetResult struct {
+	SpreadsheetID string `json:"spreadsheetId,omitempty"`
+	SheetID       int64  `json:"sheetId"`
+	Title         string `json:"title"`
+	Index         int64  `json:"index"`
+}
+
+// batchUpdateResult summarizes a spreadsheet batch update, with the replies that carry news
+type batchUpdateResult struct {
+	SpreadsheetID string             `json:"spreadsheetId"`
+	Success       bool               `json:"success"`
+	Replies       []batchUpdateReply `json:"replies,omitempty"`
+}
+
+// batchUpdateReply is the summary of one reply of a batch update; only the fields of its kind are set
+type batchUpdateReply struct {
+	AddedSheet         *sheetResult `json:"addedSheet,omitempty"`
+	DuplicatedSheet    *sheetResult `json:"duplicatedSheet,omitempty"`
+	OccurrencesChanged *int64       `json:"occurrencesChanged,omitempty"`
+	ValuesChanged      *int64       `json:"valuesChanged,omitempty"`
+	RowsChanged        *int64       `json:"rowsChanged,omitempty"`
+	SheetsChanged      *int64       `json:"sheetsChanged,omitempty"`
+}
+
+// copySheetReply holds the raw replies of copy_sheet: the copy, and the rename that gives it the requested title
+type copySheetReply struct {
+	Copy   *sheets.SheetProperties                `json:"copy"`
+	Rename *sheets.BatchUpdateSpreadsheetResponse `json:"rename,omitempty"`
+}
+
+// copySheetResult summarizes copy_sheet
+type copySheetResult struct {
+	Copy   sheetResult        `json:"copy"`
+	Rename *batchUpdateResult `json:"rename,omitempty"`
+}
+
+// findReplaceReply holds the raw reply of find_replace and the header rows, by sheet, it left out of the search
+type findReplaceReply struct {
+	Reply              *sheets.BatchUpdateSpreadsheetResponse `json:"reply"`
+	ExcludedHeaderRows map[string]int64                       `json:"excludedHeaderRows,omitempty"`
+}
+
+// findReplaceResult summarizes find_replace
+type findReplaceResult struct {
+	batchUpdateResult
+	ExcludedHeaderRows map[string]int64 `json:"excludedHeaderRows,omitempty"`
+}
+
+// summarizeReply keeps the fields of a Google API reply that tell the caller what changed and drops the echo of the request
+func summarizeReply(raw any) any {
+	switch r := raw.(type) {
+	case *sheets.UpdateValuesResponse:
+		return valuesUpdateResult{
+			UpdatedRange:   r.UpdatedRange,
+			UpdatedRows:    r.UpdatedRows,
+			UpdatedColumns: r.UpdatedColumns,
+			UpdatedCells:   r.UpdatedCells,
+		}
+	case *sheets.BatchUpdateValuesResponse:
+		ranges := make([]string, 0, len(r.Responses))
+		for _, resp := range r.Responses {
+			ranges = append(ranges, resp.UpdatedRange)
+		}
+		return batchValuesUpdateResult{
+			UpdatedRanges:  ranges,
+			UpdatedRows:    r.TotalUpdatedRows,
+			UpdatedColumns: r.TotalUpdatedColumns,
+			UpdatedCells:   r.TotalUpdatedCells,
+		}
+	case *sheets.AppendValuesResponse:
+		summary := appendResult{TableRange: r.TableRange}
+		if r.Updates != nil {
+			summary.UpdatedRange = r.Updates.UpdatedRange
+			summary.UpdatedRows = r.Updates.UpdatedRows
+			summary.UpdatedCells = r.Updates.UpdatedCells
+			summary.UpdatedBounds = describeA1Range(r.Updates.UpdatedRange)
+			if r.Updates.UpdatedData != nil {
+				summary.UpdatedValues = r.Updates.UpdatedData.Values
+			}
+		}
+		return summary
+	case *sheets.ClearValuesResponse:
+		return clearResult{ClearedRange: r.ClearedRange}
+	case *sheets.SheetProperties:
+		return summarizeSheet(r)
+	case *sheets.BatchUpdateSpreadsheetResponse:
+		return summarizeBatchUpdate(r)
+	case copySheetReply:
+		summary := copySheetResult{Copy: summarizeSheet(r.Copy)}
+		if r.Rename != nil {
+			rename := summarizeBatchUpdate(r.Rename)
+			summary.Rename = &rename
+		}
+		return summary
+	case findReplaceReply:
+		return findReplaceResult{
+			batchUpdateResult:  summarizeBatchUpdate(r.Reply),
+			ExcludedHeaderRows: r.ExcludedHeaderRows,
+		}
+	}
+	return raw
+}
+
+func summarizeSheet(props *sheets.SheetProperties) sheetResult {
+	return sheetResult{SheetID: props.SheetId, Title: props.Title, Index: props.Index}
+}
+
+func summarizeBatchUpdate(r *sheets.BatchUpdateSpreadsheetResponse) batchUpdateResult {
+	summary := batchUpdateResult{SpreadsheetID: r.SpreadsheetId, Success: true}
+	for _, reply := range r.Replies {
+		switch {
+		case reply.AddSheet != nil:
+			added := summarizeSheet(reply.AddSheet.Properties)
+			summary.Replies = append(summary.Replies, batchUpdateReply{AddedSheet: &added})
+		case reply.DuplicateSheet != nil:
+			duplicated := summarizeSheet(reply.DuplicateSheet.Properties)
+			summary.Replies = append(summary.Replies, batchUpdateReply{DuplicatedSheet: &duplicated})
+		case reply.FindReplace != nil:
+			counts := reply.FindReplace
+			summary.Replies = append(summary.Replies, batchUpdateReply{
+				OccurrencesChanged: &counts.OccurrencesChanged,
+				ValuesChanged:      &counts.ValuesChanged,
+				RowsChanged:        &counts.RowsChanged,
+				SheetsChanged:      &counts.SheetsChanged,
+			})
+		}
+	}
+	return summary
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// maxSignedLinkTTL bounds how long a signed range link can stay valid
+const maxSignedLinkTTL = 7 * 24 * time.Hour
+
+// signRange computes the signature of a read-only range grant that expires at exp (Unix seconds)
+func signRange(key []byte, spreadsheetID, sheet, rangeStr string, exp int64) string {
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%d", spreadsheetID, sheet, rangeStr, exp)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// buildSignedRangeURI returns a resource URI granting read access to one range until it expires.
+// Query parameters follow the order of the resource template so that it matches.
+func buildSignedRangeURI(key []byte, spreadsheetID, sheet, rangeStr string, expiresAt time.Time) string {
+	exp := expiresAt.Unix()
+
+	query := "sheet=" + escapeURIComponent(sheet)
+	if rangeStr != "" {
+		query += "&range=" + escapeURIComponent(rangeStr)
+	}
+	query += "&exp=" + strconv.FormatInt(exp, 10)
+	query += "&sig=" + signRange(key, spreadsheetID, sheet, rangeStr, exp)
+
+	return fmt.Sprintf("spreadsheet://%s/signed?%s", url.PathEscape(spreadsheetID), query)
+}
+
+// escapeURIComponent percent-encodes a value the way URI templates expect (spaces as %20, not +)
+func escapeURIComponent(value string) string {
+	return strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+}
+
+// verifySignedRangeURI checks the signature and expiry of a signed range URI and returns what it grants
+func verifySignedRangeURI(key []byte, uri string, now time.Time) (spreadsheetID, sheet, rangeStr string, err error) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "spreadsheet" || u.Path != "/signed" {
+		return "", "", "", fmt.Errorf("invalid signed range URI")
+	}
+
+	query := u.Query()
+	spreadsheetID = u.Host
+	sheet = query.Get("sheet")
+	rangeStr = query.Get("range")
+
+	exp, err := strconv.ParseInt(query.Get("exp"), 10, 64)
+	if err != nil {
+		return "", "", "", fmt.Errorf("invalid signed range URI: bad expiry")
+	}
+
+	expected := signRange(key, spreadsheetID, sheet, rangeStr, exp)
+	if !hmac.Equal([]byte(expected), []byte(query.Get("sig"))) {
+		return "", "", "", fmt.Errorf("invalid signature for range link")
+	}
+	if now.Unix() > exp {
+		return "", "", "", fmt.Errorf("range link expired at %s", time.Unix(exp, 0).UTC().Format(time.RFC3339))
+	}
+
+	return spreadsheetID, sheet, rangeStr, nil
+}
+
+func (s *SheetsMCPServer) handleCreateRangeLink(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID, sheet, rangeStr := parseCommonArgs(args)
+	expiresIn := parseArgument(args, "expires_in", "1h")
+
+	if spreadsheetID == "" || sheet == "" {
+		return respondWithError("spreadsheet_id and sheet are required")
+	}
+
+	ttl, err := time.ParseDuration(expiresIn)
+	if err != nil || ttl <= 0 {
+		return respondWithError(fmt.Sprintf("invalid expires_in '%s': use a duration such as 15m or 2h", expiresIn))
+	}
+	if ttl > maxSignedLinkTTL {
+		return respondWithError(fmt.Sprintf("expires_in must not exceed %s", maxSignedLinkTTL))
+	}
+
+	expiresAt := time.Now().Add(ttl)
+
+	response := signedLinkResult{
+		URI:       buildSignedRangeURI(s.config.ResourceSigningKey, spreadsheetID, sheet, rangeStr, expiresAt),
+		ExpiresAt: expiresAt.UTC().Format(time.RFC3339),
+	}
+
+	return respondWithJSON(response)
+}
+
+func (s *SheetsMCPServer) handleReadSignedRange(ctx context.Context, request *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	uri := request.Params.URI
+
+	spreadsheetID, sheet, rangeStr, err := verifySignedRangeURI(s.config.ResourceSigningKey, uri, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	fullRange := buildFullRange(sheet, rangeStr)
+
+	valuesResult, err := s.sheetsService.Spreadsheets.Values.Get(spreadsheetID, fullRange).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sheet values: %w", err)
+	}
+
+	data, err := json.Marshal(map[string]any{
+		"range":  valuesResult.Range,
+		"values": valuesResult.Values,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal values: %w", err)
+	}
+
+	return &mcp.ReadResourceResult{
+		Contents: []*mcp.ResourceContents{
+			{
+				URI:      uri,
+				MIMEType: "application/json",
+				Text:     string(data),
+			},
+		},
+	}, nil
+}
+
+// signedLinkResult is a signed resource URI and when it stops working
+type signedLinkResult struct {
+	URI       string `json:"uri"`
+	ExpiresAt string `json:"expiresAt"`
+}
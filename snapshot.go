@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"google.golang.org/api/drive/v3"
+)
+
+// Snapshots are Drive copies of a spreadsheet, marked with app properties so they can be found
+// and pruned without relying on their names
+const (
+	snapshotOfProperty   = "sheetsMcpSnapshotOf"
+	snapshotTimeProperty = "sheetsMcpSnapshotTime"
+	snapshotFields       = "id,name,createdTime,webViewLink,appProperties"
+)
+
+type snapshot struct {
+	ID      string    `json:"id"`
+	Name    string    `json:"name"`
+	Created time.Time `json:"created"`
+	URL     string    `json:"url,omitempty"`
+	Reason  string    `json:"reason,omitempty"`
+}
+
+// retainSnapshots splits snapshots into the ones the retention policy keeps and the ones it prunes.
+// The newest snapshot is always kept, so pruning can never remove the last backup.
+func retainSnapshots(snapshots []snapshot, policy SnapshotRetention, now time.Time) (keep, prune []snapshot) {
+	sorted := append([]snapshot{}, snapshots...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Created.After(sorted[j].Created) })
+
+	dailyCutoff := now.AddDate(0, 0, -int(policy.DailyDays))
+	weeklyCutoff := now.AddDate(0, 0, -int(policy.WeeklyDays))
+	days := map[string]bool{}
+	weeks := map[string]bool{}
+
+	for i, snap := range sorted {
+		created := snap.Created.In(now.Location())
+		day := created.Format(time.DateOnly)
+		year, week := created.ISOWeek()
+		weekKey := fmt.Sprintf("%d-W%02d", year, week)
+
+		switch {
+		case i == 0:
+			snap.Reason = "latest"
+		case created.After(dailyCutoff) && !days[day]:
+			snap.Reason = "daily"
+		case created.After(weeklyCutoff) && !weeks[weekKey]:
+			snap.Reason = "weekly"
+		default:
+			prune = append(prune, snap)
+			continue
+		}
+		days[day] = true
+		weeks[weekKey] = true
+		keep = append(keep, snap)
+	}
+
+	return keep, prune
+}
+
+// listSnapshots returns every snapshot of a spreadsheet that is not in the trash
+func (s *SheetsMCPServer) listSnapshots(ctx context.Context, spreadsheetID string) ([]snapshot, error) {
+	query := fmt.Sprintf("appProperties has { key='%s' and value='%s' } and trashed = false", snapshotOfProperty, escapeDriveQuery(spreadsheetID))
+
+	// The appProperties marker finds the snapshots wherever they were filed, so the search is not
+	// narrowed to SHARED_DRIVE_ID
+	var snapshots []snapshot
+	err := s.driveService.Files.List().
+		Q(query).
+		Corpora("allDrives").
+		SupportsAllDrives(true).
+		IncludeItemsFromAllDrives(true).
+		Fields("nextPageToken,files("+snapshotFields+")").
+		Pages(ctx, func(page *drive.FileList) error {
+			for _, file := range page.Files {
+				created, err := time.Parse(time.RFC3339, file.AppProperties[snapshotTimeProperty])
+				if err != nil {
+					created, _ = time.Parse(time.RFC3339, file.CreatedTime)
+				}
+				snapshots = append(snapshots, snapshot{ID: file.Id, Name: file.Name, Created: created, URL: file.WebViewLink})
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Created.After(snapshots[j].Created) })
+	return snapshots, nil
+}
+
+func (s *SheetsMCPServer) handleCreateSnapshot(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID := parseArgument(args, "spreadsheet_id", "")
+	folderID := parseArgument(args, "folder_id", s.driveFolder(ctx))
+
+	if spreadsheetID == "" {
+		return respondWithError("spreadsheet_id is required")
+	}
+
+	original, err := s.driveService.Files.Get(spreadsheetID).SupportsAllDrives(true).Fields("name").Context(ctx).Do()
+	if err != nil {
+		return s.respondWithAPIError("failed to get spreadsheet", err)
+	}
+
+	now := time.Now().UTC()
+	file := &drive.File{
+		Name: fmt.Sprintf("%s snapshot %s", original.Name, now.Format(time.RFC3339)),
+		AppProperties: map[string]string{
+			snapshotOfProperty:   spreadsheetID,
+			snapshotTimeProperty: now.Format(time.RFC3339),
+		},
+	}
+	if folderID != "" {
+		file.Parents = []string{folderID}
+	}
+
+	result, err := s.driveService.Files.Copy(spreadsheetID, file).
+		SupportsAllDrives(true).
+		Fields(snapshotFields).
+		Context(ctx).
+		Do()
+	if err != nil {
+		return s.respondWithAPIError("failed to create snapshot", err)
+	}
+
+	response := snapshotResult{
+		SpreadsheetID: spreadsheetID,
+		Snapshot:      snapshot{ID: result.Id, Name: result.Name, Created: now, URL: result.WebViewLink},
+	}
+
+	return respondWithJSON(response)
+}
+
+func (s *SheetsMCPServer) handleListSnapshots(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID := parseArgument(args, "spreadsheet_id", "")
+
+	if spreadsheetID == "" {
+		return respondWithError("spreadsheet_id is required")
+	}
+
+	snapshots, err := s.listSnapshots(ctx, spreadsheetID)
+	if err != nil {
+		return s.respondWithAPIError("failed to list snapshots", err)
+	}
+
+	keep, prune := retainSnapshots(snapshots, s.config.Snapshots, time.Now())
+	if keep == nil {
+		keep = []snapshot{}
+	}
+	if prune == nil {
+		prune = []snapshot{}
+	}
+
+	response := snapshotsResult{
+		SpreadsheetID: spreadsheetID,
+		Retention: snapshotRetention{
+			DailyDays:  s.config.Snapshots.DailyDays,
+			WeeklyDays: s.config.Snapshots.WeeklyDays,
+		},
+		Kept:      keep,
+		Prunable:  prune,
+		Snapshots: len(snapshots),
+	}
+
+	return respondWithJSON(response)
+}
+
+func (s *SheetsMCPServer) handlePruneSnapshots(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID := parseArgument(args, "spreadsheet_id", "")
+	dryRun := s.config.DryRun || parseArgument(args, "dry_run", false)
+
+	if spreadsheetID == "" {
+		return respondWithError("spreadsheet_id is required")
+	}
+
+	snapshots, err := s.listSnapshots(ctx, spreadsheetID)
+	if err != nil {
+		return s.respondWithAPIError("failed to list snapshots", err)
+	}
+
+	keep, prune := retainSnapshots(snapshots, s.config.Snapshots, time.Now())
+
+	// Pruned snapshots go to the trash, so a bad policy can still be undone with restore_spreadsheet
+	pruned := []snapshot{}
+	failures := []snapshotFailure{}
+	for _, snap := range prune {
+		if !dryRun {
+			_, err := s.driveService.Files.Update(snap.ID, &drive.File{Trashed: true}).SupportsAllDrives(true).Context(ctx).Do()
+			if err != nil {
+				failures = append(failures, snapshotFailure{ID: snap.ID, Error: err.Error()})
+				continue
+			}
+		}
+		pruned = append(pruned, snap)
+	}
+
+	response := pruneSnapshotsResult{
+		SpreadsheetID: spreadsheetID,
+		DryRun:        dryRun,
+		Kept:          len(keep),
+		Pruned:        pruned,
+		Failures:      failures,
+	}
+
+	return respondWithJSON(response)
+}
+
+// snapshotResult is the snapshot create_snapshot made
+type snapshotResult struct {
+	SpreadsheetID string   `json:"spreadsheetId"`
+	Snapshot      snapshot `json:"snapshot"`
+}
+
+// snapshotRetention is how long daily and weekly snapshots are kept
+type snapshotRetention struct {
+	DailyDays  int64 `json:"dailyDays"`
+	WeeklyDays int64 `json:"weeklyDays"`
+}
+
+// snapshotsResult splits the snapshots of a spreadsheet into those the retention policy keeps and
+// those it would prune
+type snapshotsResult struct {
+	SpreadsheetID string            `json:"spreadsheetId"`
+	Retention     snapshotRetention `json:"retention"`
+	Kept          []snapshot        `json:"kept"`
+	Prunable      []snapshot        `json:"prunable"`
+	Snapshots     int               `json:"snapshots"`
+}
+
+// snapshotFailure is a snapshot prune_snapshots could not trash
+type snapshotFailure struct {
+	ID    string `json:"id"`
+	Error string `json:"error"`
+}
+
+// pruneSnapshotsResult reports the snapshots prune_snapshots trashed
+type pruneSnapshotsResult struct {
+	SpreadsheetID string            `json:"spreadsheetId"`
+	DryRun        bool              `json:"dryRun"`
+	Kept          int               `json:"kept"`
+	Pruned        []snapshot        `json:"pruned"`
+	Failures      []snapshotFailure `json:"failures"`
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+var (
+	spreadsheetURLPattern = regexp.MustCompile(`/spreadsheets/(?:u/\d+/)?d/([a-zA-Z0-9_-]+)`)
+	gidPattern            = regexp.MustCompile(`[#?&]gid=(\d+)`)
+)
+
+// parseSpreadsheetURL extracts the spreadsheet ID and, when present, the sheet ID of a Google Sheets URL.
+// Anything that is not a spreadsheet URL is returned unchanged with ok set to false.
+func parseSpreadsheetURL(value string) (id string, gid int64, hasGID bool, ok bool) {
+	match := spreadsheetURLPattern.FindStringSubmatch(value)
+	if match == nil {
+		return value, 0, false, false
+	}
+	if g := gidPattern.FindStringSubmatch(value); g != nil {
+		if n, err := strconv.ParseInt(g[1], 10, 64); err == nil {
+			return match[1], n, true, true
+		}
+	}
+	return match[1], 0, false, true
+}
+
+// sheetTitleByID resolves a sheet ID, such as the gid of a URL, to the sheet's name
+func (s *SheetsMCPServer) sheetTitleByID(ctx context.Context, spreadsheetID string, sheetID int64) (string, error) {
+	props, err := s.getSheetProperties(ctx, spreadsheetID)
+	if err != nil {
+		return "", err
+	}
+	for _, p := range props {
+		if p.SheetId == sheetID {
+			return p.Title, nil
+		}
+	}
+	return "", fmt.Errorf("no sheet with gid %d in spreadsheet %s", sheetID, spreadsheetID)
+}
+
+// withSpreadsheetURLs lets every spreadsheet ID argument be a full spreadsheet URL, including
+// destination_spreadsheet_id and the IDs inside spreadsheet_ids, queries and sources. The ID is extracted
+// before the tool runs, and a #gid= fragment of spreadsheet_id fills in the sheet argument when the
+// tool takes one.
+func (s *SheetsMCPServer) withSpreadsheetURLs(takesSheet bool, handler mcp.ToolHandler) mcp.ToolHandler {
+	return func(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArgsFromRequest(request)
+		if err != nil {
+			return handler(ctx, request)
+		}
+
+		changed := false
+		if raw, ok := args["spreadsheet_id"].(string); ok {
+			if id, gid, hasGID, isURL := parseSpreadsheetURL(raw); isURL {
+				args["spreadsheet_id"] = id
+				changed = true
+				if _, hasSheet := args["sheet"]; takesSheet && hasGID && !hasSheet {
+					title, err := s.sheetTitleByID(ctx, id, gid)
+					if err != nil {
+						return s.respondWithAPIError("failed to resolve the sheet in the URL", err)
+					}
+					args["sheet"] = title
+				}
+			}
+		}
+
+		if raw, ok := args["destination_spreadsheet_id"].(string); ok {
+			if id, _, _, isURL := parseSpreadsheetURL(raw); isURL {
+				args["destination_spreadsheet_id"] = id
+				changed = true
+			}
+		}
+
+		// Tools working on several spreadsheets take lists of IDs, or of queries or sources with an ID each
+		if ids, ok := args["spreadsheet_ids"].([]any); ok {
+			for i, raw := range ids {
+				if value, ok := raw.(string); ok {
+					if id, _, _, isURL := parseSpreadsheetURL(value); isURL {
+						ids[i] = id
+						changed = true
+					}
+				}
+			}
+		}
+		for _, key := range []string{"queries", "sources"} {
+			items, _ := args[key].([]any)
+			for _, raw := range items {
+				if item, ok := raw.(map[string]any); ok {
+					if value, ok := item["spreadsheet_id"].(string); ok {
+						if id, _, _, isURL := parseSpreadsheetURL(value); isURL {
+							item["spreadsheet_id"] = id
+							changed = true
+						}
+					}
+				}
+			}
+		}
+
+		if changed {
+			data, err := json.Marshal(args)
+			if err != nil {
+				return respondWithError(fmt.Sprintf("failed to rewrite arguments: %v", err))
+			}
+			request.Params.Arguments = data
+		}
+		return handler(ctx, request)
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"gopkg.in/yaml.v3"
+)
+
+// tenantsConfig is the TENANTS_FILE document: the tenants sharing one deployed server, by name
+type tenantsConfig struct {
+	Tenants map[string]*tenantConfig `yaml:"tenants"`
+}
+
+// tenantConfig narrows what one session may do, so tenants sharing one deployed server stay isolated.
+// Tenants are only defined on the server; clients are bound to them by their credentials.
+type tenantConfig struct {
+	Name string `json:"name" yaml:"-"`
+	// FolderID replaces DRIVE_FOLDER_ID as the folder new files are created in and listings look in
+	FolderID string `json:"folderId" yaml:"folder_id"`
+	// ReadOnly rejects every tool that can modify a file
+	ReadOnly bool `json:"readOnly" yaml:"read_only"`
+	// AllowedSpreadsheets, when not empty, are the only spreadsheets the session may open
+	AllowedSpreadsheets []string `json:"allowedSpreadsheets" yaml:"allowed_spreadsheets"`
+	// Profile binds the session to one PROFILES_FILE profile, which its calls may not override
+	Profile string `json:"profile" yaml:"profile"`
+	// Clients are the HTTP clients bound to the tenant: token-N for the Nth HTTP_AUTH_TOKENS entry, or
+	// oidc:<subject> for an OIDC user
+	Clients []string `json:"-" yaml:"clients"`
+}
+
+// loadTenants reads and checks TENANTS_FILE, or returns nil when it is not set
+func loadTenants(path string) (map[string]*tenantConfig, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TENANTS_FILE: %w", err)
+	}
+
+	var tenants tenantsConfig
+	if err := yaml.Unmarshal(data, &tenants); err != nil {
+		return nil, fmt.Errorf("invalid TENANTS_FILE %s: %w", path, err)
+	}
+	if len(tenants.Tenants) == 0 {
+		return nil, fmt.Errorf("invalid TENANTS_FILE %s: no tenants are defined", path)
+	}
+	bound := map[string]string{}
+	for _, name := range slices.Sorted(maps.Keys(tenants.Tenants)) {
+		tenant := tenants.Tenants[name]
+		if name == "" || tenant == nil {
+			return nil, fmt.Errorf("invalid TENANTS_FILE %s: every tenant needs a name and settings", path)
+		}
+		tenant.Name = name
+		for _, client := range tenant.Clients {
+			if other, ok := bound[client]; ok {
+				return nil, fmt.Errorf("invalid TENANTS_FILE %s: client %s is bound to both %s and %s", path, client, other, name)
+			}
+			bound[client] = name
+		}
+	}
+	return tenants.Tenants, nil
+}
+
+// clientTenant returns the tenant an authenticated HTTP client is bound to, or nil
+func clientTenant(tenants map[string]*tenantConfig, client string) *tenantConfig {
+	for _, tenant := range tenants {
+		if slices.Contains(tenant.Clients, client) {
+			return tenant
+		}
+	}
+	return nil
+}
+
+type tenantKey struct{}
+
+// withTenantConfig attaches tenant settings to a context
+func withTenantConfig(ctx context.Context, tenant *tenantConfig) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+func tenantFromContext(ctx context.Context) *tenantConfig {
+	tenant, _ := ctx.Value(tenantKey{}).(*tenantConfig)
+	return tenant
+}
+
+// callerTenant returns the tenant a tool call or resource read runs as: the one its HTTP credentials
+// are bound to, or TENANT for the stdio client. It is nil when the caller is not limited.
+func (s *SheetsMCPServer) callerTenant(ctx context.Context, extra *mcp.RequestExtra) *tenantConfig {
+	if tenant := tenantFromContext(ctx); tenant != nil {
+		return tenant
+	}
+	if extra != nil && extra.TokenInfo != nil {
+		if tenant, ok := extra.TokenInfo.Extra["tenant"].(*tenantConfig); ok {
+			return tenant
+		}
+	}
+	if s.config.Tenant != "" {
+		return s.config.Tenants[s.config.Tenant]
+	}
+	return nil
+}
+
+// allows reports whether the tenant may open a spreadsheet; a nil tenant may open any
+func (t *tenantConfig) allows(spreadsheetID string) bool {
+	return t == nil || len(t.AllowedSpreadsheets) == 0 || slices.Contains(t.AllowedSpreadsheets, spreadsheetID)
+}
+
+// driveFolder returns the folder new files go to for the calling tenant. The ID of a shared drive
+// doubles as the ID of its root folder, so SHARED_DRIVE_ID is the last fallback.
+func (s *SheetsMCPServer) driveFolder(ctx context.Context) string {
+	if tenant := tenantFromContext(ctx); tenant != nil && tenant.FolderID != "" {
+		return tenant.FolderID
+	}
+	if s.config.DriveFolderID != "" {
+		return s.config.DriveFolderID
+	}
+	return s.config.SharedDriveID
+}
+
+// check reports why a tenant may not make a tool call, or nil when it may
+func (t *tenantConfig) check(tool string, args map[string]any) error {
+	if t.ReadOnly && writesSpreadsheets(tool) {
+		return fmt.Errorf("%s is not available: this session is read-only", tool)
+	}
+	// The tenant folder is where the session's files go and what it lists, so calls cannot point elsewhere
+	if folderID := parseArgument(args, "folder_id", ""); t.FolderID != "" && folderID != "" && folderID != t.FolderID {
+		return fmt.Errorf("folder %s is not available to this session", folderID)
+	}
+	if len(t.AllowedSpreadsheets) == 0 {
+		return nil
+	}
+
+	for _, id := range spreadsheetIDArguments(args) {
+		if !t.allows(id) {
+			return fmt.Errorf("spreadsheet %s is not available to this session", id)
+		}
+	}
+	// file_id names a spreadsheet or a folder, which may be the tenant's own
+	if id := parseArgument(args, "file_id", ""); id != "" && id != t.FolderID && !t.allows(id) {
+		return fmt.Errorf("file %s is not available to this session", id)
+	}
+	return nil
+}
+
+// spreadsheetIDArguments returns every spreadsheet ID a call's arguments name, including those in
+// spreadsheet_ids and in the entries of queries and sources
+func spreadsheetIDArguments(args map[string]any) []string {
+	ids := []string{}
+	for _, key := range []string{"spreadsheet_id", "destination_spreadsheet_id"} {
+		if id := parseArgument(args, key, ""); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	for _, key := range []string{"spreadsheet_ids", "queries", "sources"} {
+		var items []any
+		if raw, ok := args[key]; ok && convertToType(raw, &items) == nil {
+			for _, item := range items {
+				switch v := item.(type) {
+				case string:
+					ids = append(ids, v)
+				case map[string]any:
+					if id := parseArgument(v, "spreadsheet_id", ""); id != "" {
+						ids = append(ids, id)
+					}
+				}
+			}
+		}
+	}
+	return ids
+}
+
+// withTenant applies the calling session's tenant settings before a tool runs
+func (s *SheetsMCPServer) withTenant(name string, handler mcp.ToolHandler) mcp.ToolHandler {
+	return func(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		tenant := s.callerTenant(ctx, request.Extra)
+		if tenant == nil {
+			return handler(ctx, request)
+		}
+
+		// A session bound to a profile must never fall through to other credentials
+		if tenant.Profile != "" && tenant.Profile != s.profile {
+			return respondWithToolError(toolError{Code: errorPermission, Message: fmt.Sprintf("this session is bound to profile %s, which this server does not act as", tenant.Profile)})
+		}
+
+		args, err := getArgsFromRequest(request)
+		if err != nil {
+			return respondWithError(err.Error())
+		}
+		if err := tenant.check(name, args); err != nil {
+			return respondWithToolError(toolError{Code: errorPermission, Message: err.Error()})
+		}
+
+		return handler(withTenantConfig(ctx, tenant), request)
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// withTimeout bounds a tool call by its configured timeout. Handlers pass their context to every
+// Google API call, so when the deadline passes, or the client cancels the request, the upstream
+// HTTP call is aborted rather than left running.
+func (s *SheetsMCPServer) withTimeout(name string, handler mcp.ToolHandler) mcp.ToolHandler {
+	timeout := s.config.toolTimeout(name)
+	if timeout <= 0 {
+		return handler
+	}
+
+	return func(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		callCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		result, err := handler(callCtx, request)
+		if errors.Is(callCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+			// A write that timed out may still have been applied, so only reads are safe to repeat
+			return respondWithToolError(toolError{
+				Code:      errorTimeout,
+				Message:   fmt.Sprintf("%s timed out after %s; raise TOOL_TIMEOUT or TOOL_TIMEOUTS for slow calls", name, timeout),
+				Retryable: !writesSpreadsheets(name),
+			})
+		}
+		return result, err
+	}
+}
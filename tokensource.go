@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/oauth2"
+)
+
+// savedTokenSource refreshes an OAuth token with its refresh token as access tokens expire, and writes
+// every new token to TOKEN_PATH so that a restarted server picks up where this one left off. When the
+// refresh token stops working, reauthenticate swaps in the token of a new browser sign-in.
+type savedTokenSource struct {
+	// ctx outlives tool calls; refreshes and background sign-ins run under it
+	ctx    context.Context
+	auth   *AuthConfig
+	config *oauth2.Config
+
+	mu     sync.Mutex
+	source oauth2.TokenSource
+	saved  *oauth2.Token
+	signIn *webSignIn
+}
+
+func newSavedTokenSource(ctx context.Context, auth *AuthConfig, config *oauth2.Config, token *oauth2.Token) *savedTokenSource {
+	return &savedTokenSource{
+		ctx:    ctx,
+		auth:   auth,
+		config: config,
+		source: config.TokenSource(ctx, token),
+		saved:  token,
+	}
+}
+
+func (s *savedTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, err := s.source.Token()
+	if err != nil {
+		return nil, err
+	}
+	if s.saved == nil || token.AccessToken != s.saved.AccessToken {
+		s.saved = token
+		if err := s.auth.saveToken(token); err != nil {
+			slog.Warn("failed to save refreshed token", "path", s.auth.TokenPath, "error", err)
+		}
+	}
+	return token, nil
+}
+
+// replace starts using the token of a new sign-in
+func (s *savedTokenSource) replace(token *oauth2.Token) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.source = s.config.TokenSource(s.ctx, token)
+	s.saved = token
+	if err := s.auth.saveToken(token); err != nil {
+		slog.Warn("failed to save token", "path", s.auth.TokenPath, "error", err)
+	}
+}
+
+// reauthenticate starts a browser sign-in whose token replaces the current one when it completes, or
+// returns the sign-in already waiting for the user, in which case started is false
+func (s *savedTokenSource) reauthenticate() (signIn *webSignIn, started bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.signIn != nil && !s.signIn.finished() {
+		return s.signIn, false, nil
+	}
+	signIn, err = s.auth.startWebSignIn(s.ctx, s.config)
+	if err != nil {
+		return nil, false, err
+	}
+	s.signIn = signIn
+
+	go func() {
+		token, err := signIn.wait(s.ctx)
+		if err != nil {
+			slog.Warn("sign-in failed", "error", err)
+			return
+		}
+		s.replace(token)
+		slog.Info("signed in again; calls use the new token")
+	}()
+	return signIn, true, nil
+}
+
+// reauthResult is what reauthenticate returns
+type reauthResult struct {
+	Status    string `json:"status"`
+	AuthURL   string `json:"authUrl"`
+	ExpiresAt string `json:"expiresAt"`
+	Message   string `json:"message"`
+}
+
+// handleReauthenticate starts a new OAuth sign-in without restarting the server. It returns as soon as
+// the sign-in link is ready, since the user may take longer than a tool call is allowed to run.
+func (s *SheetsMCPServer) handleReauthenticate(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	source, ok := s.tokenSource.(*savedTokenSource)
+	if !ok {
+		return respondWithError(fmt.Sprintf("reauthenticate only applies to OAuth sign-in; the server uses %s credentials", s.authMethod))
+	}
+	signIn, started, err := source.reauthenticate()
+	if err != nil {
+		return respondWithError(fmt.Sprintf("failed to start sign-in: %v", err))
+	}
+	if started {
+		if err := openBrowser(signIn.authURL); err != nil {
+			slog.Debug("failed to open the browser", "error", err)
+		}
+	}
+
+	response := reauthResult{
+		Status:    "pending",
+		AuthURL:   signIn.authURL,
+		ExpiresAt: signIn.expires.UTC().Format(time.RFC3339),
+		Message:   "A browser window was opened on the machine running the server. Ask the user to sign in there, or to open authUrl on that machine; calls use the new token as soon as the sign-in completes. Call health_check to confirm.",
+	}
+	if !started {
+		response.Message = "A sign-in is already waiting. Ask the user to complete it by opening authUrl on the machine running the server."
+	}
+	return respondWithJSON(response)
+}
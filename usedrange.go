@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"google.golang.org/api/sheets/v4"
+)
+
+// usedExtent is the bounding box of the non-empty cells of a sheet, with 0-based bounds
+type usedExtent struct {
+	firstRow, lastRow int
+	firstCol, lastCol int
+	cells             int64
+}
+
+// measureValues finds the bounding box of the non-empty cells; ok is false when every cell is empty
+func measureValues(values [][]any) (extent usedExtent, ok bool) {
+	extent = usedExtent{firstRow: -1, firstCol: -1}
+	for r, row := range values {
+		for c, cell := range row {
+			if formatCell(cell) == "" {
+				continue
+			}
+			extent.cells++
+			if extent.firstRow < 0 {
+				extent.firstRow = r
+			}
+			extent.lastRow = r
+			if extent.firstCol < 0 || c < extent.firstCol {
+				extent.firstCol = c
+			}
+			extent.lastCol = max(extent.lastCol, c)
+		}
+	}
+	return extent, extent.cells > 0
+}
+
+// looksLikeHeader guesses whether a row is a header row: every non-empty cell is text that is not a
+// number, the labels are distinct, and there is data below it
+func looksLikeHeader(row []any, rowsBelow int) bool {
+	if rowsBelow == 0 {
+		return false
+	}
+	seen := make(map[string]bool)
+	for _, cell := range row {
+		text := formatCell(cell)
+		if text == "" {
+			continue
+		}
+		if _, isText := cell.(string); !isText {
+			return false
+		}
+		if _, err := strconv.ParseFloat(text, 64); err == nil {
+			return false
+		}
+		if seen[text] {
+			return false
+		}
+		seen[text] = true
+	}
+	return len(seen) > 0
+}
+
+func (s *SheetsMCPServer) handleGetUsedRange(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	spreadsheetID, sheet, _ := parseCommonArgs(args)
+
+	if spreadsheetID == "" || sheet == "" {
+		return respondWithError("spreadsheet_id and sheet are required")
+	}
+
+	props, err := s.getSheetProperties(ctx, spreadsheetID)
+	if err != nil {
+		return s.respondWithAPIError("failed to get sheet properties", err)
+	}
+	var grid *sheets.GridProperties
+	for _, p := range props {
+		if p.Title == sheet {
+			grid = p.GridProperties
+		}
+	}
+	if grid == nil {
+		return respondWithError(fmt.Sprintf("sheet '%s' not found or is not a grid sheet", sheet))
+	}
+
+	// The API trims trailing empty rows and cells from value reads, so one read of the whole sheet
+	// shows where the data ends without transferring the empty part of the grid
+	valuesResult, err := s.sheetsService.Spreadsheets.Values.Get(spreadsheetID, buildFullRange(sheet, "")).
+		ValueRenderOption("UNFORMATTED_VALUE").
+		Fields("values").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return s.respondWithAPIError("failed to get sheet values", err)
+	}
+
+	response := usedRangeResult{
+		SpreadsheetID: spreadsheetID,
+		Sheet:         sheet,
+		GridRows:      grid.RowCount,
+		GridColumns:   grid.ColumnCount,
+		FrozenRows:    grid.FrozenRowCount,
+	}
+
+	extent, ok := measureValues(valuesResult.Values)
+	if !ok {
+		response.Empty = true
+		return respondWithJSON(response)
+	}
+
+	first := valuesResult.Values[extent.firstRow]
+	hasHeader := looksLikeHeader(first, extent.lastRow-extent.firstRow)
+
+	response.UsedRange = fmt.Sprintf("%s%d:%s%d", columnToLetter(int64(extent.firstCol)), extent.firstRow+1, columnToLetter(int64(extent.lastCol)), extent.lastRow+1)
+	response.FirstRow = extent.firstRow + 1
+	response.LastRow = extent.lastRow + 1
+	response.FirstColumn = columnToLetter(int64(extent.firstCol))
+	response.LastColumn = columnToLetter(int64(extent.lastCol))
+	response.Rows = extent.lastRow - extent.firstRow + 1
+	response.Columns = extent.lastCol - extent.firstCol + 1
+	response.NonEmptyCells = extent.cells
+	response.HasHeaderRow = &hasHeader
+	if hasHeader {
+		headers := make([]string, 0, len(first))
+		for _, cell := range first[extent.firstCol:] {
+			headers = append(headers, formatCell(cell))
+		}
+		response.Headers = headers
+	}
+	return respondWithJSON(response)
+}
+
+// usedRangeResult is where the data of a sheet lies within its grid; an empty sheet leaves the extent out
+type usedRangeResult struct {
+	SpreadsheetID string   `json:"spreadsheetId"`
+	Sheet         string   `json:"sheet"`
+	GridRows      int64    `json:"gridRows"`
+	GridColumns   int64    `json:"gridColumns"`
+	FrozenRows    int64    `json:"frozenRows"`
+	Empty         bool     `json:"empty,omitempty"`
+	UsedRange     string   `json:"usedRange"`
+	FirstRow      int      `json:"firstRow,omitempty"`
+	LastRow       int      `json:"lastRow,omitempty"`
+	FirstColumn   string   `json:"firstColumn,omitempty"`
+	LastColumn    string   `json:"lastColumn,omitempty"`
+	Rows          int      `json:"rows,omitempty"`
+	Columns       int      `json:"columns,omitempty"`
+	NonEmptyCells int64    `json:"nonEmptyCells"`
+	HasHeaderRow  *bool    `json:"hasHeaderRow,omitempty"`
+	Headers       []string `json:"headers,omitempty"`
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// withValidation checks the arguments of every call against the tool's input schema before the handler
+// runs. Handlers read arguments with parseArgument, which falls back to the default when a value has
+// the wrong type, so without this a string passed for count would quietly become 0.
+func (s *SheetsMCPServer) withValidation(tool *mcp.Tool, handler mcp.ToolHandler) mcp.ToolHandler {
+	schema, ok := tool.InputSchema.(map[string]any)
+	if !ok {
+		return handler
+	}
+	return func(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArgsFromRequest(request)
+		if err != nil {
+			return respondWithError(err.Error())
+		}
+		if err := validateObject("", schema, args); err != nil {
+			return respondWithError(err.Error())
+		}
+		return handler(ctx, request)
+	}
+}
+
+// validateObject checks that an object has the required properties of its schema and that each
+// declared property matches its own schema. Unknown properties are left to the handler.
+func validateObject(path string, schema, object map[string]any) error {
+	var missing []string
+	for _, key := range schemaStrings(schema["required"]) {
+		if !argumentPresent(object, key) {
+			missing = append(missing, argumentPath(path, key))
+		}
+	}
+	switch len(missing) {
+	case 0:
+	case 1:
+		return fmt.Errorf("%s is required", missing[0])
+	default:
+		return fmt.Errorf("%s are required", joinWords(missing, "and"))
+	}
+
+	properties, _ := schema["properties"].(map[string]any)
+	for _, key := range slices.Sorted(maps.Keys(object)) {
+		property, ok := properties[key].(map[string]any)
+		if !ok {
+			property, ok = schema["additionalProperties"].(map[string]any)
+		}
+		if !ok {
+			continue
+		}
+		if err := validateValue(argumentPath(path, key), property, object[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateValue checks a value against the type and enum of its schema, and the items or properties
+// inside it. Null counts as leaving an optional argument out.
+func validateValue(path string, schema map[string]any, value any) error {
+	if value == nil {
+		return nil
+	}
+	if kind, ok := schema["type"].(string); ok && !hasJSONType(value, kind) {
+		return fmt.Errorf("%s must be %s, got %s", path, withArticle(kind), describeJSON(value))
+	}
+	// Handlers upper-case or lower-case enum arguments themselves, so case does not matter here
+	if options := schemaStrings(schema["enum"]); options != nil {
+		text, _ := value.(string)
+		if !slices.ContainsFunc(options, func(option string) bool { return strings.EqualFold(option, text) }) {
+			return fmt.Errorf("invalid %s '%v': must be %s", path, value, joinWords(options, "or"))
+		}
+	}
+
+	switch v := value.(type) {
+	case []any:
+		items, ok := schema["items"].(map[string]any)
+		if !ok {
+			return nil
+		}
+		for i, item := range v {
+			if err := validateValue(fmt.Sprintf("%s[%d]", path, i), items, item); err != nil {
+				return err
+			}
+		}
+	case map[string]any:
+		return validateObject(path, schema, v)
+	}
+	return nil
+}
+
+// argumentPresent reports whether an argument was given. A sheet may also come from the sheet name of
+// the range, such as 'Q1 Sales'!A1:C9, which parseCommonArgs splits off.
+func argumentPresent(args map[string]any, key string) bool {
+	if args[key] != nil {
+		return true
+	}
+	if key == "sheet" {
+		rangeStr, _ := args["range"].(string)
+		prefix, _, err := splitSheetPrefix(rangeStr)
+		return err == nil && prefix != ""
+	}
+	return false
+}
+
+// hasJSONType reports whether a decoded JSON value has a JSON schema type
+func hasJSONType(value any, kind string) bool {
+	switch kind {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == float64(int64(n))
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	}
+	return true
+}
+
+// describeJSON names the type of a decoded JSON value, with the value itself when it is short
+func describeJSON(value any) string {
+	var kind string
+	switch value.(type) {
+	case string:
+		kind = "string"
+	case float64:
+		kind = "number"
+	case bool:
+		kind = "boolean"
+	case []any:
+		return "an array"
+	case map[string]any:
+		return "an object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil || len(encoded) > 40 {
+		return withArticle(kind)
+	}
+	return fmt.Sprintf("%s %s", withArticle(kind), encoded)
+}
+
+// withArticle puts a or an before a JSON schema type
+func withArticle(kind string) string {
+	switch kind {
+	case "array", "object", "integer":
+		return "an " + kind
+	}
+	return "a " + kind
+}
+
+// argumentPath names a property inside an argument, such as queries[0].sheet
+func argumentPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// joinWords lists words as in "a, b, and c" or "a, b, or c"
+func joinWords(words []string, conjunction string) string {
+	switch len(words) {
+	case 0:
+		return ""
+	case 1:
+		return words[0]
+	case 2:
+		return words[0] + " " + conjunction + " " + words[1]
+	}
+	return strings.Join(words[:len(words)-1], ", ") + ", " + conjunction + " " + words[len(words)-1]
+}
+
+// schemaStrings reads a list of strings from a schema written as a Go literal or decoded from JSON
+func schemaStrings(value any) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []any:
+		strs := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				strs = append(strs, s)
+			}
+		}
+		return strs
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/sync/errgroup"
+)
+
+// valueHit is one cell whose value matched a search_values query
+type valueHit struct {
+	SpreadsheetID string `json:"spreadsheetId"`
+	Title         string `json:"spreadsheetTitle,omitempty"`
+	Sheet         string `json:"sheet"`
+	Cell          string `json:"cell"`
+	Value         string `json:"value"`
+}
+
+// searchSpreadsheetValues returns the cells of every sheet in a spreadsheet that match, reading all
+// sheets with one batchGet; it stops collecting after limit hits
+func (s *SheetsMCPServer) searchSpreadsheetValues(ctx context.Context, spreadsheetID string, match func(string) bool, limit int) ([]valueHit, error) {
+	spreadsheet, err := s.sheetsService.Spreadsheets.Get(spreadsheetID).
+		Fields("properties.title,sheets.properties.title").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return nil, err
+	}
+
+	ranges := make([]string, 0, len(spreadsheet.Sheets))
+	for _, sheet := range spreadsheet.Sheets {
+		ranges = append(ranges, buildFullRange(sheet.Properties.Title, ""))
+	}
+	if len(ranges) == 0 {
+		return nil, nil
+	}
+
+	batchResult, err := s.sheetsService.Spreadsheets.Values.BatchGet(spreadsheetID).
+		Ranges(ranges...).
+		Fields("valueRanges.values").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return nil, err
+	}
+
+	var hits []valueHit
+	for i, valueRange := range batchResult.ValueRanges {
+		recordCellsRead(ctx, valueRange.Values)
+		for r, row := range valueRange.Values {
+			for c, cell := range row {
+				text := formatCell(cell)
+				if text == "" || !match(text) {
+					continue
+				}
+				hits = append(hits, valueHit{
+					SpreadsheetID: spreadsheetID,
+					Title:         spreadsheet.Properties.Title,
+					Sheet:         spreadsheet.Sheets[i].Properties.Title,
+					Cell:          fmt.Sprintf("%s%d", columnToLetter(int64(c)), r+1),
+					Value:         text,
+				})
+				if len(hits) == limit {
+					return hits, nil
+				}
+			}
+		}
+	}
+	return hits, nil
+}
+
+// folderSpreadsheets lists up to limit spreadsheets in a folder, newest first; with no folder it lists
+// the most recently modified spreadsheets the credentials can see
+func (s *SheetsMCPServer) folderSpreadsheets(ctx context.Context, folderID string, limit int64) ([]string, error) {
+	query := fmt.Sprintf("mimeType = '%s' and trashed = false", spreadsheetMimeType)
+	if folderID != "" {
+		query += fmt.Sprintf(" and '%s' in parents", escapeDriveQuery(folderID))
+	}
+
+	result, err := s.driveService.Files.List().
+		Q(query).
+		PageSize(limit).
+		OrderBy("modifiedTime desc").
+		SupportsAllDrives(true).
+		IncludeItemsFromAllDrives(true).
+		Fields("files(id)").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(result.Files))
+	for _, file := range result.Files {
+		ids = append(ids, file.Id)
+	}
+	return ids, nil
+}
+
+func (s *SheetsMCPServer) handleSearchValues(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := getArgsFromRequest(request)
+	if err != nil {
+		return respondWithError(err.Error())
+	}
+	query := parseArgument(args, "query", "")
+	pattern := parseArgument(args, "regex", "")
+	matchCase := parseArgument(args, "match_case", false)
+	spreadsheetID := parseArgument(args, "spreadsheet_id", "")
+	folderID := parseArgument(args, "folder_id", s.driveFolder(ctx))
+	maxResults := int(parseArgument(args, "max_results", float64(100)))
+	maxSpreadsheets := int64(parseArgument(args, "max_spreadsheets", float64(20)))
+
+	if (query == "") == (pattern == "") {
+		return respondWithError("exactly one of query or regex is required")
+	}
+	if maxResults < 1 {
+		return respondWithError("max_results must be at least 1")
+	}
+	if maxSpreadsheets < 1 || maxSpreadsheets > 100 {
+		return respondWithError("max_spreadsheets must be between 1 and 100")
+	}
+
+	var match func(string) bool
+	switch {
+	case pattern != "":
+		if !matchCase {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return respondWithError(fmt.Sprintf("invalid regex: %v", err))
+		}
+		match = re.MatchString
+	case matchCase:
+		match = func(text string) bool { return strings.Contains(text, query) }
+	default:
+		lowered := strings.ToLower(query)
+		match = func(text string) bool { return strings.Contains(strings.ToLower(text), lowered) }
+	}
+
+	spreadsheetIDs := []string{spreadsheetID}
+	if spreadsheetID == "" {
+		if err := s.requireDrive("searching a folder"); err != nil {
+			return respondWithError(err.Error() + "; pass spreadsheet_id to search one spreadsheet")
+		}
+		spreadsheetIDs, err = s.folderSpreadsheets(ctx, folderID, maxSpreadsheets)
+		if err != nil {
+			return s.respondWithAPIError("failed to list spreadsheets", err)
+		}
+		// A session limited to certain spreadsheets must not see into the rest of the folder
+		tenant := tenantFromContext(ctx)
+		spreadsheetIDs = slices.DeleteFunc(spreadsheetIDs, func(id string) bool {
+			return !tenant.allows(id)
+		})
+	}
+
+	hits := make([][]valueHit, len(spreadsheetIDs))
+	failures := make([]*searchFailure, len(spreadsheetIDs))
+	var group errgroup.Group
+	group.SetLimit(maxParallelSpreadsheets)
+	for i, id := range spreadsheetIDs {
+		group.Go(func() error {
+			found, err := s.searchSpreadsheetValues(ctx, id, match, maxResults+1)
+			if err != nil {
+				failures[i] = &searchFailure{SpreadsheetID: id, Error: err.Error()}
+			}
+			hits[i] = found
+			return nil
+		})
+	}
+	group.Wait()
+
+	results := []valueHit{}
+	truncated := false
+	for _, found := range hits {
+		for _, hit := range found {
+			if len(results) == maxResults {
+				truncated = true
+				break
+			}
+			results = append(results, hit)
+		}
+	}
+
+	response := valueSearchResult{
+		Hits:                 results,
+		Truncated:            truncated,
+		SpreadsheetsSearched: len(spreadsheetIDs),
+	}
+	for _, failure := range failures {
+		if failure != nil {
+			response.Errors = append(response.Errors, *failure)
+		}
+	}
+	if spreadsheetID == "" && folderID != "" {
+		response.FolderID = folderID
+	}
+	return respondWithJSON(response)
+}
+
+// searchFailure is a spreadsheet search_values could not search
+type searchFailure struct {
+	SpreadsheetID string `json:"spreadsheetId"`
+	Error         string `json:"error"`
+}
+
+// valueSearchResult is the cells search_values found
+type valueSearchResult struct {
+	Hits                 []valueHit      `json:"hits"`
+	Truncated            bool            `json:"truncated"`
+	SpreadsheetsSearched int             `json:"spreadsheetsSearched"`
+	Errors               []searchFailure `json:"errors,omitempty"`
+	FolderID             string          `json:"folderId,omitempty"`
+}
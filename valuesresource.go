@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// defaultResourceCells caps sheet value resources when MAX_RESPONSE_CELLS is 0. Unlike tool calls,
+// resource reads cannot be paged, so they are never left uncapped.
+const defaultResourceCells = 100000
+
+// resourceCellLimit returns the most cells a sheet value resource holds
+func (s *SheetsMCPServer) resourceCellLimit() int64 {
+	if s.config.MaxResponseCells > 0 {
+		return s.config.MaxResponseCells
+	}
+	return defaultResourceCells
+}
+
+// parseSheetValuesURI splits spreadsheet://{spreadsheet_id}/{sheet}/values{?range,format} into its
+// parts. The sheet name is percent-encoded in the URI, since it may contain spaces or slashes.
+func parseSheetValuesURI(uri string) (spreadsheetID, sheet, rangeStr, format string, err error) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "spreadsheet" {
+		return "", "", "", "", fmt.Errorf("invalid sheet values URI %q", uri)
+	}
+	escapedSheet, ok := strings.CutSuffix(strings.TrimPrefix(u.EscapedPath(), "/"), "/values")
+	if !ok || escapedSheet == "" || strings.Contains(escapedSheet, "/") {
+		return "", "", "", "", fmt.Errorf("invalid sheet values URI %q: expected spreadsheet://{spreadsheet_id}/{sheet}/values", uri)
+	}
+	if sheet, err = url.PathUnescape(escapedSheet); err != nil {
+		return "", "", "", "", fmt.Errorf("invalid sheet name in %q: %w", uri, err)
+	}
+
+	query := u.Query()
+	return u.Host, sheet, query.Get("range"), query.Get("format"), nil
+}
+
+// valuesMIMEType resolves the format of a sheet value resource, given as json or csv or as a MIME type
+func valuesMIMEType(format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "", "json", "application/json":
+		return "application/json", nil
+	case "csv", "text/csv":
+		return "text/csv", nil
+	}
+	return "", fmt.Errorf("invalid format '%s': must be json or csv", format)
+}
+
+// handleReadSheetValues serves live sheet values as a resource, so clients can attach a sheet as
+// context without a tool call. Reads are capped at resourceCellLimit cells; a capped read says so
+// in its JSON, or in _meta for CSV.
+func (s *SheetsMCPServer) handleReadSheetValues(ctx context.Context, request *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	uri := request.Params.URI
+
+	spreadsheetID, sheet, rangeStr, format, err := parseSheetValuesURI(uri)
+	if err != nil {
+		return nil, err
+	}
+	mimeType, err := valuesMIMEType(format)
+	if err != nil {
+		return nil, err
+	}
+
+	if tenant := s.callerTenant(ctx, request.Extra); tenant != nil {
+		if err := tenant.check("get_sheet_data", map[string]any{"spreadsheet_id": spreadsheetID}); err != nil {
+			return nil, err
+		}
+	}
+
+	fullRange := buildFullRange(sheet, rangeStr)
+	valuesResult, err := s.sheetsService.Spreadsheets.Values.Get(spreadsheetID, fullRange).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sheet values: %w", err)
+	}
+
+	values, page := paginate(valuesResult.Values, 0, 0, s.resourceCellLimit())
+	contents := &mcp.ResourceContents{URI: uri, MIMEType: mimeType}
+
+	if mimeType == "text/csv" {
+		if contents.Text, err = encodeCSV(values); err != nil {
+			return nil, err
+		}
+		if page.HasMore {
+			contents.Meta = mcp.Meta{"pagination": page}
+		}
+	} else {
+		result := valueRangeResult{Range: valuesResult.Range, Values: values}
+		if result.Values == nil {
+			result.Values = [][]any{}
+		}
+		response := sheetDataResult{SpreadsheetID: spreadsheetID, ValueRanges: []valueRangeResult{result}}
+		if page.HasMore {
+			response.Pagination = &page
+		}
+		data, err := json.Marshal(response)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal values: %w", err)
+		}
+		contents.Text = string(data)
+	}
+
+	return &mcp.ReadResourceResult{Contents: []*mcp.ResourceContents{contents}}, nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// writeQueue serializes mutating tool calls per spreadsheet so that concurrent sessions
+// cannot interleave conflicting batch updates. Reads never wait on the queue.
+type writeQueue struct {
+	mu        sync.Mutex
+	queues    map[string]*spreadsheetQueue
+	processed int64
+}
+
+type spreadsheetQueue struct {
+	slot    chan struct{}
+	pending int
+}
+
+// WriteQueueStats describes the current state of the write queue
+type WriteQueueStats struct {
+	Processed int64          `json:"processed"`
+	InFlight  int            `json:"inFlight"`
+	Depths    map[string]int `json:"depths"`
+}
+
+func newWriteQueue() *writeQueue {
+	return &writeQueue{
+		queues: make(map[string]*spreadsheetQueue),
+	}
+}
+
+// acquire blocks until the caller holds the write slot for the spreadsheet and returns its release function
+func (q *writeQueue) acquire(ctx context.Context, spreadsheetID string) (func(), error) {
+	q.mu.Lock()
+	sq, ok := q.queues[spreadsheetID]
+	if !ok {
+		sq = &spreadsheetQueue{slot: make(chan struct{}, 1)}
+		q.queues[spreadsheetID] = sq
+	}
+	sq.pending++
+	q.mu.Unlock()
+
+	select {
+	case sq.slot <- struct{}{}:
+		return func() { q.release(spreadsheetID, sq, true) }, nil
+	case <-ctx.Done():
+		q.release(spreadsheetID, sq, false)
+		return nil, ctx.Err()
+	}
+}
+
+func (q *writeQueue) release(spreadsheetID string, sq *spreadsheetQueue, held bool) {
+	if held {
+		<-sq.slot
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	sq.pending--
+	if held {
+		q.processed++
+	}
+	if sq.pending == 0 {
+		delete(q.queues, spreadsheetID)
+	}
+}
+
+func (q *writeQueue) stats() WriteQueueStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	stats := WriteQueueStats{
+		Processed: q.processed,
+		Depths:    make(map[string]int, len(q.queues)),
+	}
+	for spreadsheetID, sq := range q.queues {
+		stats.Depths[spreadsheetID] = sq.pending
+		stats.InFlight += sq.pending
+	}
+	return stats
+}
+
+// withWriteQueue runs a mutating tool handler while holding the write slot of the spreadsheet it targets
+func (s *SheetsMCPServer) withWriteQueue(handler mcp.ToolHandler) mcp.ToolHandler {
+	return func(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArgsFromRequest(request)
+		if err != nil {
+			return handler(ctx, request)
+		}
+
+		spreadsheetID := mutationTarget(args)
+		if spreadsheetID == "" {
+			return handler(ctx, request)
+		}
+
+		release, err := s.writeQueue.acquire(ctx, spreadsheetID)
+		if err != nil {
+			return respondWithToolError(toolError{Code: errorCancelled, Message: fmt.Sprintf("cancelled while waiting for pending writes on %s: %v", spreadsheetID, err)})
+		}
+		defer release()
+
+		return handler(ctx, request)
+	}
+}
+
+// mutationTarget returns the spreadsheet a mutating tool call writes to
+func mutationTarget(args map[string]any) string {
+	for _, key := range []string{"destination_spreadsheet_id", "spreadsheet_id", "spreadsheet", "dst_spreadsheet"} {
+		if id := parseArgument(args, key, ""); id != "" {
+			return id
+		}
+	}
+	return ""
+}
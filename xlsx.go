@@ -0,0 +1,254 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// readLocalTable loads a .csv or .xlsx file into rows of cell strings. For workbooks, sheetName
+// picks the worksheet; an empty name reads the first one.
+func readLocalTable(filePath, sheetName string) ([][]string, error) {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".csv":
+		return readCSVFile(filePath)
+	case ".xlsx":
+		return readXLSXFile(filePath, sheetName)
+	default:
+		return nil, fmt.Errorf("unsupported file type %q: expected .csv or .xlsx", filepath.Ext(filePath))
+	}
+}
+
+func readCSVFile(filePath string) ([][]string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	return r.ReadAll()
+}
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxRichText `xml:"si"`
+}
+
+type xlsxRichText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxRichText) String() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+	var b strings.Builder
+	for _, r := range t.Runs {
+		b.WriteString(r.Text)
+	}
+	return b.String()
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		Ref   int64 `xml:"r,attr"`
+		Cells []struct {
+			Ref    string       `xml:"r,attr"`
+			Type   string       `xml:"t,attr"`
+			Value  string       `xml:"v"`
+			Inline xlsxRichText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// Worksheets larger than Excel's own limits, or with more cells than Google Sheets holds, are refused
+// before a crafted cell reference can make the reader allocate a huge grid
+const (
+	xlsxMaxRows    = 1048576
+	xlsxMaxColumns = 16384
+	xlsxMaxCells   = 10000000
+)
+
+// errWorksheetNotFound is returned when a workbook has no worksheet with the requested name
+var errWorksheetNotFound = errors.New("worksheet not found in workbook")
+
+// readXLSXFile is a deliberately small reader: it resolves shared strings, inline strings, and
+// booleans, and returns every other cell as the raw stored value (numbers, date serials, formula results)
+func readXLSXFile(filePath, sheetName string) ([][]string, error) {
+	zr, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open workbook: %w", err)
+	}
+	defer zr.Close()
+
+	return readXLSXWorksheet(&zr.Reader, sheetName)
+}
+
+// xlsxSheetNames lists the sheets of an opened workbook in tab order
+func xlsxSheetNames(zr *zip.Reader) ([]string, error) {
+	var workbook xlsxWorkbook
+	if err := decodeZipXML(zr, "xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	names := make([]string, len(workbook.Sheets))
+	for i, sheet := range workbook.Sheets {
+		names[i] = sheet.Name
+	}
+	return names, nil
+}
+
+// readXLSXWorksheet reads one worksheet of an opened workbook; an empty name reads the first one
+func readXLSXWorksheet(zr *zip.Reader, sheetName string) ([][]string, error) {
+	var workbook xlsxWorkbook
+	if err := decodeZipXML(zr, "xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	if len(workbook.Sheets) == 0 {
+		return nil, fmt.Errorf("workbook has no worksheets")
+	}
+
+	var rels xlsxRelationships
+	if err := decodeZipXML(zr, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+
+	rID := workbook.Sheets[0].RID
+	if sheetName != "" {
+		rID = ""
+		for _, sheet := range workbook.Sheets {
+			if sheet.Name == sheetName {
+				rID = sheet.RID
+				break
+			}
+		}
+		if rID == "" {
+			return nil, fmt.Errorf("'%s': %w", sheetName, errWorksheetNotFound)
+		}
+	}
+
+	target := ""
+	for _, rel := range rels.Relationships {
+		if rel.ID == rID {
+			target = rel.Target
+			break
+		}
+	}
+	if target == "" {
+		return nil, fmt.Errorf("worksheet relationship %s not found", rID)
+	}
+	if strings.HasPrefix(target, "/") {
+		target = strings.TrimPrefix(target, "/")
+	} else {
+		target = path.Join("xl", target)
+	}
+
+	var shared xlsxSharedStrings
+	if err := decodeZipXML(zr, "xl/sharedStrings.xml", &shared); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	var worksheet xlsxWorksheet
+	if err := decodeZipXML(zr, target, &worksheet); err != nil {
+		return nil, err
+	}
+
+	var rows [][]string
+	cells := int64(0)
+	rowIndex := int64(-1)
+	for _, row := range worksheet.Rows {
+		// The r attributes are optional; without them rows and cells follow on from the previous one
+		rowIndex++
+		if row.Ref > 0 {
+			rowIndex = row.Ref - 1
+		}
+		col := int64(-1)
+		for _, cell := range row.Cells {
+			col++
+			if cell.Ref != "" {
+				var err error
+				if col, rowIndex, err = parseA1Notation(cell.Ref); err != nil {
+					return nil, fmt.Errorf("invalid cell reference %q in workbook: %w", cell.Ref, err)
+				}
+				if col < 0 || rowIndex < 0 {
+					return nil, fmt.Errorf("invalid cell reference %q in workbook: not a single cell", cell.Ref)
+				}
+			}
+			if rowIndex >= xlsxMaxRows || col >= xlsxMaxColumns {
+				return nil, fmt.Errorf("cell %s%d is outside the largest worksheet a workbook can have", columnToLetter(col), rowIndex+1)
+			}
+
+			value := cell.Value
+			switch cell.Type {
+			case "s":
+				var idx int
+				if _, err := fmt.Sscanf(cell.Value, "%d", &idx); err != nil || idx < 0 || idx >= len(shared.Items) {
+					return nil, fmt.Errorf("invalid shared string index %q at %s", cell.Value, cell.Ref)
+				}
+				value = shared.Items[idx].String()
+			case "inlineStr":
+				value = cell.Inline.String()
+			case "b":
+				value = "FALSE"
+				if cell.Value == "1" {
+					value = "TRUE"
+				}
+			}
+
+			for int64(len(rows)) <= rowIndex {
+				rows = append(rows, nil)
+			}
+			if grow := col + 1 - int64(len(rows[rowIndex])); grow > 0 {
+				if cells += grow; cells > xlsxMaxCells {
+					return nil, fmt.Errorf("worksheet has more than %d cells", xlsxMaxCells)
+				}
+				rows[rowIndex] = append(rows[rowIndex], make([]string, grow)...)
+			}
+			rows[rowIndex][col] = value
+		}
+	}
+
+	return rows, nil
+}
+
+func decodeZipXML(zr *zip.Reader, name string, v any) error {
+	for _, f := range zr.File {
+		if f.Name != name {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+
+		if err := xml.NewDecoder(rc).Decode(v); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+		return nil
+	}
+	return &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+}